@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sync"
 )
 
 // ExternType classifies imports and exports with their respective types.
@@ -51,6 +52,44 @@ func ExternTypeName(et ExternType) string {
 	return fmt.Sprintf("%#x", et)
 }
 
+// ImportDefinition describes an import a module requires to be instantiated, in the order of its import section.
+//
+// Ex. Given the Text Format below, the definition is {ModuleName: "env", Name: "log", Type: ExternTypeFunc,
+// ParamTypes: []ValueType{ValueTypeI32}}:
+//	(import "env" "log" (func (param i32)))
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#imports%E2%91%A0
+type ImportDefinition struct {
+	// ModuleName is the possibly empty name of the module that must export this, ex. "env".
+	ModuleName string
+	// Name is the possibly empty name of the export in ModuleName, ex. "log".
+	Name string
+	// Type is the type of the export, ex. ExternTypeFunc.
+	Type ExternType
+	// ParamTypes are the expected parameter types when Type is ExternTypeFunc, or nil.
+	ParamTypes []ValueType
+	// ResultTypes are the expected result types when Type is ExternTypeFunc, or nil.
+	ResultTypes []ValueType
+}
+
+// ExportDefinition describes an export of a module, in the order of its export section.
+//
+// Ex. Given the Text Format below, the definition is {Name: "add", Type: ExternTypeFunc,
+// ParamTypes: []ValueType{ValueTypeI32, ValueTypeI32}, ResultTypes: []ValueType{ValueTypeI32}}:
+//	(func (export "add") (param i32 i32) (result i32) local.get 0 local.get 1 i32.add)
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#exports%E2%91%A0
+type ExportDefinition struct {
+	// Name is the possibly empty name of the export, ex. "add".
+	Name string
+	// Type is the type of the export, ex. ExternTypeFunc.
+	Type ExternType
+	// ParamTypes are the parameter types when Type is ExternTypeFunc, or nil.
+	ParamTypes []ValueType
+	// ResultTypes are the result types when Type is ExternTypeFunc, or nil.
+	ResultTypes []ValueType
+}
+
 // ValueType describes a numeric type used in Web Assembly 1.0 (20191205). For example, Function parameters and results are
 // only definable as a value type.
 //
@@ -59,8 +98,9 @@ func ExternTypeName(et ExternType) string {
 //  * ValueTypeI64 - uint64(int64)
 //  * ValueTypeF32 - EncodeF32 DecodeF32 from float32
 //  * ValueTypeF64 - EncodeF64 DecodeF64 from float64
-//  * ValueTypeV128 TODO:
-//  * ValueTypeExternref - unintptr(unsafe.Pointer(p)) where p is any pointer type in Go (e.g. *string)
+//  * ValueTypeV128 - two uint64 values: the lower 64 bits, then the higher 64 bits
+//  * ValueTypeExternref - unintptr(unsafe.Pointer(p)) where p is any pointer type in Go (e.g. *string), or a handle
+//    from ExternrefValues for any Go value
 //
 // Ex. Given a Text Format type use (param i64) (result i64), no conversion is necessary.
 //
@@ -94,6 +134,11 @@ const (
 	//
 	// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/syntax/types.html#syntax-vectype
 	ValueTypeV128 ValueType = 0x7b
+	// ValueTypeFuncref is a funcref type, which is a reference to a function. This is only used as the element type of
+	// a table, such as in ModuleBuilder.ExportTable.
+	//
+	// Note: The usage of this type in function signatures is not supported by host functions.
+	ValueTypeFuncref ValueType = 0x70
 	// ValueTypeExternref is a externref type.
 	//
 	// Note: in wazero, externref type value are opaque raw 64-bit pointers, and the ValueTypeExternref type
@@ -124,6 +169,8 @@ func ValueTypeName(t ValueType) string {
 		return "f64"
 	case ValueTypeV128:
 		return "v128"
+	case ValueTypeFuncref:
+		return "funcref"
 	case ValueTypeExternref:
 		return "externref"
 	}
@@ -147,7 +194,8 @@ type Module interface {
 	// ExportedFunction returns a function exported from this module or nil if it wasn't.
 	ExportedFunction(name string) Function
 
-	// TODO: Table
+	// ExportedTable returns a table exported from this module or nil if it wasn't.
+	ExportedTable(name string) Table
 
 	// ExportedMemory returns a memory exported from this module or nil if it wasn't.
 	//
@@ -156,6 +204,19 @@ type Module interface {
 	// See https://github.com/WebAssembly/WASI/blob/snapshot-01/design/application-abi.md#current-unstable-abi
 	ExportedMemory(name string) Memory
 
+	// ExportedMemoryDefinition returns the limits and current size, in pages, of a memory exported from this module,
+	// or false if it wasn't. This is useful to provision buffers before writing to the memory.
+	//
+	// * min - the minimum pages declared by the memory.
+	// * current - the pages the memory has now, which is at least min, as it may have grown.
+	// * max - the maximum pages the memory can grow to, which is 65536 (4GiB) unless declared otherwise.
+	//
+	// Ex. To learn the largest size in bytes the memory named "memory" can grow to:
+	//	if _, _, max, ok := module.ExportedMemoryDefinition("memory"); ok {
+	//		maxBytes := uint64(max) * 65536
+	//	--snip--
+	ExportedMemoryDefinition(name string) (min, current, max uint32, ok bool)
+
 	// ExportedGlobal a global exported from this module or nil if it wasn't.
 	ExportedGlobal(name string) Global
 
@@ -167,10 +228,36 @@ type Module interface {
 	// be removed, making its name available again.
 	//
 	// Calling this inside a host function is safe, and may cause ExportedFunction callers to receive a sys.ExitError
-	// with the exitCode.
+	// with the exitCode. Host functions blocked reading stdin are unblocked.
+	//
+	// If the context is done before resources are released, for example due to a deadline, this returns early with
+	// an error wrapping the context error. In this case, the module is closed, but some resources may remain open.
+	//
 	// Note: When the context is nil, it defaults to context.Background.
 	CloseWithExitCode(ctx context.Context, exitCode uint32) error
 
+	// ExitCode returns the exit code this module was closed with, or false if it wasn't closed yet. This allows
+	// reading the exit status of a command, such as one calling WASI `proc_exit`, without inspecting errors.
+	//
+	// Ex. After a function returned a sys.ExitError:
+	//	if exitCode, ok := module.ExitCode(); ok && exitCode != 0 {
+	//		os.Exit(int(exitCode))
+	//	}
+	//
+	// Note: Close records an exit code of zero.
+	ExitCode() (exitCode uint32, ok bool)
+
+	// IsClosed returns true if this module was closed, such as via Close or WASI `proc_exit`. Functions of a closed
+	// module return a sys.ExitError when called.
+	//
+	// Ex. To avoid handing out a module from a pool after it was closed:
+	//	if module.IsClosed() {
+	//		module, err = r.InstantiateModule(ctx, compiled, config)
+	//	--snip--
+	//
+	// Note: This is cheaper than ExitCode when the exit code isn't needed.
+	IsClosed() bool
+
 	// Closer closes this module by delegating to CloseWithExitCode with an exit code of zero.
 	Closer
 }
@@ -202,11 +289,29 @@ type Function interface {
 	// encoded according to ResultTypes. An error is returned for any failure looking up or invoking the function
 	// including signature mismatch.
 	//
+	// Note: A ValueTypeV128 param or result occupies two uint64 values, the lower then the higher 64 bits. For
+	// example, a function of type (param i32 v128) (result v128) is called with three params and returns two results.
 	// Note: When the context is nil, it defaults to context.Background.
 	// Note: If Module.Close or Module.CloseWithExitCode were invoked during this call, the error returned may be a
 	// sys.ExitError. Interpreting this is specific to the module. For example, some "main" functions always call a
 	// function that exits.
 	Call(ctx context.Context, params ...uint64) ([]uint64, error)
+
+	// CallInto is like Call, except results are written into the given slice instead of a new one. The length of
+	// results must be at least the length of ResultTypes, counting each ValueTypeV128 twice.
+	//
+	// This is useful for high-frequency calls, as results can be reused to avoid allocating on each call. Ex.
+	//
+	//	results := make([]uint64, len(fn.ResultTypes()))
+	//	for _, v := range values {
+	//		if err := fn.CallInto(ctx, results, v); err != nil {
+	//			return err
+	//		}
+	//		sum += results[0]
+	//	}
+	//
+	// Note: When the context is nil, it defaults to context.Background.
+	CallInto(ctx context.Context, results []uint64, params ...uint64) error
 }
 
 // Global is a WebAssembly 1.0 (20191205) global exported from an instantiated module (wazero.Runtime InstantiateModule).
@@ -237,6 +342,32 @@ type Global interface {
 	//
 	// Note: When the context is nil, it defaults to context.Background.
 	Get(context.Context) uint64
+
+	// GetI32 returns the last known value of this global decoded as ValueTypeI32.
+	//
+	// Note: This panics if Type is not ValueTypeI32.
+	GetI32(context.Context) int32
+
+	// GetI64 returns the last known value of this global decoded as ValueTypeI64.
+	//
+	// Note: This panics if Type is not ValueTypeI64.
+	GetI64(context.Context) int64
+
+	// GetF32 returns the last known value of this global decoded as ValueTypeF32, via DecodeF32.
+	//
+	// Note: This panics if Type is not ValueTypeF32.
+	GetF32(context.Context) float32
+
+	// GetF64 returns the last known value of this global decoded as ValueTypeF64, via DecodeF64.
+	//
+	// Note: This panics if Type is not ValueTypeF64.
+	GetF64(context.Context) float64
+
+	// GetV128 returns the last known value of this global as the lower and higher 64 bits of a ValueTypeV128. Get
+	// only returns the lower 64 bits.
+	//
+	// Note: When Type is not ValueTypeV128, hi is zero.
+	GetV128(context.Context) (lo, hi uint64)
 }
 
 // MutableGlobal is a Global whose value can be updated at runtime (variable).
@@ -248,6 +379,43 @@ type MutableGlobal interface {
 	//
 	// Note: When the context is nil, it defaults to context.Background.
 	Set(ctx context.Context, v uint64)
+
+	// SetI32 updates the value of this global encoded as ValueTypeI32, via EncodeI32.
+	//
+	// Note: This panics if Type is not ValueTypeI32.
+	SetI32(ctx context.Context, v int32)
+
+	// SetI64 updates the value of this global encoded as ValueTypeI64, via EncodeI64.
+	//
+	// Note: This panics if Type is not ValueTypeI64.
+	SetI64(ctx context.Context, v int64)
+
+	// SetF32 updates the value of this global encoded as ValueTypeF32, via EncodeF32.
+	//
+	// Note: This panics if Type is not ValueTypeF32.
+	SetF32(ctx context.Context, v float32)
+
+	// SetF64 updates the value of this global encoded as ValueTypeF64, via EncodeF64.
+	//
+	// Note: This panics if Type is not ValueTypeF64.
+	SetF64(ctx context.Context, v float64)
+}
+
+// Table allows restricted access to a module's table. Notably, this does not allow growing.
+//
+// Note: All functions accept a context.Context, which when nil, default to context.Background.
+// Note: This is an interface for decoupling, not third-party implementations. All implementations are in wazero.
+// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/exec/runtime.html#table-instances
+type Table interface {
+	// Size returns the current count of elements in the table.
+	Size(context.Context) uint32
+
+	// SetFunc installs the function at the index of a funcref table, so that a guest can call it with
+	// "call_indirect". Like any other element, the function type is checked when the guest calls it.
+	//
+	// Returns an error if the table isn't funcref, the index is out of range, or fn wasn't exported by a module in
+	// the same wazero.Runtime.
+	SetFunc(ctx context.Context, index uint32, fn Function) error
 }
 
 // Memory allows restricted access to a module's memory. Notably, this does not allow growing.
@@ -301,6 +469,19 @@ type Memory interface {
 	// See math.Float64bits
 	ReadFloat64Le(ctx context.Context, offset uint32) (float64, bool)
 
+	// ReadUint32Be reads a uint32 in big-endian encoding from the underlying buffer at the offset in or returns false
+	// if out of range.
+	//
+	// Note: Wasm instructions always use little-endian encoding. This is for host functions bridging big-endian
+	// formats, such as network byte order, in memory they read and write themselves.
+	ReadUint32Be(ctx context.Context, offset uint32) (uint32, bool)
+
+	// ReadUint64Be reads a uint64 in big-endian encoding from the underlying buffer at the offset or returns false if
+	// out of range.
+	//
+	// See ReadUint32Be
+	ReadUint64Be(ctx context.Context, offset uint32) (uint64, bool)
+
 	// Read reads byteCount bytes from the underlying buffer at the offset or returns false if out of range.
 	//
 	// This returns a view of the underlying memory, not a copy. This means any writes to the slice returned are visible
@@ -318,6 +499,17 @@ type Memory interface {
 	// min=max, or use wazero.RuntimeConfig WithMemoryCapacityPages to ensure max is always allocated.
 	Read(ctx context.Context, offset, byteCount uint32) ([]byte, bool)
 
+	// Slice returns a view of byteCount bytes of the underlying buffer at the offset or returns false if out of range.
+	// This is the same as Read, but named for host functions processing large guest buffers without copying them.
+	//
+	// The returned slice aliases Wasm memory, so writes to it are visible to Wasm and vice versa. Its capacity is
+	// bounded to byteCount, so appending to it never overwrites the memory after it.
+	//
+	// Note: The slice is only valid until the memory grows, ex. via "memory.grow" or Grow, as that may reallocate the
+	// underlying buffer. Afterwards, writes to the slice are no longer visible to Wasm, and it doesn't see writes from
+	// Wasm. Don't retain it across calls into Wasm, unless memory min=max.
+	Slice(ctx context.Context, offset, byteCount uint32) ([]byte, bool)
+
 	// WriteByte writes a single byte to the underlying buffer at the offset in or returns false if out of range.
 	WriteByte(ctx context.Context, offset uint32, v byte) bool
 
@@ -343,6 +535,18 @@ type Memory interface {
 	// See math.Float64bits
 	WriteFloat64Le(ctx context.Context, offset uint32, v float64) bool
 
+	// WriteUint32Be writes the value in big-endian encoding to the underlying buffer at the offset in or returns false
+	// if out of range.
+	//
+	// See ReadUint32Be
+	WriteUint32Be(ctx context.Context, offset, v uint32) bool
+
+	// WriteUint64Be writes the value in big-endian encoding to the underlying buffer at the offset in or returns false
+	// if out of range.
+	//
+	// See ReadUint32Be
+	WriteUint64Be(ctx context.Context, offset uint32, v uint64) bool
+
 	// Write writes the slice to the underlying buffer at the offset or returns false if out of range.
 	Write(ctx context.Context, offset uint32, v []byte) bool
 }
@@ -359,6 +563,75 @@ func DecodeExternref(input uint64) uintptr {
 	return uintptr(input)
 }
 
+// ExternrefValues boxes Go values as ValueTypeExternref handles, so that the guest can store them in an externref
+// table or global, and pass them back to the host. Unlike EncodeExternref, the values are referenced by the
+// ExternrefValues, so they aren't garbage collected while only the guest holds them.
+//
+// Handles are only meaningful to the ExternrefValues which encoded them, so use one per wazero.Runtime, closed with it.
+// The zero value is ready to use.
+//
+// Ex. Pass a host object into the guest, and decode it when the guest passes it back:
+//
+//	var refs api.ExternrefValues
+//	defer refs.Close(ctx) // This releases all values, for example when closing the wazero.Runtime.
+//
+//	handle := refs.Encode(&dog{name: "hachi"})
+//	_, err := setDog.Call(ctx, handle)
+//	--snip--
+//	results, err := getDog.Call(ctx)
+//	d := refs.Decode(results[0]).(*dog)
+type ExternrefValues struct {
+	mu     sync.Mutex
+	next   uint64
+	values map[uint64]interface{}
+}
+
+// compile-time check to ensure ExternrefValues implements Closer.
+var _ Closer = &ExternrefValues{}
+
+// Encode boxes v as a handle, which is retained until Release or Close.
+//
+// Note: nil encodes as zero, which is the null reference. Otherwise, each call returns a new handle.
+// See Decode
+func (e *ExternrefValues) Encode(v interface{}) uint64 {
+	if v == nil {
+		return 0
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.values == nil {
+		e.values = map[uint64]interface{}{}
+	}
+	e.next++ // handles start at one, as zero is the null reference.
+	e.values[e.next] = v
+	return e.next
+}
+
+// Decode returns the Go value boxed by Encode, or nil if the input is the null reference, a released handle or one
+// encoded by another ExternrefValues.
+// See Encode
+func (e *ExternrefValues) Decode(input uint64) interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.values[input]
+}
+
+// Release releases the Go value boxed by Encode. Call this when the guest no longer holds the handle, as Decode
+// returns nil afterwards.
+func (e *ExternrefValues) Release(input uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.values, input)
+}
+
+// Close implements Closer.Close by releasing all values. Handles encoded afterwards don't reuse released ones.
+func (e *ExternrefValues) Close(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.values = nil
+	return nil
+}
+
 // EncodeI32 encodes the input as a ValueTypeI32.
 func EncodeI32(input int32) uint64 {
 	return uint64(uint32(input))
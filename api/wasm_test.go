@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"testing"
@@ -41,6 +42,7 @@ func TestValueTypeName(t *testing.T) {
 		{"i64", ValueTypeI64, "i64"},
 		{"f32", ValueTypeF32, "f32"},
 		{"f64", ValueTypeF64, "f64"},
+		{"funcref", ValueTypeFuncref, "funcref"},
 		{"externref", ValueTypeExternref, "externref"},
 		{"unknown", 100, "unknown"},
 	}
@@ -66,6 +68,57 @@ func TestEncodeDecodeExternRef(t *testing.T) {
 	}
 }
 
+func TestExternrefValues(t *testing.T) {
+	type dog struct {
+		name string
+	}
+
+	t.Run("nil is the null reference", func(t *testing.T) {
+		var refs ExternrefValues
+		require.Zero(t, refs.Encode(nil))
+		require.Nil(t, refs.Decode(0))
+	})
+
+	t.Run("round-trip", func(t *testing.T) {
+		var refs ExternrefValues
+		d := &dog{name: "hachi"}
+		handle := refs.Encode(d)
+		require.NotEqual(t, uint64(0), handle)
+		require.Equal(t, d, refs.Decode(handle))
+
+		// Values which aren't pointers can also be boxed, each with a different handle.
+		other := refs.Encode(dog{name: "hachi"})
+		require.NotEqual(t, handle, other)
+		require.Equal(t, dog{name: "hachi"}, refs.Decode(other))
+
+		refs.Release(handle)
+		require.Nil(t, refs.Decode(handle))
+		require.Equal(t, dog{name: "hachi"}, refs.Decode(other))
+	})
+
+	t.Run("handles are owned", func(t *testing.T) {
+		var refs1, refs2 ExternrefValues
+		handle := refs1.Encode(&dog{name: "hachi"})
+		require.Nil(t, refs2.Decode(handle))
+	})
+
+	t.Run("close releases all values", func(t *testing.T) {
+		var refs ExternrefValues
+		handle1 := refs.Encode(&dog{name: "hachi"})
+		handle2 := refs.Encode(&dog{name: "pochi"})
+
+		require.NoError(t, refs.Close(context.Background()))
+		require.Nil(t, refs.Decode(handle1))
+		require.Nil(t, refs.Decode(handle2))
+
+		// A handle encoded after close is new.
+		handle3 := refs.Encode(&dog{name: "hachi"})
+		require.NotEqual(t, handle1, handle3)
+		require.NotEqual(t, handle2, handle3)
+		require.Equal(t, &dog{name: "hachi"}, refs.Decode(handle3))
+	})
+}
+
 func TestEncodeDecodeF32(t *testing.T) {
 	for _, v := range []float32{
 		0, 100, -100, 1, -1,
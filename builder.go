@@ -3,6 +3,8 @@ package wazero
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"time"
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/leb128"
@@ -56,7 +58,7 @@ type ModuleBuilder interface {
 	//		return x + y
 	//	}
 	//
-	// Host functions may also have an initial parameter (param[0]) of type context.Context or api.Module.
+	// Host functions may also have an initial parameter (param[0]) of type context.Context, api.Module or api.Memory.
 	//
 	// Ex. This uses a Go Context:
 	//
@@ -74,7 +76,16 @@ type ModuleBuilder interface {
 	//		return x + y
 	//	}
 	//
-	// If both parameters exist, they must be in order at positions zero and one.
+	// Ex. This uses an api.Memory, which is the memory of the calling module, when access to other exports isn't needed:
+	//
+	//	addInts := func(ctx context.Context, mem api.Memory, offset uint32) uint32 {
+	//		x, _ := mem.ReadUint32Le(ctx, offset)
+	//		y, _ := mem.ReadUint32Le(ctx, offset + 4) // 32 bits == 4 bytes!
+	//		return x + y
+	//	}
+	//
+	// If a context.Context and either an api.Module or api.Memory exist, they must be in order at positions zero and
+	// one. Neither are Wasm parameters, so aren't included in the function's signature.
 	//
 	// Ex. This uses propagates context properly when calling other functions exported in the api.Module:
 	//	callRead := func(ctx context.Context, m api.Module, offset, byteCount uint32) uint32 {
@@ -89,6 +100,32 @@ type ModuleBuilder interface {
 	// ExportFunctions is a convenience that calls ExportFunction for each key/value in the provided map.
 	ExportFunctions(nameToGoFunc map[string]interface{}) ModuleBuilder
 
+	// ExportFromStruct is a convenience that calls ExportFunction for each exported method of the struct v, named
+	// prefix followed by the method name. Each method must have a signature supported by ExportFunction.
+	//
+	// Ex. This exports the functions "env.Add" and "env.Log":
+	//
+	//	type hostAPI struct{ logger *log.Logger }
+	//
+	//	func (h *hostAPI) Add(x, y uint32) uint32 { return x + y }
+	//
+	//	func (h *hostAPI) Log(ctx context.Context, m api.Module, offset, byteCount uint32) {
+	//		buf, _ := m.Memory().Read(ctx, offset, byteCount)
+	//		h.logger.Println(string(buf))
+	//	}
+	//
+	//	builder.ExportFromStruct("env.", &hostAPI{logger: log.Default()})
+	//
+	// Note: Methods with a pointer receiver are only exported when v is a pointer to a struct.
+	// Note: Methods with unsupported signatures are skipped, and reported together as an error from Compile.
+	ExportFromStruct(prefix string, v interface{}) ModuleBuilder
+
+	// WithHostFunctionObserver notifies the observer before and after each call to a function added by ExportFunction,
+	// ExportFunctions or ExportFromStruct. For example, this can count and time calls for metrics.
+	//
+	// Note: This wraps each function using reflection, so it adds overhead to every call.
+	WithHostFunctionObserver(HostFunctionObserver) ModuleBuilder
+
 	// ExportMemory adds linear memory, which a WebAssembly module can import and become available via api.Memory.
 	//
 	// * name - the name to export. Ex "memory" for wasi.ModuleSnapshotPreview1
@@ -157,6 +194,23 @@ type ModuleBuilder interface {
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#syntax-globaltype
 	ExportGlobalF64(name string, v float64) ModuleBuilder
 
+	// ExportTable adds a table, which a WebAssembly module can import, for example to "call_indirect" through
+	// function references installed by another module.
+	//
+	// * name - the name to export. Ex "__indirect_function_table"
+	// * min - the possibly zero initial count of elements.
+	// * max - the maximum count of elements, or nil if unbounded.
+	// * refType - the type of the elements: api.ValueTypeFuncref or api.ValueTypeExternref.
+	//
+	// For example, the WebAssembly 1.0 Text Format below is the equivalent of this builder method:
+	//	// (table (export "table") 1 funcref)
+	//	builder.ExportTable("table", 1, nil, api.ValueTypeFuncref)
+	//
+	// Note: If a table is already exported with the same name, this overwrites it.
+	// Note: api.ValueTypeExternref or exporting more than one table requires RuntimeConfig.WithFeatureReferenceTypes.
+	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#syntax-tabletype
+	ExportTable(name string, min uint32, max *uint32, refType api.ValueType) ModuleBuilder
+
 	// Compile returns a module to instantiate, or an error if any of the configuration is invalid.
 	//
 	// Note: Closing the wazero.Runtime closes any CompiledModule it compiled.
@@ -170,6 +224,15 @@ type ModuleBuilder interface {
 	Instantiate(context.Context) (api.Module, error)
 }
 
+// HostFunctionObserver is notified of calls to host functions. See ModuleBuilder.WithHostFunctionObserver
+type HostFunctionObserver interface {
+	// Before is invoked before the host function exported as funcName is called.
+	Before(funcName string)
+
+	// After is invoked after the host function exported as funcName returned or panicked, with the duration of the call.
+	After(funcName string, duration time.Duration)
+}
+
 // moduleBuilder implements ModuleBuilder
 type moduleBuilder struct {
 	r            *runtime
@@ -177,6 +240,10 @@ type moduleBuilder struct {
 	nameToGoFunc map[string]interface{}
 	nameToMemory map[string]*wasm.Memory
 	nameToGlobal map[string]*wasm.Global
+	nameToTable  map[string]*wasm.Table
+	observer     HostFunctionObserver
+	// err is returned by Compile, when an earlier call failed.
+	err error
 }
 
 // NewModuleBuilder implements Runtime.NewModuleBuilder
@@ -187,6 +254,7 @@ func (r *runtime) NewModuleBuilder(moduleName string) ModuleBuilder {
 		nameToGoFunc: map[string]interface{}{},
 		nameToMemory: map[string]*wasm.Memory{},
 		nameToGlobal: map[string]*wasm.Global{},
+		nameToTable:  map[string]*wasm.Table{},
 	}
 }
 
@@ -204,6 +272,21 @@ func (b *moduleBuilder) ExportFunctions(nameToGoFunc map[string]interface{}) Mod
 	return b
 }
 
+// ExportFromStruct implements ModuleBuilder.ExportFromStruct
+func (b *moduleBuilder) ExportFromStruct(prefix string, v interface{}) ModuleBuilder {
+	nameToGoFunc, err := wasm.StructGoFuncs(prefix, v, b.r.enabledFeatures)
+	if err != nil && b.err == nil {
+		b.err = err
+	}
+	return b.ExportFunctions(nameToGoFunc)
+}
+
+// WithHostFunctionObserver implements ModuleBuilder.WithHostFunctionObserver
+func (b *moduleBuilder) WithHostFunctionObserver(observer HostFunctionObserver) ModuleBuilder {
+	b.observer = observer
+	return b
+}
+
 // ExportMemory implements ModuleBuilder.ExportMemory
 func (b *moduleBuilder) ExportMemory(name string, minPages uint32) ModuleBuilder {
 	b.nameToMemory[name] = &wasm.Memory{Min: minPages}
@@ -254,6 +337,12 @@ func (b *moduleBuilder) ExportGlobalF64(name string, v float64) ModuleBuilder {
 	return b
 }
 
+// ExportTable implements ModuleBuilder.ExportTable
+func (b *moduleBuilder) ExportTable(name string, min uint32, max *uint32, refType api.ValueType) ModuleBuilder {
+	b.nameToTable[name] = &wasm.Table{Min: min, Max: max, Type: refType}
+	return b
+}
+
 // Compile implements ModuleBuilder.Compile
 func (b *moduleBuilder) Compile(ctx context.Context, cConfig CompileConfig) (CompiledModule, error) {
 	config, ok := cConfig.(*compileConfig)
@@ -261,6 +350,10 @@ func (b *moduleBuilder) Compile(ctx context.Context, cConfig CompileConfig) (Com
 		panic(fmt.Errorf("unsupported wazero.CompileConfig implementation: %#v", cConfig))
 	}
 
+	if b.err != nil {
+		return nil, b.err
+	}
+
 	// Verify the maximum limit here, so we don't have to pass it to wasm.NewHostModule
 	for name, mem := range b.nameToMemory {
 		var maxP *uint32
@@ -273,7 +366,15 @@ func (b *moduleBuilder) Compile(ctx context.Context, cConfig CompileConfig) (Com
 		}
 	}
 
-	module, err := wasm.NewHostModule(b.moduleName, b.nameToGoFunc, b.nameToMemory, b.nameToGlobal, b.r.enabledFeatures)
+	nameToGoFunc := b.nameToGoFunc
+	if b.observer != nil {
+		nameToGoFunc = make(map[string]interface{}, len(b.nameToGoFunc))
+		for name, goFunc := range b.nameToGoFunc {
+			nameToGoFunc[name] = observeGoFunc(b.observer, name, goFunc)
+		}
+	}
+
+	module, err := wasm.NewHostModule(b.moduleName, nameToGoFunc, b.nameToMemory, b.nameToGlobal, b.nameToTable, b.r.enabledFeatures)
 	if err != nil {
 		return nil, err
 	}
@@ -298,3 +399,17 @@ func (b *moduleBuilder) Instantiate(ctx context.Context) (api.Module, error) {
 		return b.r.InstantiateModule(ctx, compiled, NewModuleConfig().WithName(b.moduleName))
 	}
 }
+
+// observeGoFunc returns a function of the same type as goFunc, which notifies the observer around each call to it.
+func observeGoFunc(observer HostFunctionObserver, name string, goFunc interface{}) interface{} {
+	fn := reflect.ValueOf(goFunc)
+	if fn.Kind() != reflect.Func {
+		return goFunc // wasm.NewHostModule reports the error.
+	}
+	return reflect.MakeFunc(fn.Type(), func(args []reflect.Value) []reflect.Value {
+		observer.Before(name)
+		start := time.Now()
+		defer func() { observer.After(name, time.Since(start)) }()
+		return fn.Call(args)
+	}).Interface()
+}
@@ -1,9 +1,11 @@
 package wazero
 
 import (
+	"context"
 	"math"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/leb128"
@@ -159,7 +161,7 @@ func TestNewModuleBuilder_Build(t *testing.T) {
 				return r.NewModuleBuilder("").ExportMemory("memory", 1)
 			},
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages}},
 				ExportSection: []*wasm.Export{
 					{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
 				},
@@ -171,7 +173,7 @@ func TestNewModuleBuilder_Build(t *testing.T) {
 				return r.NewModuleBuilder("").ExportMemory("memory", 1).ExportMemory("memory", 2)
 			},
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 2, Cap: 2, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 2, Cap: 2, Max: wasm.MemoryLimitPages}},
 				ExportSection: []*wasm.Export{
 					{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
 				},
@@ -183,7 +185,7 @@ func TestNewModuleBuilder_Build(t *testing.T) {
 				return r.NewModuleBuilder("").ExportMemoryWithMax("memory", 1, 1)
 			},
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: 1, IsMaxEncoded: true},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: 1, IsMaxEncoded: true}},
 				ExportSection: []*wasm.Export{
 					{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
 				},
@@ -195,7 +197,7 @@ func TestNewModuleBuilder_Build(t *testing.T) {
 				return r.NewModuleBuilder("").ExportMemoryWithMax("memory", 1, 1).ExportMemoryWithMax("memory", 1, 2)
 			},
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true}},
 				ExportSection: []*wasm.Export{
 					{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
 				},
@@ -337,6 +339,30 @@ func TestNewModuleBuilder_Build(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ExportTable",
+			input: func(r Runtime) ModuleBuilder {
+				return r.NewModuleBuilder("").ExportTable("table", 1, nil, api.ValueTypeFuncref)
+			},
+			expected: &wasm.Module{
+				TableSection: []*wasm.Table{{Min: 1, Type: wasm.RefTypeFuncref}},
+				ExportSection: []*wasm.Export{
+					{Name: "table", Type: wasm.ExternTypeTable, Index: 0},
+				},
+			},
+		},
+		{
+			name: "ExportTable overwrites",
+			input: func(r Runtime) ModuleBuilder {
+				return r.NewModuleBuilder("").ExportTable("table", 1, nil, api.ValueTypeFuncref).ExportTable("table", 2, nil, api.ValueTypeFuncref)
+			},
+			expected: &wasm.Module{
+				TableSection: []*wasm.Table{{Min: 2, Type: wasm.RefTypeFuncref}},
+				ExportSection: []*wasm.Export{
+					{Name: "table", Type: wasm.ExternTypeTable, Index: 0},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -385,6 +411,14 @@ func TestNewModuleBuilder_Build_Errors(t *testing.T) {
 			}),
 			expectedErr: "memory[memory] capacity 1 pages (64 Ki) less than minimum 2 pages (128 Ki)",
 		},
+		{
+			name: "struct method with unsupported signature",
+			input: func(rt Runtime) ModuleBuilder {
+				return rt.NewModuleBuilder("").ExportFromStruct("", &stringer{})
+			},
+			config:      NewCompileConfig(),
+			expectedErr: "func[String] result[0] is unsupported: string",
+		},
 	}
 
 	for _, tt := range tests {
@@ -397,6 +431,95 @@ func TestNewModuleBuilder_Build_Errors(t *testing.T) {
 	}
 }
 
+// counter is a host API exported via ModuleBuilder.ExportFromStruct.
+type counter struct {
+	count uint32
+}
+
+func (c *counter) Add(delta uint32) uint32 {
+	c.count += delta
+	return c.count
+}
+
+func (c *counter) Reset(ctx context.Context, m api.Module) {
+	c.count = 0
+}
+
+// stringer has a method with a signature unsupported by ModuleBuilder.ExportFunction.
+type stringer struct{}
+
+func (*stringer) String() string {
+	return "stringer"
+}
+
+func TestNewModuleBuilder_ExportFromStruct(t *testing.T) {
+	r := NewRuntime()
+
+	c := &counter{}
+	host, err := r.NewModuleBuilder("counter").ExportFromStruct("counter.", c).Instantiate(testCtx)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	mod, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module
+	(import "counter" "counter.Add" (func $add (param i32) (result i32)))
+	(import "counter" "counter.Reset" (func $reset))
+	(func $call_add (param i32) (result i32) local.get 0 call $add)
+	(func $call_reset call $reset)
+	(export "add" (func $call_add))
+	(export "reset" (func $call_reset))
+)`))
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	results, err := mod.ExportedFunction("add").Call(testCtx, 2)
+	require.NoError(t, err)
+	results, err = mod.ExportedFunction("add").Call(testCtx, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), results[0])
+
+	_, err = mod.ExportedFunction("reset").Call(testCtx)
+	require.NoError(t, err)
+	require.Zero(t, c.count)
+}
+
+// hostFunctionObserver records the names passed to HostFunctionObserver
+type hostFunctionObserver struct{ calls []string }
+
+func (o *hostFunctionObserver) Before(funcName string) {
+	o.calls = append(o.calls, "before "+funcName)
+}
+
+func (o *hostFunctionObserver) After(funcName string, duration time.Duration) {
+	if duration < 0 {
+		panic("negative duration")
+	}
+	o.calls = append(o.calls, "after "+funcName)
+}
+
+func TestNewModuleBuilder_WithHostFunctionObserver(t *testing.T) {
+	r := NewRuntime()
+	defer r.Close(testCtx)
+
+	observer := &hostFunctionObserver{}
+	mod, err := r.NewModuleBuilder("env").
+		ExportFunction("add", func(x, y uint32) uint32 { return x + y }).
+		ExportFunction("log", func(ctx context.Context, m api.Module) {
+			observer.calls = append(observer.calls, "log")
+		}).
+		WithHostFunctionObserver(observer).
+		Instantiate(testCtx)
+	require.NoError(t, err)
+
+	results, err := mod.ExportedFunction("add").Call(testCtx, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{3}, results)
+
+	_, err = mod.ExportedFunction("log").Call(testCtx)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"before add", "after add", "before log", "log", "after log"}, observer.calls)
+}
+
 // TestNewModuleBuilder_Instantiate ensures Runtime.InstantiateModule is called on success.
 func TestNewModuleBuilder_Instantiate(t *testing.T) {
 	r := NewRuntime()
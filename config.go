@@ -2,6 +2,7 @@ package wazero
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"math"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/compilationcache"
 	"github.com/tetratelabs/wazero/internal/engine/compiler"
 	"github.com/tetratelabs/wazero/internal/engine/interpreter"
 	"github.com/tetratelabs/wazero/internal/wasm"
@@ -22,6 +24,38 @@ import (
 // Note: RuntimeConfig is immutable. Each WithXXX function returns a new instance including the corresponding change.
 type RuntimeConfig interface {
 
+	// WithCompilationCache persists compiled code in the given cache, so that compiling the same module again, even in
+	// a different process, can skip compilation. This defaults to nil, which disables the cache.
+	//
+	// Entries are keyed by a hash of the module, the enabled features and the version of wazero and its encoding of
+	// compiled code. Hence, changing any of these results in a cache miss, as opposed to using stale code. Errors
+	// reading or writing the cache are ignored, compiling the module as if there were no cache.
+	//
+	// Ex. To cache compiled code in the directory "/tmp/wazero":
+	//	cache, _ := wazero.NewCompilationCacheWithDir("/tmp/wazero")
+	//	rConfig = wazero.NewRuntimeConfigInterpreter().WithCompilationCache(cache)
+	//
+	// Note: This is interpreter-only for now, as compiled machine code isn't relocatable.
+	WithCompilationCache(CompilationCache) RuntimeConfig
+
+	// WithDebugInfoEnabled prints verbose traces to os.Stdout while compiling modules, such as each instruction as it is
+	// lowered to the intermediate representation. This defaults to false as the output is large.
+	//
+	// Ex. To see how a module is lowered:
+	//	rConfig = wazero.NewRuntimeConfig().WithDebugInfoEnabled(true)
+	//
+	// Note: Nothing is printed when the compiled code is found in the compilation cache.
+	WithDebugInfoEnabled(bool) RuntimeConfig
+
+	// WithDWARFSymbols parses the DWARF debug information in custom sections of the WebAssembly binary format, such
+	// as ".debug_info" and ".debug_line" emitted by rustc or clang. This defaults to false as parsing is costly.
+	//
+	// When enabled, a trap returns a *sys.TrapError whose location can be translated to the original source file and
+	// line via CompiledModule.DWARFLine.
+	//
+	// Note: This is interpreter-only for now, and has no effect on the WebAssembly text format.
+	WithDWARFSymbols(bool) RuntimeConfig
+
 	// WithFeatureBulkMemoryOperations adds instructions modify ranges of memory or table entries
 	// ("bulk-memory-operations"). This defaults to false as the feature was not finished in WebAssembly 1.0.
 	//
@@ -39,6 +73,30 @@ type RuntimeConfig interface {
 	// See https://github.com/WebAssembly/spec/pull/1287
 	WithFeatureBulkMemoryOperations(bool) RuntimeConfig
 
+	// WithFeatureExtendedConst allows constant expressions to combine integer constants and imported globals with
+	// `add`, `sub` and `mul` ("extended-const"). This defaults to false as the feature was not in WebAssembly 1.0 or
+	// 2.0.
+	//
+	// Ex. A global can be initialized with `(i32.add (global.get $base) (i32.const 16))`.
+	//
+	// Note: This applies to global initializers and data segment offsets, but not yet element segment offsets.
+	// See https://github.com/WebAssembly/extended-const/blob/main/proposals/extended-const/Overview.md
+	WithFeatureExtendedConst(bool) RuntimeConfig
+
+	// WithFeatureMultiMemory allows a module to import or define more than one memory ("multi-memory"). This defaults
+	// to false as the feature was not in WebAssembly 1.0 or 2.0.
+	//
+	// Here are the notable effects:
+	// * The memory section and imports can declare more than one memory.
+	// * The memory argument of load and store instructions can set the memory index flag (0x40), followed by a
+	//   memory index.
+	// * The reserved byte of `memory.size` and `memory.grow` is a memory index.
+	//
+	// Note: Only the interpreter supports instructions that access a memory other than index zero. The compiler
+	// returns an error when compiling such a function.
+	// See https://github.com/WebAssembly/multi-memory/blob/main/proposals/multi-memory/Overview.md
+	WithFeatureMultiMemory(bool) RuntimeConfig
+
 	// WithFeatureMultiValue enables multiple values ("multi-value"). This defaults to false as the feature was not
 	// finished in WebAssembly 1.0 (20191205).
 	//
@@ -111,6 +169,67 @@ type RuntimeConfig interface {
 	// See https://github.com/WebAssembly/spec/blob/main/proposals/simd/SIMD.md
 	WithFeatureSIMD(bool) RuntimeConfig
 
+	// WithFeatureTailCall enables the tail calls `return_call` and `return_call_indirect` ("tail-call"). This defaults
+	// to false as the feature was not in WebAssembly 1.0 or 2.0.
+	//
+	// A tail call returns the results of its callee, so it can reuse the frame of the calling function. This allows
+	// languages that rely on tail calls for iteration, such as functional ones, to recurse without overflowing the
+	// call stack.
+	//
+	// Note: Only the interpreter supports tail calls. The compiler returns an error when compiling a function that
+	// uses them.
+	// See https://github.com/WebAssembly/tail-call/blob/main/proposals/tail-call/Overview.md
+	WithFeatureTailCall(bool) RuntimeConfig
+
+	// WithFeatureThreads enables the atomic instructions of the threads proposal ("threads"). This defaults to false
+	// as the feature was not in WebAssembly 1.0 or 2.0.
+	//
+	// Here are the notable effects:
+	// * Atomic loads, stores and read-modify-write instructions, such as `i32.atomic.rmw.add`, must access an address
+	//   that is a multiple of their size, or they trap.
+	// * Memories can be declared shared, which requires a max. A shared memory is allocated with the capacity from
+	//   CompileConfig.WithMemorySizer up-front, and growing it past that fails, so that it never moves.
+	// * `memory.atomic.wait32` and `memory.atomic.wait64` trap unless the memory is shared. Otherwise, they block until
+	//   `memory.atomic.notify` from a call on another goroutine wakes them, the timeout elapses, or the context.Context
+	//   of the call is done.
+	//
+	// Note: Only the interpreter supports atomic instructions. The compiler returns an error when compiling a function
+	// that uses them.
+	// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+	WithFeatureThreads(bool) RuntimeConfig
+
+	// WithMemoryGrowObserver notifies the given observer each time the memory of a module grows, for example to
+	// enforce a quota across guests or to record metrics without polling the memory size. This defaults to nil.
+	//
+	// Ex. To track how many pages each module has grown by:
+	//	grown := map[string]uint32{}
+	//	rConfig = wazero.NewRuntimeConfig().WithMemoryGrowObserver(
+	//		func(ctx context.Context, moduleName string, previousPages, newPages uint32) {
+	//			grown[moduleName] += newPages - previousPages
+	//		})
+	WithMemoryGrowObserver(MemoryGrowObserver) RuntimeConfig
+
+	// WithRandSource sets the default source of random bytes, such as for "random_get" in "wasi_snapshot_preview1", of
+	// each module instantiated by the runtime. newRandSource is called once per module, so that modules don't consume
+	// each other's bytes. This defaults to nil, which uses crypto/rand.Reader.
+	//
+	// Ex. To make all modules draw the same, deterministic bytes in tests:
+	//	rConfig = wazero.NewRuntimeConfig().WithRandSource(func() io.Reader {
+	//		return rand.New(rand.NewSource(42))
+	//	})
+	//
+	// Note: ModuleConfig.WithRandSource takes precedence over this.
+	WithRandSource(newRandSource func() io.Reader) RuntimeConfig
+
+	// WithSourceMap records the line and column of each instruction when compiling the WebAssembly text format. This
+	// defaults to false as the positions are only needed for debugging.
+	//
+	// When enabled, a trap returns a *sys.TrapError whose location can be translated to the text format source via
+	// CompiledModule.SourcePosition.
+	//
+	// Note: This is interpreter-only for now, and has no effect on the WebAssembly binary format.
+	WithSourceMap(bool) RuntimeConfig
+
 	// WithWasmCore1 enables features included in the WebAssembly Core Specification 1.0. Selecting this
 	// overwrites any currently accumulated features with only those included in this W3C recommendation.
 	//
@@ -136,8 +255,14 @@ type RuntimeConfig interface {
 }
 
 type runtimeConfig struct {
-	enabledFeatures wasm.Features
-	newEngine       func(wasm.Features) wasm.Engine
+	enabledFeatures    wasm.Features
+	newEngine          func(wasm.Features) wasm.Engine
+	sourceMap          bool
+	compilationCache   CompilationCache
+	dwarfSymbols       bool
+	debugInfo          bool
+	memoryGrowObserver MemoryGrowObserver
+	newRandSource      func() io.Reader
 }
 
 // engineLessConfig helps avoid copy/pasting the wrong defaults.
@@ -165,12 +290,37 @@ func NewRuntimeConfigCompiler() RuntimeConfig {
 }
 
 // NewRuntimeConfigInterpreter interprets WebAssembly modules instead of compiling them into assembly.
+//
+// Note: When the context passed to api.Function Call can be canceled, the interpreter checks it at each loop iteration
+// and tail call. Once it is done, the call returns a sys.TrapError with code sys.TrapContextDone, wrapping the context
+// error.
 func NewRuntimeConfigInterpreter() RuntimeConfig {
 	ret := *engineLessConfig // copy
 	ret.newEngine = interpreter.NewEngine
 	return &ret
 }
 
+// WithCompilationCache implements RuntimeConfig.WithCompilationCache
+func (c *runtimeConfig) WithCompilationCache(cache CompilationCache) RuntimeConfig {
+	ret := *c // copy
+	ret.compilationCache = cache
+	return &ret
+}
+
+// WithDebugInfoEnabled implements RuntimeConfig.WithDebugInfoEnabled
+func (c *runtimeConfig) WithDebugInfoEnabled(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.debugInfo = enabled
+	return &ret
+}
+
+// WithDWARFSymbols implements RuntimeConfig.WithDWARFSymbols
+func (c *runtimeConfig) WithDWARFSymbols(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.dwarfSymbols = enabled
+	return &ret
+}
+
 // WithFeatureBulkMemoryOperations implements RuntimeConfig.WithFeatureBulkMemoryOperations
 func (c *runtimeConfig) WithFeatureBulkMemoryOperations(enabled bool) RuntimeConfig {
 	ret := *c // copy
@@ -180,6 +330,20 @@ func (c *runtimeConfig) WithFeatureBulkMemoryOperations(enabled bool) RuntimeCon
 	return &ret
 }
 
+// WithFeatureExtendedConst implements RuntimeConfig.WithFeatureExtendedConst
+func (c *runtimeConfig) WithFeatureExtendedConst(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.enabledFeatures = ret.enabledFeatures.Set(wasm.FeatureExtendedConst, enabled)
+	return &ret
+}
+
+// WithFeatureMultiMemory implements RuntimeConfig.WithFeatureMultiMemory
+func (c *runtimeConfig) WithFeatureMultiMemory(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.enabledFeatures = ret.enabledFeatures.Set(wasm.FeatureMultiMemory, enabled)
+	return &ret
+}
+
 // WithFeatureMultiValue implements RuntimeConfig.WithFeatureMultiValue
 func (c *runtimeConfig) WithFeatureMultiValue(enabled bool) RuntimeConfig {
 	ret := *c // copy
@@ -224,6 +388,41 @@ func (c *runtimeConfig) WithFeatureSIMD(enabled bool) RuntimeConfig {
 	return &ret
 }
 
+// WithFeatureTailCall implements RuntimeConfig.WithFeatureTailCall
+func (c *runtimeConfig) WithFeatureTailCall(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.enabledFeatures = ret.enabledFeatures.Set(wasm.FeatureTailCall, enabled)
+	return &ret
+}
+
+// WithFeatureThreads implements RuntimeConfig.WithFeatureThreads
+func (c *runtimeConfig) WithFeatureThreads(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.enabledFeatures = ret.enabledFeatures.Set(wasm.FeatureThreads, enabled)
+	return &ret
+}
+
+// WithMemoryGrowObserver implements RuntimeConfig.WithMemoryGrowObserver
+func (c *runtimeConfig) WithMemoryGrowObserver(observer MemoryGrowObserver) RuntimeConfig {
+	ret := *c // copy
+	ret.memoryGrowObserver = observer
+	return &ret
+}
+
+// WithRandSource implements RuntimeConfig.WithRandSource
+func (c *runtimeConfig) WithRandSource(newRandSource func() io.Reader) RuntimeConfig {
+	ret := *c // copy
+	ret.newRandSource = newRandSource
+	return &ret
+}
+
+// WithSourceMap implements RuntimeConfig.WithSourceMap
+func (c *runtimeConfig) WithSourceMap(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.sourceMap = enabled
+	return &ret
+}
+
 // WithWasmCore1 implements RuntimeConfig.WithWasmCore1
 func (c *runtimeConfig) WithWasmCore1() RuntimeConfig {
 	ret := *c // copy
@@ -238,6 +437,34 @@ func (c *runtimeConfig) WithWasmCore2() RuntimeConfig {
 	return &ret
 }
 
+// MemoryGrowObserver is called after the memory of a module grows. See RuntimeConfig.WithMemoryGrowObserver
+//
+// * moduleName - the name of the module that defined the memory, which isn't necessarily the one that grew it.
+// * previousPages - the size of the memory in pages before it grew.
+// * newPages - the size of the memory in pages after it grew.
+//
+// Note: This is called for the "memory.grow" instruction and api.Memory Grow alike, but not when the size is
+// unchanged, such as when growing by zero pages or past the maximum.
+type MemoryGrowObserver func(ctx context.Context, moduleName string, previousPages, newPages uint32)
+
+// CompilationCache persists compiled code, so that it can be reused across runtimes and processes. The default
+// implementation is NewCompilationCacheWithDir. See RuntimeConfig.WithCompilationCache
+//
+// Note: Implementations must be safe for concurrent use.
+type CompilationCache interface {
+	// Get returns the content added for the key, or false if there is none.
+	Get(key [sha256.Size]byte) (content []byte, ok bool, err error)
+
+	// Add stores the content for the key, replacing any existing content.
+	Add(key [sha256.Size]byte, content []byte) error
+}
+
+// NewCompilationCacheWithDir returns a CompilationCache which stores each entry as a file in the given directory,
+// creating it if it doesn't exist.
+func NewCompilationCacheWithDir(dir string) (CompilationCache, error) {
+	return compilationcache.NewFileCache(dir)
+}
+
 // CompiledModule is a WebAssembly 1.0 module ready to be instantiated (Runtime.InstantiateModule) as an api.Module.
 //
 // Note: Closing the wazero.Runtime closes any CompiledModule it compiled.
@@ -245,6 +472,77 @@ func (c *runtimeConfig) WithWasmCore2() RuntimeConfig {
 // the name "Module" for both before and after instantiation as the name conflation has caused confusion.
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#semantic-phases%E2%91%A0
 type CompiledModule interface {
+	// CustomSection returns the data of the first custom section with the given name, or false if there is none.
+	//
+	// Ex. To read the producers metadata emitted by many compilers:
+	//	producers, ok := compiled.CustomSection("producers")
+	//
+	// Note: The "name" section is decoded as opposed to retained, so it is never returned here.
+	// Note: The returned slice must not be modified.
+	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#custom-section%E2%91%A0
+	CustomSection(name string) ([]byte, bool)
+
+	// DWARFLine returns the original source file, one-based line and column of the instruction at offset pc in the
+	// body of funcIdx, or false if unknown. A column of zero means it is unknown.
+	//
+	// Ex. To find the source line where a trap occurred:
+	//	if trapErr, ok := err.(*sys.TrapError); ok && trapErr.Location() != nil {
+	//		loc := trapErr.Location()
+	//		file, line, _, ok := compiled.DWARFLine(loc.FunctionIndex, loc.ProgramCounter)
+	//	--snip--
+	//
+	// Note: This is only known when compiled from a binary with DWARF custom sections, and RuntimeConfig.WithDWARFSymbols
+	// enabled.
+	DWARFLine(funcIdx uint32, pc uint64) (file string, line, col uint32, ok bool)
+
+	// ExportedNames returns the names of all exports of this module, in the order of its export section.
+	//
+	// See Exports for their types.
+	ExportedNames() []string
+
+	// Exports returns the definitions of all exports of this module, in the order of its export section. This allows
+	// tooling to decide how to use a module without instantiating it.
+	Exports() []api.ExportDefinition
+
+	// ImportedFunctions returns the definitions of all functions this module imports, in the order of its import
+	// section. This allows checking which host modules must be instantiated before Runtime.InstantiateModule.
+	//
+	// Ex. To ensure each imported module is instantiated:
+	//	for _, def := range compiled.ImportedFunctions() {
+	//		if r.Module(def.ModuleName) == nil {
+	//	--snip--
+	//
+	// See Imports for all types of imports, such as memories.
+	ImportedFunctions() []api.ImportDefinition
+
+	// Imports returns the definitions of all imports of this module, in the order of its import section. Unlike
+	// ImportedFunctions, this includes imported tables, memories and globals.
+	Imports() []api.ImportDefinition
+
+	// LocalName returns the name of a local, or false if it wasn't in the custom "name" section.
+	//
+	// * funcIdx is in the function index namespace, which begins with imported functions.
+	// * localIdx is in the local index namespace of that function, which begins with its parameters.
+	//
+	// Ex. Given the Text Format below, LocalName(0, 1) returns "y":
+	//	(module (func $add (param $x i32) (param $y i32) (result i32) local.get 0 local.get 1 i32.add))
+	//
+	// Note: Local names are only used for debugging. At runtime, locals are accessed by numeric index.
+	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-localnamesec
+	LocalName(funcIdx, localIdx uint32) (string, bool)
+
+	// SourcePosition returns the one-based line and column in the text format source of the instruction at offset pc
+	// in the body of funcIdx, or false if unknown.
+	//
+	// Ex. To find where a trap occurred:
+	//	if trapErr, ok := err.(*sys.TrapError); ok && trapErr.Location() != nil {
+	//		loc := trapErr.Location()
+	//		line, col, ok := compiled.SourcePosition(loc.FunctionIndex, loc.ProgramCounter)
+	//	--snip--
+	//
+	// Note: This is only known when compiled from the text format with RuntimeConfig.WithSourceMap enabled.
+	SourcePosition(funcIdx uint32, pc uint64) (line, col uint32, ok bool)
+
 	// Close releases all the allocated resources for this CompiledModule.
 	//
 	// Note: It is safe to call Close while having outstanding calls from an api.Module instantiated from this.
@@ -255,12 +553,104 @@ type compiledCode struct {
 	module *wasm.Module
 	// compiledEngine holds an engine on which `module` is compiled.
 	compiledEngine wasm.Engine
+	// runtime tracks this module until it is closed, or is nil if compiled by a ModuleBuilder.
+	runtime *runtime
+}
+
+// CustomSection implements CompiledModule.CustomSection
+func (c *compiledCode) CustomSection(name string) ([]byte, bool) {
+	for _, s := range c.module.CustomSections {
+		if s.Name == name {
+			return s.Data, true
+		}
+	}
+	return nil, false
+}
+
+// DWARFLine implements CompiledModule.DWARFLine
+func (c *compiledCode) DWARFLine(funcIdx uint32, pc uint64) (file string, line, col uint32, ok bool) {
+	return c.module.DWARFLine(funcIdx, pc)
+}
+
+// ExportedNames implements CompiledModule.ExportedNames
+func (c *compiledCode) ExportedNames() []string {
+	ret := make([]string, 0, len(c.module.ExportSection))
+	for _, exp := range c.module.ExportSection {
+		ret = append(ret, exp.Name)
+	}
+	return ret
+}
+
+// Exports implements CompiledModule.Exports
+func (c *compiledCode) Exports() []api.ExportDefinition {
+	ret := make([]api.ExportDefinition, 0, len(c.module.ExportSection))
+	for _, exp := range c.module.ExportSection {
+		def := api.ExportDefinition{Name: exp.Name, Type: exp.Type}
+		if exp.Type == api.ExternTypeFunc {
+			ft := c.module.TypeOfFunction(exp.Index)
+			def.ParamTypes, def.ResultTypes = copyValueTypes(ft.Params), copyValueTypes(ft.Results)
+		}
+		ret = append(ret, def)
+	}
+	return ret
+}
+
+// ImportedFunctions implements CompiledModule.ImportedFunctions
+func (c *compiledCode) ImportedFunctions() (ret []api.ImportDefinition) {
+	for _, def := range c.Imports() {
+		if def.Type == api.ExternTypeFunc {
+			ret = append(ret, def)
+		}
+	}
+	return
+}
+
+// Imports implements CompiledModule.Imports
+func (c *compiledCode) Imports() []api.ImportDefinition {
+	ret := make([]api.ImportDefinition, 0, len(c.module.ImportSection))
+	for _, imp := range c.module.ImportSection {
+		def := api.ImportDefinition{ModuleName: imp.Module, Name: imp.Name, Type: imp.Type}
+		if imp.Type == api.ExternTypeFunc {
+			ft := c.module.TypeSection[imp.DescFunc]
+			def.ParamTypes, def.ResultTypes = copyValueTypes(ft.Params), copyValueTypes(ft.Results)
+		}
+		ret = append(ret, def)
+	}
+	return ret
+}
+
+// copyValueTypes returns a copy of the types of a wasm.FunctionType, so that callers can't change the module.
+func copyValueTypes(types []api.ValueType) []api.ValueType {
+	if len(types) == 0 {
+		return nil
+	}
+	return append(make([]api.ValueType, 0, len(types)), types...)
+}
+
+// LocalName implements CompiledModule.LocalName
+func (c *compiledCode) LocalName(funcIdx, localIdx uint32) (string, bool) {
+	if c.module.NameSection == nil {
+		return "", false
+	}
+	return c.module.NameSection.LocalName(funcIdx, localIdx)
+}
+
+// SourcePosition implements CompiledModule.SourcePosition
+func (c *compiledCode) SourcePosition(funcIdx uint32, pc uint64) (line, col uint32, ok bool) {
+	var pos *wasm.SourcePosition
+	if pos, ok = c.module.SourcePosition(funcIdx, pc); ok {
+		line, col = pos.Line, pos.Col
+	}
+	return
 }
 
 // Close implements CompiledModule.Close
 func (c *compiledCode) Close(_ context.Context) error {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	if c.runtime != nil {
+		c.runtime.deleteCompiledModule(c)
+	}
 	c.compiledEngine.DeleteCompiledModule(c.module)
 	// It is possible the underlying may need to return an error later, but in any case this matches api.Module.Close.
 	return nil
@@ -283,6 +673,8 @@ type CompileConfig interface {
 	// WithMemorySizer are the allocation parameters used for a Wasm memory.
 	// The default is to set cap=min and max=65536 if unset.
 	//
+	// Note: A shared memory can't grow past its capacity. See RuntimeConfig.WithFeatureThreads
+	//
 	// Note: A nil function is invalid and ignored.
 	WithMemorySizer(api.MemorySizer) CompileConfig
 }
@@ -348,7 +740,9 @@ type ModuleConfig interface {
 	// WithEnv sets an environment variable visible to a Module that imports functions. Defaults to none.
 	//
 	// Validation is the same as os.Setenv on Linux and replaces any existing value. Unlike exec.Cmd Env, this does not
-	// default to the current process environment as that would violate sandboxing. This also does not preserve order.
+	// default to the current process environment as that would violate sandboxing.
+	//
+	// Entries are in the order their keys were first set. Replacing the value of an existing key keeps its position.
 	//
 	// Environment variables are commonly read by the functions like "environ_get" in "wasi_snapshot_preview1" although
 	// they could be read by functions imported from other modules.
@@ -376,16 +770,53 @@ type ModuleConfig interface {
 	//	config := wazero.NewModuleConfig().WithFS(rooted)
 	//
 	// Note: This sets WithWorkDirFS to the same file-system unless already set.
+	// Note: WASI only sandboxes symbolic links in an experimental.DirFS. Others, such as os.DirFS, follow a symbolic
+	// link even when its target is outside the directory.
 	WithFS(fs.FS) ModuleConfig
 
 	// WithName configures the module name. Defaults to what was decoded or overridden via CompileConfig.WithModuleName.
 	WithName(string) ModuleConfig
 
+	// WithOutputBufferSize buffers writes to stdout and stderr in memory, up to the given size in bytes. This defaults
+	// to zero, which writes each "fd_write" directly to the io.Writer configured by WithStdout or WithStderr.
+	//
+	// Buffering reduces the overhead of guests that write many small chunks, such as logging line by line to an
+	// *os.File. Buffered output is written when full, on "fd_sync" or "fd_datasync" of the file descriptor, and when
+	// the module is closed, including via "proc_exit". When WithStdout and WithStderr are the same io.Writer, they
+	// share one buffer, so that their output isn't reordered.
+	//
+	// Note: Only these buffers are flushed. An io.Writer you supply is never flushed, even if it has a Flush method.
+	//
+	// Ex. To write to os.Stdout in 4KiB chunks:
+	//	config := wazero.NewModuleConfig().WithStdout(os.Stdout).WithOutputBufferSize(4096)
+	WithOutputBufferSize(int) ModuleConfig
+
+	// WithRandSource configures the source of random bytes, such as for "random_get" in "wasi_snapshot_preview1".
+	// Defaults to RuntimeConfig.WithRandSource, or crypto/rand.Reader if that isn't set.
+	//
+	// Ex. To make "random_get" deterministic in a test:
+	//	config := wazero.NewModuleConfig().WithRandSource(rand.New(rand.NewSource(42)))
+	//
+	// Note: The caller is responsible to close any io.Reader they supply: It is not closed on api.Module Close.
+	WithRandSource(io.Reader) ModuleConfig
+
 	// WithStartFunctions configures the functions to call after the module is instantiated. Defaults to "_start".
 	//
 	// Note: If any function doesn't exist, it is skipped. However, all functions that do exist are called in order.
 	WithStartFunctions(...string) ModuleConfig
 
+	// WithStartFunctionObserver configures a function called with the name of each function configured by
+	// WithStartFunctions that was called and returned without error. Defaults to nil.
+	//
+	// As missing start functions are skipped, this helps diagnose which ran, such as "_initialize" for a WASI reactor
+	// vs "_start" for a WASI command.
+	//
+	// Ex. To log the start functions that ran:
+	//	config := wazero.NewModuleConfig().
+	//		WithStartFunctions("_initialize", "_start").
+	//		WithStartFunctionObserver(func(name string) { log.Println("ran start function", name) })
+	WithStartFunctionObserver(func(name string)) ModuleConfig
+
 	// WithStderr configures where standard error (file descriptor 2) is written. Defaults to io.Discard.
 	//
 	// This writer is most commonly used by the functions like "fd_write" in "wasi_snapshot_preview1" although it could
@@ -402,6 +833,9 @@ type ModuleConfig interface {
 	// be used by functions imported from other modules.
 	//
 	// Note: The caller is responsible to close any io.Reader they supply: It is not closed on api.Module Close.
+	// Note: api.Module Close unblocks a read in progress, such as "fd_read" waiting on a pipe. However, that Read of the
+	// io.Reader continues in the background until it returns, and any data it reads is discarded. The io.Reader is
+	// never read again after close.
 	// Note: This does not default to os.Stdin as that both violates sandboxing and prevents concurrent modules.
 	// See https://linux.die.net/man/3/stdin
 	WithStdin(io.Reader) ModuleConfig
@@ -424,11 +858,32 @@ type ModuleConfig interface {
 	//	var rootFS embed.FS
 	//
 	//	// Files relative to this source under appA are available under "/" and files relative to "/work/appA" under ".".
-	//	config := wazero.NewModuleConfig().WithFS(rootFS).WithWorkDirFS(os.DirFS("/work/appA"))
+	//	config := wazero.NewModuleConfig().WithFS(rootFS).WithWorkDirFS(experimental.DirFS("/work/appA"))
 	//
+	// Note: WASI can only create or write files when the file system is an experimental.WritableFS.
+	// Note: Like WithFS, WASI only sandboxes symbolic links in an experimental.DirFS.
 	// Note: os.DirFS documentation includes important notes about isolation, which also applies to fs.Sub. As of Go 1.18,
 	// the built-in file-systems are not jailed (chroot). See https://github.com/golang/go/issues/42322
 	WithWorkDirFS(fs.FS) ModuleConfig
+
+	// WithoutWorkDirFS disables defaulting the working directory (".") to the file system configured by WithFS. This
+	// has no effect on WithWorkDirFS.
+	//
+	// Ex. This results in only one pre-opened directory ("/"), so it is the only one returned by "fd_prestat_dir_name":
+	//	config := wazero.NewModuleConfig().WithFS(rootFS).WithoutWorkDirFS()
+	WithoutWorkDirFS() ModuleConfig
+
+	// WithoutStartSection skips calling the function in the start section of the module, if any, on instantiation.
+	// This has no effect on WithStartFunctions.
+	//
+	// This is useful for tools that inspect the memory or tables of a module without running its code, such as static
+	// analysis or fuzzing harnesses.
+	//
+	// Ex. This instantiates a module without running any of its functions:
+	//	config := wazero.NewModuleConfig().WithoutStartSection().WithStartFunctions()
+	//
+	// Note: The module may not be usable as intended, as the start function often initializes its state.
+	WithoutStartSection() ModuleConfig
 }
 
 type moduleConfig struct {
@@ -437,7 +892,12 @@ type moduleConfig struct {
 	stdin          io.Reader
 	stdout         io.Writer
 	stderr         io.Writer
-	args           []string
+	randSource     io.Reader
+	// startFunctionObserver is called with each of startFunctions that ran, or nil.
+	startFunctionObserver func(name string)
+	// outputBufferSize is the size of the buffers wrapping stdout and stderr, or zero if unbuffered.
+	outputBufferSize int
+	args             []string
 	// environ is pair-indexed to retain order similar to os.Environ.
 	environ []string
 	// environKeys allow overwriting of existing values.
@@ -449,6 +909,10 @@ type moduleConfig struct {
 	preopens map[uint32]*wasm.FileEntry
 	// preopenPaths allow overwriting of existing paths.
 	preopenPaths map[string]uint32
+	// withoutWorkDirFS disables defaulting the "." preopen to the "/" one.
+	withoutWorkDirFS bool
+	// withoutStartSection skips calling the start function of the module.
+	withoutStartSection bool
 }
 
 func NewModuleConfig() ModuleConfig {
@@ -469,9 +933,19 @@ func (c *moduleConfig) WithArgs(args ...string) ModuleConfig {
 }
 
 // WithEnv implements ModuleConfig.WithEnv
+//
+// Note: This copies environ and environKeys before modifying them, as they are otherwise shared with the config this
+// was copied from.
 func (c *moduleConfig) WithEnv(key, value string) ModuleConfig {
 	ret := *c // copy
-	// Check to see if this key already exists and update it.
+	ret.environ = make([]string, len(c.environ), len(c.environ)+2)
+	copy(ret.environ, c.environ)
+	ret.environKeys = make(map[string]int, len(c.environKeys)+1)
+	for k, i := range c.environKeys {
+		ret.environKeys[k] = i
+	}
+
+	// Check to see if this key already exists and update it in place, which retains its original position.
 	if i, ok := ret.environKeys[key]; ok {
 		ret.environ[i+1] = value // environ is pair-indexed, so the value is 1 after the key.
 	} else {
@@ -495,6 +969,20 @@ func (c *moduleConfig) WithName(name string) ModuleConfig {
 	return &ret
 }
 
+// WithOutputBufferSize implements ModuleConfig.WithOutputBufferSize
+func (c *moduleConfig) WithOutputBufferSize(size int) ModuleConfig {
+	ret := *c // copy
+	ret.outputBufferSize = size
+	return &ret
+}
+
+// WithRandSource implements ModuleConfig.WithRandSource
+func (c *moduleConfig) WithRandSource(randSource io.Reader) ModuleConfig {
+	ret := *c // copy
+	ret.randSource = randSource
+	return &ret
+}
+
 // WithStartFunctions implements ModuleConfig.WithStartFunctions
 func (c *moduleConfig) WithStartFunctions(startFunctions ...string) ModuleConfig {
 	ret := *c // copy
@@ -502,6 +990,13 @@ func (c *moduleConfig) WithStartFunctions(startFunctions ...string) ModuleConfig
 	return &ret
 }
 
+// WithStartFunctionObserver implements ModuleConfig.WithStartFunctionObserver
+func (c *moduleConfig) WithStartFunctionObserver(startFunctionObserver func(name string)) ModuleConfig {
+	ret := *c // copy
+	ret.startFunctionObserver = startFunctionObserver
+	return &ret
+}
+
 // WithStderr implements ModuleConfig.WithStderr
 func (c *moduleConfig) WithStderr(stderr io.Writer) ModuleConfig {
 	ret := *c // copy
@@ -530,8 +1025,31 @@ func (c *moduleConfig) WithWorkDirFS(fs fs.FS) ModuleConfig {
 	return &ret
 }
 
+// WithoutWorkDirFS implements ModuleConfig.WithoutWorkDirFS
+func (c *moduleConfig) WithoutWorkDirFS() ModuleConfig {
+	ret := *c // copy
+	ret.withoutWorkDirFS = true
+	return &ret
+}
+
+// WithoutStartSection implements ModuleConfig.WithoutStartSection
+func (c *moduleConfig) WithoutStartSection() ModuleConfig {
+	ret := *c // copy
+	ret.withoutStartSection = true
+	return &ret
+}
+
 // setFS maps a path to a file-system. This is only used for base paths: "/" and ".".
+//
+// Note: This copies the maps before modifying them, as they are otherwise shared with the config this was copied from.
 func (c *moduleConfig) setFS(path string, fs fs.FS) {
+	c.preopens = copyPreopens(c.preopens, 1)
+	preopenPaths := make(map[string]uint32, len(c.preopenPaths)+1)
+	for p, fd := range c.preopenPaths {
+		preopenPaths[p] = fd
+	}
+	c.preopenPaths = preopenPaths
+
 	// Check to see if this key already exists and update it.
 	entry := &wasm.FileEntry{Path: path, FS: fs}
 	if fd, ok := c.preopenPaths[path]; ok {
@@ -543,6 +1061,16 @@ func (c *moduleConfig) setFS(path string, fs fs.FS) {
 	}
 }
 
+// copyPreopens returns a copy of the preopens, with capacity for extra entries.
+func copyPreopens(preopens map[uint32]*wasm.FileEntry, extra int) map[uint32]*wasm.FileEntry {
+	ret := make(map[uint32]*wasm.FileEntry, len(preopens)+extra)
+	for fd, entry := range preopens {
+		e := *entry // copy, as the wasm.SysContext may modify it
+		ret[fd] = &e
+	}
+	return ret
+}
+
 // toSysContext creates a baseline wasm.SysContext configured by ModuleConfig.
 func (c *moduleConfig) toSysContext() (sys *wasm.SysContext, err error) {
 	var environ []string // Intentionally doesn't pre-allocate to reduce logic to default to nil.
@@ -565,7 +1093,9 @@ func (c *moduleConfig) toSysContext() (sys *wasm.SysContext, err error) {
 	// Ensure no-one set a nil FD. We do this here instead of at the call site to allow chaining as nil is unexpected.
 	rootFD := uint32(0) // zero is invalid
 	setWorkDirFS := false
-	preopens := c.preopens
+	// Copy the preopens, as the wasm.SysContext owns its opened files. Otherwise, closing a module would remove them
+	// from this config, and the fd of the working directory could differ on the next instantiation.
+	preopens := copyPreopens(c.preopens, 1)
 	for fd, entry := range preopens {
 		if entry.FS == nil {
 			err = fmt.Errorf("FS for %s is nil", entry.Path)
@@ -577,10 +1107,16 @@ func (c *moduleConfig) toSysContext() (sys *wasm.SysContext, err error) {
 		}
 	}
 
-	// Default the working directory to the root FS if it exists.
-	if rootFD != 0 && !setWorkDirFS {
+	// Default the working directory to the root FS if it exists, unless disabled.
+	if rootFD != 0 && !setWorkDirFS && !c.withoutWorkDirFS {
 		preopens[c.preopenFD] = &wasm.FileEntry{Path: ".", FS: preopens[rootFD].FS}
 	}
 
-	return wasm.NewSysContext(math.MaxUint32, c.args, environ, c.stdin, c.stdout, c.stderr, preopens)
+	stdout, stderr := c.stdout, c.stderr
+	if c.outputBufferSize > 0 {
+		// Nil writers default to io.Discard, so there's nothing to buffer.
+		stdout, stderr = wasm.NewOutputBuffers(stdout, stderr, c.outputBufferSize)
+	}
+
+	return wasm.NewSysContext(math.MaxUint32, c.args, environ, c.stdin, stdout, stderr, c.randSource, preopens)
 }
@@ -2,6 +2,7 @@ package wazero
 
 import (
 	"context"
+	_ "embed"
 	"io"
 	"math"
 	"reflect"
@@ -11,9 +12,13 @@ import (
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 func TestRuntimeConfig(t *testing.T) {
+	cache := &countingCompilationCache{}
 	tests := []struct {
 		name     string
 		with     func(RuntimeConfig) RuntimeConfig
@@ -28,6 +33,15 @@ func TestRuntimeConfig(t *testing.T) {
 				enabledFeatures: wasm.FeatureBulkMemoryOperations | wasm.FeatureReferenceTypes,
 			},
 		},
+		{
+			name: "extended-const",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFeatureExtendedConst(true)
+			},
+			expected: &runtimeConfig{
+				enabledFeatures: wasm.FeatureExtendedConst,
+			},
+		},
 		{
 			name: "multi-value",
 			with: func(c RuntimeConfig) RuntimeConfig {
@@ -91,6 +105,15 @@ func TestRuntimeConfig(t *testing.T) {
 				enabledFeatures: wasm.FeatureBulkMemoryOperations | wasm.FeatureReferenceTypes,
 			},
 		},
+		{
+			name: "multi-memory",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFeatureMultiMemory(true)
+			},
+			expected: &runtimeConfig{
+				enabledFeatures: wasm.FeatureMultiMemory,
+			},
+		},
 		{
 			name: "simd",
 			with: func(c RuntimeConfig) RuntimeConfig {
@@ -100,6 +123,60 @@ func TestRuntimeConfig(t *testing.T) {
 				enabledFeatures: wasm.FeatureSIMD,
 			},
 		},
+		{
+			name: "tail-call",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFeatureTailCall(true)
+			},
+			expected: &runtimeConfig{
+				enabledFeatures: wasm.FeatureTailCall,
+			},
+		},
+		{
+			name: "threads",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFeatureThreads(true)
+			},
+			expected: &runtimeConfig{
+				enabledFeatures: wasm.FeatureThreads,
+			},
+		},
+		{
+			name: "WithSourceMap",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithSourceMap(true)
+			},
+			expected: &runtimeConfig{
+				sourceMap: true,
+			},
+		},
+		{
+			name: "WithDebugInfoEnabled",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithDebugInfoEnabled(true)
+			},
+			expected: &runtimeConfig{
+				debugInfo: true,
+			},
+		},
+		{
+			name: "WithDWARFSymbols",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithDWARFSymbols(true)
+			},
+			expected: &runtimeConfig{
+				dwarfSymbols: true,
+			},
+		},
+		{
+			name: "WithCompilationCache",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithCompilationCache(cache)
+			},
+			expected: &runtimeConfig{
+				compilationCache: cache,
+			},
+		},
 	}
 	for _, tt := range tests {
 		tc := tt
@@ -277,6 +354,24 @@ func TestModuleConfig(t *testing.T) {
 				name: "wa0",
 			},
 		},
+		{
+			name: "WithOutputBufferSize",
+			with: func(c ModuleConfig) ModuleConfig {
+				return c.WithOutputBufferSize(4096)
+			},
+			expected: &moduleConfig{
+				outputBufferSize: 4096,
+			},
+		},
+		{
+			name: "WithoutStartSection",
+			with: func(c ModuleConfig) ModuleConfig {
+				return c.WithoutStartSection()
+			},
+			expected: &moduleConfig{
+				withoutStartSection: true,
+			},
+		},
 	}
 	for _, tt := range tests {
 		tc := tt
@@ -450,6 +545,21 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				},
 			),
 		},
+		{
+			name:  "WithFS and WithoutWorkDirFS",
+			input: NewModuleConfig().WithFS(testFS).WithoutWorkDirFS(),
+			expected: requireSysContext(t,
+				math.MaxUint32, // max
+				nil,            // args
+				nil,            // environ
+				nil,            // stdin
+				nil,            // stdout
+				nil,            // stderr
+				map[uint32]*wasm.FileEntry{ // openedFiles
+					3: {Path: "/", FS: testFS},
+				},
+			),
+		},
 		{
 			name:  "WithWorkDirFS",
 			input: NewModuleConfig().WithWorkDirFS(testFS),
@@ -481,6 +591,22 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 				},
 			),
 		},
+		{
+			name:  "WithFS, WithWorkDirFS and WithoutWorkDirFS",
+			input: NewModuleConfig().WithFS(testFS).WithWorkDirFS(testFS2).WithoutWorkDirFS(),
+			expected: requireSysContext(t,
+				math.MaxUint32, // max
+				nil,            // args
+				nil,            // environ
+				nil,            // stdin
+				nil,            // stdout
+				nil,            // stderr
+				map[uint32]*wasm.FileEntry{ // openedFiles
+					3: {Path: "/", FS: testFS},
+					4: {Path: ".", FS: testFS2},
+				},
+			),
+		},
 		{
 			name:  "WithWorkDirFS and WithFS",
 			input: NewModuleConfig().WithWorkDirFS(testFS).WithFS(testFS2),
@@ -509,6 +635,41 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 	}
 }
 
+// TestModuleConfig_toSysContext_preopens ensures the fd of each preopen doesn't change across configs derived from
+// the same one, nor across instantiations.
+func TestModuleConfig_toSysContext_preopens(t *testing.T) {
+	rootFS := fstest.MapFS{}
+	workDirFS := fstest.MapFS{}
+
+	base := NewModuleConfig().WithFS(rootFS)
+	withWorkDir := base.WithWorkDirFS(workDirFS)
+	withoutWorkDir := base.WithoutWorkDirFS()
+
+	for i := 0; i < 3; i++ {
+		for _, tc := range []struct {
+			config   ModuleConfig
+			expected map[uint32]*wasm.FileEntry
+		}{
+			{config: base, expected: map[uint32]*wasm.FileEntry{3: {Path: "/", FS: rootFS}, 4: {Path: ".", FS: rootFS}}},
+			{config: withWorkDir, expected: map[uint32]*wasm.FileEntry{3: {Path: "/", FS: rootFS}, 4: {Path: ".", FS: workDirFS}}},
+			{config: withoutWorkDir, expected: map[uint32]*wasm.FileEntry{3: {Path: "/", FS: rootFS}}},
+		} {
+			sysCtx, err := tc.config.(*moduleConfig).toSysContext()
+			require.NoError(t, err)
+			for fd, expected := range tc.expected {
+				actual, ok := sysCtx.OpenedFile(fd)
+				require.True(t, ok)
+				require.Equal(t, expected, actual)
+			}
+			_, ok := sysCtx.OpenedFile(uint32(3 + len(tc.expected)))
+			require.False(t, ok)
+
+			// Closing removes the opened files, which must not affect the next instantiation.
+			require.NoError(t, sysCtx.Close())
+		}
+	}
+}
+
 func TestModuleConfig_toSysContext_Errors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -563,7 +724,7 @@ func TestModuleConfig_toSysContext_Errors(t *testing.T) {
 
 // requireSysContext ensures wasm.NewSysContext doesn't return an error, which makes it usable in test matrices.
 func requireSysContext(t *testing.T, max uint32, args, environ []string, stdin io.Reader, stdout, stderr io.Writer, openedFiles map[uint32]*wasm.FileEntry) *wasm.SysContext {
-	sys, err := wasm.NewSysContext(max, args, environ, stdin, stdout, stderr, openedFiles)
+	sys, err := wasm.NewSysContext(max, args, environ, stdin, stdout, stderr, nil, openedFiles)
 	require.NoError(t, err)
 	return sys
 }
@@ -591,3 +752,286 @@ func TestCompiledCode_Close(t *testing.T) {
 		require.Zero(t, len(e.cachedModules))
 	}
 }
+
+func TestCompiledCode_CustomSection(t *testing.T) {
+	r := NewRuntime()
+	defer r.Close(testCtx)
+
+	source := binary.EncodeModule(&wasm.Module{
+		NameSection: &wasm.NameSection{ModuleName: "test"},
+		CustomSections: []*wasm.CustomSection{
+			{Name: "producers", Data: []byte{1, 2, 3}},
+			{Name: "producers", Data: []byte{4, 5, 6}},
+			{Name: ".debug_info", Data: []byte{7}},
+		},
+	})
+
+	compiled, err := r.CompileModule(testCtx, source, NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	// The first section of a name is returned.
+	data, ok := compiled.CustomSection("producers")
+	require.True(t, ok)
+	require.Equal(t, []byte{1, 2, 3}, data)
+
+	data, ok = compiled.CustomSection(".debug_info")
+	require.True(t, ok)
+	require.Equal(t, []byte{7}, data)
+
+	// The name section is decoded, not retained.
+	_, ok = compiled.CustomSection("name")
+	require.False(t, ok)
+
+	_, ok = compiled.CustomSection("missing")
+	require.False(t, ok)
+}
+
+func TestCompiledCode_Exports(t *testing.T) {
+	i32 := api.ValueTypeI32
+	r := NewRuntime()
+	defer r.Close(testCtx)
+
+	source := binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}},
+		},
+		ImportSection: []*wasm.Import{
+			{Module: "env", Name: "log", Type: wasm.ExternTypeFunc, DescFunc: 0},
+		},
+		FunctionSection: []wasm.Index{1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd}},
+		},
+		MemorySection: []*wasm.Memory{{Min: 1}},
+		GlobalSection: []*wasm.Global{
+			{Type: &wasm.GlobalType{ValType: i32}, Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}}},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "add", Type: wasm.ExternTypeFunc, Index: 1}, // index 0 is the imported function
+			{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
+			{Name: "zero", Type: wasm.ExternTypeGlobal, Index: 0},
+		},
+	})
+
+	compiled, err := r.CompileModule(testCtx, source, NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	require.Equal(t, []string{"add", "memory", "zero"}, compiled.ExportedNames())
+	require.Equal(t, []api.ExportDefinition{
+		{Name: "add", Type: api.ExternTypeFunc, ParamTypes: []api.ValueType{i32, i32}, ResultTypes: []api.ValueType{i32}},
+		{Name: "memory", Type: api.ExternTypeMemory},
+		{Name: "zero", Type: api.ExternTypeGlobal},
+	}, compiled.Exports())
+
+	// Changing the result doesn't change the module.
+	compiled.Exports()[0].ParamTypes[0] = api.ValueTypeF64
+	require.Equal(t, []api.ValueType{i32, i32}, compiled.Exports()[0].ParamTypes)
+}
+
+func TestCompiledCode_Imports(t *testing.T) {
+	i32, i64, f64 := api.ValueTypeI32, api.ValueTypeI64, api.ValueTypeF64
+	r := NewRuntime()
+	defer r.Close(testCtx)
+
+	source := binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32, i32}},
+			{Params: []wasm.ValueType{i32, i64, i32}, Results: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{f64}, Results: []wasm.ValueType{f64}},
+		},
+		ImportSection: []*wasm.Import{
+			{Module: "env", Name: "log", Type: wasm.ExternTypeFunc, DescFunc: 0},
+			{Module: "env", Name: "memory", Type: wasm.ExternTypeMemory, DescMem: &wasm.Memory{Min: 1}},
+			{Module: "wasi_snapshot_preview1", Name: "clock_time_get", Type: wasm.ExternTypeFunc, DescFunc: 1},
+			{Module: "env", Name: "pi", Type: wasm.ExternTypeGlobal, DescGlobal: &wasm.GlobalType{ValType: f64}},
+			{Module: "math", Name: "sqrt", Type: wasm.ExternTypeFunc, DescFunc: 2},
+		},
+	})
+
+	compiled, err := r.CompileModule(testCtx, source, NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	log := api.ImportDefinition{ModuleName: "env", Name: "log", Type: api.ExternTypeFunc, ParamTypes: []api.ValueType{i32, i32}}
+	clockTimeGet := api.ImportDefinition{
+		ModuleName:  "wasi_snapshot_preview1",
+		Name:        "clock_time_get",
+		Type:        api.ExternTypeFunc,
+		ParamTypes:  []api.ValueType{i32, i64, i32},
+		ResultTypes: []api.ValueType{i32},
+	}
+	sqrt := api.ImportDefinition{
+		ModuleName:  "math",
+		Name:        "sqrt",
+		Type:        api.ExternTypeFunc,
+		ParamTypes:  []api.ValueType{f64},
+		ResultTypes: []api.ValueType{f64},
+	}
+	require.Equal(t, []api.ImportDefinition{log, clockTimeGet, sqrt}, compiled.ImportedFunctions())
+	require.Equal(t, []api.ImportDefinition{
+		log,
+		{ModuleName: "env", Name: "memory", Type: api.ExternTypeMemory},
+		clockTimeGet,
+		{ModuleName: "env", Name: "pi", Type: api.ExternTypeGlobal},
+		sqrt,
+	}, compiled.Imports())
+
+	// Changing the result doesn't change the module.
+	compiled.Imports()[0].ParamTypes[0] = api.ValueTypeF64
+	compiled.ImportedFunctions()[1].ResultTypes[0] = api.ValueTypeF64
+	require.Equal(t, []api.ImportDefinition{log, clockTimeGet, sqrt}, compiled.ImportedFunctions())
+}
+
+func TestCompiledCode_LocalName(t *testing.T) {
+	i32 := wasm.ValueTypeI32
+	r := NewRuntime()
+	defer r.Close(testCtx)
+
+	// Ex. what a compiler emits for (func $add (param $x i32) (param i32) (result i32) (local $sum i32) ...)
+	source := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}}},
+		ImportSection:   []*wasm.Import{{Type: wasm.ExternTypeFunc, Module: "env", Name: "log", DescFunc: 0}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{
+			LocalTypes: []wasm.ValueType{i32},
+			Body:       []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd},
+		}},
+		NameSection: &wasm.NameSection{LocalNames: wasm.IndirectNameMap{
+			{Index: 0, NameMap: wasm.NameMap{{Index: 0, Name: "level"}, {Index: 1, Name: "msg"}}},
+			{Index: 1, NameMap: wasm.NameMap{{Index: 0, Name: "x"}, {Index: 2, Name: "sum"}}},
+		}},
+	})
+
+	compiled, err := r.CompileModule(testCtx, source, NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	for _, tc := range []struct {
+		funcIdx, localIdx uint32
+		expected          string
+		expectedOk        bool
+	}{
+		{funcIdx: 0, localIdx: 0, expected: "level", expectedOk: true},
+		{funcIdx: 0, localIdx: 1, expected: "msg", expectedOk: true},
+		{funcIdx: 1, localIdx: 0, expected: "x", expectedOk: true},
+		{funcIdx: 1, localIdx: 1}, // unnamed param
+		{funcIdx: 1, localIdx: 2, expected: "sum", expectedOk: true},
+		{funcIdx: 2, localIdx: 0}, // no such function
+	} {
+		name, ok := compiled.LocalName(tc.funcIdx, tc.localIdx)
+		require.Equal(t, tc.expectedOk, ok)
+		require.Equal(t, tc.expected, name)
+	}
+
+	t.Run("no name section", func(t *testing.T) {
+		compiled, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{}), NewCompileConfig())
+		require.NoError(t, err)
+		defer compiled.Close(testCtx)
+
+		_, ok := compiled.LocalName(0, 0)
+		require.False(t, ok)
+	})
+}
+
+func TestCompiledCode_SourcePosition(t *testing.T) {
+	r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter().WithSourceMap(true))
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module $math
+	(import "env" "log" (func $log (param i32)))
+	(func $div (param i32) (param i32) (result i32)
+		local.get 0
+		local.get 1
+		i32.div_s
+	)
+	(export "div" (func $div))
+)`), NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	_, err = r.NewModuleBuilder("env").ExportFunction("log", func(uint32) {}).Instantiate(testCtx)
+	require.NoError(t, err)
+
+	m, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig())
+	require.NoError(t, err)
+
+	_, err = m.ExportedFunction("div").Call(testCtx, 1, 0)
+	require.ErrorIs(t, err, wasmruntime.ErrRuntimeIntegerDivideByZero)
+
+	trapErr, ok := err.(*sys.TrapError)
+	require.True(t, ok)
+	loc := trapErr.Location()
+	require.Equal(t, &sys.TrapLocation{ModuleName: "math", FunctionIndex: 1, ProgramCounter: 4}, loc)
+
+	line, col, ok := compiled.SourcePosition(loc.FunctionIndex, loc.ProgramCounter)
+	require.True(t, ok)
+	require.Equal(t, uint32(6), line) // i32.div_s
+	require.Equal(t, uint32(3), col)
+
+	_, _, ok = compiled.SourcePosition(0, 0) // imported functions have no source.
+	require.False(t, ok)
+
+	t.Run("disabled", func(t *testing.T) {
+		r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter())
+		defer r.Close(testCtx)
+
+		compiled, err := r.CompileModule(testCtx, []byte(`(module (func i32.const 1 drop))`), NewCompileConfig())
+		require.NoError(t, err)
+		defer compiled.Close(testCtx)
+
+		_, _, ok := compiled.SourcePosition(0, 0)
+		require.False(t, ok)
+	})
+}
+
+// greetWasm was compiled by rustc with debug information from examples/allocation/rust/testdata/src/lib.rs
+//go:embed examples/allocation/rust/testdata/greet.wasm
+var greetWasm []byte
+
+func TestCompiledCode_DWARFLine(t *testing.T) {
+	r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter().WithDWARFSymbols(true))
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, greetWasm, NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	_, err = r.NewModuleBuilder("env").ExportFunction("log", func(uint32, uint32) {}).Instantiate(testCtx)
+	require.NoError(t, err)
+
+	m, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig())
+	require.NoError(t, err)
+
+	// A string of the maximum length overflows the capacity of a Vec, which panics, then aborts via unreachable.
+	_, err = m.ExportedFunction("greet").Call(testCtx, 0, math.MaxUint32)
+	require.ErrorIs(t, err, wasmruntime.ErrRuntimeUnreachable)
+
+	trapErr, ok := err.(*sys.TrapError)
+	require.True(t, ok)
+	loc := trapErr.Location()
+	require.NotNil(t, loc)
+
+	file, line, col, ok := compiled.DWARFLine(loc.FunctionIndex, loc.ProgramCounter)
+	require.True(t, ok)
+	require.Equal(t, "/rustc/7737e0b5c4103216d6fd8cf941b7ab9bdbaace7c/library/panic_abort/src/lib.rs", file)
+	require.Equal(t, uint32(84), line)
+	require.Equal(t, uint32(17), col)
+
+	_, _, _, ok = compiled.DWARFLine(0, 0) // imported functions have no source.
+	require.False(t, ok)
+
+	t.Run("disabled", func(t *testing.T) {
+		r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter())
+		defer r.Close(testCtx)
+
+		compiled, err := r.CompileModule(testCtx, greetWasm, NewCompileConfig())
+		require.NoError(t, err)
+		defer compiled.Close(testCtx)
+
+		_, _, _, ok := compiled.DWARFLine(loc.FunctionIndex, loc.ProgramCounter)
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,48 @@
+package experimental
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WritableFS is a fs.FS that can also open files for writing. Configure one with wazero.ModuleConfig WithFS or
+// WithWorkDirFS to allow WASI "path_open" to create, truncate or write files. Any other fs.FS is read-only.
+//
+// See https://github.com/tetratelabs/wazero/issues/390
+type WritableFS interface {
+	fs.FS
+
+	// OpenFile is like os.OpenFile, except name is a path valid per fs.ValidPath, relative to the root of this file
+	// system. flag includes one of os.O_RDONLY, os.O_WRONLY or os.O_RDWR.
+	OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error)
+}
+
+// DirFS is a WritableFS for the tree of files rooted at the host directory it names. Reads are the same as os.DirFS.
+//
+// Ex. This allows the guest to write files under "/work/appA" as its working directory (".").
+//
+//	config := wazero.NewModuleConfig().WithWorkDirFS(experimental.DirFS("/work/appA"))
+//
+// Note: Unlike os.DirFS, WASI rejects opening a path which resolves outside the directory via a symbolic link.
+type DirFS string
+
+// compile-time check to ensure DirFS implements WritableFS.
+var _ WritableFS = DirFS("")
+
+// Open implements fs.FS
+func (d DirFS) Open(name string) (fs.File, error) {
+	return os.DirFS(string(d)).Open(name)
+}
+
+// OpenFile implements WritableFS.OpenFile
+func (d DirFS) OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	f, err := os.OpenFile(filepath.Join(string(d), filepath.FromSlash(name)), flag, perm)
+	if err != nil {
+		return nil, err // avoid a non-nil fs.File holding a nil *os.File
+	}
+	return f, nil
+}
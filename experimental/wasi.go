@@ -0,0 +1,16 @@
+package experimental
+
+import "context"
+
+// WASILoggerKey is a context.Context Value key. Its associated value should be a WASILogger.
+//
+// Note: The logger is read from the context passed to wasi.InstantiateSnapshotPreview1.
+type WASILoggerKey struct{}
+
+// WASILogger traces WASI functions that act on a file descriptor, such as "fd_read" or "path_open".
+type WASILogger interface {
+	// LogFd is called after the WASI function named functionName returns errno for the file descriptor fd.
+	//
+	// Note: errno is the numeric value of a wasi.Errno, where zero is success.
+	LogFd(ctx context.Context, functionName string, fd uint32, errno uint32)
+}
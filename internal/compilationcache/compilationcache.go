@@ -0,0 +1,99 @@
+// Package compilationcache persists the result of Engine.CompileModule, so that compiling the same module again can be
+// skipped, even in a different process.
+package compilationcache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+
+	"github.com/tetratelabs/wazero/internal/version"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// Key is the unique identifier of compiled code in a Cache.
+type Key = [sha256.Size]byte
+
+// Cache persists compiled code by Key.
+//
+// Note: Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the content added for the key, or false if there is none.
+	Get(key Key) (content []byte, ok bool, err error)
+
+	// Add stores the content for the key, replacing any existing content.
+	Add(key Key, content []byte) error
+}
+
+// NewKey returns the Key of the module compiled by the current version of wazero with the given features.
+//
+// The key changes whenever any input of compilation does: the module, the enabled features or the version of wazero.
+// extra is for any other configuration which changes the compiled code, such as whether source offsets are recorded.
+func NewKey(moduleID wasm.ModuleID, enabledFeatures wasm.Features, extra ...byte) Key {
+	h := sha256.New()
+	h.Write(moduleID[:])
+	var features [8]byte
+	binary.LittleEndian.PutUint64(features[:], uint64(enabledFeatures))
+	h.Write(features[:])
+	h.Write([]byte(version.GetWazeroVersion()))
+	h.Write(extra)
+
+	var ret Key
+	copy(ret[:], h.Sum(nil))
+	return ret
+}
+
+// NewFileCache returns a Cache which stores each entry as a file in dir, creating it if it doesn't exist.
+func NewFileCache(dir string) (Cache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &fileCache{dir: dir}, nil
+}
+
+// fileCache implements Cache with one file per Key, named by its hex encoding.
+type fileCache struct {
+	dir string
+}
+
+func (c *fileCache) path(key Key) string {
+	return path.Join(c.dir, hex.EncodeToString(key[:]))
+}
+
+// Get implements Cache.Get
+func (c *fileCache) Get(key Key) (content []byte, ok bool, err error) {
+	content, err = os.ReadFile(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return content, true, nil
+}
+
+// Add implements Cache.Add
+//
+// The content is written to a temporary file first and then renamed, so that a concurrent Get never sees a partial
+// entry.
+func (c *fileCache) Add(key Key, content []byte) error {
+	f, err := os.CreateTemp(c.dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, c.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+	}
+	return err
+}
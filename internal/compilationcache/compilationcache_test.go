@@ -0,0 +1,61 @@
+package compilationcache
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+func TestNewKey(t *testing.T) {
+	id := wasm.ModuleID{1, 2, 3}
+	key := NewKey(id, wasm.Features20191205)
+
+	// Same inputs produce the same key.
+	require.Equal(t, key, NewKey(id, wasm.Features20191205))
+
+	// Any change of inputs produces a different key.
+	require.NotEqual(t, key, NewKey(wasm.ModuleID{1, 2, 4}, wasm.Features20191205))
+	require.NotEqual(t, key, NewKey(id, wasm.Features20220419))
+	require.NotEqual(t, key, NewKey(id, wasm.Features20191205, 1))
+}
+
+func TestFileCache(t *testing.T) {
+	dir := path.Join(t.TempDir(), "cache") // doesn't exist yet
+	c, err := NewFileCache(dir)
+	require.NoError(t, err)
+
+	key := NewKey(wasm.ModuleID{1}, wasm.Features20191205)
+
+	_, ok, err := c.Get(key)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, c.Add(key, []byte("wazero")))
+	content, ok, err := c.Get(key)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte("wazero"), content)
+
+	// Adding again replaces the content.
+	require.NoError(t, c.Add(key, []byte("wa")))
+	content, ok, err = c.Get(key)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte("wa"), content)
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+
+	// Another cache of the same directory, such as in a different process, sees the entry.
+	c2, err := NewFileCache(dir)
+	require.NoError(t, err)
+	content, ok, err = c2.Get(key)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte("wa"), content)
+}
@@ -38,6 +38,9 @@ type (
 		functions []*function
 
 		importedFunctionCount uint32
+
+		// callEngines pools callEngine, so that stacks allocated by one Call are reused by the next.
+		callEngines sync.Pool
 	}
 
 	// callEngine holds context per moduleEngine.Call, and shared across all the
@@ -552,6 +555,17 @@ func (me *moduleEngine) InitializeFuncrefGlobals(globals []*wasm.GlobalInstance)
 
 // Call implements the same method as documented on wasm.ModuleEngine.
 func (me *moduleEngine) Call(ctx context.Context, callCtx *wasm.CallContext, f *wasm.FunctionInstance, params ...uint64) (results []uint64, err error) {
+	if resultCount := f.Type.ResultNumInUint64; resultCount > 0 {
+		results = make([]uint64, resultCount)
+	}
+	if err = me.CallInto(ctx, callCtx, f, results, params...); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// CallInto implements the same method as documented on wasm.ModuleEngine.
+func (me *moduleEngine) CallInto(ctx context.Context, callCtx *wasm.CallContext, f *wasm.FunctionInstance, results []uint64, params ...uint64) (err error) {
 	// Note: The input parameters are pre-validated, so a compiled function is only absent on close. Updates to
 	// code on close aren't locked, neither is this read.
 	compiled := me.functions[f.Idx]
@@ -565,8 +579,13 @@ func (me *moduleEngine) Call(ctx context.Context, callCtx *wasm.CallContext, f *
 	paramSignature := f.Type.Params
 	paramCount := len(params)
 	if len(paramSignature) != paramCount {
-		return nil, fmt.Errorf("expected %d params, but passed %d", len(paramSignature), paramCount)
+		return fmt.Errorf("expected %d params, but passed %d", len(paramSignature), paramCount)
+	}
+	resultCount := f.Type.ResultNumInUint64
+	if len(results) < resultCount {
+		return fmt.Errorf("expected %d results, but passed %d", resultCount, len(results))
 	}
+	results = results[:resultCount]
 
 	ce := me.newCallEngine()
 
@@ -594,6 +613,7 @@ func (me *moduleEngine) Call(ctx context.Context, callCtx *wasm.CallContext, f *
 			}
 			err = builder.FromRecovered(v)
 		}
+		me.releaseCallEngine(ce)
 	}()
 
 	if f.Kind == wasm.FunctionKindWasm {
@@ -601,9 +621,11 @@ func (me *moduleEngine) Call(ctx context.Context, callCtx *wasm.CallContext, f *
 			ce.pushValue(v)
 		}
 		ce.execWasmFunction(ctx, callCtx, compiled)
-		results = wasm.PopValues(f.Type.ResultNumInUint64, ce.popValue)
+		for i := resultCount - 1; i >= 0; i-- {
+			results[i] = ce.popValue()
+		}
 	} else {
-		results = wasm.CallGoFunc(ctx, callCtx, compiled.source, params)
+		copy(results, wasm.CallGoFunc(ctx, callCtx, compiled.source, params))
 	}
 	return
 }
@@ -652,12 +674,44 @@ var (
 	initialCallFrameStackSize = 16
 )
 
+// maxPooledValueStackSize and maxPooledCallFrameStackSize bound the stacks of a pooled callEngine, so that a deep call,
+// such as one overflowing at callStackCeiling, doesn't keep its stacks after it returns.
+const (
+	maxPooledValueStackSize     = 1 << 12
+	maxPooledCallFrameStackSize = 1 << 8
+)
+
 func (me *moduleEngine) newCallEngine() *callEngine {
+	if ce, ok := me.callEngines.Get().(*callEngine); ok {
+		return ce
+	}
 	ce := &callEngine{
 		valueStack:     make([]uint64, initialValueStackSize),
 		callFrameStack: make([]callFrame, initialCallFrameStackSize),
-		archContext:    newArchContext(),
 	}
+	ce.reset()
+	return ce
+}
+
+// releaseCallEngine resets ce and returns it to the pool, so that the next Call doesn't allocate its stacks. ce is
+// dropped instead when its stacks grew beyond maxPooledValueStackSize or maxPooledCallFrameStackSize.
+func (me *moduleEngine) releaseCallEngine(ce *callEngine) {
+	if len(ce.valueStack) > maxPooledValueStackSize || len(ce.callFrameStack) > maxPooledCallFrameStackSize {
+		return
+	}
+	for i := range ce.callFrameStack {
+		ce.callFrameStack[i] = callFrame{} // don't pin functions, and their modules, while pooled
+	}
+	ce.reset()
+	me.callEngines.Put(ce)
+}
+
+// reset initializes all the contexts of ce for a new Call, retaining its (possibly grown) stacks.
+func (ce *callEngine) reset() {
+	ce.moduleContext = moduleContext{}
+	ce.valueStackContext = valueStackContext{}
+	ce.exitContext = exitContext{}
+	ce.archContext = newArchContext()
 
 	valueStackHeader := (*reflect.SliceHeader)(unsafe.Pointer(&ce.valueStack))
 	callFrameStackHeader := (*reflect.SliceHeader)(unsafe.Pointer(&ce.callFrameStack))
@@ -668,7 +722,6 @@ func (me *moduleEngine) newCallEngine() *callEngine {
 		callFrameStackLen:                uint64(callFrameStackHeader.Len),
 		callFrameStackPointer:            0,
 	}
-	return ce
 }
 
 func (ce *callEngine) popValue() (ret uint64) {
@@ -840,6 +893,38 @@ func compileHostFunction(sig *wasm.FunctionType) (*code, error) {
 	return &code{codeSegment: c}, nil
 }
 
+// requireMemoryIndexZero returns an error if the operation accesses a memory other than the first, as the compiler
+// doesn't yet support wasm.FeatureMultiMemory.
+func requireMemoryIndexZero(op wazeroir.Operation) error {
+	var memoryIndex uint32
+	switch o := op.(type) {
+	case *wazeroir.OperationLoad:
+		memoryIndex = o.Arg.MemoryIndex
+	case *wazeroir.OperationLoad8:
+		memoryIndex = o.Arg.MemoryIndex
+	case *wazeroir.OperationLoad16:
+		memoryIndex = o.Arg.MemoryIndex
+	case *wazeroir.OperationLoad32:
+		memoryIndex = o.Arg.MemoryIndex
+	case *wazeroir.OperationStore:
+		memoryIndex = o.Arg.MemoryIndex
+	case *wazeroir.OperationStore8:
+		memoryIndex = o.Arg.MemoryIndex
+	case *wazeroir.OperationStore16:
+		memoryIndex = o.Arg.MemoryIndex
+	case *wazeroir.OperationStore32:
+		memoryIndex = o.Arg.MemoryIndex
+	case *wazeroir.OperationMemorySize:
+		memoryIndex = o.MemoryIndex
+	case *wazeroir.OperationMemoryGrow:
+		memoryIndex = o.MemoryIndex
+	}
+	if memoryIndex != 0 {
+		return fmt.Errorf("%s on memory[%d] is not yet supported by the compiler", op.Kind(), memoryIndex)
+	}
+	return nil
+}
+
 func compileWasmFunction(enabledFeatures wasm.Features, ir *wazeroir.CompilationResult) (*code, error) {
 	compiler, err := newCompiler(ir)
 	if err != nil {
@@ -865,6 +950,11 @@ func compileWasmFunction(enabledFeatures wasm.Features, ir *wazeroir.Compilation
 		if buildoptions.IsDebugMode {
 			fmt.Printf("compiling op=%s: %s\n", op.Kind(), compiler)
 		}
+		if enabledFeatures.Get(wasm.FeatureMultiMemory) {
+			if err := requireMemoryIndexZero(op); err != nil {
+				return nil, err
+			}
+		}
 		var err error
 		switch o := op.(type) {
 		case *wazeroir.OperationLabel:
@@ -884,7 +974,11 @@ func compileWasmFunction(enabledFeatures wasm.Features, ir *wazeroir.Compilation
 		case *wazeroir.OperationDrop:
 			err = compiler.compileDrop(o)
 		case *wazeroir.OperationSelect:
-			err = compiler.compileSelect()
+			if o.IsTargetVector {
+				err = errors.New("unsupported")
+			} else {
+				err = compiler.compileSelect()
+			}
 		case *wazeroir.OperationPick:
 			err = compiler.compilePick(o)
 		case *wazeroir.OperationSwap:
@@ -1041,6 +1135,12 @@ func compileWasmFunction(enabledFeatures wasm.Features, ir *wazeroir.Compilation
 			err = compiler.compileTableSize(o)
 		case *wazeroir.OperationTableFill:
 			err = compiler.compileTableFill(o)
+		case *wazeroir.OperationAtomicLoad, *wazeroir.OperationAtomicStore, *wazeroir.OperationAtomicRMW,
+			*wazeroir.OperationAtomicRMWCmpxchg, *wazeroir.OperationAtomicFence, *wazeroir.OperationAtomicMemoryNotify,
+			*wazeroir.OperationAtomicMemoryWait:
+			err = errors.New("atomic instructions are not yet supported by the compiler")
+		case *wazeroir.OperationReturnCall, *wazeroir.OperationReturnCallIndirect:
+			err = errors.New("tail calls are not yet supported by the compiler")
 		default:
 			err = errors.New("unsupported")
 		}
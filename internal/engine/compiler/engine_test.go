@@ -173,6 +173,11 @@ func TestCompiler_ModuleEngine_Memory(t *testing.T) {
 	enginetest.RunTestModuleEngine_Memory(t, et)
 }
 
+func TestCompiler_ModuleEngine_DataDrop(t *testing.T) {
+	requireSupportedOSArch(t)
+	enginetest.RunTestModuleEngine_DataDrop(t, et)
+}
+
 func requireSupportedOSArch(t *testing.T) {
 	if runtime.GOARCH != "amd64" && runtime.GOARCH != "arm64" {
 		t.Skip()
@@ -258,6 +263,33 @@ func TestCompiler_Releasecode_Panic(t *testing.T) {
 	require.Contains(t, captured.Error(), fmt.Sprintf("compiler: failed to munmap code segment for %[1]s.function[2]", t.Name()))
 }
 
+func TestCompiler_ModuleEngine_releaseCallEngine(t *testing.T) {
+	me := &moduleEngine{}
+
+	t.Run("clears frames", func(t *testing.T) {
+		ce := me.newCallEngine()
+		ce.callFrameStack[0].function = &function{}
+		ce.callFrameStack[1].function = &function{}
+		ce.callFrameStackPointer = 1
+
+		me.releaseCallEngine(ce)
+		require.Zero(t, ce.callFrameStackPointer)
+		for _, f := range ce.callFrameStack {
+			require.Nil(t, f.function)
+		}
+	})
+
+	t.Run("drops grown stacks", func(t *testing.T) {
+		ce := me.newCallEngine()
+		ce.callFrameStack = make([]callFrame, maxPooledCallFrameStackSize+1)
+		ce.callFrameStack[0].function = &function{}
+
+		me.releaseCallEngine(ce)
+		require.NotSame(t, ce, me.newCallEngine())
+		require.NotNil(t, ce.callFrameStack[0].function) // untouched, as it isn't pooled
+	})
+}
+
 // Ensures that value stack and call-frame stack are allocated on heap which
 // allows us to safely access to their data region from native code.
 // See comments on initialValueStackSize and initialCallFrameStackSize.
@@ -284,7 +316,7 @@ func TestCompiler_SliceAllocatedOnHeap(t *testing.T) {
 		// Trigger relocation of goroutine stack because at this point we have the majority of
 		// goroutine stack unused after recursive call.
 		runtime.GC()
-	}}, map[string]*wasm.Memory{}, map[string]*wasm.Global{}, enabledFeatures)
+	}}, map[string]*wasm.Memory{}, map[string]*wasm.Global{}, map[string]*wasm.Table{}, enabledFeatures)
 	require.NoError(t, err)
 
 	err = store.Engine.CompileModule(testCtx, hm)
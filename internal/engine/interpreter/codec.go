@@ -0,0 +1,216 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wazeroir"
+)
+
+// codecMagic prefixes the encoded code of a module, so that content encoded by another engine or an incompatible
+// version of this encoding is rejected. Increment the trailing version when changing the encoding, interpreterOp or
+// the values of wazeroir.OperationKind.
+var codecMagic = []byte("wazero-interpreter\x03")
+
+// CompiledCodeVersion implements wasm.CompiledCodeCodec.CompiledCodeVersion
+func (e *engine) CompiledCodeVersion() []byte {
+	return codecMagic
+}
+
+// EncodeCompiledCode implements wasm.CompiledCodeCodec.EncodeCompiledCode
+func (e *engine) EncodeCompiledCode(module *wasm.Module) ([]byte, error) {
+	codes, ok := e.getCodes(module)
+	if !ok {
+		return nil, fmt.Errorf("source module for %s must be compiled before encoding", module.ID)
+	} else if module.IsHostModule() {
+		return nil, errors.New("host modules cannot be encoded")
+	}
+
+	var buf bytes.Buffer
+	buf.Write(codecMagic)
+	writeUvarint(&buf, uint64(len(codes)))
+	for _, c := range codes {
+		writeUvarint(&buf, uint64(len(c.body)))
+		for _, op := range c.body {
+			writeUvarint(&buf, uint64(op.kind))
+			buf.WriteByte(op.b1)
+			buf.WriteByte(op.b2)
+			if op.b3 {
+				buf.WriteByte(1)
+			} else {
+				buf.WriteByte(0)
+			}
+			writeUint64s(&buf, op.us)
+			writeNilableLen(&buf, op.rs == nil, len(op.rs))
+			for _, r := range op.rs {
+				if r == nil {
+					buf.WriteByte(0)
+					continue
+				}
+				buf.WriteByte(1)
+				writeVarint(&buf, int64(r.Start))
+				writeVarint(&buf, int64(r.End))
+			}
+		}
+		writeUint64s(&buf, c.sourceOffsets)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeCompiledCode implements wasm.CompiledCodeCodec.DecodeCompiledCode
+func (e *engine) DecodeCompiledCode(module *wasm.Module, content []byte) error {
+	if _, ok := e.getCodes(module); ok { // cache hit!
+		return nil
+	}
+
+	codes, err := decodeCodes(content)
+	if err != nil {
+		return fmt.Errorf("invalid compiled code: %w", err)
+	} else if len(codes) != len(module.FunctionSection) {
+		return fmt.Errorf("invalid compiled code: expected %d functions, but have %d", len(module.FunctionSection), len(codes))
+	}
+	e.addCodes(module, codes)
+	return nil
+}
+
+func decodeCodes(content []byte) ([]*code, error) {
+	if !bytes.HasPrefix(content, codecMagic) {
+		return nil, errors.New("invalid header")
+	}
+	r := bytes.NewReader(content[len(codecMagic):])
+
+	codeCount, err := readLen(r)
+	if err != nil {
+		return nil, fmt.Errorf("read code count: %w", err)
+	}
+	codes := make([]*code, codeCount)
+	for i := range codes {
+		c := &code{}
+		opCount, err := readLen(r)
+		if err != nil {
+			return nil, fmt.Errorf("code[%d]: read op count: %w", i, err)
+		}
+		c.body = make([]*interpreterOp, opCount)
+		for j := range c.body {
+			if c.body[j], err = decodeOp(r); err != nil {
+				return nil, fmt.Errorf("code[%d]: op[%d]: %w", i, j, err)
+			}
+		}
+		if c.sourceOffsets, err = readUint64s(r); err != nil {
+			return nil, fmt.Errorf("code[%d]: read source offsets: %w", i, err)
+		}
+		codes[i] = c
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d bytes remain after the last code", r.Len())
+	}
+	return codes, nil
+}
+
+func decodeOp(r *bytes.Reader) (*interpreterOp, error) {
+	kind, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("read kind: %w", err)
+	}
+	var fixed [3]byte
+	if _, err = io.ReadFull(r, fixed[:]); err != nil {
+		return nil, fmt.Errorf("read immediates: %w", err)
+	}
+	op := &interpreterOp{kind: wazeroir.OperationKind(kind), b1: fixed[0], b2: fixed[1], b3: fixed[2] != 0}
+	if op.us, err = readUint64s(r); err != nil {
+		return nil, fmt.Errorf("read us: %w", err)
+	}
+
+	isNil, rangeCount, err := readNilableLen(r)
+	if err != nil {
+		return nil, fmt.Errorf("read rs count: %w", err)
+	} else if isNil {
+		return op, nil
+	}
+	op.rs = make([]*wazeroir.InclusiveRange, rangeCount)
+	for i := range op.rs {
+		present, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("read rs[%d]: %w", i, err)
+		} else if present == 0 {
+			continue
+		}
+		start, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, fmt.Errorf("read rs[%d].Start: %w", i, err)
+		}
+		end, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, fmt.Errorf("read rs[%d].End: %w", i, err)
+		}
+		op.rs[i] = &wazeroir.InclusiveRange{Start: int(start), End: int(end)}
+	}
+	return op, nil
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func writeVarint(buf *bytes.Buffer, v int64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutVarint(b[:], v)])
+}
+
+// writeNilableLen writes zero for a nil slice, or its length plus one otherwise, so that decoding preserves nil.
+func writeNilableLen(buf *bytes.Buffer, isNil bool, length int) {
+	if isNil {
+		writeUvarint(buf, 0)
+	} else {
+		writeUvarint(buf, uint64(length)+1)
+	}
+}
+
+func writeUint64s(buf *bytes.Buffer, vs []uint64) {
+	writeNilableLen(buf, vs == nil, len(vs))
+	for _, v := range vs {
+		writeUvarint(buf, v)
+	}
+}
+
+// readLen reads a length, which can't exceed the remaining bytes as each element is at least one byte.
+func readLen(r *bytes.Reader) (int, error) {
+	v, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, err
+	} else if v > uint64(r.Len()) {
+		return 0, fmt.Errorf("length %d exceeds the remaining %d bytes", v, r.Len())
+	}
+	return int(v), nil
+}
+
+func readNilableLen(r *bytes.Reader) (isNil bool, length int, err error) {
+	v, err := binary.ReadUvarint(r)
+	if err != nil {
+		return false, 0, err
+	} else if v == 0 {
+		return true, 0, nil
+	} else if v-1 > uint64(r.Len()) {
+		return false, 0, fmt.Errorf("length %d exceeds the remaining %d bytes", v-1, r.Len())
+	}
+	return false, int(v - 1), nil
+}
+
+func readUint64s(r *bytes.Reader) ([]uint64, error) {
+	isNil, length, err := readNilableLen(r)
+	if err != nil || isNil {
+		return nil, err
+	}
+	vs := make([]uint64, length)
+	for i := range vs {
+		if vs[i], err = binary.ReadUvarint(r); err != nil {
+			return nil, err
+		}
+	}
+	return vs, nil
+}
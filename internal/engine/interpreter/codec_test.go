@@ -0,0 +1,115 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wazeroir"
+)
+
+func TestEngine_EncodeDecodeCompiledCode(t *testing.T) {
+	i32 := wasm.ValueTypeI32
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{
+				wasm.OpcodeBlock, 0x40, // (block
+				wasm.OpcodeBlock, 0x40, // (block
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeBrTable, 2, 0, 1, 0, // br_table 0 1 0
+				wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI32Const, 0x7f, // -1
+				wasm.OpcodeI32Add,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 0, wasm.OpcodeEnd}},
+		},
+		ID: wasm.ModuleID{1},
+	}
+	e := NewEngine(wasm.Features20191205).(*engine)
+	require.NoError(t, e.CompileModule(testCtx, m))
+	expected, ok := e.getCodes(m)
+	require.True(t, ok)
+
+	content, err := e.EncodeCompiledCode(m)
+	require.NoError(t, err)
+
+	// Decode into a different engine, as if it were a different process.
+	e2 := NewEngine(wasm.Features20191205).(*engine)
+	require.NoError(t, e2.DecodeCompiledCode(m, content))
+	actual, ok := e2.getCodes(m)
+	require.True(t, ok)
+	require.Equal(t, expected, actual)
+}
+
+func TestEngine_EncodeCompiledCode_Errors(t *testing.T) {
+	e := NewEngine(wasm.Features20191205).(*engine)
+
+	_, err := e.EncodeCompiledCode(&wasm.Module{ID: wasm.ModuleID{1}})
+	require.Contains(t, err.Error(), "must be compiled before encoding")
+}
+
+func TestEngine_DecodeCompiledCode_Errors(t *testing.T) {
+	m := &wasm.Module{FunctionSection: []wasm.Index{0}, ID: wasm.ModuleID{1}}
+	valid, err := (&engine{codes: map[wasm.ModuleID][]*code{m.ID: {{
+		body: []*interpreterOp{
+			{kind: wazeroir.OperationKindDrop, rs: []*wazeroir.InclusiveRange{{Start: -1, End: 3}, nil}},
+			{kind: wazeroir.OperationKindBr, us: []uint64{1<<64 - 1}},
+		},
+	}}}}).EncodeCompiledCode(m)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		module      *wasm.Module
+		content     []byte
+		expectedErr string
+	}{
+		{
+			name:        "invalid header",
+			module:      m,
+			content:     []byte("wazero-compiler\x01"),
+			expectedErr: "invalid compiled code: invalid header",
+		},
+		{
+			name:        "truncated",
+			module:      m,
+			content:     valid[:len(valid)-1],
+			expectedErr: "invalid compiled code: code[0]: read source offsets: EOF",
+		},
+		{
+			name:        "trailing bytes",
+			module:      m,
+			content:     append(append([]byte{}, valid...), 0),
+			expectedErr: "invalid compiled code: 1 bytes remain after the last code",
+		},
+		{
+			name:        "count too large",
+			module:      m,
+			content:     append(append([]byte{}, codecMagic...), 0xff, 0x01),
+			expectedErr: "invalid compiled code: read code count: length 255 exceeds the remaining 0 bytes",
+		},
+		{
+			name:        "function count mismatch",
+			module:      &wasm.Module{FunctionSection: []wasm.Index{0, 0}, ID: wasm.ModuleID{2}},
+			content:     valid,
+			expectedErr: "invalid compiled code: expected 2 functions, but have 1",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			e := NewEngine(wasm.Features20191205).(*engine)
+			err := e.DecodeCompiledCode(tc.module, tc.content)
+			require.EqualError(t, err, tc.expectedErr)
+			_, ok := e.getCodes(tc.module)
+			require.False(t, ok)
+		})
+	}
+}
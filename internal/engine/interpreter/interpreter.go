@@ -71,6 +71,9 @@ type moduleEngine struct {
 	// parentEngine holds *engine from which this module engine is created from.
 	parentEngine          *engine
 	importedFunctionCount uint32
+
+	// callEngines pools callEngine, so that stacks allocated by one Call are reused by the next.
+	callEngines sync.Pool
 }
 
 // callEngine holds context per moduleEngine.Call, and shared across all the
@@ -85,9 +88,35 @@ type callEngine struct {
 }
 
 func (me *moduleEngine) newCallEngine() *callEngine {
+	if ce, ok := me.callEngines.Get().(*callEngine); ok {
+		return ce
+	}
 	return &callEngine{}
 }
 
+// maxPooledStackLen and maxPooledFramesLen bound the stacks of a pooled callEngine, so that a deep call, such as one
+// overflowing at callStackCeiling, doesn't keep its stacks after it returns.
+const (
+	maxPooledStackLen  = 1 << 12
+	maxPooledFramesLen = 1 << 8
+)
+
+// releaseCallEngine resets the stacks of ce and returns it to the pool, so that the next Call doesn't allocate them.
+// ce is dropped instead when its stacks grew beyond maxPooledStackLen or maxPooledFramesLen.
+func (me *moduleEngine) releaseCallEngine(ce *callEngine) {
+	if cap(ce.stack) > maxPooledStackLen || cap(ce.frames) > maxPooledFramesLen {
+		return
+	}
+	frames := ce.frames[:cap(ce.frames)]
+	for _, frame := range frames {
+		if frame != nil {
+			*frame = callFrame{} // don't pin functions, and their modules, while pooled, but reuse the frame in newFrame
+		}
+	}
+	ce.stack, ce.frames = ce.stack[:0], frames[:0]
+	me.callEngines.Put(ce)
+}
+
 func (ce *callEngine) pushValue(v uint64) {
 	ce.stack = append(ce.stack, v)
 }
@@ -137,6 +166,17 @@ func (ce *callEngine) drop(r *wazeroir.InclusiveRange) {
 	}
 }
 
+// newFrame returns a callFrame for f to push next, reusing one popped earlier, so that calls don't allocate.
+func (ce *callEngine) newFrame(f *function) (frame *callFrame) {
+	if next := len(ce.frames); next < cap(ce.frames) {
+		if frame = ce.frames[:next+1][next]; frame != nil {
+			*frame = callFrame{f: f}
+			return
+		}
+	}
+	return &callFrame{f: f}
+}
+
 func (ce *callEngine) pushFrame(frame *callFrame) {
 	if callStackCeiling <= len(ce.frames) {
 		panic(wasmruntime.ErrRuntimeCallStackOverflow)
@@ -163,12 +203,16 @@ type callFrame struct {
 type code struct {
 	body   []*interpreterOp
 	hostFn *reflect.Value
+	// sourceOffsets is index-correlated with body and is only set when wazeroir.CompilationResult SourceOffsets was.
+	sourceOffsets []uint64
 }
 
 type function struct {
 	source *wasm.FunctionInstance
 	body   []*interpreterOp
 	hostFn *reflect.Value
+	// sourceOffsets is described by code.sourceOffsets
+	sourceOffsets []uint64
 }
 
 // functionFromUintptr resurrects the original *function from the given uintptr
@@ -185,9 +229,10 @@ func functionFromUintptr(ptr uintptr) *function {
 
 func (c *code) instantiate(f *wasm.FunctionInstance) *function {
 	return &function{
-		source: f,
-		body:   c.body,
-		hostFn: c.hostFn,
+		source:        f,
+		body:          c.body,
+		hostFn:        c.hostFn,
+		sourceOffsets: c.sourceOffsets,
 	}
 }
 
@@ -285,7 +330,7 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 	ret := &code{}
 	labelAddress := map[string]uint64{}
 	onLabelAddressResolved := map[string][]func(addr uint64){}
-	for _, original := range ops {
+	for i, original := range ops {
 		op := &interpreterOp{kind: original.Kind()}
 		switch o := original.(type) {
 		case *wazeroir.OperationUnreachable:
@@ -378,10 +423,15 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.TypeIndex)
 			op.us[1] = uint64(o.TableIndex)
+		case *wazeroir.OperationReturnCall:
+			op.us = []uint64{uint64(o.FunctionIndex)}
+		case *wazeroir.OperationReturnCallIndirect:
+			op.us = []uint64{uint64(o.TypeIndex), uint64(o.TableIndex)}
 		case *wazeroir.OperationDrop:
 			op.rs = make([]*wazeroir.InclusiveRange, 1)
 			op.rs[0] = o.Depth
 		case *wazeroir.OperationSelect:
+			op.b3 = o.IsTargetVector
 		case *wazeroir.OperationPick:
 			op.us = make([]uint64, 1)
 			op.us[0] = uint64(o.Depth)
@@ -396,47 +446,59 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 			op.us[0] = uint64(o.Index)
 		case *wazeroir.OperationLoad:
 			op.b1 = byte(o.Type)
-			op.us = make([]uint64, 2)
+			op.us = make([]uint64, 3)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.us[2] = uint64(o.Arg.MemoryIndex)
 		case *wazeroir.OperationLoad8:
 			op.b1 = byte(o.Type)
-			op.us = make([]uint64, 2)
+			op.us = make([]uint64, 3)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.us[2] = uint64(o.Arg.MemoryIndex)
 		case *wazeroir.OperationLoad16:
 			op.b1 = byte(o.Type)
-			op.us = make([]uint64, 2)
+			op.us = make([]uint64, 3)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.us[2] = uint64(o.Arg.MemoryIndex)
 		case *wazeroir.OperationLoad32:
 			if o.Signed {
 				op.b1 = 1
 			}
-			op.us = make([]uint64, 2)
+			op.us = make([]uint64, 3)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.us[2] = uint64(o.Arg.MemoryIndex)
 		case *wazeroir.OperationStore:
 			op.b1 = byte(o.Type)
-			op.us = make([]uint64, 2)
+			op.us = make([]uint64, 3)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.us[2] = uint64(o.Arg.MemoryIndex)
 		case *wazeroir.OperationStore8:
 			op.b1 = byte(o.Type)
-			op.us = make([]uint64, 2)
+			op.us = make([]uint64, 3)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.us[2] = uint64(o.Arg.MemoryIndex)
 		case *wazeroir.OperationStore16:
 			op.b1 = byte(o.Type)
-			op.us = make([]uint64, 2)
+			op.us = make([]uint64, 3)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.us[2] = uint64(o.Arg.MemoryIndex)
 		case *wazeroir.OperationStore32:
-			op.us = make([]uint64, 2)
+			op.us = make([]uint64, 3)
 			op.us[0] = uint64(o.Arg.Alignment)
 			op.us[1] = uint64(o.Arg.Offset)
+			op.us[2] = uint64(o.Arg.MemoryIndex)
 		case *wazeroir.OperationMemorySize:
+			op.us = make([]uint64, 1)
+			op.us[0] = uint64(o.MemoryIndex)
 		case *wazeroir.OperationMemoryGrow:
+			op.us = make([]uint64, 1)
+			op.us[0] = uint64(o.MemoryIndex)
 		case *wazeroir.OperationConstI32:
 			op.us = make([]uint64, 1)
 			op.us[0] = uint64(o.Value)
@@ -581,10 +643,36 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 			op.us[1] = o.Hi
 		case *wazeroir.OperationI32x4Add:
 		case *wazeroir.OperationI64x2Add:
+		case *wazeroir.OperationAtomicLoad:
+			op.b1 = byte(o.Type)
+			op.us = []uint64{uint64(o.Width), uint64(o.Arg.Offset), uint64(o.Arg.MemoryIndex)}
+		case *wazeroir.OperationAtomicStore:
+			op.b1 = byte(o.Type)
+			op.us = []uint64{uint64(o.Width), uint64(o.Arg.Offset), uint64(o.Arg.MemoryIndex)}
+		case *wazeroir.OperationAtomicRMW:
+			op.b1 = byte(o.Type)
+			op.b2 = byte(o.Op)
+			op.us = []uint64{uint64(o.Width), uint64(o.Arg.Offset), uint64(o.Arg.MemoryIndex)}
+		case *wazeroir.OperationAtomicRMWCmpxchg:
+			op.b1 = byte(o.Type)
+			op.us = []uint64{uint64(o.Width), uint64(o.Arg.Offset), uint64(o.Arg.MemoryIndex)}
+		case *wazeroir.OperationAtomicFence:
+		case *wazeroir.OperationAtomicMemoryNotify:
+			op.us = []uint64{4, uint64(o.Arg.Offset), uint64(o.Arg.MemoryIndex)}
+		case *wazeroir.OperationAtomicMemoryWait:
+			op.b1 = byte(o.Type)
+			width := uint64(4)
+			if o.Type == wazeroir.UnsignedTypeI64 {
+				width = 8
+			}
+			op.us = []uint64{width, uint64(o.Arg.Offset), uint64(o.Arg.MemoryIndex)}
 		default:
 			return nil, fmt.Errorf("unreachable: a bug in wazeroir engine")
 		}
 		ret.body = append(ret.body, op)
+		if ir.SourceOffsets != nil {
+			ret.sourceOffsets = append(ret.sourceOffsets, ir.SourceOffsets[i])
+		}
 	}
 
 	if len(onLabelAddressResolved) > 0 {
@@ -632,6 +720,17 @@ func (me *moduleEngine) InitializeFuncrefGlobals(globals []*wasm.GlobalInstance)
 
 // Call implements the same method as documented on wasm.ModuleEngine.
 func (me *moduleEngine) Call(ctx context.Context, m *wasm.CallContext, f *wasm.FunctionInstance, params ...uint64) (results []uint64, err error) {
+	if resultCount := f.Type.ResultNumInUint64; resultCount > 0 {
+		results = make([]uint64, resultCount)
+	}
+	if err = me.CallInto(ctx, m, f, results, params...); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// CallInto implements the same method as documented on wasm.ModuleEngine.
+func (me *moduleEngine) CallInto(ctx context.Context, m *wasm.CallContext, f *wasm.FunctionInstance, results []uint64, params ...uint64) (err error) {
 	// Note: The input parameters are pre-validated, so a compiled function is only absent on close. Updates to
 	// code on close aren't locked, neither is this read.
 	compiled := me.functions[f.Idx]
@@ -645,8 +744,13 @@ func (me *moduleEngine) Call(ctx context.Context, m *wasm.CallContext, f *wasm.F
 	paramSignature := f.Type.ParamNumInUint64
 	paramCount := len(params)
 	if paramSignature != paramCount {
-		return nil, fmt.Errorf("expected %d params, but passed %d", paramSignature, paramCount)
+		return fmt.Errorf("expected %d params, but passed %d", paramSignature, paramCount)
 	}
+	resultCount := f.Type.ResultNumInUint64
+	if len(results) < resultCount {
+		return fmt.Errorf("expected %d results, but passed %d", resultCount, len(results))
+	}
+	results = results[:resultCount]
 
 	ce := me.newCallEngine()
 	defer func() {
@@ -662,10 +766,14 @@ func (me *moduleEngine) Call(ctx context.Context, m *wasm.CallContext, f *wasm.F
 			for i := 0; i < frameCount; i++ {
 				frame := ce.popFrame()
 				fn := frame.f.source
+				if i == 0 && frame.pc < uint64(len(frame.f.sourceOffsets)) { // the innermost frame is where the trap occurred.
+					builder.SetProgramCounter(fn.Module.Name, fn.Idx, frame.f.sourceOffsets[frame.pc])
+				}
 				builder.AddFrame(fn.DebugName, fn.ParamTypes(), fn.ResultTypes())
 			}
 			err = builder.FromRecovered(v)
 		}
+		me.releaseCallEngine(ce)
 	}()
 
 	if f.Kind == wasm.FunctionKindWasm {
@@ -676,13 +784,15 @@ func (me *moduleEngine) Call(ctx context.Context, m *wasm.CallContext, f *wasm.F
 			ce.pushValue(param)
 		}
 		ce.callNativeFunc(ctx, m, compiled)
-		results = wasm.PopValues(f.Type.ResultNumInUint64, ce.popValue)
+		for i := resultCount - 1; i >= 0; i-- {
+			results[i] = ce.popValue()
+		}
 		if f.FunctionListener != nil {
 			// TODO: This doesn't get the error due to use of panic to propagate them.
 			f.FunctionListener.After(ctx, nil, results)
 		}
 	} else {
-		results = ce.callGoFunc(ctx, m, compiled, params)
+		copy(results, ce.callGoFunc(ctx, m, compiled, params))
 	}
 	return
 }
@@ -695,7 +805,7 @@ func (ce *callEngine) callGoFunc(ctx context.Context, callCtx *wasm.CallContext,
 	if f.source.FunctionListener != nil {
 		ctx = f.source.FunctionListener.Before(ctx, params)
 	}
-	frame := &callFrame{f: f}
+	frame := ce.newFrame(f)
 	ce.pushFrame(frame)
 	results = wasm.CallGoFunc(ctx, callCtx, f.source, params)
 	ce.popFrame()
@@ -707,9 +817,10 @@ func (ce *callEngine) callGoFunc(ctx context.Context, callCtx *wasm.CallContext,
 }
 
 func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallContext, f *function) {
-	frame := &callFrame{f: f}
+	frame := ce.newFrame(f)
 	moduleInst := f.source.Module
 	memoryInst := moduleInst.Memory
+	memories := moduleInst.Memories
 	globals := moduleInst.Globals
 	tables := moduleInst.Tables
 	typeIDs := f.source.Module.TypeIDs
@@ -717,6 +828,8 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 	dataInstances := f.source.Module.DataInstances
 	elementInstances := f.source.Module.ElementInstances
 	listener := f.source.FunctionListener
+	// done is nil when the context can't be canceled, which keeps the check below off the hot path.
+	done := ctx.Done()
 	ce.pushFrame(frame)
 	bodyLen := uint64(len(frame.f.body))
 	for frame.pc < bodyLen {
@@ -729,12 +842,14 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			panic(wasmruntime.ErrRuntimeUnreachable)
 		case wazeroir.OperationKindBr:
 			{
+				checkDoneOnBackEdge(ctx, done, frame.pc, op.us[0])
 				frame.pc = op.us[0]
 			}
 		case wazeroir.OperationKindBrIf:
 			{
 				if ce.popValue() > 0 {
 					ce.drop(op.rs[0])
+					checkDoneOnBackEdge(ctx, done, frame.pc, op.us[0])
 					frame.pc = op.us[0]
 				} else {
 					ce.drop(op.rs[1])
@@ -745,10 +860,12 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			{
 				if v := uint64(ce.popValue()); v < uint64(len(op.us)-1) {
 					ce.drop(op.rs[v+1])
+					checkDoneOnBackEdge(ctx, done, frame.pc, op.us[v+1])
 					frame.pc = op.us[v+1]
 				} else {
 					// Default branch.
 					ce.drop(op.rs[0])
+					checkDoneOnBackEdge(ctx, done, frame.pc, op.us[0])
 					frame.pc = op.us[0]
 				}
 			}
@@ -766,20 +883,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 		case wazeroir.OperationKindCallIndirect:
 			{
-				offset := ce.popValue()
-				table := tables[op.us[1]]
-				if offset >= uint64(len(table.References)) {
-					panic(wasmruntime.ErrRuntimeInvalidTableAccess)
-				}
-				rawPtr := table.References[offset]
-				if rawPtr == 0 {
-					panic(wasmruntime.ErrRuntimeInvalidTableAccess)
-				}
-
-				tf := functionFromUintptr(rawPtr)
-				if tf.source.TypeID != typeIDs[op.us[0]] {
-					panic(wasmruntime.ErrRuntimeIndirectCallTypeMismatch)
-				}
+				tf := ce.popIndirectCallee(op, tables, typeIDs)
 
 				// Call in.
 				if tf.hostFn != nil {
@@ -791,6 +895,34 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 				}
 				frame.pc++
 			}
+		case wazeroir.OperationKindReturnCall, wazeroir.OperationKindReturnCallIndirect:
+			{
+				var tf *function
+				if op.kind == wazeroir.OperationKindReturnCall {
+					tf = functions[op.us[0]]
+				} else {
+					tf = ce.popIndirectCallee(op, tables, typeIDs)
+				}
+
+				// Only the callee's parameters remain on the stack, so a function in the same module can reuse this
+				// frame instead of pushing a new one. Otherwise, call it normally and return its results.
+				if tf.hostFn == nil && listener == nil && tf.source.Module == moduleInst {
+					checkDone(ctx, done) // reusing the frame can loop forever, like a back-edge
+
+					frame.f = tf
+					frame.pc = 0
+					bodyLen = uint64(len(tf.body))
+				} else {
+					if tf.hostFn != nil {
+						ce.callGoFuncWithStack(ctx, callCtx, tf)
+					} else if listener != nil {
+						ctx = ce.callNativeFuncWithListener(ctx, callCtx, tf, listener)
+					} else {
+						ce.callNativeFunc(ctx, callCtx, tf)
+					}
+					frame.pc = bodyLen
+				}
+			}
 		case wazeroir.OperationKindDrop:
 			{
 				ce.drop(op.rs[0])
@@ -799,10 +931,19 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 		case wazeroir.OperationKindSelect:
 			{
 				c := ce.popValue()
-				v2 := ce.popValue()
-				if c == 0 {
-					_ = ce.popValue()
-					ce.pushValue(v2)
+				if op.b3 { // Target is vector.
+					x2Hi, x2Lo := ce.popValue(), ce.popValue()
+					if c == 0 {
+						_, _ = ce.popValue(), ce.popValue() // Drop x1.
+						ce.pushValue(x2Lo)
+						ce.pushValue(x2Hi)
+					}
+				} else {
+					v2 := ce.popValue()
+					if c == 0 {
+						_ = ce.popValue()
+						ce.pushValue(v2)
+					}
 				}
 				frame.pc++
 			}
@@ -837,29 +978,21 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 		case wazeroir.OperationKindLoad:
 			{
-				offset := ce.popMemoryOffset(op)
+				mem := memoryAt(memoryInst, memories, op.us[2])
 				switch wazeroir.UnsignedType(op.b1) {
 				case wazeroir.UnsignedTypeI32, wazeroir.UnsignedTypeF32:
-					if val, ok := memoryInst.ReadUint32Le(ctx, offset); !ok {
-						panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-					} else {
-						ce.pushValue(uint64(val))
-					}
+					val, _ := mem.ReadUint32Le(ctx, ce.popMemoryOffset(op, mem, 4))
+					ce.pushValue(uint64(val))
 				case wazeroir.UnsignedTypeI64, wazeroir.UnsignedTypeF64:
-					if val, ok := memoryInst.ReadUint64Le(ctx, offset); !ok {
-						panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-					} else {
-						ce.pushValue(val)
-					}
+					val, _ := mem.ReadUint64Le(ctx, ce.popMemoryOffset(op, mem, 8))
+					ce.pushValue(val)
 				}
 				frame.pc++
 			}
 		case wazeroir.OperationKindLoad8:
 			{
-				val, ok := memoryInst.ReadByte(ctx, ce.popMemoryOffset(op))
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				mem := memoryAt(memoryInst, memories, op.us[2])
+				val, _ := mem.ReadByte(ctx, ce.popMemoryOffset(op, mem, 1))
 
 				switch wazeroir.SignedInt(op.b1) {
 				case wazeroir.SignedInt32, wazeroir.SignedInt64:
@@ -871,10 +1004,8 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 		case wazeroir.OperationKindLoad16:
 			{
-				val, ok := memoryInst.ReadUint16Le(ctx, ce.popMemoryOffset(op))
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				mem := memoryAt(memoryInst, memories, op.us[2])
+				val, _ := mem.ReadUint16Le(ctx, ce.popMemoryOffset(op, mem, 2))
 
 				switch wazeroir.SignedInt(op.b1) {
 				case wazeroir.SignedInt32, wazeroir.SignedInt64:
@@ -886,10 +1017,8 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 		case wazeroir.OperationKindLoad32:
 			{
-				val, ok := memoryInst.ReadUint32Le(ctx, ce.popMemoryOffset(op))
-				if !ok {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				mem := memoryAt(memoryInst, memories, op.us[2])
+				val, _ := mem.ReadUint32Le(ctx, ce.popMemoryOffset(op, mem, 4))
 
 				if op.b1 == 1 { // Signed
 					ce.pushValue(uint64(int32(val)))
@@ -900,56 +1029,48 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 		case wazeroir.OperationKindStore:
 			{
+				mem := memoryAt(memoryInst, memories, op.us[2])
 				val := ce.popValue()
-				offset := ce.popMemoryOffset(op)
 				switch wazeroir.UnsignedType(op.b1) {
 				case wazeroir.UnsignedTypeI32, wazeroir.UnsignedTypeF32:
-					if !memoryInst.WriteUint32Le(ctx, offset, uint32(val)) {
-						panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-					}
+					mem.WriteUint32Le(ctx, ce.popMemoryOffset(op, mem, 4), uint32(val))
 				case wazeroir.UnsignedTypeI64, wazeroir.UnsignedTypeF64:
-					if !memoryInst.WriteUint64Le(ctx, offset, val) {
-						panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-					}
+					mem.WriteUint64Le(ctx, ce.popMemoryOffset(op, mem, 8), val)
 				}
 				frame.pc++
 			}
 		case wazeroir.OperationKindStore8:
 			{
+				mem := memoryAt(memoryInst, memories, op.us[2])
 				val := byte(ce.popValue())
-				offset := ce.popMemoryOffset(op)
-				if !memoryInst.WriteByte(ctx, offset, val) {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				mem.WriteByte(ctx, ce.popMemoryOffset(op, mem, 1), val)
 				frame.pc++
 			}
 		case wazeroir.OperationKindStore16:
 			{
+				mem := memoryAt(memoryInst, memories, op.us[2])
 				val := uint16(ce.popValue())
-				offset := ce.popMemoryOffset(op)
-				if !memoryInst.WriteUint16Le(ctx, offset, val) {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				mem.WriteUint16Le(ctx, ce.popMemoryOffset(op, mem, 2), val)
 				frame.pc++
 			}
 		case wazeroir.OperationKindStore32:
 			{
+				mem := memoryAt(memoryInst, memories, op.us[2])
 				val := uint32(ce.popValue())
-				offset := ce.popMemoryOffset(op)
-				if !memoryInst.WriteUint32Le(ctx, offset, val) {
-					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
-				}
+				mem.WriteUint32Le(ctx, ce.popMemoryOffset(op, mem, 4), val)
 				frame.pc++
 			}
 		case wazeroir.OperationKindMemorySize:
 			{
-				ce.pushValue(uint64(memoryInst.PageSize(ctx)))
+				mem := memoryAt(memoryInst, memories, op.us[0])
+				ce.pushValue(uint64(mem.PageSize(ctx)))
 				frame.pc++
 			}
 		case wazeroir.OperationKindMemoryGrow:
 			{
+				mem := memoryAt(memoryInst, memories, op.us[0])
 				n := ce.popValue()
-				if res, ok := memoryInst.Grow(ctx, uint32(n)); !ok {
+				if res, ok := mem.Grow(ctx, uint32(n)); !ok {
 					ce.pushValue(uint64(0xffffffff)) // = -1 in signed 32-bit integer.
 				} else {
 					ce.pushValue(uint64(res))
@@ -1952,11 +2073,191 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			ce.pushValue(xLow + yLow)
 			ce.pushValue(xHigh + yHigh)
 			frame.pc++
+		case wazeroir.OperationKindAtomicLoad:
+			{
+				mem := memoryAt(memoryInst, memories, op.us[2])
+				offset := ce.popAtomicOffset(op)
+				mem.AtomicMux.Lock()
+				val, ok := readAtomic(ctx, mem, offset, op.us[0])
+				mem.AtomicMux.Unlock()
+				if !ok {
+					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+				}
+				ce.pushValue(val)
+				frame.pc++
+			}
+		case wazeroir.OperationKindAtomicStore:
+			{
+				mem := memoryAt(memoryInst, memories, op.us[2])
+				val := ce.popValue()
+				offset := ce.popAtomicOffset(op)
+				mem.AtomicMux.Lock()
+				ok := writeAtomic(ctx, mem, offset, op.us[0], val)
+				mem.AtomicMux.Unlock()
+				if !ok {
+					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+				}
+				frame.pc++
+			}
+		case wazeroir.OperationKindAtomicRMW:
+			{
+				mem := memoryAt(memoryInst, memories, op.us[2])
+				operand := ce.popValue()
+				offset := ce.popAtomicOffset(op)
+				mem.AtomicMux.Lock()
+				old, ok := readAtomic(ctx, mem, offset, op.us[0])
+				if ok {
+					var val uint64
+					switch wazeroir.AtomicRMWOp(op.b2) {
+					case wazeroir.AtomicRMWOpAdd:
+						val = old + operand
+					case wazeroir.AtomicRMWOpSub:
+						val = old - operand
+					case wazeroir.AtomicRMWOpAnd:
+						val = old & operand
+					case wazeroir.AtomicRMWOpOr:
+						val = old | operand
+					case wazeroir.AtomicRMWOpXor:
+						val = old ^ operand
+					case wazeroir.AtomicRMWOpXchg:
+						val = operand
+					}
+					writeAtomic(ctx, mem, offset, op.us[0], val)
+				}
+				mem.AtomicMux.Unlock()
+				if !ok {
+					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+				}
+				ce.pushValue(old)
+				frame.pc++
+			}
+		case wazeroir.OperationKindAtomicRMWCmpxchg:
+			{
+				mem := memoryAt(memoryInst, memories, op.us[2])
+				replacement, expected := ce.popValue(), ce.popValue()
+				offset := ce.popAtomicOffset(op)
+				mem.AtomicMux.Lock()
+				old, ok := readAtomic(ctx, mem, offset, op.us[0])
+				// The expected value is wrapped to the width, as the value read is zero-extended.
+				if ok && old == expected&(math.MaxUint64>>(64-8*op.us[0])) {
+					writeAtomic(ctx, mem, offset, op.us[0], replacement)
+				}
+				mem.AtomicMux.Unlock()
+				if !ok {
+					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+				}
+				ce.pushValue(old)
+				frame.pc++
+			}
+		case wazeroir.OperationKindAtomicFence:
+			// Atomic instructions are already sequentially consistent as they hold wasm.MemoryInstance AtomicMux.
+			frame.pc++
+		case wazeroir.OperationKindAtomicMemoryNotify:
+			{
+				mem := memoryAt(memoryInst, memories, op.us[2])
+				count := uint32(ce.popValue())
+				offset := ce.popAtomicOffset(op)
+				if _, ok := mem.ReadUint32Le(ctx, offset); !ok {
+					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+				}
+				ce.pushValue(uint64(mem.AtomicNotify(offset, count)))
+				frame.pc++
+			}
+		case wazeroir.OperationKindAtomicMemoryWait:
+			{
+				mem := memoryAt(memoryInst, memories, op.us[2])
+				timeout, expected := int64(ce.popValue()), ce.popValue()
+				offset := ce.popAtomicOffset(op)
+				mem.AtomicMux.Lock()
+				val, ok := readAtomic(ctx, mem, offset, op.us[0])
+				if !ok {
+					mem.AtomicMux.Unlock()
+					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+				} else if !mem.Shared {
+					mem.AtomicMux.Unlock()
+					panic(wasmruntime.ErrRuntimeExpectedSharedMemory)
+				} else if val != expected&(math.MaxUint64>>(64-8*op.us[0])) {
+					mem.AtomicMux.Unlock()
+					ce.pushValue(1) // "not-equal"
+				} else if result, err := mem.AtomicWait(ctx, offset, timeout); err != nil { // unlocks AtomicMux
+					panic(&wasmruntime.ContextDoneError{Err: err})
+				} else {
+					ce.pushValue(result)
+				}
+				frame.pc++
+			}
 		}
 	}
 	ce.popFrame()
 }
 
+// checkDoneOnBackEdge calls checkDone if the branch from pc to target is a loop back-edge.
+func checkDoneOnBackEdge(ctx context.Context, done <-chan struct{}, pc, target uint64) {
+	if target <= pc {
+		checkDone(ctx, done)
+	}
+}
+
+// checkDone panics with wasmruntime.ContextDoneError if the context is done. done is nil when the context can't be
+// canceled, so this is a no-op.
+func checkDone(ctx context.Context, done <-chan struct{}) {
+	if done == nil {
+		return
+	}
+	select {
+	case <-done:
+		panic(&wasmruntime.ContextDoneError{Err: ctx.Err()})
+	default:
+	}
+}
+
+// popAtomicOffset pops the address of an atomic instruction, which traps unless the effective address is a multiple
+// of the width in op.us[0].
+func (ce *callEngine) popAtomicOffset(op *interpreterOp) uint32 {
+	offset := op.us[1] + ce.popValue()
+	if offset > math.MaxUint32 {
+		panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+	} else if offset%op.us[0] != 0 {
+		panic(wasmruntime.ErrRuntimeUnalignedAtomic)
+	}
+	return uint32(offset)
+}
+
+// readAtomic reads the little-endian value of the given width in bytes, zero-extended to 64 bits.
+func readAtomic(ctx context.Context, mem *wasm.MemoryInstance, offset uint32, width uint64) (val uint64, ok bool) {
+	switch width {
+	case 1:
+		var v byte
+		v, ok = mem.ReadByte(ctx, offset)
+		val = uint64(v)
+	case 2:
+		var v uint16
+		v, ok = mem.ReadUint16Le(ctx, offset)
+		val = uint64(v)
+	case 4:
+		var v uint32
+		v, ok = mem.ReadUint32Le(ctx, offset)
+		val = uint64(v)
+	default:
+		val, ok = mem.ReadUint64Le(ctx, offset)
+	}
+	return
+}
+
+// writeAtomic writes the low bytes of the value given its width in bytes, in little-endian order.
+func writeAtomic(ctx context.Context, mem *wasm.MemoryInstance, offset uint32, width uint64, val uint64) bool {
+	switch width {
+	case 1:
+		return mem.WriteByte(ctx, offset, byte(val))
+	case 2:
+		return mem.WriteUint16Le(ctx, offset, uint16(val))
+	case 4:
+		return mem.WriteUint32Le(ctx, offset, uint32(val))
+	default:
+		return mem.WriteUint64Le(ctx, offset, val)
+	}
+}
+
 func (ce *callEngine) callNativeFuncWithListener(ctx context.Context, callCtx *wasm.CallContext, f *function, fnl experimental.FunctionListener) context.Context {
 	ctx = fnl.Before(ctx, ce.peekValues(len(f.source.Type.Params)))
 	ce.callNativeFunc(ctx, callCtx, f)
@@ -1965,13 +2266,44 @@ func (ce *callEngine) callNativeFuncWithListener(ctx context.Context, callCtx *w
 	return ctx
 }
 
-// popMemoryOffset takes a memory offset off the stack for use in load and store instructions.
-// As the top of stack value is 64-bit, this ensures it is in range before returning it.
-func (ce *callEngine) popMemoryOffset(op *interpreterOp) uint32 {
+// popIndirectCallee takes the table offset off the stack and returns the function it references, for use in
+// call_indirect and return_call_indirect. This panics if the reference is invalid or doesn't match the expected type.
+func (ce *callEngine) popIndirectCallee(op *interpreterOp, tables []*wasm.TableInstance, typeIDs []wasm.FunctionTypeID) *function {
+	offset := ce.popValue()
+	table := tables[op.us[1]]
+	if offset >= uint64(len(table.References)) {
+		panic(wasmruntime.ErrRuntimeInvalidTableAccess)
+	}
+	rawPtr := table.References[offset]
+	if rawPtr == 0 {
+		panic(wasmruntime.ErrRuntimeInvalidTableAccess)
+	}
+
+	tf := functionFromUintptr(rawPtr)
+	if tf.source.TypeID != typeIDs[op.us[0]] {
+		panic(wasmruntime.ErrRuntimeIndirectCallTypeMismatch)
+	}
+	return tf
+}
+
+// memoryAt returns the memory at the given index, where the first memory is the common case. Others only exist when
+// wasm.FeatureMultiMemory is enabled.
+func memoryAt(memoryInst *wasm.MemoryInstance, memories []*wasm.MemoryInstance, index uint64) *wasm.MemoryInstance {
+	if index == 0 {
+		return memoryInst
+	}
+	return memories[index]
+}
+
+// popMemoryOffset takes a memory offset off the stack for use in a load or store of width bytes. This traps with a
+// wasmruntime.MemoryAccessError unless the whole access is within mem, so the caller can ignore the "ok" result of
+// the read or write.
+func (ce *callEngine) popMemoryOffset(op *interpreterOp, mem *wasm.MemoryInstance, width uint32) uint32 {
 	// TODO: Document what 'us' is and why we expect to look at value 1.
 	offset := op.us[1] + ce.popValue()
-	if offset > math.MaxUint32 {
-		panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+	// Check offset on its own first, as adding width to a 64-bit value could overflow.
+	if memorySize := uint64(len(mem.Buffer)); offset > memorySize || offset+uint64(width) > memorySize {
+		panic(&wasmruntime.MemoryAccessError{Address: offset, Width: width, MemorySize: memorySize})
 	}
 	return uint32(offset)
 }
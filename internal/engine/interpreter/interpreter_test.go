@@ -4,15 +4,19 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"runtime"
 	"strconv"
 	"testing"
+	"time"
 	"unsafe"
 
 	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/enginetest"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/internal/wazeroir"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 // testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
@@ -51,6 +55,35 @@ func TestInterpreter_CallEngine_PushFrame_StackOverflow(t *testing.T) {
 	require.EqualError(t, captured, "callstack overflow")
 }
 
+func TestInterpreter_ModuleEngine_releaseCallEngine(t *testing.T) {
+	me := &moduleEngine{}
+
+	t.Run("clears frames", func(t *testing.T) {
+		ce := me.newCallEngine()
+		ce.pushValue(1)
+		ce.pushFrame(&callFrame{f: &function{}})
+		ce.pushFrame(&callFrame{f: &function{}})
+		ce.popFrame()
+
+		me.releaseCallEngine(ce)
+		require.Equal(t, 0, len(ce.stack))
+		require.Equal(t, 0, len(ce.frames))
+		for _, f := range ce.frames[:2] {
+			require.Equal(t, &callFrame{}, f) // kept for reuse by newFrame
+		}
+	})
+
+	t.Run("drops grown stacks", func(t *testing.T) {
+		ce := me.newCallEngine()
+		ce.frames = make([]*callFrame, maxPooledFramesLen+1)
+		ce.frames[0] = &callFrame{}
+
+		me.releaseCallEngine(ce)
+		require.NotSame(t, ce, me.newCallEngine())
+		require.NotNil(t, ce.frames[0]) // untouched, as it isn't pooled
+	})
+}
+
 // et is used for tests defined in the enginetest package.
 var et = &engineTester{}
 
@@ -85,6 +118,18 @@ func (e engineTester) CompiledFunctionPointerValue(me wasm.ModuleEngine, funcInd
 	return uint64(uintptr(unsafe.Pointer(internal.functions[funcIndex])))
 }
 
+// callOps calls a function whose body is ops followed by a return, on the operands already pushed to ce. module is
+// only needed when the ops access it, for example its memory or tables.
+func callOps(ctx context.Context, ce *callEngine, module *wasm.ModuleInstance, ops ...*interpreterOp) {
+	if module == nil {
+		module = &wasm.ModuleInstance{}
+	}
+	module.Engine = &moduleEngine{}
+	body := append(ops[:len(ops):len(ops)], &interpreterOp{kind: wazeroir.OperationKindBr, us: []uint64{math.MaxUint64}})
+	f := &function{source: &wasm.FunctionInstance{Module: module}, body: body}
+	ce.callNativeFunc(ctx, &wasm.CallContext{}, f)
+}
+
 func TestInterpreter_Engine_NewModuleEngine(t *testing.T) {
 	enginetest.RunTestEngine_NewModuleEngine(t, et)
 }
@@ -113,6 +158,10 @@ func TestInterpreter_ModuleEngine_Memory(t *testing.T) {
 	enginetest.RunTestModuleEngine_Memory(t, et)
 }
 
+func TestInterpreter_ModuleEngine_DataDrop(t *testing.T) {
+	enginetest.RunTestModuleEngine_DataDrop(t, et)
+}
+
 func TestInterpreter_NonTrappingFloatToIntConversion(t *testing.T) {
 	_0x80000000 := uint32(0x80000000)
 	_0xffffffff := uint32(0xffffffff)
@@ -284,17 +333,8 @@ func TestInterpreter_NonTrappingFloatToIntConversion(t *testing.T) {
 						b3:   true, // NonTrapping = true.
 					})
 
-					// Return from function.
-					body = append(body,
-						&interpreterOp{kind: wazeroir.OperationKindBr, us: []uint64{math.MaxUint64}},
-					)
-
 					ce := &callEngine{}
-					f := &function{
-						source: &wasm.FunctionInstance{Module: &wasm.ModuleInstance{Engine: &moduleEngine{}}},
-						body:   body,
-					}
-					ce.callNativeFunc(testCtx, &wasm.CallContext{}, f)
+					callOps(testCtx, ce, nil, body...)
 
 					if len(tc.expected32bit) > 0 {
 						require.Equal(t, tc.expected32bit[i], int32(uint32(ce.popValue())))
@@ -308,6 +348,74 @@ func TestInterpreter_NonTrappingFloatToIntConversion(t *testing.T) {
 	}
 }
 
+// TestInterpreter_CallEngine_callNativeFunc_truncTrappingVsSaturating ensures the trapping float-to-int truncations
+// (e.g. "i32.trunc_f32_s") trap on NaN and out-of-range inputs, where the saturating ones (e.g. "i32.trunc_sat_f32_s")
+// return zero for NaN and clamp to the minimum or maximum of the result type.
+func TestInterpreter_CallEngine_callNativeFunc_truncTrappingVsSaturating(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		inputType        wazeroir.Float
+		outputType       wazeroir.SignedInt
+		pastMax, pastMin float64 // the closest values to the range of the result type which are out of it.
+		max, min         uint64  // the saturated results.
+	}{
+		{name: "i32.trunc_f32_s", inputType: wazeroir.Float32, outputType: wazeroir.SignedInt32,
+			pastMax: 2147483648.0, pastMin: -2147483904.0, max: math.MaxInt32, min: uint64(uint32(math.MinInt32 & math.MaxUint32))},
+		{name: "i32.trunc_f32_u", inputType: wazeroir.Float32, outputType: wazeroir.SignedUint32,
+			pastMax: 4294967296.0, pastMin: -1.0, max: math.MaxUint32, min: 0},
+		{name: "i32.trunc_f64_s", inputType: wazeroir.Float64, outputType: wazeroir.SignedInt32,
+			pastMax: 2147483648.0, pastMin: -2147483649.0, max: math.MaxInt32, min: uint64(uint32(math.MinInt32 & math.MaxUint32))},
+		{name: "i32.trunc_f64_u", inputType: wazeroir.Float64, outputType: wazeroir.SignedUint32,
+			pastMax: 4294967296.0, pastMin: -1.0, max: math.MaxUint32, min: 0},
+		{name: "i64.trunc_f32_s", inputType: wazeroir.Float32, outputType: wazeroir.SignedInt64,
+			pastMax: 9223372036854775808.0, pastMin: -9223373136366403584.0, max: math.MaxInt64, min: 1 << 63},
+		{name: "i64.trunc_f32_u", inputType: wazeroir.Float32, outputType: wazeroir.SignedUint64,
+			pastMax: 18446744073709551616.0, pastMin: -1.0, max: math.MaxUint64, min: 0},
+		{name: "i64.trunc_f64_s", inputType: wazeroir.Float64, outputType: wazeroir.SignedInt64,
+			pastMax: 9223372036854775808.0, pastMin: -9223372036854777856.0, max: math.MaxInt64, min: 1 << 63},
+		{name: "i64.trunc_f64_u", inputType: wazeroir.Float64, outputType: wazeroir.SignedUint64,
+			pastMax: 18446744073709551616.0, pastMin: -1.0, max: math.MaxUint64, min: 0},
+	} {
+		tc := tc
+		is32bit := tc.outputType == wazeroir.SignedInt32 || tc.outputType == wazeroir.SignedUint32
+
+		trunc := func(input float64, nonTrapping bool) uint64 {
+			constOp := &interpreterOp{kind: wazeroir.OperationKindConstF64, us: []uint64{math.Float64bits(input)}}
+			if tc.inputType == wazeroir.Float32 {
+				constOp = &interpreterOp{kind: wazeroir.OperationKindConstF32, us: []uint64{uint64(math.Float32bits(float32(input)))}}
+			}
+			truncOp := &interpreterOp{kind: wazeroir.OperationKindITruncFromF, b1: byte(tc.inputType), b2: byte(tc.outputType), b3: nonTrapping}
+			ce := &callEngine{}
+			callOps(testCtx, ce, nil, constOp, truncOp)
+			if is32bit {
+				return uint64(uint32(ce.popValue()))
+			}
+			return ce.popValue()
+		}
+
+		for _, in := range []struct {
+			name           string
+			input          float64
+			expectedTrap   error
+			expectedResult uint64
+		}{
+			{name: "NaN", input: math.NaN(), expectedTrap: wasmruntime.ErrRuntimeInvalidConversionToInteger, expectedResult: 0},
+			{name: "+Inf", input: math.Inf(1), expectedTrap: wasmruntime.ErrRuntimeIntegerOverflow, expectedResult: tc.max},
+			{name: "-Inf", input: math.Inf(-1), expectedTrap: wasmruntime.ErrRuntimeIntegerOverflow, expectedResult: tc.min},
+			{name: "past max", input: tc.pastMax, expectedTrap: wasmruntime.ErrRuntimeIntegerOverflow, expectedResult: tc.max},
+			{name: "past min", input: tc.pastMin, expectedTrap: wasmruntime.ErrRuntimeIntegerOverflow, expectedResult: tc.min},
+		} {
+			in := in
+			t.Run(fmt.Sprintf("%s %s", tc.name, in.name), func(t *testing.T) {
+				err := require.CapturePanic(func() { trunc(in.input, false) })
+				require.Equal(t, in.expectedTrap, err)
+
+				require.Equal(t, in.expectedResult, trunc(in.input, true))
+			})
+		}
+	}
+}
+
 func TestInterpreter_CallEngine_callNativeFunc_signExtend(t *testing.T) {
 	translateToIROperationKind := func(op wasm.Opcode) (kind wazeroir.OperationKind) {
 		switch op {
@@ -351,15 +459,10 @@ func TestInterpreter_CallEngine_callNativeFunc_signExtend(t *testing.T) {
 			tc := tc
 			t.Run(fmt.Sprintf("%s(i32.const(0x%x))", wasm.InstructionName(tc.opcode), tc.in), func(t *testing.T) {
 				ce := &callEngine{}
-				f := &function{
-					source: &wasm.FunctionInstance{Module: &wasm.ModuleInstance{Engine: &moduleEngine{}}},
-					body: []*interpreterOp{
-						{kind: wazeroir.OperationKindConstI32, us: []uint64{uint64(uint32(tc.in))}},
-						{kind: translateToIROperationKind(tc.opcode)},
-						{kind: wazeroir.OperationKindBr, us: []uint64{math.MaxUint64}},
-					},
-				}
-				ce.callNativeFunc(testCtx, &wasm.CallContext{}, f)
+				callOps(testCtx, ce, nil,
+					&interpreterOp{kind: wazeroir.OperationKindConstI32, us: []uint64{uint64(uint32(tc.in))}},
+					&interpreterOp{kind: translateToIROperationKind(tc.opcode)},
+				)
 				require.Equal(t, tc.expected, int32(uint32(ce.popValue())))
 			})
 		}
@@ -403,21 +506,585 @@ func TestInterpreter_CallEngine_callNativeFunc_signExtend(t *testing.T) {
 			tc := tc
 			t.Run(fmt.Sprintf("%s(i64.const(0x%x))", wasm.InstructionName(tc.opcode), tc.in), func(t *testing.T) {
 				ce := &callEngine{}
-				f := &function{
-					source: &wasm.FunctionInstance{Module: &wasm.ModuleInstance{Engine: &moduleEngine{}}},
-					body: []*interpreterOp{
-						{kind: wazeroir.OperationKindConstI64, us: []uint64{uint64(tc.in)}},
-						{kind: translateToIROperationKind(tc.opcode)},
-						{kind: wazeroir.OperationKindBr, us: []uint64{math.MaxUint64}},
-					},
-				}
-				ce.callNativeFunc(testCtx, &wasm.CallContext{}, f)
+				callOps(testCtx, ce, nil,
+					&interpreterOp{kind: wazeroir.OperationKindConstI64, us: []uint64{uint64(tc.in)}},
+					&interpreterOp{kind: translateToIROperationKind(tc.opcode)},
+				)
 				require.Equal(t, tc.expected, int64(ce.popValue()))
 			})
 		}
 	})
 }
 
+func TestInterpreter_CallEngine_callNativeFunc_select(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		x1, x2   []uint64
+		isVector bool
+		cond     uint32
+		expected []uint64
+	}{
+		{name: "i64 true", x1: []uint64{1}, x2: []uint64{2}, cond: 1, expected: []uint64{1}},
+		{name: "i64 false", x1: []uint64{1}, x2: []uint64{2}, cond: 0, expected: []uint64{2}},
+		{name: "f64 true", x1: []uint64{math.Float64bits(1.5)}, x2: []uint64{math.Float64bits(-2.5)}, cond: 1, expected: []uint64{math.Float64bits(1.5)}},
+		{name: "f64 false", x1: []uint64{math.Float64bits(1.5)}, x2: []uint64{math.Float64bits(-2.5)}, cond: 0, expected: []uint64{math.Float64bits(-2.5)}},
+		{name: "v128 true", x1: []uint64{1, 2}, x2: []uint64{3, 4}, isVector: true, cond: 1, expected: []uint64{1, 2}},
+		{name: "v128 false", x1: []uint64{1, 2}, x2: []uint64{3, 4}, isVector: true, cond: 0, expected: []uint64{3, 4}},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ce := &callEngine{}
+			ce.pushValue(0xff) // sentinel to ensure select doesn't consume more than its operands.
+			for _, v := range tc.x1 {
+				ce.pushValue(v)
+			}
+			for _, v := range tc.x2 {
+				ce.pushValue(v)
+			}
+			ce.pushValue(uint64(tc.cond))
+			callOps(testCtx, ce, nil, &interpreterOp{kind: wazeroir.OperationKindSelect, b3: tc.isVector})
+			require.Equal(t, append([]uint64{0xff}, tc.expected...), ce.stack)
+		})
+	}
+}
+
+// TestInterpreter_CallEngine_callNativeFunc_nearest ensures f32.nearest and f64.nearest round half to even, unlike
+// math.Round which rounds half away from zero.
+func TestInterpreter_CallEngine_callNativeFunc_nearest(t *testing.T) {
+	for _, tc := range []struct {
+		input, expected float64
+	}{
+		{input: 0.5, expected: 0},
+		{input: 1.5, expected: 2},
+		{input: 2.5, expected: 2},
+		{input: 3.5, expected: 4},
+		{input: 1.4, expected: 1},
+		{input: 1.6, expected: 2},
+		{input: -0.5, expected: math.Copysign(0, -1)},
+		{input: -1.5, expected: -2},
+		{input: -2.5, expected: -2},
+		{input: -3.5, expected: -4},
+		{input: -1.6, expected: -2},
+	} {
+		tc := tc
+		for _, is64 := range []bool{false, true} {
+			is64 := is64
+			name := fmt.Sprintf("f32(%v)", tc.input)
+			if is64 {
+				name = fmt.Sprintf("f64(%v)", tc.input)
+			}
+			t.Run(name, func(t *testing.T) {
+				ce := &callEngine{}
+				op := &interpreterOp{kind: wazeroir.OperationKindNearest}
+				var expected uint64
+				if is64 {
+					op.b1 = 1
+					ce.pushValue(math.Float64bits(tc.input))
+					expected = math.Float64bits(tc.expected)
+				} else {
+					ce.pushValue(uint64(math.Float32bits(float32(tc.input))))
+					expected = uint64(math.Float32bits(float32(tc.expected)))
+				}
+				callOps(testCtx, ce, nil, op)
+				require.Equal(t, []uint64{expected}, ce.stack) // compare bits, so that the sign of zero is checked.
+			})
+		}
+	}
+}
+
+// TestInterpreter_CallEngine_callNativeFunc_copysign ensures f32.copysign and f64.copysign copy the sign bit, even when
+// either operand is a zero or NaN.
+func TestInterpreter_CallEngine_callNativeFunc_copysign(t *testing.T) {
+	negZero, negNaN := math.Copysign(0, -1), math.Copysign(math.NaN(), -1)
+	for _, tc := range []struct {
+		name             string
+		x1, x2, expected float64
+	}{
+		{name: "copysign(1, -0)", x1: 1, x2: negZero, expected: -1},
+		{name: "copysign(-1, +0)", x1: -1, x2: 0, expected: 1},
+		{name: "copysign(+0, -0)", x1: 0, x2: negZero, expected: negZero},
+		{name: "copysign(-0, +0)", x1: negZero, x2: 0, expected: 0},
+		{name: "copysign(1, -NaN)", x1: 1, x2: negNaN, expected: -1},
+		{name: "copysign(-1, +NaN)", x1: -1, x2: math.NaN(), expected: 1},
+		{name: "copysign(+NaN, -1)", x1: math.NaN(), x2: -1, expected: negNaN},
+		{name: "copysign(-NaN, 1)", x1: negNaN, x2: 1, expected: math.NaN()},
+	} {
+		tc := tc
+		for _, is64 := range []bool{false, true} {
+			is64 := is64
+			name := "f32 " + tc.name
+			if is64 {
+				name = "f64 " + tc.name
+			}
+			t.Run(name, func(t *testing.T) {
+				ce := &callEngine{}
+				op := &interpreterOp{kind: wazeroir.OperationKindCopysign}
+				var expected uint64
+				if is64 {
+					op.b1 = 1
+					ce.pushValue(math.Float64bits(tc.x1))
+					ce.pushValue(math.Float64bits(tc.x2))
+					expected = math.Float64bits(tc.expected)
+				} else {
+					ce.pushValue(uint64(math.Float32bits(float32(tc.x1))))
+					ce.pushValue(uint64(math.Float32bits(float32(tc.x2))))
+					expected = uint64(math.Float32bits(float32(tc.expected)))
+				}
+				callOps(testCtx, ce, nil, op)
+				require.Equal(t, []uint64{expected}, ce.stack) // compare bits, so that the sign of zero and NaN is checked.
+			})
+		}
+	}
+}
+
+func TestInterpreter_CallEngine_callNativeFunc_atomic(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		op             *interpreterOp
+		width, initial uint64 // initial is the value at offset 4.
+		operands       []uint64
+		expected       uint64
+		expectedMemory []byte
+	}{
+		{
+			name:           "i32.atomic.rmw.add",
+			op:             &interpreterOp{kind: wazeroir.OperationKindAtomicRMW, b2: byte(wazeroir.AtomicRMWOpAdd)},
+			width:          4,
+			initial:        41,
+			operands:       []uint64{4, 1},
+			expected:       41,
+			expectedMemory: []byte{0, 0, 0, 0, 42, 0, 0, 0},
+		},
+		{
+			name:           "i32.atomic.rmw.add overflows",
+			op:             &interpreterOp{kind: wazeroir.OperationKindAtomicRMW, b2: byte(wazeroir.AtomicRMWOpAdd)},
+			width:          4,
+			initial:        0xffffffff,
+			operands:       []uint64{4, 2},
+			expected:       0xffffffff,
+			expectedMemory: []byte{0, 0, 0, 0, 1, 0, 0, 0},
+		},
+		{
+			name:           "i32.atomic.rmw8.add_u doesn't carry into the next byte",
+			op:             &interpreterOp{kind: wazeroir.OperationKindAtomicRMW, b2: byte(wazeroir.AtomicRMWOpAdd)},
+			width:          1,
+			initial:        0xff,
+			operands:       []uint64{4, 1},
+			expected:       0xff,
+			expectedMemory: []byte{0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:           "i32.atomic.rmw.cmpxchg equal",
+			op:             &interpreterOp{kind: wazeroir.OperationKindAtomicRMWCmpxchg},
+			width:          4,
+			initial:        5,
+			operands:       []uint64{4, 5, 7},
+			expected:       5,
+			expectedMemory: []byte{0, 0, 0, 0, 7, 0, 0, 0},
+		},
+		{
+			name:           "i32.atomic.rmw.cmpxchg not equal",
+			op:             &interpreterOp{kind: wazeroir.OperationKindAtomicRMWCmpxchg},
+			width:          4,
+			initial:        5,
+			operands:       []uint64{4, 6, 7},
+			expected:       5,
+			expectedMemory: []byte{0, 0, 0, 0, 5, 0, 0, 0},
+		},
+		{
+			name:           "i32.atomic.rmw8.cmpxchg_u wraps the expected value",
+			op:             &interpreterOp{kind: wazeroir.OperationKindAtomicRMWCmpxchg},
+			width:          1,
+			initial:        5,
+			operands:       []uint64{4, 0x105, 7},
+			expected:       5,
+			expectedMemory: []byte{0, 0, 0, 0, 7, 0, 0, 0},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mem := &wasm.MemoryInstance{Buffer: make([]byte, 8)}
+			writeAtomic(testCtx, mem, 4, tc.width, tc.initial)
+
+			tc.op.b1 = byte(wazeroir.UnsignedTypeI32)
+			tc.op.us = []uint64{tc.width, 0, 0}
+			ce := &callEngine{}
+			for _, v := range tc.operands {
+				ce.pushValue(v)
+			}
+			callOps(testCtx, ce, &wasm.ModuleInstance{Memory: mem}, tc.op)
+			require.Equal(t, []uint64{tc.expected}, ce.stack)
+			require.Equal(t, tc.expectedMemory, mem.Buffer)
+		})
+	}
+
+	t.Run("unaligned", func(t *testing.T) {
+		ce := &callEngine{}
+		ce.pushValue(2) // address
+		ce.pushValue(1) // operand
+		module := &wasm.ModuleInstance{Memory: &wasm.MemoryInstance{Buffer: make([]byte, 8)}}
+		err := require.CapturePanic(func() {
+			callOps(testCtx, ce, module, &interpreterOp{kind: wazeroir.OperationKindAtomicRMW, us: []uint64{4, 0, 0}})
+		})
+		require.Equal(t, wasmruntime.ErrRuntimeUnalignedAtomic, err)
+	})
+}
+
+// TestInterpreter_CallEngine_callNativeFunc_atomicNotifyWait ensures "memory.atomic.notify" wakes no waiters and
+// "memory.atomic.wait32" traps on an unshared memory, as single-threaded modules may still emit them.
+func TestInterpreter_CallEngine_callNativeFunc_atomicNotifyWait(t *testing.T) {
+	notify := &interpreterOp{kind: wazeroir.OperationKindAtomicMemoryNotify, us: []uint64{4, 0, 0}}
+	wait32 := &interpreterOp{kind: wazeroir.OperationKindAtomicMemoryWait, b1: byte(wazeroir.UnsignedTypeI32), us: []uint64{4, 0, 0}}
+
+	callNativeFunc := func(mem *wasm.MemoryInstance, op *interpreterOp, operands ...uint64) *callEngine {
+		ce := &callEngine{}
+		for _, v := range operands {
+			ce.pushValue(v)
+		}
+		callOps(testCtx, ce, &wasm.ModuleInstance{Memory: mem}, op)
+		return ce
+	}
+
+	t.Run("notify without waiters returns zero", func(t *testing.T) {
+		ce := callNativeFunc(&wasm.MemoryInstance{Buffer: make([]byte, 8)}, notify, 4, 1) // address, count
+		require.Equal(t, []uint64{0}, ce.stack)
+	})
+
+	t.Run("notify out of bounds", func(t *testing.T) {
+		err := require.CapturePanic(func() {
+			callNativeFunc(&wasm.MemoryInstance{Buffer: make([]byte, 8)}, notify, 8, 1)
+		})
+		require.Equal(t, wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess, err)
+	})
+
+	t.Run("wait traps on unshared memory", func(t *testing.T) {
+		err := require.CapturePanic(func() {
+			callNativeFunc(&wasm.MemoryInstance{Buffer: make([]byte, 8)}, wait32, 4, 0, 0) // address, expected, timeout
+		})
+		require.Equal(t, wasmruntime.ErrRuntimeExpectedSharedMemory, err)
+		require.Equal(t, sys.TrapExpectedSharedMemory, err.(*wasmruntime.Error).Code())
+	})
+
+	t.Run("wait not-equal on shared memory", func(t *testing.T) {
+		ce := callNativeFunc(&wasm.MemoryInstance{Buffer: make([]byte, 8), Shared: true}, wait32, 4, 1, 0)
+		require.Equal(t, []uint64{1}, ce.stack)
+	})
+
+	t.Run("wait timed-out on shared memory", func(t *testing.T) {
+		ce := callNativeFunc(&wasm.MemoryInstance{Buffer: make([]byte, 8), Shared: true}, wait32, 4, 0, 0)
+		require.Equal(t, []uint64{2}, ce.stack)
+	})
+
+	t.Run("notify wakes wait on shared memory", func(t *testing.T) {
+		mem := &wasm.MemoryInstance{Buffer: make([]byte, 8), Shared: true}
+		waited := make(chan []uint64)
+		go func() {
+			waited <- callNativeFunc(mem, wait32, 4, 0, math.MaxUint64).stack // negative timeout waits until notified
+		}()
+
+		// Notify until the waiter is registered, as it runs concurrently.
+		for {
+			if ce := callNativeFunc(mem, notify, 4, 1); ce.stack[0] == 1 {
+				break
+			}
+			runtime.Gosched()
+		}
+		require.Equal(t, []uint64{0}, <-waited) // "ok"
+	})
+
+	t.Run("wait without timeout until context done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(testCtx)
+		cancel()
+
+		err := require.CapturePanic(func() {
+			callOps(ctx, &callEngine{stack: []uint64{4, 0, math.MaxUint64}},
+				&wasm.ModuleInstance{Memory: &wasm.MemoryInstance{Buffer: make([]byte, 8), Shared: true}}, wait32)
+		})
+		require.Equal(t, &wasmruntime.ContextDoneError{Err: context.Canceled}, err)
+	})
+}
+
+// TestInterpreter_CallEngine_callNativeFunc_memoryAccessError ensures an out-of-bounds store reports the effective
+// address and width of the access, as well as the memory size.
+func TestInterpreter_CallEngine_callNativeFunc_memoryAccessError(t *testing.T) {
+	const memSize = uint64(wasm.MemoryPageSize)
+
+	for _, tc := range []struct {
+		name                string
+		base, offset        uint64
+		expectedAddress     uint64
+		expectedErrorString string
+	}{
+		{
+			name:                "memory.size - 1",
+			base:                memSize - 1,
+			expectedAddress:     memSize - 1,
+			expectedErrorString: "out of bounds memory access: 4 byte access at address 65535, but memory size is 65536",
+		},
+		{
+			name:                "static offset",
+			base:                memSize - 4,
+			offset:              2,
+			expectedAddress:     memSize - 2,
+			expectedErrorString: "out of bounds memory access: 4 byte access at address 65534, but memory size is 65536",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mem := &wasm.MemoryInstance{Buffer: make([]byte, memSize)}
+			err := require.CapturePanic(func() {
+				callOps(testCtx, &callEngine{}, &wasm.ModuleInstance{Memory: mem},
+					&interpreterOp{kind: wazeroir.OperationKindConstI32, us: []uint64{tc.base}},
+					&interpreterOp{kind: wazeroir.OperationKindConstI32, us: []uint64{0xffffffff}},
+					&interpreterOp{kind: wazeroir.OperationKindStore, b1: byte(wazeroir.UnsignedTypeI32), us: []uint64{2, tc.offset, 0}},
+				)
+			})
+			require.Equal(t, &wasmruntime.MemoryAccessError{Address: tc.expectedAddress, Width: 4, MemorySize: memSize}, err)
+			require.ErrorIs(t, err, wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+			require.EqualError(t, err, tc.expectedErrorString)
+
+			// Nothing was written, as the store doesn't partially succeed.
+			require.Equal(t, make([]byte, memSize), mem.Buffer)
+		})
+	}
+}
+
+// TestInterpreter_CallEngine_callNativeFunc_contextDone ensures a loop back-edge returns promptly with a
+// wasmruntime.ContextDoneError once the context is canceled.
+func TestInterpreter_CallEngine_callNativeFunc_contextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(testCtx)
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := require.CapturePanic(func() {
+		// An infinite loop: the branch at pc zero targets itself.
+		callOps(ctx, &callEngine{}, nil, &interpreterOp{kind: wazeroir.OperationKindBr, us: []uint64{0}})
+	})
+	require.Equal(t, &wasmruntime.ContextDoneError{Err: context.Canceled}, err)
+	require.ErrorIs(t, err, context.Canceled)
+	require.EqualError(t, err, "context done: context canceled")
+	require.True(t, time.Since(start) < time.Second)
+}
+
+// TestInterpreter_CallEngine_callNativeFunc_memoryInit ensures "memory.init" traps when reading past the passive data
+// segment or writing past the memory, including after "data.drop", but succeeds at the exact end of either.
+func TestInterpreter_CallEngine_callNativeFunc_memoryInit(t *testing.T) {
+	const memSize = 16
+	segment := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	memoryInit := &interpreterOp{kind: wazeroir.OperationKindMemoryInit, us: []uint64{0}}
+	dataDrop := &interpreterOp{kind: wazeroir.OperationKindDataDrop, us: []uint64{0}}
+
+	for _, tc := range []struct {
+		name                            string
+		drop                            bool
+		memOffset, dataOffset, copySize uint64
+		expectedMemory                  []byte
+		expectedErr                     error
+	}{
+		{
+			name:      "exact end of segment",
+			memOffset: 0, dataOffset: 6, copySize: 4,
+			expectedMemory: []byte{7, 8, 9, 10, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:      "exact end of memory",
+			memOffset: memSize - 4, dataOffset: 0, copySize: 4,
+			expectedMemory: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4},
+		},
+		{
+			name:      "zero length at the end of segment",
+			memOffset: 0, dataOffset: uint64(len(segment)), copySize: 0,
+			expectedMemory: make([]byte, memSize),
+		},
+		{
+			name:      "past the end of segment",
+			memOffset: 0, dataOffset: 6, copySize: 5,
+			expectedErr: wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess,
+		},
+		{
+			name:      "count longer than segment",
+			memOffset: 0, dataOffset: 0, copySize: uint64(len(segment)) + 1,
+			expectedErr: wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess,
+		},
+		{
+			name:      "past the end of memory",
+			memOffset: memSize - 3, dataOffset: 0, copySize: 4,
+			expectedErr: wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess,
+		},
+		{
+			name: "dropped",
+			drop: true, memOffset: 0, dataOffset: 0, copySize: 1,
+			expectedErr: wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess,
+		},
+		{
+			name: "zero length after dropped",
+			drop: true, memOffset: 0, dataOffset: 0, copySize: 0,
+			expectedMemory: make([]byte, memSize),
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mem := &wasm.MemoryInstance{Buffer: make([]byte, memSize)}
+			body := []*interpreterOp{memoryInit}
+			if tc.drop {
+				body = append([]*interpreterOp{dataDrop}, body...)
+			}
+			module := &wasm.ModuleInstance{Memory: mem, DataInstances: []wasm.DataInstance{segment}}
+
+			ce := &callEngine{}
+			ce.pushValue(tc.memOffset)
+			ce.pushValue(tc.dataOffset)
+			ce.pushValue(tc.copySize)
+			err := require.CapturePanic(func() { callOps(testCtx, ce, module, body...) })
+			if tc.expectedErr != nil {
+				require.Equal(t, tc.expectedErr, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedMemory, mem.Buffer)
+			}
+		})
+	}
+}
+
+// TestInterpreter_CallEngine_callNativeFunc_tableInit ensures "table.init" traps when reading past the element segment
+// or writing past the table, including after "elem.drop", but succeeds at the exact end of either.
+func TestInterpreter_CallEngine_callNativeFunc_tableInit(t *testing.T) {
+	const tableSize = 8
+	refs := []wasm.Reference{1, 2, 3, 4, 5}
+
+	tableInit := &interpreterOp{kind: wazeroir.OperationKindTableInit, us: []uint64{0, 0}}
+	elemDrop := &interpreterOp{kind: wazeroir.OperationKindElemDrop, us: []uint64{0}}
+
+	for _, refType := range []wasm.RefType{wasm.RefTypeFuncref, wasm.RefTypeExternref} {
+		refType := refType
+		for _, tc := range []struct {
+			name                              string
+			drop                              bool
+			tableOffset, elemOffset, copySize uint64
+			expectedTable                     []wasm.Reference
+			expectedErr                       error
+		}{
+			{
+				name:        "exact end of segment",
+				tableOffset: 0, elemOffset: 3, copySize: 2,
+				expectedTable: []wasm.Reference{4, 5, 0, 0, 0, 0, 0, 0},
+			},
+			{
+				name:        "exact end of table",
+				tableOffset: tableSize - 2, elemOffset: 0, copySize: 2,
+				expectedTable: []wasm.Reference{0, 0, 0, 0, 0, 0, 1, 2},
+			},
+			{
+				name:        "zero length at the end of segment",
+				tableOffset: 0, elemOffset: uint64(len(refs)), copySize: 0,
+				expectedTable: make([]wasm.Reference, tableSize),
+			},
+			{
+				name:        "past the end of segment",
+				tableOffset: 0, elemOffset: 3, copySize: 3,
+				expectedErr: wasmruntime.ErrRuntimeInvalidTableAccess,
+			},
+			{
+				name:        "count longer than segment",
+				tableOffset: 0, elemOffset: 0, copySize: uint64(len(refs)) + 1,
+				expectedErr: wasmruntime.ErrRuntimeInvalidTableAccess,
+			},
+			{
+				name:        "past the end of table",
+				tableOffset: tableSize - 1, elemOffset: 0, copySize: 2,
+				expectedErr: wasmruntime.ErrRuntimeInvalidTableAccess,
+			},
+			{
+				name: "dropped",
+				drop: true, tableOffset: 0, elemOffset: 0, copySize: 1,
+				expectedErr: wasmruntime.ErrRuntimeInvalidTableAccess,
+			},
+			{
+				name: "zero length after dropped",
+				drop: true, tableOffset: 0, elemOffset: 0, copySize: 0,
+				expectedTable: make([]wasm.Reference, tableSize),
+			},
+		} {
+			tc := tc
+			t.Run(fmt.Sprintf("%s %s", wasm.RefTypeName(refType), tc.name), func(t *testing.T) {
+				table := &wasm.TableInstance{References: make([]wasm.Reference, tableSize), Min: tableSize, Type: refType}
+				body := []*interpreterOp{tableInit}
+				if tc.drop {
+					body = append([]*interpreterOp{elemDrop}, body...)
+				}
+				module := &wasm.ModuleInstance{
+					Tables:           []*wasm.TableInstance{table},
+					ElementInstances: []wasm.ElementInstance{{References: refs, Type: refType}},
+				}
+
+				ce := &callEngine{}
+				ce.pushValue(tc.tableOffset)
+				ce.pushValue(tc.elemOffset)
+				ce.pushValue(tc.copySize)
+				err := require.CapturePanic(func() { callOps(testCtx, ce, module, body...) })
+				if tc.expectedErr != nil {
+					require.Equal(t, tc.expectedErr, err)
+				} else {
+					require.NoError(t, err)
+					require.Equal(t, tc.expectedTable, table.References)
+				}
+			})
+		}
+	}
+}
+
+// TestInterpreter_CallEngine_callNativeFunc_tableGrow ensures "table.grow" fills new slots with the init reference and
+// returns the previous size, or -1 when growing past the table max.
+func TestInterpreter_CallEngine_callNativeFunc_tableGrow(t *testing.T) {
+	max := uint32(4)
+	const hostRef = wasm.Reference(0xdeadbeef) // ex. a handle from api.ExternrefValues
+
+	for _, tc := range []struct {
+		name          string
+		delta         uint64
+		expected      uint64
+		expectedTable []wasm.Reference
+	}{
+		{
+			name:          "non-null init",
+			delta:         2,
+			expected:      1, // previous size
+			expectedTable: []wasm.Reference{1, hostRef, hostRef},
+		},
+		{
+			name:          "up to max",
+			delta:         3,
+			expected:      1,
+			expectedTable: []wasm.Reference{1, hostRef, hostRef, hostRef},
+		},
+		{
+			name:          "zero delta",
+			delta:         0,
+			expected:      1,
+			expectedTable: []wasm.Reference{1},
+		},
+		{
+			name:          "past max",
+			delta:         4,
+			expected:      uint64(uint32(0xffffffff)), // -1 as i32
+			expectedTable: []wasm.Reference{1},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			table := &wasm.TableInstance{References: []wasm.Reference{1}, Min: 1, Max: &max, Type: wasm.RefTypeExternref}
+			ce := &callEngine{}
+			ce.pushValue(uint64(hostRef)) // init
+			ce.pushValue(tc.delta)
+			callOps(testCtx, ce, &wasm.ModuleInstance{Tables: []*wasm.TableInstance{table}},
+				&interpreterOp{kind: wazeroir.OperationKindTableGrow, us: []uint64{0}})
+			require.Equal(t, []uint64{tc.expected}, ce.stack)
+			require.Equal(t, tc.expectedTable, table.References)
+		})
+	}
+}
+
 func TestInterpreter_Compile(t *testing.T) {
 	t.Run("uncompiled", func(t *testing.T) {
 		e := et.NewEngine(wasm.Features20191205).(*engine)
@@ -0,0 +1,91 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// callWat is a trivial function, so that BenchmarkCall measures the overhead of api.Function Call and engine dispatch.
+const callWat = `(module
+  (func $one (result i32) i32.const 1)
+  (export "one" (func $one))
+)`
+
+// BenchmarkCall measures the overhead of calling an exported function per engine. Watch allocs/op, as boxing or
+// other allocations on this path affect every call.
+func BenchmarkCall(b *testing.B) {
+	b.Run("interpreter", func(b *testing.B) {
+		runCallBench(b, wazero.NewRuntimeConfigInterpreter())
+	})
+	if wazero.CompilerSupported {
+		b.Run("compiler", func(b *testing.B) {
+			runCallBench(b, wazero.NewRuntimeConfigCompiler())
+		})
+	}
+}
+
+// TestCall_CallIntoDoesntAllocate ensures BenchmarkCall CallInto is zero allocs/op, as benchmarks only report it.
+func TestCall_CallIntoDoesntAllocate(t *testing.T) {
+	configs := map[string]wazero.RuntimeConfig{"interpreter": wazero.NewRuntimeConfigInterpreter()}
+	if wazero.CompilerSupported {
+		configs["compiler"] = wazero.NewRuntimeConfigCompiler()
+	}
+	for name, config := range configs {
+		config := config
+		t.Run(name, func(t *testing.T) {
+			fn, closer := newCallBenchFunction(t, config)
+			defer closer()
+
+			results := make([]uint64, 1)
+			allocs := testing.AllocsPerRun(100, func() {
+				if err := fn.CallInto(testCtx, results); err != nil {
+					t.Fatal(err)
+				}
+			})
+			if allocs != 0 {
+				t.Fatalf("expected CallInto to not allocate, but was %v allocs/op", allocs)
+			}
+		})
+	}
+}
+
+func runCallBench(b *testing.B, config wazero.RuntimeConfig) {
+	fn, closer := newCallBenchFunction(b, config)
+	defer closer()
+
+	b.Run("Call", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if results, err := fn.Call(testCtx); err != nil {
+				b.Fatal(err)
+			} else if results[0] != 1 {
+				b.Fatalf("unexpected result: %d", results[0])
+			}
+		}
+	})
+
+	// CallInto should not allocate, as the results are reused.
+	b.Run("CallInto", func(b *testing.B) {
+		results := make([]uint64, 1)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := fn.CallInto(testCtx, results); err != nil {
+				b.Fatal(err)
+			} else if results[0] != 1 {
+				b.Fatalf("unexpected result: %d", results[0])
+			}
+		}
+	})
+}
+
+func newCallBenchFunction(tb testing.TB, config wazero.RuntimeConfig) (api.Function, func()) {
+	r := wazero.NewRuntimeWithConfig(config)
+
+	m, err := r.InstantiateModuleFromCode(testCtx, []byte(callWat))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return m.ExportedFunction("one"), func() { _ = r.Close(testCtx) }
+}
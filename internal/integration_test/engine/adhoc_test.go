@@ -11,8 +11,12 @@ import (
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/u64"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/sys"
 )
 
@@ -37,12 +41,25 @@ var tests = map[string]func(t *testing.T, r wazero.Runtime){
 	"recursive entry":                                   testRecursiveEntry,
 	"imported-and-exported func":                        testImportedAndExportedFunc,
 	"host function with context parameter":              testHostFunctionContextParameter,
+	"host function with memory parameter":               testHostFunctionMemoryParameter,
 	"host function with nested context":                 testNestedGoContext,
 	"host function with numeric parameter":              testHostFunctionNumericParameter,
 	"close module with in-flight calls":                 testCloseInFlight,
 	"multiple instantiation from same source":           testMultipleInstantiation,
 	"exported function that grows memory":               testMemOps,
+	"memory with zero minimum pages":                    testZeroPageMemory,
 	"import functions with reference type in signature": testReftypeImports,
+	"externref global holds a host value":               testExternrefGlobal,
+	"float constants preserve NaN payloads":             testNaNPayloads,
+	"float promotion and demotion":                      testPromoteDemote,
+	"traps are classified by code":                      testTrapCodes,
+	"signed division overflow traps":                    testSignedDivOverflow,
+	"call_indirect through a host-exported table":       testHostTable,
+	"host functions installed with Table.SetFunc":       testTableSetFunc,
+	"imported mutable global reflects host writes":      testImportedMutableGlobal,
+	"ref.is_null on funcref":                            testRefIsNullFuncref,
+	"call to an imported function by index":             testCallImportedFunc,
+	"host function sees memory grown by its caller":     testHostFunctionMemoryGrown,
 }
 
 func TestEngineCompiler(t *testing.T) {
@@ -68,6 +85,95 @@ func runAllTests(t *testing.T, tests map[string]func(t *testing.T, r wazero.Runt
 	}
 }
 
+// TestEngineInterpreter_SelectV128 ensures "select (result v128)" picks the whole vector selected by the condition, by
+// storing the result in a v128 global read by the host.
+//
+// Note: This only runs on the interpreter, as the compiler doesn't yet support vector instructions.
+func TestEngineInterpreter_SelectV128(t *testing.T) {
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithWasmCore2())
+	defer r.Close(testCtx)
+
+	v128Const := func(lo, hi uint64) []byte {
+		return append(append([]byte{wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Const}, u64.LeBytes(lo)...), u64.LeBytes(hi)...)
+	}
+	body := append(v128Const(1, 2), v128Const(3, 4)...)
+	body = append(body,
+		wasm.OpcodeLocalGet, 0,
+		wasm.OpcodeTypedSelect, 1, wasm.ValueTypeV128,
+		wasm.OpcodeGlobalSet, 0,
+		wasm.OpcodeEnd,
+	)
+
+	compiled, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}, ParamNumInUint64: 1}},
+		FunctionSection: []wasm.Index{0},
+		GlobalSection: []*wasm.Global{{
+			Type: &wasm.GlobalType{ValType: wasm.ValueTypeV128, Mutable: true},
+			Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeVecV128Const, Data: make([]byte, 16)},
+		}},
+		CodeSection: []*wasm.Code{{Body: body}},
+		ExportSection: []*wasm.Export{
+			{Name: "select", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "result", Type: wasm.ExternTypeGlobal, Index: 0},
+		},
+	}), wazero.NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	module, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig())
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	for _, tc := range []struct {
+		cond                   uint64
+		expectedLo, expectedHi uint64
+	}{
+		{cond: 1, expectedLo: 1, expectedHi: 2},
+		{cond: 0, expectedLo: 3, expectedHi: 4},
+	} {
+		_, err = module.ExportedFunction("select").Call(testCtx, tc.cond)
+		require.NoError(t, err)
+		lo, hi := module.ExportedGlobal("result").GetV128(testCtx)
+		require.Equal(t, tc.expectedLo, lo)
+		require.Equal(t, tc.expectedHi, hi)
+	}
+}
+
+// TestEngineInterpreter_V128ParamsResults ensures an exported function with a v128 param and result can be called, by
+// passing and receiving each v128 as two uint64 values: the lower then the higher 64 bits.
+//
+// Note: This only runs on the interpreter, as the compiler doesn't yet support vector instructions.
+func TestEngineInterpreter_V128ParamsResults(t *testing.T) {
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithWasmCore2())
+	defer r.Close(testCtx)
+
+	v128 := wasm.ValueTypeV128
+	module, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{{
+			Params:  []wasm.ValueType{wasm.ValueTypeI32, v128},
+			Results: []wasm.ValueType{v128, wasm.ValueTypeI32},
+		}},
+		FunctionSection: []wasm.Index{0},
+		// Swaps the params, so that the v128 has to move to a different position.
+		CodeSection:   []*wasm.Code{{Body: []byte{wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 0, wasm.OpcodeEnd}}},
+		ExportSection: []*wasm.Export{{Name: "swap", Type: wasm.ExternTypeFunc, Index: 0}},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	swap := module.ExportedFunction("swap")
+	results, err := swap.Call(testCtx, 42, 0x0102030405060708, 0x090a0b0c0d0e0f10)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0x0102030405060708, 0x090a0b0c0d0e0f10, 42}, results)
+
+	// The count of params is validated against the v128-expanded arity.
+	_, err = swap.Call(testCtx, 42, 0x0102030405060708)
+	require.EqualError(t, err, "expected 3 params, but passed 2")
+
+	err = swap.CallInto(testCtx, make([]uint64, 2), 42, 0x0102030405060708, 0x090a0b0c0d0e0f10)
+	require.EqualError(t, err, "expected 3 results, but passed 2")
+}
+
 var (
 	//go:embed testdata/unreachable.wasm
 	unreachableWasm []byte
@@ -106,6 +212,287 @@ func testReftypeImports(t *testing.T, r wazero.Runtime) {
 	require.Equal(t, uintptr(unsafe.Pointer(hostObj)), uintptr(actual[0]))
 }
 
+// testExternrefGlobal ensures a Go value boxed by api.ExternrefValues can be stored by the guest with global.set
+// and decoded after the guest returns it with global.get.
+func testExternrefGlobal(t *testing.T, r wazero.Runtime) {
+	type dog struct {
+		name string
+	}
+
+	externref := wasm.ValueTypeExternref
+	module, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{externref}, ParamNumInUint64: 1},
+			{Results: []wasm.ValueType{externref}, ResultNumInUint64: 1},
+		},
+		FunctionSection: []wasm.Index{0, 1},
+		GlobalSection: []*wasm.Global{{
+			Type: &wasm.GlobalType{ValType: externref, Mutable: true},
+			Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeRefNull, Data: []byte{wasm.RefTypeExternref}},
+		}},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeGlobalSet, 0, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeGlobalGet, 0, wasm.OpcodeEnd}},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "set", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "get", Type: wasm.ExternTypeFunc, Index: 1},
+		},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	var refs api.ExternrefValues
+	defer refs.Close(testCtx)
+
+	// The global is initialized to the null reference.
+	results, err := module.ExportedFunction("get").Call(testCtx)
+	require.NoError(t, err)
+	require.Nil(t, refs.Decode(results[0]))
+
+	hostObj := &dog{name: "hachi"}
+	handle := refs.Encode(hostObj)
+
+	_, err = module.ExportedFunction("set").Call(testCtx, handle)
+	require.NoError(t, err)
+
+	results, err = module.ExportedFunction("get").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, hostObj, refs.Decode(results[0]))
+}
+
+// testImportedMutableGlobal ensures an imported mutable global is shared by reference, so "global.get" in the importing
+// module sees values set by the host on the exporting module, and the reverse for "global.set".
+func testImportedMutableGlobal(t *testing.T, r wazero.Runtime) {
+	exporting, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		GlobalSection: []*wasm.Global{{
+			Type: &wasm.GlobalType{ValType: wasm.ValueTypeI32, Mutable: true},
+			Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{1}},
+		}},
+		ExportSection: []*wasm.Export{{Name: "g", Type: wasm.ExternTypeGlobal, Index: 0}},
+		NameSection:   &wasm.NameSection{ModuleName: "exporting"},
+	}))
+	require.NoError(t, err)
+	defer exporting.Close(testCtx)
+
+	importing, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Results: []wasm.ValueType{wasm.ValueTypeI32}, ResultNumInUint64: 1},
+			{Params: []wasm.ValueType{wasm.ValueTypeI32}, ParamNumInUint64: 1},
+		},
+		ImportSection: []*wasm.Import{{
+			Module: "exporting", Name: "g", Type: wasm.ExternTypeGlobal,
+			DescGlobal: &wasm.GlobalType{ValType: wasm.ValueTypeI32, Mutable: true},
+		}},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeGlobalGet, 0, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeGlobalSet, 0, wasm.OpcodeEnd}},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "get", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "set", Type: wasm.ExternTypeFunc, Index: 1},
+		},
+	}))
+	require.NoError(t, err)
+	defer importing.Close(testCtx)
+
+	get := importing.ExportedFunction("get")
+	results, err := get.Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1}, results)
+
+	g := exporting.ExportedGlobal("g").(api.MutableGlobal)
+	g.Set(testCtx, 42)
+
+	results, err = get.Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+
+	_, err = importing.ExportedFunction("set").Call(testCtx, 7)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), g.Get(testCtx))
+}
+
+// testRefIsNullFuncref ensures "ref.is_null" is true for a null funcref regardless of where it comes from: "ref.null",
+// a defaulted local, a global initialized with "ref.null func", whose value is a non-zero sentinel until the engine
+// lowers it on instantiation, or a global initialized by "global.get" of an imported one.
+func testRefIsNullFuncref(t *testing.T, r wazero.Runtime) {
+	funcref := wasm.ValueTypeFuncref
+	isNullType := &wasm.FunctionType{Results: []wasm.ValueType{wasm.ValueTypeI32}, ResultNumInUint64: 1}
+	globalIsNull := func(idx byte) *wasm.Code {
+		return &wasm.Code{Body: []byte{wasm.OpcodeGlobalGet, idx, wasm.OpcodeRefIsNull, wasm.OpcodeEnd}}
+	}
+	exporting, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{isNullType},
+		FunctionSection: []wasm.Index{0, 0, 0, 0, 0},
+		GlobalSection: []*wasm.Global{
+			{
+				Type: &wasm.GlobalType{ValType: funcref},
+				Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeRefNull, Data: []byte{wasm.RefTypeFuncref}},
+			},
+			{
+				Type: &wasm.GlobalType{ValType: funcref},
+				Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeRefFunc, Data: []byte{0}},
+			},
+		},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeRefNull, wasm.RefTypeFuncref, wasm.OpcodeRefIsNull, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeRefFunc, 0, wasm.OpcodeRefIsNull, wasm.OpcodeEnd}},
+			globalIsNull(0),
+			globalIsNull(1),
+			{LocalTypes: []wasm.ValueType{funcref}, Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeRefIsNull, wasm.OpcodeEnd}},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "null", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "func", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "null global", Type: wasm.ExternTypeFunc, Index: 2},
+			{Name: "func global", Type: wasm.ExternTypeFunc, Index: 3},
+			{Name: "default local", Type: wasm.ExternTypeFunc, Index: 4},
+			{Name: "null_ref", Type: wasm.ExternTypeGlobal, Index: 0},
+			{Name: "func_ref", Type: wasm.ExternTypeGlobal, Index: 1},
+		},
+		NameSection: &wasm.NameSection{ModuleName: "exporting"},
+	}))
+	require.NoError(t, err)
+	defer exporting.Close(testCtx)
+
+	importedGlobalType := &wasm.GlobalType{ValType: funcref}
+	importing, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{isNullType},
+		ImportSection: []*wasm.Import{
+			{Module: "exporting", Name: "null_ref", Type: wasm.ExternTypeGlobal, DescGlobal: importedGlobalType},
+			{Module: "exporting", Name: "func_ref", Type: wasm.ExternTypeGlobal, DescGlobal: importedGlobalType},
+		},
+		FunctionSection: []wasm.Index{0, 0},
+		// Globals initialized by "global.get" of the imported ones at index 0 and 1.
+		GlobalSection: []*wasm.Global{
+			{Type: &wasm.GlobalType{ValType: funcref}, Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeGlobalGet, Data: []byte{0}}},
+			{Type: &wasm.GlobalType{ValType: funcref}, Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeGlobalGet, Data: []byte{1}}},
+		},
+		CodeSection: []*wasm.Code{globalIsNull(2), globalIsNull(3)},
+		ExportSection: []*wasm.Export{
+			{Name: "null global", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "func global", Type: wasm.ExternTypeFunc, Index: 1},
+		},
+	}))
+	require.NoError(t, err)
+	defer importing.Close(testCtx)
+
+	for _, tc := range []struct {
+		module   api.Module
+		name     string
+		expected uint64
+	}{
+		{module: exporting, name: "null", expected: 1},
+		{module: exporting, name: "func", expected: 0},
+		{module: exporting, name: "null global", expected: 1},
+		{module: exporting, name: "func global", expected: 0},
+		{module: exporting, name: "default local", expected: 1},
+		{module: importing, name: "null global", expected: 1},
+		{module: importing, name: "func global", expected: 0},
+	} {
+		results, err := tc.module.ExportedFunction(tc.name).Call(testCtx)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{tc.expected}, results, tc.module.Name()+"."+tc.name)
+	}
+}
+
+// testNaNPayloads ensures NaN bit patterns in f32.const and f64.const are not canonicalized, regardless of whether
+// they are evaluated as a global initializer or as an instruction in a function body.
+func testNaNPayloads(t *testing.T, r wazero.Runtime) {
+	const f32NaN, f64NaN = uint32(0x7fa00000), uint64(0x7ff4000000000000) // nan:0x200000 and nan:0x4000000000000
+
+	f32Data, f64Data := u64.LeBytes(uint64(f32NaN))[:4], u64.LeBytes(f64NaN)
+	f32Body := append(append([]byte{wasm.OpcodeF32Const}, f32Data...), wasm.OpcodeEnd)
+	f64Body := append(append([]byte{wasm.OpcodeF64Const}, f64Data...), wasm.OpcodeEnd)
+
+	module, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Results: []wasm.ValueType{wasm.ValueTypeF32}},
+			{Results: []wasm.ValueType{wasm.ValueTypeF64}},
+		},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection:     []*wasm.Code{{Body: f32Body}, {Body: f64Body}},
+		GlobalSection: []*wasm.Global{
+			{
+				Type: &wasm.GlobalType{ValType: wasm.ValueTypeF32},
+				Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeF32Const, Data: f32Data},
+			},
+			{
+				Type: &wasm.GlobalType{ValType: wasm.ValueTypeF64},
+				Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeF64Const, Data: f64Data},
+			},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "f32", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "f64", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "global_f32", Type: wasm.ExternTypeGlobal, Index: 0},
+			{Name: "global_f64", Type: wasm.ExternTypeGlobal, Index: 1},
+		},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	require.Equal(t, uint64(f32NaN), module.ExportedGlobal("global_f32").Get(testCtx))
+	require.Equal(t, f64NaN, module.ExportedGlobal("global_f64").Get(testCtx))
+
+	results, err := module.ExportedFunction("f32").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(f32NaN), results[0])
+
+	results, err = module.ExportedFunction("f64").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, f64NaN, results[0])
+}
+
+// testPromoteDemote ensures "f64.promote_f32" quiets a NaN but keeps its payload, and "f32.demote_f64" rounds to the
+// nearest, ties to even, including overflow to infinity.
+func testPromoteDemote(t *testing.T, r wazero.Runtime) {
+	module, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{wasm.ValueTypeF32}, Results: []wasm.ValueType{wasm.ValueTypeF64}},
+			{Params: []wasm.ValueType{wasm.ValueTypeF64}, Results: []wasm.ValueType{wasm.ValueTypeF32}},
+		},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeF64PromoteF32, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeF32DemoteF64, wasm.OpcodeEnd}},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "promote", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "demote", Type: wasm.ExternTypeFunc, Index: 1},
+		},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	for _, tc := range []struct {
+		name          string
+		fn            string
+		input, output uint64
+	}{
+		{name: "promote signaling NaN", fn: "promote", input: 0x7fa00000, output: 0x7ffc000000000000},
+		{name: "promote negative NaN", fn: "promote", input: 0xffc00001, output: 0xfff8000020000000},
+		{name: "promote -0", fn: "promote", input: 0x80000000, output: 0x8000000000000000},
+		{name: "demote signaling NaN", fn: "demote", input: 0x7ff4000000000000, output: 0x7fe00000},
+		{name: "demote NaN with low payload", fn: "demote", input: 0x7ff0000000000001, output: 0x7fc00000},
+		{name: "demote tie to even down", fn: "demote", input: 0x3ff0000010000000, output: 0x3f800000},     // 1+2^-24
+		{name: "demote tie to even up", fn: "demote", input: 0x3ff0000030000000, output: 0x3f800002},       // 1+3*2^-24
+		{name: "demote max float32", fn: "demote", input: 0x47efffffefffffff, output: 0x7f7fffff},          // below half ulp
+		{name: "demote overflow to infinity", fn: "demote", input: 0x47effffff0000000, output: 0x7f800000}, // half ulp
+		{name: "demote overflow to -infinity", fn: "demote", input: 0xc7effffff0000000, output: 0xff800000},
+	} {
+		results, err := module.ExportedFunction(tc.fn).Call(testCtx, tc.input)
+		require.NoError(t, err)
+		result := results[0]
+		if tc.fn == "demote" {
+			result = uint64(uint32(result)) // Only the lower 32 bits encode a ValueTypeF32, per api.DecodeF32.
+		}
+		require.Equal(t, tc.output, result, tc.name)
+	}
+}
+
 func testHugeStack(t *testing.T, r wazero.Runtime) {
 	module, err := r.InstantiateModuleFromCode(testCtx, hugestackWasm)
 	require.NoError(t, err)
@@ -140,6 +527,277 @@ wasm stack trace:
 	require.Equal(t, exp, err.Error())
 }
 
+// testHostTable ensures a guest can import a table exported by ModuleBuilder, and call a host function installed in
+// it with "call_indirect".
+func testHostTable(t *testing.T, r wazero.Runtime) {
+	host, err := r.NewModuleBuilder("env").
+		ExportFunction("answer", func() uint32 { return 42 }).
+		ExportTable("table", 1, nil, api.ValueTypeFuncref).
+		Instantiate(testCtx)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	zero := wasm.Index(0)
+	module, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		ImportSection: []*wasm.Import{
+			{Module: "env", Name: "answer", Type: wasm.ExternTypeFunc, DescFunc: 0},
+			{Module: "env", Name: "table", Type: wasm.ExternTypeTable, DescTable: &wasm.Table{Min: 1, Type: wasm.RefTypeFuncref}},
+		},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{
+			// Calls the table element at index zero, expecting the type () -> i32
+			{Body: []byte{wasm.OpcodeI32Const, 0, wasm.OpcodeCallIndirect, 0, 0, wasm.OpcodeEnd}},
+		},
+		// Installs the host function "answer" at index zero of the host table.
+		ElementSection: []*wasm.ElementSegment{
+			{
+				OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+				Init:       []*wasm.Index{&zero},
+				Type:       wasm.RefTypeFuncref,
+			},
+		},
+		ExportSection: []*wasm.Export{{Name: "call_indirect", Type: wasm.ExternTypeFunc, Index: 1}},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	results, err := module.ExportedFunction("call_indirect").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+}
+
+// testCallImportedFunc ensures "call" dispatches to an imported function, which is numbered before the functions
+// defined in the module.
+func testCallImportedFunc(t *testing.T, r wazero.Runtime) {
+	var calls int
+	host, err := r.NewModuleBuilder("env").
+		ExportFunction("add", func(x, y uint32) uint32 {
+			calls++
+			return x + y
+		}).
+		Instantiate(testCtx)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	i32 := wasm.ValueTypeI32
+	module, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{{Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}}},
+		ImportSection: []*wasm.Import{
+			{Module: "env", Name: "add", Type: wasm.ExternTypeFunc, DescFunc: 0}, // function index 0
+		},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			// function index 1: calls the imported "add"
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeCall, 0, wasm.OpcodeEnd}},
+			// function index 2: calls function index 1 with its params doubled
+			{Body: []byte{
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Add,
+				wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Add,
+				wasm.OpcodeCall, 1, wasm.OpcodeEnd,
+			}},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "call_add", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "call_add_doubled", Type: wasm.ExternTypeFunc, Index: 2},
+		},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	results, err := module.ExportedFunction("call_add").Call(testCtx, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{3}, results)
+	require.Equal(t, 1, calls)
+
+	results, err = module.ExportedFunction("call_add_doubled").Call(testCtx, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{6}, results)
+	require.Equal(t, 2, calls)
+}
+
+// testTableSetFunc ensures functions installed with api.Table SetFunc can be called by a guest with "call_indirect".
+func testTableSetFunc(t *testing.T, r wazero.Runtime) {
+	host, err := r.NewModuleBuilder("env").
+		ExportFunction("one", func() uint32 { return 1 }).
+		ExportFunction("two", func() uint32 { return 2 }).
+		ExportTable("table", 2, nil, api.ValueTypeFuncref).
+		Instantiate(testCtx)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	table := host.ExportedTable("table")
+	require.Equal(t, uint32(2), table.Size(testCtx))
+	require.NoError(t, table.SetFunc(testCtx, 0, host.ExportedFunction("one")))
+	require.NoError(t, table.SetFunc(testCtx, 1, host.ExportedFunction("two")))
+	require.EqualError(t, table.SetFunc(testCtx, 2, host.ExportedFunction("one")),
+		"index 2 out of range for table of size 2")
+
+	other := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter())
+	defer other.Close(testCtx)
+	otherHost, err := other.NewModuleBuilder("env").ExportFunction("one", func() uint32 { return 1 }).Instantiate(testCtx)
+	require.NoError(t, err)
+	require.EqualError(t, table.SetFunc(testCtx, 0, otherHost.ExportedFunction("one")),
+		"function is not from the same runtime as the table")
+
+	module, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Results: []wasm.ValueType{wasm.ValueTypeI32}},
+			{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}},
+		},
+		ImportSection: []*wasm.Import{
+			{Module: "env", Name: "table", Type: wasm.ExternTypeTable, DescTable: &wasm.Table{Min: 2, Type: wasm.RefTypeFuncref}},
+		},
+		FunctionSection: []wasm.Index{1},
+		CodeSection: []*wasm.Code{
+			// Calls the table element at the param index, expecting the type () -> i32
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeCallIndirect, 0, 0, wasm.OpcodeEnd}},
+		},
+		ExportSection: []*wasm.Export{{Name: "call_indirect", Type: wasm.ExternTypeFunc, Index: 0}},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	callIndirect := module.ExportedFunction("call_indirect")
+	for i, expected := range []uint64{1, 2} {
+		results, err := callIndirect.Call(testCtx, uint64(i))
+		require.NoError(t, err)
+		require.Equal(t, []uint64{expected}, results)
+	}
+}
+
+func testTrapCodes(t *testing.T, r wazero.Runtime) {
+	i32, f32 := wasm.ValueTypeI32, wasm.ValueTypeF32
+	zero := wasm.Index(0)
+	module, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Results: []wasm.ValueType{i32}},
+			{},
+			{Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{f32}, Results: []wasm.ValueType{i32}},
+		},
+		FunctionSection: []wasm.Index{1, 2, 3, 4, 3},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeUnreachable, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32DivS, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Load, 0x2, 0x0, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeI32TruncF32S, wasm.OpcodeEnd}},
+			// Calls the table element at the param index, expecting the type () -> i32
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeCallIndirect, 0, 0, wasm.OpcodeEnd}},
+		},
+		// The table has the function "unreachable" at index zero and null at index one.
+		TableSection:  []*wasm.Table{{Min: 2, Type: wasm.RefTypeFuncref}},
+		MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: 1}},
+		ElementSection: []*wasm.ElementSegment{
+			{
+				OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+				Init:       []*wasm.Index{&zero},
+				Type:       wasm.RefTypeFuncref,
+			},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "unreachable", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "div", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "load", Type: wasm.ExternTypeFunc, Index: 2},
+			{Name: "trunc", Type: wasm.ExternTypeFunc, Index: 3},
+			{Name: "call_indirect", Type: wasm.ExternTypeFunc, Index: 4},
+		},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	for _, tc := range []struct {
+		name     string
+		params   []uint64
+		expected sys.TrapCode
+	}{
+		{name: "unreachable", expected: sys.TrapUnreachable},
+		{name: "div", params: []uint64{1, 0}, expected: sys.TrapDivByZero},
+		{name: "div", params: []uint64{math.MaxUint32 &^ math.MaxInt32, math.MaxUint32}, expected: sys.TrapIntegerOverflow},
+		{name: "load", params: []uint64{uint64(wasm.MemoryPageSize)}, expected: sys.TrapMemoryOutOfBounds},
+		{name: "trunc", params: []uint64{uint64(math.Float32bits(float32(math.NaN())))}, expected: sys.TrapInvalidConversion},
+		{name: "call_indirect", params: []uint64{0}, expected: sys.TrapIndirectCallTypeMismatch},
+		{name: "call_indirect", params: []uint64{1}, expected: sys.TrapInvalidTableAccess},
+	} {
+		_, err = module.ExportedFunction(tc.name).Call(testCtx, tc.params...)
+		trapErr, ok := err.(*sys.TrapError)
+		require.True(t, ok, "%s%v: %v", tc.name, tc.params, err)
+		require.Equal(t, tc.expected, trapErr.Code(), "%s%v: %v", tc.name, tc.params, err)
+	}
+}
+
+// testSignedDivOverflow ensures dividing the minimum signed integer by -1 traps as the quotient isn't representable,
+// while the remainder of the same operands is zero.
+func testSignedDivOverflow(t *testing.T, r wazero.Runtime) {
+	i32, i64 := wasm.ValueTypeI32, wasm.ValueTypeI64
+	body := func(opcode wasm.Opcode) *wasm.Code {
+		return &wasm.Code{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, opcode, wasm.OpcodeEnd}}
+	}
+	module, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i64, i64}, Results: []wasm.ValueType{i64}},
+		},
+		FunctionSection: []wasm.Index{0, 1, 0, 1},
+		CodeSection:     []*wasm.Code{body(wasm.OpcodeI32DivS), body(wasm.OpcodeI64DivS), body(wasm.OpcodeI32RemS), body(wasm.OpcodeI64RemS)},
+		ExportSection: []*wasm.Export{
+			{Name: "i32.div_s", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "i64.div_s", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "i32.rem_s", Type: wasm.ExternTypeFunc, Index: 2},
+			{Name: "i64.rem_s", Type: wasm.ExternTypeFunc, Index: 3},
+		},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	minInt32, minusOne32 := uint64(uint32(math.MaxUint32&^math.MaxInt32)), uint64(math.MaxUint32)
+	minInt64, minusOne64 := uint64(math.MaxUint64&^math.MaxInt64), uint64(math.MaxUint64)
+
+	for _, tc := range []struct {
+		name   string
+		params []uint64
+	}{
+		{name: "i32.div_s", params: []uint64{minInt32, minusOne32}},
+		{name: "i64.div_s", params: []uint64{minInt64, minusOne64}},
+	} {
+		_, err = module.ExportedFunction(tc.name).Call(testCtx, tc.params...)
+		require.ErrorIs(t, err, wasmruntime.ErrRuntimeIntegerOverflow, tc.name)
+		trapErr, ok := err.(*sys.TrapError)
+		require.True(t, ok, tc.name)
+		require.Equal(t, sys.TrapIntegerOverflow, trapErr.Code(), tc.name)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		params []uint64
+	}{
+		{name: "i32.rem_s", params: []uint64{minInt32, minusOne32}},
+		{name: "i64.rem_s", params: []uint64{minInt64, minusOne64}},
+	} {
+		results, err := module.ExportedFunction(tc.name).Call(testCtx, tc.params...)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, []uint64{0}, results, tc.name)
+	}
+
+	// Unlike the above, a zero divisor traps for rem_s, as it does for div_s.
+	for _, tc := range []struct {
+		name   string
+		params []uint64
+	}{
+		{name: "i32.rem_s", params: []uint64{minInt32, 0}},
+		{name: "i64.rem_s", params: []uint64{minInt64, 0}},
+		{name: "i32.rem_s", params: []uint64{1, 0}},
+		{name: "i64.rem_s", params: []uint64{1, 0}},
+	} {
+		_, err = module.ExportedFunction(tc.name).Call(testCtx, tc.params...)
+		require.ErrorIs(t, err, wasmruntime.ErrRuntimeIntegerDivideByZero, tc.name)
+		trapErr, ok := err.(*sys.TrapError)
+		require.True(t, ok, tc.name)
+		require.Equal(t, sys.TrapDivByZero, trapErr.Code(), tc.name)
+	}
+}
+
 func testRecursiveEntry(t *testing.T, r wazero.Runtime) {
 	hostfunc := func(mod api.Module) {
 		_, err := mod.ExportedFunction("called_by_host_func").Call(testCtx)
@@ -286,6 +944,74 @@ func testHostFunctionContextParameter(t *testing.T, r wazero.Runtime) {
 	}
 }
 
+// testHostFunctionMemoryParameter ensures an api.Memory parameter is injected with the caller's memory, and isn't
+// counted as a Wasm parameter.
+func testHostFunctionMemoryParameter(t *testing.T, r wazero.Runtime) {
+	var read string
+	imported, err := r.NewModuleBuilder(t.Name()+"-imported").
+		ExportFunction("read", func(ctx context.Context, mem api.Memory, offset, byteCount uint32) uint32 {
+			buf, ok := mem.Read(ctx, offset, byteCount)
+			require.True(t, ok)
+			read = string(buf)
+			return byteCount
+		}).Instantiate(testCtx)
+	require.NoError(t, err)
+	defer imported.Close(testCtx)
+
+	importing, err := r.InstantiateModuleFromCode(testCtx, []byte(fmt.Sprintf(`(module $%[1]s
+	(import "%[2]s" "read" (func $read (param i32 i32) (result i32)))
+	(memory 1)
+	(func $call_read (param i32 i32) (result i32) local.get 0 local.get 1 call $read)
+	(export "call->read" (func $call_read))
+)`, t.Name()+"-importing", t.Name()+"-imported")))
+	require.NoError(t, err)
+	defer importing.Close(testCtx)
+
+	require.True(t, importing.Memory().Write(testCtx, 8, []byte("wazero")))
+
+	results, err := importing.ExportedFunction("call->read").Call(testCtx, 8, 6)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), results[0])
+	require.Equal(t, "wazero", read)
+}
+
+// testHostFunctionMemoryGrown ensures a host function called after "memory.grow" sees the grown memory, and that the
+// caller can read what the host wrote to the new pages.
+func testHostFunctionMemoryGrown(t *testing.T, r wazero.Runtime) {
+	var size uint32
+	imported, err := r.NewModuleBuilder(t.Name()+"-imported").
+		ExportFunction("write_last_byte", func(ctx context.Context, mem api.Memory) {
+			size = mem.Size(ctx)
+			require.True(t, mem.WriteByte(ctx, size-1, 0xff))
+		}).Instantiate(testCtx)
+	require.NoError(t, err)
+	defer imported.Close(testCtx)
+
+	// Grows from one to three pages, past the capacity, so the buffer is reallocated.
+	body := []byte{wasm.OpcodeI32Const, 2, wasm.OpcodeMemoryGrow, 0, wasm.OpcodeDrop, wasm.OpcodeCall, 0, wasm.OpcodeI32Const}
+	body = append(body, leb128.EncodeInt32(3*65536-1)...) // the last byte of three pages
+	body = append(body, wasm.OpcodeI32Load8U, 0, 0, wasm.OpcodeEnd)
+
+	importing, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{{}, {Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		ImportSection: []*wasm.Import{
+			{Module: t.Name() + "-imported", Name: "write_last_byte", Type: wasm.ExternTypeFunc, DescFunc: 0},
+		},
+		MemorySection:   []*wasm.Memory{{Min: 1, Max: 3, IsMaxEncoded: true}},
+		FunctionSection: []wasm.Index{1},
+		CodeSection:     []*wasm.Code{{Body: body}},
+		ExportSection:   []*wasm.Export{{Name: "grow", Type: wasm.ExternTypeFunc, Index: 1}},
+	}))
+	require.NoError(t, err)
+	defer importing.Close(testCtx)
+
+	results, err := importing.ExportedFunction("grow").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, uint32(3*65536), size)
+	require.Equal(t, []uint64{0xff}, results)
+	require.Equal(t, size, importing.Memory().Size(testCtx))
+}
+
 // testHostFunctionNumericParameter ensures numeric parameters aren't corrupted
 func testHostFunctionNumericParameter(t *testing.T, r wazero.Runtime) {
 	importedName := t.Name() + "-imported"
@@ -529,6 +1255,53 @@ func testMemOps(t *testing.T, r wazero.Runtime) {
 	require.NoError(t, err)
 }
 
+// testZeroPageMemory ensures a memory with zero minimum pages, and no capacity to grow into, traps on access until
+// grown. Some toolchains emit these, as the size is set at runtime.
+func testZeroPageMemory(t *testing.T, r wazero.Runtime) {
+	// Use the default config, so that the initial buffer has zero capacity.
+	module, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $zero_page
+  (memory 0)
+  (func $size (result i32) memory.size)
+  (func $grow (param $delta i32) (result i32) local.get 0 memory.grow)
+  (func $store (param $offset i32) (param $value i32) local.get 0 local.get 1 i32.store)
+  (func $load (param $offset i32) (result i32) local.get 0 i32.load)
+  (export "size" (func $size))
+  (export "grow" (func $grow))
+  (export "store" (func $store))
+  (export "load" (func $load))
+)`))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	results, err := module.ExportedFunction("size").Call(testCtx)
+	require.NoError(t, err)
+	require.Zero(t, results[0])
+	require.Zero(t, module.Memory().Size(testCtx))
+
+	// Even offset zero is out of bounds, as there are no bytes.
+	_, err = module.ExportedFunction("load").Call(testCtx, 0)
+	trapErr, ok := err.(*sys.TrapError)
+	require.True(t, ok, "%v", err)
+	require.Equal(t, sys.TrapMemoryOutOfBounds, trapErr.Code())
+
+	results, err = module.ExportedFunction("grow").Call(testCtx, 1)
+	require.NoError(t, err)
+	require.Zero(t, results[0]) // previous size in pages
+
+	results, err = module.ExportedFunction("size").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), results[0])
+
+	// Store and load both at the start and the end of the new page.
+	for _, offset := range []uint64{0, uint64(wasm.MemoryPageSize) - 4} {
+		_, err = module.ExportedFunction("store").Call(testCtx, offset, 0xdeadbeef)
+		require.NoError(t, err)
+		results, err = module.ExportedFunction("load").Call(testCtx, offset)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0xdeadbeef), results[0])
+	}
+}
+
 func testMultipleInstantiation(t *testing.T, r wazero.Runtime) {
 	compiled, err := r.CompileModule(testCtx, []byte(`(module $test
 		(memory 1)
@@ -566,3 +1339,339 @@ func testMultipleInstantiation(t *testing.T, r wazero.Runtime) {
 		require.Equal(t, uint64(1000), after)
 	}
 }
+
+// featureTests are like tests, except each requires a feature which isn't enabled by default. TestFeatures runs them.
+var featureTests = map[string]struct {
+	// enable returns the config with the feature enabled.
+	enable func(wazero.RuntimeConfig) wazero.RuntimeConfig
+	// module is the source compiled with compileConfig, or the default CompileConfig if nil.
+	module        []byte
+	compileConfig wazero.CompileConfig
+	// disabledErr is the error compiling module without the feature enabled.
+	disabledErr string
+	// compilerErr is a part of the error compiling module when the compiler doesn't yet support the feature.
+	compilerErr string
+	test        func(t *testing.T, module api.Module)
+}{
+	"multi-memory": {
+		enable:      func(c wazero.RuntimeConfig) wazero.RuntimeConfig { return c.WithFeatureMultiMemory(true) },
+		module:      multiMemoryWasm,
+		disabledErr: "section memory: at most one memory allowed in module, but read 2",
+		compilerErr: "on memory[1] is not yet supported by the compiler",
+		test:        testMultiMemory,
+	},
+	"shared memory": {
+		enable: func(c wazero.RuntimeConfig) wazero.RuntimeConfig { return c.WithFeatureThreads(true) },
+		module: sharedMemoryWat,
+		compileConfig: wazero.NewCompileConfig().WithMemorySizer(func(minPages uint32, maxPages *uint32) (min, capacity, max uint32) {
+			min, _, max = wasm.MemorySizer(minPages, maxPages)
+			return min, max, max // reserve the max, as a shared memory can't grow past its capacity.
+		}),
+		disabledErr: `shared memory invalid as feature "threads" is disabled`,
+		test:        testSharedMemory,
+	},
+	"tail-call": {
+		enable:      func(c wazero.RuntimeConfig) wazero.RuntimeConfig { return c.WithFeatureTailCall(true) },
+		module:      tailCallWasm,
+		disabledErr: `invalid function[0] export["return_call"]: return_call invalid as feature "tail-call" is disabled`,
+		compilerErr: "tail calls are not yet supported by the compiler",
+		test:        testTailCall,
+	},
+	"extended-const": {
+		enable:      func(c wazero.RuntimeConfig) wazero.RuntimeConfig { return c.WithFeatureExtendedConst(true) },
+		module:      extendedConstWasm,
+		disabledErr: "global[0]: constant expression has been not terminated",
+		test:        testExtendedConst,
+	},
+}
+
+func TestFeatures(t *testing.T) {
+	configs := map[string]wazero.RuntimeConfig{"interpreter": wazero.NewRuntimeConfigInterpreter()}
+	if wazero.CompilerSupported {
+		configs["compiler"] = wazero.NewRuntimeConfigCompiler()
+	}
+	for name, tc := range featureTests {
+		tc := tc
+		compileConfig := tc.compileConfig
+		if compileConfig == nil {
+			compileConfig = wazero.NewCompileConfig()
+		}
+		t.Run(name, func(t *testing.T) {
+			t.Run("disabled", func(t *testing.T) {
+				r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter())
+				_, err := r.CompileModule(testCtx, tc.module, compileConfig)
+				require.EqualError(t, err, tc.disabledErr)
+			})
+
+			for engine, config := range configs {
+				config := config
+				t.Run(engine, func(t *testing.T) {
+					r := wazero.NewRuntimeWithConfig(tc.enable(config))
+					defer r.Close(testCtx)
+
+					compiled, err := r.CompileModule(testCtx, tc.module, compileConfig)
+					if engine == "compiler" && tc.compilerErr != "" {
+						require.Error(t, err)
+						require.Contains(t, err.Error(), tc.compilerErr)
+						return
+					}
+					require.NoError(t, err)
+
+					module, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig())
+					require.NoError(t, err)
+					tc.test(t, module)
+				})
+			}
+		})
+	}
+}
+
+// multiMemoryWasm exports functions whose load, store and memory.size address the second memory via their immediate.
+var multiMemoryWasm = func() []byte {
+	i32 := wasm.ValueTypeI32
+	memIdxAlign := byte(wasm.MemoryArgMemoryIndexFlag | 2) // i32 alignment, followed by a memory index.
+	return binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32, i32}},
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}},
+			{Results: []wasm.ValueType{i32}},
+		},
+		FunctionSection: []wasm.Index{0, 1, 1, 2},
+		MemorySection:   []*wasm.Memory{{Min: 1, Cap: 1, Max: 1}, {Min: 2, Cap: 2, Max: 2}},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{ // store1
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeI32Store, memIdxAlign, 1, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Load, 2, 0, wasm.OpcodeEnd}},              // load0
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Load, memIdxAlign, 1, 0, wasm.OpcodeEnd}}, // load1
+			{Body: []byte{wasm.OpcodeMemorySize, 1, wasm.OpcodeEnd}},                                      // size1
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "store1", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "load0", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "load1", Type: wasm.ExternTypeFunc, Index: 2},
+			{Name: "size1", Type: wasm.ExternTypeFunc, Index: 3},
+		},
+	})
+}()
+
+// testMultiMemory ensures load, store and memory.size address the memory in their immediate.
+func testMultiMemory(t *testing.T, module api.Module) {
+	results, err := module.ExportedFunction("size1").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), results[0])
+
+	// Offsets past the first memory are only in bounds of the second.
+	offset := uint64(wasm.MemoryPageSize)
+	_, err = module.ExportedFunction("store1").Call(testCtx, offset, 0xdeadbeef)
+	require.NoError(t, err)
+
+	results, err = module.ExportedFunction("load1").Call(testCtx, offset)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0xdeadbeef), results[0])
+
+	_, err = module.ExportedFunction("load0").Call(testCtx, offset)
+	trapErr, ok := err.(*sys.TrapError)
+	require.True(t, ok, "%v", err)
+	require.Equal(t, sys.TrapMemoryOutOfBounds, trapErr.Code())
+
+	// The first memory, which is the one exposed by api.Module, is untouched.
+	results, err = module.ExportedFunction("load0").Call(testCtx, 0)
+	require.NoError(t, err)
+	require.Zero(t, results[0])
+	require.Equal(t, uint32(wasm.MemoryPageSize), module.Memory().Size(testCtx))
+}
+
+var sharedMemoryWat = []byte(`(module $shared
+  (memory 1 4 shared)
+  (func $size (result i32) memory.size)
+  (func $grow (param $delta i32) (result i32) local.get 0 memory.grow)
+  (func $store (param $offset i32) (param $value i32) local.get 0 local.get 1 i32.store)
+  (func $load (param $offset i32) (result i32) local.get 0 i32.load)
+  (export "size" (func $size))
+  (export "grow" (func $grow))
+  (export "store" (func $store))
+  (export "load" (func $load))
+)`)
+
+// testSharedMemory ensures a function called from one goroutine sees memory grown by a function called concurrently
+// from another.
+func testSharedMemory(t *testing.T, module api.Module) {
+	const maxPages = 4
+	size, grow := module.ExportedFunction("size"), module.ExportedFunction("grow")
+	store, load := module.ExportedFunction("store"), module.ExportedFunction("load")
+
+	// The grower grows one page at a time, and stores the new size in the first byte of each new page. At the same
+	// time, the reader loads the last page it sees until the memory reached its max.
+	start, errs := make(chan struct{}), make(chan error, 2)
+	go func() {
+		<-start
+		for pages := uint64(1); pages < maxPages; pages++ {
+			if results, err := grow.Call(testCtx, 1); err != nil {
+				errs <- err
+				return
+			} else if results[0] != pages {
+				errs <- fmt.Errorf("grow returned %d, expected %d", results[0], pages)
+				return
+			}
+			if _, err := store.Call(testCtx, pages*uint64(wasm.MemoryPageSize), pages+1); err != nil {
+				errs <- err
+				return
+			}
+		}
+		errs <- nil
+	}()
+	go func() {
+		<-start
+		for previous := uint64(1); previous < maxPages; {
+			results, err := size.Call(testCtx)
+			if err != nil {
+				errs <- err
+				return
+			} else if pages := results[0]; pages < previous || pages > maxPages {
+				errs <- fmt.Errorf("size %d after %d", pages, previous)
+				return
+			} else {
+				previous = pages
+			}
+			// The last page is readable as soon as it is visible. This skips the value the grower stores, as the
+			// non-atomic store and load would race.
+			if results, err = load.Call(testCtx, (previous-1)*uint64(wasm.MemoryPageSize)+4); err != nil {
+				errs <- err
+				return
+			} else if v := results[0]; v != 0 {
+				errs <- fmt.Errorf("loaded %d from page %d", v, previous-1)
+				return
+			}
+		}
+		errs <- nil
+	}()
+	close(start)
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+
+	for pages := uint64(1); pages < maxPages; pages++ {
+		results, err := load.Call(testCtx, pages*uint64(wasm.MemoryPageSize))
+		require.NoError(t, err)
+		require.Equal(t, pages+1, results[0])
+	}
+
+	// The memory can't grow past its capacity.
+	results, err := grow.Call(testCtx, 1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0xffffffff), results[0])
+}
+
+// tailCallWasm exports functions that return acc after adding one to it n times, by recursing with return_call,
+// return_call_indirect or call.
+var tailCallWasm = func() []byte {
+	i64 := wasm.ValueTypeI64
+	countdown := func(call ...byte) *wasm.Code {
+		body := []byte{
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Eqz,
+			wasm.OpcodeIf, 0x40, wasm.OpcodeLocalGet, 1, wasm.OpcodeReturn, wasm.OpcodeEnd,
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Const, 1, wasm.OpcodeI64Sub, // n - 1
+			wasm.OpcodeLocalGet, 1, wasm.OpcodeI64Const, 1, wasm.OpcodeI64Add, // acc + 1
+		}
+		return &wasm.Code{Body: append(append(body, call...), wasm.OpcodeEnd)}
+	}
+	zero := wasm.Index(0)
+	return binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i64, i64}, Results: []wasm.ValueType{i64}}},
+		FunctionSection: []wasm.Index{0, 0, 0},
+		TableSection:    []*wasm.Table{{Min: 1, Type: wasm.RefTypeFuncref}},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&zero},
+			Type:       wasm.RefTypeFuncref,
+		}},
+		CodeSection: []*wasm.Code{
+			countdown(wasm.OpcodeReturnCall, 0),
+			countdown(wasm.OpcodeI32Const, 0, wasm.OpcodeReturnCallIndirect, 0, 0),
+			countdown(wasm.OpcodeCall, 2),
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "return_call", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "return_call_indirect", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "call", Type: wasm.ExternTypeFunc, Index: 2},
+		},
+	})
+}()
+
+// testTailCall ensures tail calls complete with a constant call stack, even when the same recursion with `call`
+// overflows it.
+func testTailCall(t *testing.T, module api.Module) {
+	// depth is far more than the maximum call stack height.
+	const depth = 1_000_000
+
+	for _, name := range []string{"return_call", "return_call_indirect"} {
+		results, err := module.ExportedFunction(name).Call(testCtx, depth, 0)
+		require.NoError(t, err, name)
+		require.Equal(t, uint64(depth), results[0], name)
+	}
+
+	_, err := module.ExportedFunction("call").Call(testCtx, depth, 0)
+	require.ErrorIs(t, err, wasmruntime.ErrRuntimeCallStackOverflow)
+}
+
+// extendedConstWasm exports a global initialized by (i32.add (i32.const 1) (i32.const 2)). The same expression is
+// the offset of an element segment placing function 0, which returns 42, into the table, and (i32.mul (i32.const 2) (i32.const 4)) is
+// the offset of a data segment writing "hi" into memory.
+var extendedConstWasm = func() []byte {
+	zero := wasm.Index(0)
+	return binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		FunctionSection: []wasm.Index{0, 0},
+		TableSection:    []*wasm.Table{{Min: 4, Type: wasm.RefTypeFuncref}},
+		MemorySection:   []*wasm.Memory{{Min: 1, Cap: 1, Max: 1}},
+		GlobalSection: []*wasm.Global{{
+			Type: &wasm.GlobalType{ValType: wasm.ValueTypeI32},
+			Init: &wasm.ConstantExpression{
+				Opcode: wasm.OpcodeI32Add,
+				Data:   []byte{wasm.OpcodeI32Const, 1, wasm.OpcodeI32Const, 2},
+			},
+		}},
+		ExportSection: []*wasm.Export{
+			{Name: "three", Type: wasm.ExternTypeGlobal, Index: 0},
+			{Name: "call_three", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
+		},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{
+				Opcode: wasm.OpcodeI32Add,
+				Data:   []byte{wasm.OpcodeI32Const, 1, wasm.OpcodeI32Const, 2},
+			},
+			Init: []*wasm.Index{&zero},
+			Type: wasm.RefTypeFuncref,
+			Mode: wasm.ElementModeActive,
+		}},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeI32Const, 42, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeI32Const, 3, wasm.OpcodeCallIndirect, 0, 0, wasm.OpcodeEnd}},
+		},
+		DataSection: []*wasm.DataSegment{{
+			OffsetExpression: &wasm.ConstantExpression{
+				Opcode: wasm.OpcodeI32Mul,
+				Data:   []byte{wasm.OpcodeI32Const, 2, wasm.OpcodeI32Const, 4},
+			},
+			Init: []byte("hi"),
+		}},
+	})
+}()
+
+// testExtendedConst ensures globals and segment offsets can be initialized by arithmetic on constants.
+func testExtendedConst(t *testing.T, module api.Module) {
+	require.Equal(t, uint64(3), module.ExportedGlobal("three").Get(testCtx))
+
+	// The element segment placed the function at table offset 3.
+	results, err := module.ExportedFunction("call_three").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+
+	// The data segment was written at memory offset 8.
+	hi, ok := module.Memory().Read(testCtx, 8, 2)
+	require.True(t, ok)
+	require.Equal(t, "hi", string(hi))
+}
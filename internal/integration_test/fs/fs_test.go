@@ -86,13 +86,9 @@ func (f *wasiFile) Read(bytes []byte) (int, error) {
 	iovsOff := uint32(4)
 	// We do not directly write to hardware, there is no need for more than one iovec
 	iovsCount := uint32(1)
-	// iov starts at iovsOff + 8 because we first write four bytes for the offset itself, and
-	// four bytes for the length of the iov.
+	// iov starts at iovsOff + 8 because the iovec is eight bytes: the offset itself, and the length of the iov.
 	iovOff := iovsOff + uint32(8)
-	ok := f.fs.memory.WriteUint32Le(testCtx, iovsOff, iovOff)
-	require.True(f.fs.t, ok)
-	// Next write the length.
-	ok = f.fs.memory.WriteUint32Le(testCtx, iovsOff+uint32(4), uint32(len(bytes)))
+	_, ok := wasi.WriteIOVecs(testCtx, f.fs.memory, iovsOff, []wasi.IOVec{{Offset: iovOff, Length: uint32(len(bytes))}})
 	require.True(f.fs.t, ok)
 
 	res, err := f.fs.fdRead.Call(testCtx, uint64(f.fd), uint64(iovsOff), uint64(iovsCount), uint64(resultSizeOff))
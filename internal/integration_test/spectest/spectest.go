@@ -347,7 +347,7 @@ func addSpectestModule(t *testing.T, store *wasm.Store) {
 
 // maybeSetMemoryCap assigns wasm.Memory Cap to Min, which is what wazero.CompileModule would do.
 func maybeSetMemoryCap(mod *wasm.Module) {
-	if mem := mod.MemorySection; mem != nil {
+	for _, mem := range mod.MemorySection {
 		mem.Cap = mem.Min
 	}
 }
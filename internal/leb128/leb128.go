@@ -152,7 +152,10 @@ func DecodeInt32(r *bytes.Reader) (ret int32, bytesRead uint64, err error) {
 		ret |= (int32(b) & 0x7f) << shift
 		shift += 7
 		bytesRead++
-		if b&0x80 == 0 {
+		if b&0x80 != 0 && bytesRead == maxVarintLen32 {
+			// Fail fast instead of reading the rest of an overlong encoding, which could be the whole input.
+			return 0, 0, errOverflow32
+		} else if b&0x80 == 0 {
 			if shift < 32 && (b&0x40) != 0 {
 				ret |= ^0 << shift
 			}
@@ -168,6 +168,8 @@ func TestDecodeInt32(t *testing.T) {
 		{bytes: []byte{0xff, 0xff, 0xff, 0xff, 0x0f}, expErr: true},
 		{bytes: []byte{0xff, 0xff, 0xff, 0xff, 0x4f}, expErr: true},
 		{bytes: []byte{0x80, 0x80, 0x80, 0x80, 0x70}, expErr: true},
+		{bytes: []byte{0x81, 0x80, 0x80, 0x80, 0x80, 0x00}, expErr: true},
+		{bytes: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, expErr: true},
 	} {
 		actual, num, err := DecodeInt32(bytes.NewReader(c.bytes))
 		if c.expErr {
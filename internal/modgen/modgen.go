@@ -226,7 +226,7 @@ func (g *generator) genMemorySection() {
 	}
 	min := g.nextRandom().Intn(4) // Min in reality is relatively small like 4.
 	max := g.nextRandom().Intn(int(wasm.MemoryLimitPages)-min) + min
-	g.m.MemorySection = &wasm.Memory{Min: uint32(min), Max: uint32(max), IsMaxEncoded: true}
+	g.m.MemorySection = []*wasm.Memory{{Min: uint32(min), Max: uint32(max), IsMaxEncoded: true}}
 }
 
 // genTableSection generates random globals.
@@ -415,14 +415,15 @@ func (g *generator) newCode() *wasm.Code {
 
 // genDataSection generates random data section if memory is declared and its min is not zero.
 func (g *generator) genDataSection() {
-	_, _, mem, _, err := g.m.AllDeclarations()
+	_, _, memories, _, err := g.m.AllDeclarations()
 	if err != nil {
 		panic("BUG:" + err.Error())
 	}
 
-	if mem == nil || mem.Min == 0 || g.numData == 0 {
+	if len(memories) == 0 || memories[0].Min == 0 || g.numData == 0 {
 		return
 	}
+	mem := memories[0]
 
 	min := int(mem.Min * wasm.MemoryPageSize)
 	for i := uint32(0); i < g.numData; i++ {
@@ -316,7 +316,7 @@ func TestGenerator_memorySection(t *testing.T) {
 	t.Run("without memory import", func(t *testing.T) {
 		g := newGenerator(100, []int{1, 100}, nil)
 		g.genMemorySection()
-		require.Equal(t, &wasm.Memory{Min: 1, Max: 101, IsMaxEncoded: true}, g.m.MemorySection)
+		require.Equal(t, []*wasm.Memory{{Min: 1, Max: 101, IsMaxEncoded: true}}, g.m.MemorySection)
 	})
 }
 
@@ -486,7 +486,7 @@ func TestGenerator_exportSection(t *testing.T) {
 			{Type: &wasm.GlobalType{}},
 		},
 		TableSection:  []*wasm.Table{{}},
-		MemorySection: &wasm.Memory{},
+		MemorySection: []*wasm.Memory{{}},
 	}
 
 	g := newGenerator(100, []int{
@@ -676,7 +676,7 @@ func TestGenerator_dataSection(t *testing.T) {
 	})
 	t.Run("min=0", func(t *testing.T) {
 		g := newGenerator(100, nil, nil)
-		g.m.MemorySection = &wasm.Memory{Min: 0}
+		g.m.MemorySection = []*wasm.Memory{{Min: 0}}
 		g.genDataSection()
 		require.Nil(t, g.m.DataSection)
 	})
@@ -725,7 +725,7 @@ func TestGenerator_dataSection(t *testing.T) {
 			tc := tc
 			t.Run(strconv.Itoa(i), func(t *testing.T) {
 				g := newGenerator(100, tc.ints, tc.bufs)
-				g.m.MemorySection = &wasm.Memory{Min: 1}
+				g.m.MemorySection = []*wasm.Memory{{Min: 1}}
 				g.numData = tc.numData
 				g.genDataSection()
 				actual := g.m.DataSection
@@ -27,6 +27,7 @@ import (
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	"github.com/tetratelabs/wazero/internal/wasmdebug"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
 )
 
 // testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
@@ -165,6 +166,20 @@ func RunTestModuleEngine_Call(t *testing.T, et EngineTester) {
 		_, err := me.Call(testCtx, module.CallCtx, fn, 1, 2)
 		require.EqualError(t, err, "expected 1 params, but passed 2")
 	})
+
+	t.Run("CallInto", func(t *testing.T) {
+		results := make([]uint64, 1)
+		for _, v := range []uint64{4, 5} { // reuses results across calls
+			err := me.CallInto(testCtx, module.CallCtx, fn, results, v)
+			require.NoError(t, err)
+			require.Equal(t, v, results[0])
+		}
+	})
+
+	t.Run("CallInto errs when not enough results", func(t *testing.T) {
+		err := me.CallInto(testCtx, module.CallCtx, fn, nil, 1)
+		require.EqualError(t, err, "expected 1 results, but passed 0")
+	})
 }
 
 func RunTestEngine_NewModuleEngine_InitTable(t *testing.T, et EngineTester) {
@@ -591,7 +606,7 @@ func RunTestModuleEngine_Memory(t *testing.T, et EngineTester) {
 	m := &wasm.Module{
 		TypeSection:     []*wasm.FunctionType{{Params: []api.ValueType{api.ValueTypeI32}, ParamNumInUint64: 1}, {}},
 		FunctionSection: []wasm.Index{0, 1},
-		MemorySection:   &wasm.Memory{Min: 1, Cap: 1, Max: 2},
+		MemorySection:   []*wasm.Memory{{Min: 1, Cap: 1, Max: 2}},
 		DataSection: []*wasm.DataSegment{
 			{
 				OffsetExpression: nil, // passive
@@ -622,7 +637,7 @@ func RunTestModuleEngine_Memory(t *testing.T, et EngineTester) {
 	// Assign memory to the module instance
 	module := &wasm.ModuleInstance{
 		Name:          t.Name(),
-		Memory:        wasm.NewMemoryInstance(m.MemorySection),
+		Memory:        wasm.NewMemoryInstance(m.MemorySection[0]),
 		DataInstances: []wasm.DataInstance{m.DataSection[0].Init},
 	}
 	var memory api.Memory = module.Memory
@@ -688,6 +703,78 @@ func RunTestModuleEngine_Memory(t *testing.T, et EngineTester) {
 	require.Equal(t, hostPhraseTruncated, string(buf2))
 }
 
+// RunTestModuleEngine_DataDrop ensures "data.drop" releases the segment's bytes, so that large passive segments can be
+// garbage collected, and that "memory.init" from a dropped segment traps unless its length is zero.
+func RunTestModuleEngine_DataDrop(t *testing.T, et EngineTester) {
+	e := et.NewEngine(wasm.Features20220419)
+
+	one := uint32(1)
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []api.ValueType{api.ValueTypeI32}, ParamNumInUint64: 1}, {}},
+		FunctionSection: []wasm.Index{0, 1},
+		MemorySection:   []*wasm.Memory{{Min: 1, Cap: 1, Max: 1}},
+		DataSection: []*wasm.DataSegment{
+			{
+				OffsetExpression: nil, // passive
+				Init:             make([]byte, 10*wasm.MemoryPageSize), // larger than the memory it initializes.
+			},
+		},
+		DataCountSection: &one,
+		CodeSection: []*wasm.Code{
+			{Body: []byte{ // "init"
+				wasm.OpcodeI32Const, 0, // target offset
+				wasm.OpcodeI32Const, 0, // source offset
+				wasm.OpcodeLocalGet, 0, // len (param)
+				wasm.OpcodeMiscPrefix, wasm.OpcodeMiscMemoryInit, 0, 0, // segment 0, memory 0
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // "drop"
+				wasm.OpcodeMiscPrefix, wasm.OpcodeMiscDataDrop, 0, // segment 0
+				wasm.OpcodeEnd,
+			}},
+		},
+	}
+	err := e.CompileModule(testCtx, m)
+	require.NoError(t, err)
+
+	module := &wasm.ModuleInstance{
+		Name:          t.Name(),
+		Memory:        wasm.NewMemoryInstance(m.MemorySection[0]),
+		DataInstances: []wasm.DataInstance{m.DataSection[0].Init},
+	}
+
+	init := getFunctionInstance(m, 0, module)
+	addFunction(module, "init", init)
+	drop := getFunctionInstance(m, 1, module)
+	addFunction(module, "drop", drop)
+
+	me, err := e.NewModuleEngine(module.Name, m, nil, module.Functions, nil, nil)
+	require.NoError(t, err)
+	linkModuleToEngine(module, me)
+
+	// Before the drop, the whole memory can be initialized from the segment.
+	_, err = me.Call(testCtx, module.CallCtx, init, uint64(wasm.MemoryPageSize))
+	require.NoError(t, err)
+
+	_, err = me.Call(testCtx, module.CallCtx, drop)
+	require.NoError(t, err)
+
+	// The instance no longer references the segment's bytes.
+	require.Nil(t, module.DataInstances[0])
+
+	// Dropping again is allowed.
+	_, err = me.Call(testCtx, module.CallCtx, drop)
+	require.NoError(t, err)
+
+	// A dropped segment behaves as if it were empty, so initializing even one byte is out of bounds.
+	_, err = me.Call(testCtx, module.CallCtx, init, 1)
+	require.ErrorIs(t, err, wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+
+	// However, a zero length initialization is still valid.
+	_, err = me.Call(testCtx, module.CallCtx, init, 0)
+	require.NoError(t, err)
+}
+
 const (
 	wasmFnName               = "wasm_div_by"
 	hostFnName               = "host_div_by"
@@ -0,0 +1,41 @@
+// Package version reports the version of wazero linked into the current binary.
+package version
+
+import "runtime/debug"
+
+// modulePath is the Go module path of wazero.
+const modulePath = "github.com/tetratelabs/wazero"
+
+// devVersion is returned when the version cannot be determined, such as in wazero's own tests.
+const devVersion = "dev"
+
+// GetWazeroVersion returns the version of wazero in the build info of the current binary, or "dev" if unknown.
+func GetWazeroVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return devVersion
+	}
+	return wazeroVersion(info)
+}
+
+func wazeroVersion(info *debug.BuildInfo) string {
+	if info.Main.Path == modulePath {
+		// When wazero is the main module, the version is "(devel)" unless it was installed with a version.
+		if v := info.Main.Version; v != "" && v != "(devel)" {
+			return v
+		}
+		return devVersion
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			if dep.Version != "" {
+				return dep.Version
+			}
+			return devVersion
+		}
+	}
+	return devVersion
+}
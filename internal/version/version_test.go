@@ -0,0 +1,61 @@
+package version
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestGetWazeroVersion(t *testing.T) {
+	// wazero's own tests aren't built with a version.
+	require.Equal(t, devVersion, GetWazeroVersion())
+}
+
+func TestWazeroVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     *debug.BuildInfo
+		expected string
+	}{
+		{
+			name:     "main module",
+			info:     &debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "(devel)"}},
+			expected: devVersion,
+		},
+		{
+			name:     "main module with version",
+			info:     &debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "v1.0.0"}},
+			expected: "v1.0.0",
+		},
+		{
+			name: "dependency",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/app"},
+				Deps: []*debug.Module{{Path: "example.com/other", Version: "v2.0.0"}, {Path: modulePath, Version: "v0.0.1"}},
+			},
+			expected: "v0.0.1",
+		},
+		{
+			name: "replaced dependency",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/app"},
+				Deps: []*debug.Module{{Path: modulePath, Version: "v0.0.1", Replace: &debug.Module{Path: "../wazero"}}},
+			},
+			expected: devVersion,
+		},
+		{
+			name:     "not a dependency",
+			info:     &debug.BuildInfo{Main: debug.Module{Path: "example.com/app"}},
+			expected: devVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, wazeroVersion(tc.info))
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 )
 
 func decodeConstantExpression(r *bytes.Reader, enabledFeatures wasm.Features) (*wasm.ConstantExpression, error) {
+	offsetAtOpcode := r.Size() - int64(r.Len())
 	b, err := r.ReadByte()
 	if err != nil {
 		return nil, fmt.Errorf("read opcode: %v", err)
@@ -83,6 +84,9 @@ func decodeConstantExpression(r *bytes.Reader, enabledFeatures wasm.Features) (*
 	}
 
 	if b != wasm.OpcodeEnd {
+		if enabledFeatures.Get(wasm.FeatureExtendedConst) {
+			return decodeExtendedConstantExpression(r, offsetAtOpcode, b)
+		}
 		return nil, fmt.Errorf("constant expression has been not terminated")
 	}
 
@@ -94,9 +98,73 @@ func decodeConstantExpression(r *bytes.Reader, enabledFeatures wasm.Features) (*
 	return &wasm.ConstantExpression{Opcode: opcode, Data: data}, nil
 }
 
+// decodeExtendedConstantExpression continues decoding a constant expression of more than one instruction, allowed by
+// wasm.FeatureExtendedConst. b is the instruction after the first, which begins at offsetAtOpcode.
+//
+// The last instruction must be arithmetic, so it is the wasm.ConstantExpression Opcode, and those before it the Data.
+func decodeExtendedConstantExpression(r *bytes.Reader, offsetAtOpcode int64, b byte) (*wasm.ConstantExpression, error) {
+	var opcode wasm.Opcode
+	var offsetAtLastOpcode int64
+	for b != wasm.OpcodeEnd {
+		opcode, offsetAtLastOpcode = b, r.Size()-int64(r.Len())-1
+
+		var err error
+		switch opcode {
+		case wasm.OpcodeI32Const:
+			_, _, err = leb128.DecodeInt32(r)
+		case wasm.OpcodeI64Const:
+			_, _, err = leb128.DecodeInt64(r)
+		case wasm.OpcodeGlobalGet:
+			_, _, err = leb128.DecodeUint32(r)
+		default:
+			if !isExtendedConstOpcode(opcode) {
+				return nil, fmt.Errorf("%v for const expression opt code: %#x", ErrInvalidByte, opcode)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read value: %v", err)
+		}
+
+		if b, err = r.ReadByte(); err != nil {
+			return nil, fmt.Errorf("look for end opcode: %v", err)
+		}
+	}
+
+	if !isExtendedConstOpcode(opcode) {
+		return nil, fmt.Errorf("constant expression must end with an arithmetic instruction, but was %s",
+			wasm.InstructionName(opcode))
+	}
+
+	data := make([]byte, offsetAtLastOpcode-offsetAtOpcode)
+	if _, err := r.ReadAt(data, offsetAtOpcode); err != nil {
+		return nil, fmt.Errorf("error re-buffering ConstantExpression.Data")
+	}
+
+	return &wasm.ConstantExpression{Opcode: opcode, Data: data}, nil
+}
+
+// isExtendedConstOpcode returns true if the opcode can only end a constant expression with
+// wasm.FeatureExtendedConst.
+func isExtendedConstOpcode(opcode wasm.Opcode) bool {
+	switch opcode {
+	case wasm.OpcodeI32Add, wasm.OpcodeI32Sub, wasm.OpcodeI32Mul,
+		wasm.OpcodeI64Add, wasm.OpcodeI64Sub, wasm.OpcodeI64Mul:
+		return true
+	}
+	return false
+}
+
 func encodeConstantExpression(expr *wasm.ConstantExpression) (ret []byte) {
-	ret = append(ret, expr.Opcode)
-	ret = append(ret, expr.Data...)
+	if isExtendedConstOpcode(expr.Opcode) { // the instructions before the last one are in Data.
+		ret = append(ret, expr.Data...)
+		ret = append(ret, expr.Opcode)
+	} else if expr.Opcode == wasm.OpcodeVecV128Const { // the only vector instruction allowed in a constant expression.
+		ret = append(ret, wasm.OpcodeVecPrefix, expr.Opcode)
+		ret = append(ret, expr.Data...)
+	} else {
+		ret = append(ret, expr.Opcode)
+		ret = append(ret, expr.Data...)
+	}
 	ret = append(ret, wasm.OpcodeEnd)
 	return
 }
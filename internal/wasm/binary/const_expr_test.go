@@ -89,6 +89,73 @@ func TestDecodeConstantExpression(t *testing.T) {
 	}
 }
 
+func TestDecodeConstantExpression_ExtendedConst(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   []byte
+		exp  *wasm.ConstantExpression
+	}{
+		{
+			name: "i32.add",
+			in: []byte{
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Const, 2,
+				wasm.OpcodeI32Add,
+				wasm.OpcodeEnd,
+			},
+			exp: &wasm.ConstantExpression{
+				Opcode: wasm.OpcodeI32Add,
+				Data:   []byte{wasm.OpcodeI32Const, 1, wasm.OpcodeI32Const, 2},
+			},
+		},
+		{
+			name: "i64.mul of i64.sub",
+			in: []byte{
+				wasm.OpcodeGlobalGet, 0,
+				wasm.OpcodeI64Const, 0x80, 0x01, // 128 in varint encoding.
+				wasm.OpcodeI64Const, 2,
+				wasm.OpcodeI64Sub,
+				wasm.OpcodeI64Mul,
+				wasm.OpcodeEnd,
+			},
+			exp: &wasm.ConstantExpression{
+				Opcode: wasm.OpcodeI64Mul,
+				Data: []byte{
+					wasm.OpcodeGlobalGet, 0,
+					wasm.OpcodeI64Const, 0x80, 0x01,
+					wasm.OpcodeI64Const, 2,
+					wasm.OpcodeI64Sub,
+				},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := decodeConstantExpression(bytes.NewReader(tc.in), wasm.FeatureExtendedConst)
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, actual)
+
+			// The encoding must round-trip, even though the opcode is the last instruction.
+			require.Equal(t, tc.in, encodeConstantExpression(actual))
+		})
+	}
+}
+
+func TestEncodeConstantExpression(t *testing.T) {
+	t.Run("v128.const", func(t *testing.T) {
+		data := []byte{1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0}
+		expected := append(append([]byte{wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Const}, data...), wasm.OpcodeEnd)
+
+		actual := encodeConstantExpression(&wasm.ConstantExpression{Opcode: wasm.OpcodeVecV128Const, Data: data})
+		require.Equal(t, expected, actual)
+
+		// The encoding must round-trip.
+		decoded, err := decodeConstantExpression(bytes.NewReader(actual), wasm.FeatureSIMD)
+		require.NoError(t, err)
+		require.Equal(t, &wasm.ConstantExpression{Opcode: wasm.OpcodeVecV128Const, Data: data}, decoded)
+	})
+}
+
 func TestDecodeConstantExpression_errors(t *testing.T) {
 	for _, tc := range []struct {
 		in          []byte
@@ -174,6 +241,44 @@ func TestDecodeConstantExpression_errors(t *testing.T) {
 			expectedErr: "read vector const instruction immediates: needs 16 bytes but was 8 bytes",
 			features:    wasm.FeatureSIMD,
 		},
+		{
+			in: []byte{
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Const, 2,
+				wasm.OpcodeI32Add,
+				wasm.OpcodeEnd,
+			},
+			expectedErr: "constant expression has been not terminated",
+			features:    wasm.Features20220419,
+		},
+		{
+			in: []byte{
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Const, 2,
+				wasm.OpcodeI32DivS,
+				wasm.OpcodeEnd,
+			},
+			expectedErr: "invalid byte for const expression opt code: 0x6d",
+			features:    wasm.FeatureExtendedConst,
+		},
+		{
+			in: []byte{
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Const, 2,
+				wasm.OpcodeEnd,
+			},
+			expectedErr: "constant expression must end with an arithmetic instruction, but was i32.const",
+			features:    wasm.FeatureExtendedConst,
+		},
+		{
+			in: []byte{
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Const, 2,
+				wasm.OpcodeI32Add,
+			},
+			expectedErr: "look for end opcode: EOF",
+			features:    wasm.FeatureExtendedConst,
+		},
 	} {
 		t.Run(tc.expectedErr, func(t *testing.T) {
 			_, err := decodeConstantExpression(bytes.NewReader(tc.in), tc.features)
@@ -0,0 +1,30 @@
+package binary
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// decodeCustomSection retains the raw data of a wasm.SectionIDCustom that isn't the "name" section.
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#custom-section%E2%91%A0
+func decodeCustomSection(r *bytes.Reader, name string, limit uint64) (*wasm.CustomSection, error) {
+	// Note: This copies as opposed to slicing the source, so that later changes to the source aren't visible.
+	data := make([]byte, limit)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("failed to read custom section[%s]: %w", name, err)
+	}
+	return &wasm.CustomSection{Name: name, Data: data}, nil
+}
+
+// encodeCustomSection encodes the name and data of a wasm.SectionIDCustom in WebAssembly 1.0 (20191205) Binary
+// Format.
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#custom-section%E2%91%A0
+func encodeCustomSection(c *wasm.CustomSection) []byte {
+	contents := append(encodeSizePrefixed([]byte(c.Name)), c.Data...)
+	return encodeSection(wasm.SectionIDCustom, contents)
+}
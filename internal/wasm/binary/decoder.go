@@ -8,6 +8,7 @@ import (
 
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasmdebug"
 )
 
 // DecodeModule implements wasm.DecodeModule for the WebAssembly 1.0 (20191205) Binary Format
@@ -16,6 +17,25 @@ func DecodeModule(
 	binary []byte,
 	enabledFeatures wasm.Features,
 	memorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32),
+) (*wasm.Module, error) {
+	return decodeModule(binary, enabledFeatures, memorySizer, false)
+}
+
+// DecodeModuleWithDWARF implements wasm.DecodeModule similar to DecodeModule, except it also populates wasm.Module
+// DWARFLines from any DWARF custom sections, and records the offset of each wasm.Code needed to look them up.
+func DecodeModuleWithDWARF(
+	binary []byte,
+	enabledFeatures wasm.Features,
+	memorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32),
+) (*wasm.Module, error) {
+	return decodeModule(binary, enabledFeatures, memorySizer, true)
+}
+
+func decodeModule(
+	binary []byte,
+	enabledFeatures wasm.Features,
+	memorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32),
+	dwarf bool,
 ) (*wasm.Module, error) {
 	r := bytes.NewReader(binary)
 
@@ -62,15 +82,16 @@ func DecodeModule(
 				break
 			}
 
-			// Now, either decode the NameSection or skip an unsupported one
+			// Now, either decode the NameSection or retain any other as raw data.
 			limit := sectionSize - nameSize
 			if name == "name" {
 				m.NameSection, err = decodeNameSection(r, uint64(limit))
 			} else {
-				// Note: Not Seek because it doesn't err when given an offset past EOF. Rather, it leads to undefined state.
-				if _, err = io.CopyN(io.Discard, r, int64(limit)); err != nil {
-					return nil, fmt.Errorf("failed to skip name[%s]: %w", name, err)
+				var c *wasm.CustomSection
+				if c, err = decodeCustomSection(r, name, uint64(limit)); err != nil {
+					return nil, err
 				}
+				m.CustomSections = append(m.CustomSections, c)
 			}
 
 		case wasm.SectionIDType:
@@ -84,7 +105,7 @@ func DecodeModule(
 		case wasm.SectionIDTable:
 			m.TableSection, err = decodeTableSection(r, enabledFeatures)
 		case wasm.SectionIDMemory:
-			m.MemorySection, err = decodeMemorySection(r, memorySizer)
+			m.MemorySection, err = decodeMemorySection(r, memorySizer, enabledFeatures)
 		case wasm.SectionIDGlobal:
 			if m.GlobalSection, err = decodeGlobalSection(r, enabledFeatures); err != nil {
 				return nil, err // avoid re-wrapping the error.
@@ -99,7 +120,7 @@ func DecodeModule(
 		case wasm.SectionIDElement:
 			m.ElementSection, err = decodeElementSection(r, enabledFeatures)
 		case wasm.SectionIDCode:
-			m.CodeSection, err = decodeCodeSection(r)
+			m.CodeSection, err = decodeCodeSection(r, dwarf)
 		case wasm.SectionIDData:
 			m.DataSection, err = decodeDataSection(r, enabledFeatures)
 		case wasm.SectionIDDataCount:
@@ -125,5 +146,12 @@ func DecodeModule(
 	if functionCount != codeCount {
 		return nil, fmt.Errorf("function and code section have inconsistent lengths: %d != %d", functionCount, codeCount)
 	}
+
+	if dwarf {
+		var err error
+		if m.DWARFLines, err = wasmdebug.NewDWARFLines(m.CustomSection); err != nil {
+			return nil, fmt.Errorf("invalid DWARF: %w", err)
+		}
+	}
 	return m, nil
 }
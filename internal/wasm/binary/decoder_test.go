@@ -25,6 +25,16 @@ func TestDecodeModule(t *testing.T) {
 			name:  "only name section",
 			input: &wasm.Module{NameSection: &wasm.NameSection{ModuleName: "simple"}},
 		},
+		{
+			name: "custom sections",
+			input: &wasm.Module{
+				NameSection: &wasm.NameSection{ModuleName: "simple"},
+				CustomSections: []*wasm.CustomSection{
+					{Name: "producers", Data: []byte{1, 2, 3}},
+					{Name: ".debug_info", Data: []byte{}},
+				},
+			},
+		},
 		{
 			name: "type section",
 			input: &wasm.Module{
@@ -71,7 +81,7 @@ func TestDecodeModule(t *testing.T) {
 			name: "table and memory section",
 			input: &wasm.Module{
 				TableSection:  []*wasm.Table{{Min: 3, Type: wasm.RefTypeFuncref}},
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: 1, IsMaxEncoded: true},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: 1, IsMaxEncoded: true}},
 			},
 		},
 		{
@@ -98,17 +108,19 @@ func TestDecodeModule(t *testing.T) {
 		})
 	}
 
-	t.Run("skips custom section", func(t *testing.T) {
+	t.Run("retains custom section", func(t *testing.T) {
 		input := append(append(Magic, version...),
 			wasm.SectionIDCustom, 0xf, // 15 bytes in this section
 			0x04, 'm', 'e', 'm', 'e',
 			1, 2, 3, 4, 5, 6, 7, 8, 9, 0)
 		m, e := DecodeModule(input, wasm.Features20191205, wasm.MemorySizer)
 		require.NoError(t, e)
-		require.Equal(t, &wasm.Module{}, m)
+		require.Equal(t, &wasm.Module{CustomSections: []*wasm.CustomSection{
+			{Name: "meme", Data: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 0}},
+		}}, m)
 	})
 
-	t.Run("skips custom section, but not name", func(t *testing.T) {
+	t.Run("retains custom section and name", func(t *testing.T) {
 		input := append(append(Magic, version...),
 			wasm.SectionIDCustom, 0xf, // 15 bytes in this section
 			0x04, 'm', 'e', 'm', 'e',
@@ -120,7 +132,10 @@ func TestDecodeModule(t *testing.T) {
 			's', 'i', 'm', 'p', 'l', 'e')
 		m, e := DecodeModule(input, wasm.Features20191205, wasm.MemorySizer)
 		require.NoError(t, e)
-		require.Equal(t, &wasm.Module{NameSection: &wasm.NameSection{ModuleName: "simple"}}, m)
+		require.Equal(t, &wasm.Module{
+			NameSection:    &wasm.NameSection{ModuleName: "simple"},
+			CustomSections: []*wasm.CustomSection{{Name: "meme", Data: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 0}}},
+		}, m)
 	})
 	t.Run("data count section disabled", func(t *testing.T) {
 		input := append(append(Magic, version...),
@@ -130,6 +145,36 @@ func TestDecodeModule(t *testing.T) {
 	})
 }
 
+func TestDecodeModuleWithDWARF(t *testing.T) {
+	input := append(append(Magic, version...),
+		wasm.SectionIDType, 0x04, // 4 bytes in this section
+		0x01,           // 1 type
+		0x60, 0x0, 0x0, // func=0x60 0 params and 0 result
+		wasm.SectionIDFunction, 0x03, // 3 bytes in this section
+		0x02,       // 2 functions
+		0x00, 0x00, // func[0] and func[1] type index 0
+		wasm.SectionIDCode, 0x0a, // 10 bytes in this section
+		0x02,                       // 2 codes
+		0x02, 0x00, wasm.OpcodeEnd, // func[0]: size 2, no locals
+		0x05, 0x01, 0x01, wasm.ValueTypeI32, wasm.OpcodeNop, wasm.OpcodeEnd, // func[1]: size 5, 1 i32 local
+	)
+
+	m, err := DecodeModuleWithDWARF(input, wasm.Features20191205, wasm.MemorySizer)
+	require.NoError(t, err)
+	require.Nil(t, m.DWARFLines) // as there are no DWARF custom sections
+
+	// Offsets are relative to the section contents, which begin with the count of codes.
+	require.Equal(t, []*wasm.Code{
+		{Body: []byte{wasm.OpcodeEnd}, BodyOffsetInCodeSection: 3},
+		{Body: []byte{wasm.OpcodeNop, wasm.OpcodeEnd}, LocalTypes: []wasm.ValueType{wasm.ValueTypeI32}, BodyOffsetInCodeSection: 8},
+	}, m.CodeSection)
+
+	// Offsets are only recorded when DWARF is enabled.
+	m, err = DecodeModule(input, wasm.Features20191205, wasm.MemorySizer)
+	require.NoError(t, err)
+	require.Zero(t, m.CodeSection[1].BodyOffsetInCodeSection)
+}
+
 func TestDecodeModule_Errors(t *testing.T) {
 	tests := []struct {
 		name        string
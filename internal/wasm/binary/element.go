@@ -2,7 +2,6 @@ package binary
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 
 	"github.com/tetratelabs/wazero/internal/leb128"
@@ -37,7 +36,9 @@ func decodeElementInitValueVector(r *bytes.Reader) ([]*wasm.Index, error) {
 	return vec, nil
 }
 
-func decodeElementConstExprVector(r *bytes.Reader, enabledFeatures wasm.Features) ([]*wasm.Index, error) {
+// decodeElementConstExprVector decodes a vector of "ref.func" or "ref.null" constant expressions, where "ref.null" must
+// be of the element type elemType. The result is nil at the position of each "ref.null".
+func decodeElementConstExprVector(r *bytes.Reader, elemType wasm.RefType, enabledFeatures wasm.Features) ([]*wasm.Index, error) {
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get size of vector: %w", err)
@@ -50,11 +51,16 @@ func decodeElementConstExprVector(r *bytes.Reader, enabledFeatures wasm.Features
 		}
 		switch expr.Opcode {
 		case wasm.OpcodeRefFunc:
+			if elemType != wasm.RefTypeFuncref {
+				return nil, fmt.Errorf("element type mismatch: want %s, but constant expression is ref.func",
+					wasm.RefTypeName(elemType))
+			}
 			v, _, _ := leb128.DecodeUint32(bytes.NewReader(expr.Data))
 			vec[i] = &v
 		case wasm.OpcodeRefNull:
-			if expr.Data[0] != wasm.RefTypeFuncref {
-				return nil, errors.New("ref type must be funcref for ref.null const expression as of WebAssembly 2.0")
+			if expr.Data[0] != elemType {
+				return nil, fmt.Errorf("element type mismatch: want %s, but constant expression is ref.null %s",
+					wasm.RefTypeName(elemType), wasm.RefTypeName(expr.Data[0]))
 			}
 			// vec[i] is already nil, so nothing to do.
 		default:
@@ -70,8 +76,14 @@ func decodeElementRefType(r *bytes.Reader, enabledFeatures wasm.Features) (ret w
 		err = fmt.Errorf("read element ref type: %w", err)
 		return
 	}
-	if ret != wasm.RefTypeFuncref {
-		return 0, errors.New("ref type must be funcref for element as of WebAssembly 2.0")
+	switch ret {
+	case wasm.RefTypeFuncref:
+	case wasm.RefTypeExternref:
+		if err = enabledFeatures.Require(wasm.FeatureReferenceTypes); err != nil {
+			return 0, fmt.Errorf("ref type must be funcref for element but was externref: %w", err)
+		}
+	default:
+		return 0, fmt.Errorf("ref type must be either funcref or externref for element but was %s", wasm.RefTypeName(ret))
 	}
 	return
 }
@@ -199,7 +211,7 @@ func decodeElementSegment(r *bytes.Reader, enabledFeatures wasm.Features) (*wasm
 			return nil, fmt.Errorf("read expr for offset: %w", err)
 		}
 
-		init, err := decodeElementConstExprVector(r, enabledFeatures)
+		init, err := decodeElementConstExprVector(r, wasm.RefTypeFuncref, enabledFeatures)
 		if err != nil {
 			return nil, err
 		}
@@ -216,7 +228,7 @@ func decodeElementSegment(r *bytes.Reader, enabledFeatures wasm.Features) (*wasm
 		if err != nil {
 			return nil, err
 		}
-		init, err := decodeElementConstExprVector(r, enabledFeatures)
+		init, err := decodeElementConstExprVector(r, refType, enabledFeatures)
 		if err != nil {
 			return nil, err
 		}
@@ -246,7 +258,7 @@ func decodeElementSegment(r *bytes.Reader, enabledFeatures wasm.Features) (*wasm
 			return nil, err
 		}
 
-		init, err := decodeElementConstExprVector(r, enabledFeatures)
+		init, err := decodeElementConstExprVector(r, refType, enabledFeatures)
 		if err != nil {
 			return nil, err
 		}
@@ -263,7 +275,7 @@ func decodeElementSegment(r *bytes.Reader, enabledFeatures wasm.Features) (*wasm
 		if err != nil {
 			return nil, err
 		}
-		init, err := decodeElementConstExprVector(r, enabledFeatures)
+		init, err := decodeElementConstExprVector(r, refType, enabledFeatures)
 		if err != nil {
 			return nil, err
 		}
@@ -74,7 +74,7 @@ func Test_decodeElementConstExprVector(t *testing.T) {
 		},
 	} {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
-			actual, err := decodeElementConstExprVector(bytes.NewReader(tc.in), tc.features)
+			actual, err := decodeElementConstExprVector(bytes.NewReader(tc.in), wasm.RefTypeFuncref, tc.features)
 			require.NoError(t, err)
 			require.Equal(t, tc.exp, actual)
 		})
@@ -257,8 +257,49 @@ func TestDecodeElementSegment(t *testing.T) {
 			in: []byte{
 				5, // Prefix.
 				wasm.RefTypeExternref,
+				// Init const expr vector.
+				2, // number of const expr.
+				wasm.OpcodeRefNull, wasm.RefTypeExternref, wasm.OpcodeEnd,
+				wasm.OpcodeRefNull, wasm.RefTypeExternref, wasm.OpcodeEnd,
+			},
+			exp: &wasm.ElementSegment{
+				Init: []*wasm.Index{nil, nil},
+				Mode: wasm.ElementModePassive,
+				Type: wasm.RefTypeExternref,
+			},
+			features: wasm.FeatureBulkMemoryOperations | wasm.FeatureReferenceTypes,
+		},
+		{
+			name: "passive const expr vector - extern ref type but feature disabled",
+			in: []byte{
+				5, // Prefix.
+				wasm.RefTypeExternref,
+			},
+			expErr:   `ref type must be funcref for element but was externref: feature "reference-types" is disabled`,
+			features: wasm.FeatureBulkMemoryOperations,
+		},
+		{
+			name: "passive const expr vector - extern ref type with ref.func",
+			in: []byte{
+				5, // Prefix.
+				wasm.RefTypeExternref,
+				// Init const expr vector.
+				1, // number of const expr.
+				wasm.OpcodeRefFunc, 0, wasm.OpcodeEnd,
+			},
+			expErr:   `element type mismatch: want externref, but constant expression is ref.func`,
+			features: wasm.FeatureBulkMemoryOperations | wasm.FeatureReferenceTypes,
+		},
+		{
+			name: "passive const expr vector - funcref with ref.null extern",
+			in: []byte{
+				5, // Prefix.
+				wasm.RefTypeFuncref,
+				// Init const expr vector.
+				1, // number of const expr.
+				wasm.OpcodeRefNull, wasm.RefTypeExternref, wasm.OpcodeEnd,
 			},
-			expErr:   `ref type must be funcref for element as of WebAssembly 2.0`,
+			expErr:   `element type mismatch: want funcref, but constant expression is ref.null externref`,
 			features: wasm.FeatureBulkMemoryOperations | wasm.FeatureReferenceTypes,
 		},
 		{
@@ -267,7 +308,7 @@ func TestDecodeElementSegment(t *testing.T) {
 				5, // Prefix.
 				0xff,
 			},
-			expErr:   `ref type must be funcref for element as of WebAssembly 2.0`,
+			expErr:   `ref type must be either funcref or externref for element but was unknown(0xff)`,
 			features: wasm.FeatureBulkMemoryOperations | wasm.FeatureReferenceTypes,
 		},
 		{
@@ -49,6 +49,9 @@ func EncodeModule(m *wasm.Module) (bytes []byte) {
 		bytes = append(bytes, encodeDataSection(m.DataSection)...)
 	}
 	if m.SectionElementCount(wasm.SectionIDCustom) > 0 {
+		for _, c := range m.CustomSections {
+			bytes = append(bytes, encodeCustomSection(c)...)
+		}
 		// >> The name section should appear only once in a module, and only after the data section.
 		// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-namesec
 		if m.NameSection != nil {
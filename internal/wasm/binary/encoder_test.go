@@ -33,6 +33,22 @@ func TestModule_Encode(t *testing.T) {
 				0x06, // the Module name simple is 6 bytes long
 				's', 'i', 'm', 'p', 'l', 'e'),
 		},
+		{
+			name: "custom sections before name section",
+			input: &wasm.Module{
+				NameSection:    &wasm.NameSection{ModuleName: "simple"},
+				CustomSections: []*wasm.CustomSection{{Name: "meme", Data: []byte{1, 2, 3}}},
+			},
+			expected: append(append(Magic, version...),
+				wasm.SectionIDCustom, 0x08, // 8 bytes in this section
+				0x04, 'm', 'e', 'm', 'e',
+				1, 2, 3,
+				wasm.SectionIDCustom, 0x0e, // 14 bytes in this section
+				0x04, 'n', 'a', 'm', 'e',
+				subsectionIDModuleName, 0x07, // 7 bytes in this subsection
+				0x06, // the Module name simple is 6 bytes long
+				's', 'i', 'm', 'p', 'l', 'e'),
+		},
 		{
 			name: "type section",
 			input: &wasm.Module{
@@ -109,7 +125,7 @@ func TestModule_Encode(t *testing.T) {
 			name: "table and memory section",
 			input: &wasm.Module{
 				TableSection:  []*wasm.Table{{Min: 3, Type: wasm.RefTypeFuncref}},
-				MemorySection: &wasm.Memory{Min: 1, Max: 1, IsMaxEncoded: true},
+				MemorySection: []*wasm.Memory{{Min: 1, Max: 1, IsMaxEncoded: true}},
 			},
 			expected: append(append(Magic, version...),
 				wasm.SectionIDTable, 0x04, // 4 bytes in this section
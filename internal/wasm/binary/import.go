@@ -60,11 +60,7 @@ func encodeImport(i *wasm.Import) []byte {
 		data = append(data, wasm.RefTypeFuncref)
 		data = append(data, encodeLimitsType(i.DescTable.Min, i.DescTable.Max)...)
 	case wasm.ExternTypeMemory:
-		maxPtr := &i.DescMem.Max
-		if !i.DescMem.IsMaxEncoded {
-			maxPtr = nil
-		}
-		data = append(data, encodeLimitsType(i.DescMem.Min, maxPtr)...)
+		data = append(data, encodeMemory(i.DescMem)...)
 	case wasm.ExternTypeGlobal:
 		g := i.DescGlobal
 		var mutable byte
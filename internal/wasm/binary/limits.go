@@ -7,16 +7,25 @@ import (
 	"github.com/tetratelabs/wazero/internal/leb128"
 )
 
+// limitsFlagShared is set in the leading byte of the limits of a shared memory. See wasm.Memory Shared.
+const limitsFlagShared = 0x02
+
 // decodeLimitsType returns the `limitsType` (min, max) decoded with the WebAssembly 1.0 (20191205) Binary Format.
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#limits%E2%91%A6
-func decodeLimitsType(r *bytes.Reader) (min uint32, max *uint32, err error) {
+func decodeLimitsType(r *bytes.Reader) (min uint32, max *uint32, shared bool, err error) {
 	var flag byte
 	if flag, err = r.ReadByte(); err != nil {
 		err = fmt.Errorf("read leading byte: %v", err)
 		return
 	}
 
+	// The threads proposal adds the shared flag, which is only valid for memories.
+	if flag == limitsFlagShared || flag == limitsFlagShared|0x01 {
+		shared = true
+		flag &^= limitsFlagShared
+	}
+
 	switch flag {
 	case 0x00:
 		min, _, err = leb128.DecodeUint32(r)
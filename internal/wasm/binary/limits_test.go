@@ -55,10 +55,32 @@ func TestLimitsType(t *testing.T) {
 		})
 
 		t.Run(fmt.Sprintf("decode - %s", tc.name), func(t *testing.T) {
-			min, max, err := decodeLimitsType(bytes.NewReader(b))
+			min, max, shared, err := decodeLimitsType(bytes.NewReader(b))
 			require.NoError(t, err)
+			require.False(t, shared)
 			require.Equal(t, min, tc.min)
 			require.Equal(t, max, tc.max)
 		})
 	}
 }
+
+func TestLimitsType_Shared(t *testing.T) {
+	one := uint32(1)
+	for _, tc := range []struct {
+		name  string
+		input []byte
+		max   *uint32
+	}{
+		{name: "min", input: []byte{0x2, 0}},
+		{name: "min max", input: []byte{0x3, 0, 1}, max: &one},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			min, max, shared, err := decodeLimitsType(bytes.NewReader(tc.input))
+			require.NoError(t, err)
+			require.Zero(t, min)
+			require.Equal(t, tc.max, max)
+			require.True(t, shared)
+		})
+	}
+}
@@ -13,13 +13,13 @@ func decodeMemory(
 	r *bytes.Reader,
 	memorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32),
 ) (*wasm.Memory, error) {
-	min, maxP, err := decodeLimitsType(r)
+	min, maxP, shared, err := decodeLimitsType(r)
 	if err != nil {
 		return nil, err
 	}
 
 	min, capacity, max := memorySizer(min, maxP)
-	mem := &wasm.Memory{Min: min, Cap: capacity, Max: max, IsMaxEncoded: maxP != nil}
+	mem := &wasm.Memory{Min: min, Cap: capacity, Max: max, IsMaxEncoded: maxP != nil, Shared: shared}
 
 	return mem, mem.Validate()
 }
@@ -32,5 +32,9 @@ func encodeMemory(i *wasm.Memory) []byte {
 	if !i.IsMaxEncoded {
 		maxPtr = nil
 	}
-	return encodeLimitsType(i.Min, maxPtr)
+	data := encodeLimitsType(i.Min, maxPtr)
+	if i.Shared {
+		data[0] |= limitsFlagShared
+	}
+	return data
 }
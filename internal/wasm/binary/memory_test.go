@@ -48,6 +48,16 @@ func TestMemoryType(t *testing.T) {
 			input:    &wasm.Memory{Min: max, Cap: max, Max: max, IsMaxEncoded: true},
 			expected: []byte{0x1, 0x80, 0x80, 0x4, 0x80, 0x80, 0x4},
 		},
+		{
+			name:     "shared",
+			input:    &wasm.Memory{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true, Shared: true},
+			expected: []byte{0x3, 1, 2},
+		},
+		{
+			name:     "shared default max", // invalid, but decodes as the module validates the max.
+			input:    &wasm.Memory{Min: 1, Cap: 1, Max: max, Shared: true},
+			expected: []byte{0x2, 1},
+		},
 	}
 
 	for _, tt := range tests {
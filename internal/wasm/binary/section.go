@@ -83,16 +83,23 @@ func decodeTableSection(r *bytes.Reader, enabledFeatures wasm.Features) ([]*wasm
 func decodeMemorySection(
 	r *bytes.Reader,
 	memorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32),
-) (*wasm.Memory, error) {
+	enabledFeatures wasm.Features,
+) ([]*wasm.Memory, error) {
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("error reading size")
 	}
-	if vs > 1 {
+	if vs > 1 && !enabledFeatures.Get(wasm.FeatureMultiMemory) {
 		return nil, fmt.Errorf("at most one memory allowed in module, but read %d", vs)
 	}
 
-	return decodeMemory(r, memorySizer)
+	result := make([]*wasm.Memory, vs)
+	for i := uint32(0); i < vs; i++ {
+		if result[i], err = decodeMemory(r, memorySizer); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
 }
 
 func decodeGlobalSection(r *bytes.Reader, enabledFeatures wasm.Features) ([]*wasm.Global, error) {
@@ -156,7 +163,9 @@ func decodeElementSection(r *bytes.Reader, enabledFeatures wasm.Features) ([]*wa
 	return result, nil
 }
 
-func decodeCodeSection(r *bytes.Reader) ([]*wasm.Code, error) {
+// decodeCodeSection decodes the code section, setting wasm.Code BodyOffsetInCodeSection when recordOffsets is true.
+func decodeCodeSection(r *bytes.Reader, recordOffsets bool) ([]*wasm.Code, error) {
+	codeSectionStart := uint64(r.Size() - int64(r.Len()))
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get size of vector: %w", err)
@@ -166,6 +175,9 @@ func decodeCodeSection(r *bytes.Reader) ([]*wasm.Code, error) {
 	for i := uint32(0); i < vs; i++ {
 		if result[i], err = decodeCode(r); err != nil {
 			return nil, fmt.Errorf("read %d-th code segment: %v", i, err)
+		} else if recordOffsets { // the body is the last part of the code, so it begins its length before the reader.
+			bodyEnd := uint64(r.Size() - int64(r.Len()))
+			result[i].BodyOffsetInCodeSection = bodyEnd - uint64(len(result[i].Body)) - codeSectionStart
 		}
 	}
 	return result, nil
@@ -270,8 +282,11 @@ func encodeTableSection(tables []*wasm.Table) []byte {
 //
 // See encodeMemory
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#memory-section%E2%91%A0
-func encodeMemorySection(memory *wasm.Memory) []byte {
-	contents := append([]byte{1}, encodeMemory(memory)...)
+func encodeMemorySection(memories []*wasm.Memory) []byte {
+	contents := leb128.EncodeUint32(uint32(len(memories)))
+	for _, m := range memories {
+		contents = append(contents, encodeMemory(m)...)
+	}
 	return encodeSection(wasm.SectionIDMemory, contents)
 }
 
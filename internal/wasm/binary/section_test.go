@@ -84,7 +84,8 @@ func TestMemorySection(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    []byte
-		expected *wasm.Memory
+		features wasm.Features
+		expected []*wasm.Memory
 	}{
 		{
 			name: "min and min with max",
@@ -92,7 +93,18 @@ func TestMemorySection(t *testing.T) {
 				0x01,             // 1 memory
 				0x01, 0x02, 0x03, // (memory 2 3)
 			},
-			expected: &wasm.Memory{Min: 2, Cap: 2, Max: three, IsMaxEncoded: true},
+			features: wasm.Features20191205,
+			expected: []*wasm.Memory{{Min: 2, Cap: 2, Max: three, IsMaxEncoded: true}},
+		},
+		{
+			name: "multiple memories",
+			input: []byte{
+				0x02,       // 2 memories
+				0x00, 0x01, // (memory 1)
+				0x01, 0x02, 0x03, // (memory 2 3)
+			},
+			features: wasm.FeatureMultiMemory,
+			expected: []*wasm.Memory{{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages}, {Min: 2, Cap: 2, Max: three, IsMaxEncoded: true}},
 		},
 	}
 
@@ -100,7 +112,7 @@ func TestMemorySection(t *testing.T) {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			memories, err := decodeMemorySection(bytes.NewReader(tc.input), wasm.MemorySizer)
+			memories, err := decodeMemorySection(bytes.NewReader(tc.input), wasm.MemorySizer, tc.features)
 			require.NoError(t, err)
 			require.Equal(t, tc.expected, memories)
 		})
@@ -128,7 +140,7 @@ func TestMemorySection_Errors(t *testing.T) {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := decodeMemorySection(bytes.NewReader(tc.input), wasm.MemorySizer)
+			_, err := decodeMemorySection(bytes.NewReader(tc.input), wasm.MemorySizer, wasm.Features20191205)
 			require.EqualError(t, err, tc.expectedErr)
 		})
 	}
@@ -22,9 +22,11 @@ func decodeTable(r *bytes.Reader, enabledFeatures wasm.Features) (*wasm.Table, e
 		}
 	}
 
-	min, max, err := decodeLimitsType(r)
+	min, max, shared, err := decodeLimitsType(r)
 	if err != nil {
 		return nil, fmt.Errorf("read limits: %v", err)
+	} else if shared {
+		return nil, fmt.Errorf("read limits: %v for limits: tables cannot be shared", ErrInvalidByte)
 	}
 	if min > wasm.MaximumFunctionIndex {
 		return nil, fmt.Errorf("table min must be at most %d", wasm.MaximumFunctionIndex)
@@ -90,6 +90,11 @@ func TestDecodeTableType_Errors(t *testing.T) {
 			expectedErr: "table min must be at most 134217728",
 			features:    wasm.FeatureReferenceTypes,
 		},
+		{
+			name:        "shared",
+			input:       []byte{wasm.RefTypeFuncref, 0x3, 0, 1},
+			expectedErr: "read limits: invalid byte for limits: tables cannot be shared",
+		},
 	}
 
 	for _, tt := range tests {
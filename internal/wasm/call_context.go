@@ -55,6 +55,19 @@ func (m *CallContext) FailIfClosed() error {
 	return nil
 }
 
+// ExitCode implements the same method as documented on api.Module.
+func (m *CallContext) ExitCode() (uint32, bool) {
+	if closed := atomic.LoadUint64(m.closed); closed != 0 {
+		return uint32(closed >> 32), true // Unpack the high order bits as the exit code.
+	}
+	return 0, false
+}
+
+// IsClosed implements the same method as documented on api.Module.
+func (m *CallContext) IsClosed() bool {
+	return atomic.LoadUint64(m.closed) != 0
+}
+
 // Name implements the same method as documented on api.Module
 func (m *CallContext) Name() string {
 	return m.module.Name
@@ -90,19 +103,43 @@ func (m *CallContext) CloseWithExitCode(ctx context.Context, exitCode uint32) (e
 
 // close marks this CallContext as closed and releases underlying system resources without removing
 // from the store.
-func (m *CallContext) close(_ context.Context, exitCode uint32) (c bool, err error) {
-	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+//
+// If ctx is done before system resources are released, this returns a partial-close error instead of waiting further.
+func (m *CallContext) close(ctx context.Context, exitCode uint32) (c bool, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
 	closed := uint64(1) + uint64(exitCode)<<32 // Store exitCode as high-order bits.
 	if !atomic.CompareAndSwapUint64(m.closed, 0, closed) {
 		return false, nil
 	}
 	if sys := m.Sys; sys != nil { // ex nil if from ModuleBuilder
-		return true, sys.Close()
+		return true, m.closeSys(ctx, sys)
 	}
 	return true, nil
 }
 
+// closeSys closes the SysContext, unless ctx is done first. Ex. a file in SysContext could block on Close.
+func (m *CallContext) closeSys(ctx context.Context, sys *SysContext) error {
+	done := ctx.Done()
+	if done == nil { // Ex. context.Background can never be done, so close synchronously.
+		return sys.Close()
+	}
+
+	closeErr := make(chan error, 1) // buffered to not leak the goroutine when ctx is done first.
+	go func() {
+		closeErr <- sys.Close()
+	}()
+
+	select {
+	case err := <-closeErr:
+		return err
+	case <-done:
+		return fmt.Errorf("module[%s] partially closed: %w", m.Name(), ctx.Err())
+	}
+}
+
 // Memory implements the same method as documented on api.Module.
 func (m *CallContext) Memory() api.Memory {
 	return m.module.Memory
@@ -117,6 +154,25 @@ func (m *CallContext) ExportedMemory(name string) api.Memory {
 	return exp.Memory
 }
 
+// ExportedMemoryDefinition implements the same method as documented on api.Module.
+func (m *CallContext) ExportedMemoryDefinition(name string) (min, current, max uint32, ok bool) {
+	exp, err := m.module.getExport(name, ExternTypeMemory)
+	if err != nil {
+		return
+	}
+	mem := exp.Memory
+	return mem.Min, mem.PageSize(context.Background()), mem.Max, true
+}
+
+// ExportedTable implements the same method as documented on api.Module.
+func (m *CallContext) ExportedTable(name string) api.Table {
+	exp, err := m.module.getExport(name, ExternTypeTable)
+	if err != nil {
+		return nil
+	}
+	return exp.Table
+}
+
 // ExportedFunction implements the same method as documented on api.Module.
 func (m *CallContext) ExportedFunction(name string) api.Function {
 	exp, err := m.module.getExport(name, ExternTypeFunc)
@@ -151,10 +207,47 @@ func (f *importedFn) Call(ctx context.Context, params ...uint64) (ret []uint64,
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if err = validateParamCount(f.importedFn, params); err != nil {
+		return nil, err
+	}
 	mod := f.importingModule
 	return f.importedFn.Module.Engine.Call(ctx, mod, f.importedFn, params...)
 }
 
+// CallInto implements the same method as documented on api.Function.
+func (f *importedFn) CallInto(ctx context.Context, results []uint64, params ...uint64) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := validateParamCount(f.importedFn, params); err != nil {
+		return err
+	}
+	if err := validateResultCount(f.importedFn, results); err != nil {
+		return err
+	}
+	mod := f.importingModule
+	return f.importedFn.Module.Engine.CallInto(ctx, mod, f.importedFn, results, params...)
+}
+
+// validateParamCount returns an error if the count of params doesn't match the type of the function, as opposed to
+// letting the engine panic on an out-of-range stack access.
+//
+// Note: Params are opaque uint64 values, so their types cannot be validated.
+func validateParamCount(f *FunctionInstance, params []uint64) error {
+	if expected := f.Type.ParamNumInUint64; expected != len(params) {
+		return fmt.Errorf("expected %d params, but passed %d", expected, len(params))
+	}
+	return nil
+}
+
+// validateResultCount returns an error if results is too short to hold the results of the function.
+func validateResultCount(f *FunctionInstance, results []uint64) error {
+	if expected := f.Type.ResultNumInUint64; expected > len(results) {
+		return fmt.Errorf("expected %d results, but passed %d", expected, len(results))
+	}
+	return nil
+}
+
 // ParamTypes implements the same method as documented on api.Function.
 func (f *FunctionInstance) ParamTypes() []api.ValueType {
 	return f.Type.Params
@@ -170,11 +263,29 @@ func (f *FunctionInstance) Call(ctx context.Context, params ...uint64) (ret []ui
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if err = validateParamCount(f, params); err != nil {
+		return nil, err
+	}
 	mod := f.Module
 	ret, err = mod.Engine.Call(ctx, mod.CallCtx, f, params...)
 	return
 }
 
+// CallInto implements the same method as documented on api.Function.
+func (f *FunctionInstance) CallInto(ctx context.Context, results []uint64, params ...uint64) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := validateParamCount(f, params); err != nil {
+		return err
+	}
+	if err := validateResultCount(f, results); err != nil {
+		return err
+	}
+	mod := f.Module
+	return mod.Engine.CallInto(ctx, mod.CallCtx, f, results, params...)
+}
+
 // ExportedGlobal implements the same method as documented on api.Module.
 func (m *CallContext) ExportedGlobal(name string) api.Global {
 	exp, err := m.module.getExport(name, ExternTypeGlobal)
@@ -182,19 +293,7 @@ func (m *CallContext) ExportedGlobal(name string) api.Global {
 		return nil
 	}
 	if exp.Global.Type.Mutable {
-		return &mutableGlobal{exp.Global}
-	}
-	valType := exp.Global.Type.ValType
-	switch valType {
-	case ValueTypeI32:
-		return globalI32(exp.Global.Val)
-	case ValueTypeI64:
-		return globalI64(exp.Global.Val)
-	case ValueTypeF32:
-		return globalF32(exp.Global.Val)
-	case ValueTypeF64:
-		return globalF64(exp.Global.Val)
-	default:
-		panic(fmt.Errorf("BUG: unknown value type %X", valType))
+		return &mutableGlobal{constantGlobal{exp.Global}}
 	}
+	return constantGlobal{exp.Global}
 }
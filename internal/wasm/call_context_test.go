@@ -3,8 +3,10 @@ package wasm
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
@@ -140,6 +142,28 @@ func TestCallContext_Close(t *testing.T) {
 		})
 	}
 
+	t.Run("ExitCode", func(t *testing.T) {
+		m, err := s.Instantiate(testCtx, &Module{}, t.Name(), nil, nil)
+		require.NoError(t, err)
+
+		_, ok := m.ExitCode()
+		require.False(t, ok)
+
+		require.NoError(t, m.CloseWithExitCode(testCtx, 42))
+		exitCode, ok := m.ExitCode()
+		require.True(t, ok)
+		require.Equal(t, uint32(42), exitCode)
+	})
+
+	t.Run("IsClosed", func(t *testing.T) {
+		m, err := s.Instantiate(testCtx, &Module{}, t.Name(), nil, nil)
+		require.NoError(t, err)
+
+		require.False(t, m.IsClosed())
+		require.NoError(t, m.Close(testCtx))
+		require.True(t, m.IsClosed())
+	})
+
 	t.Run("calls SysContext.Close()", func(t *testing.T) {
 		tempDir := t.TempDir()
 		pathName := "test"
@@ -152,6 +176,7 @@ func TestCallContext_Close(t *testing.T) {
 			nil, // stdin
 			nil, // stdout
 			nil, // stderr
+			nil, // randSource
 			map[uint32]*FileEntry{ // openedFiles
 				3: {Path: "."},
 				4: {Path: path.Join(".", pathName), File: file},
@@ -176,4 +201,39 @@ func TestCallContext_Close(t *testing.T) {
 		// Verify no error closing again.
 		require.NoError(t, m.Close(testCtx))
 	})
+
+	t.Run("returns early when the context is done", func(t *testing.T) {
+		file := &blockingCloseFile{unblock: make(chan struct{})}
+		defer close(file.unblock)
+
+		sys, err := NewSysContext(0, nil, nil, nil, nil, nil, nil, map[uint32]*FileEntry{3: {Path: "blocking", File: file}})
+		require.NoError(t, err)
+
+		moduleName := t.Name()
+		m, err := s.Instantiate(context.Background(), &Module{}, moduleName, sys, nil)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(testCtx, time.Millisecond)
+		defer cancel()
+
+		err = m.Close(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Contains(t, err.Error(), "partially closed")
+
+		// The module is closed regardless.
+		require.Nil(t, s.Module(moduleName))
+		require.NotNil(t, m.FailIfClosed())
+	})
+}
+
+// blockingCloseFile is a fs.File whose Close blocks until unblock is closed.
+type blockingCloseFile struct {
+	fs.File
+	unblock chan struct{}
+}
+
+// Close implements io.Closer
+func (f *blockingCloseFile) Close() error {
+	<-f.unblock
+	return nil
 }
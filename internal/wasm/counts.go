@@ -41,16 +41,17 @@ func (m *Module) importCount(et ExternType) (res uint32) {
 //
 // For example...
 // * SectionIDType returns the count of FunctionType
-// * SectionIDCustom returns one if the NameSection is present
+// * SectionIDCustom returns the count of CustomSections, plus one if the NameSection is present
 // * SectionIDHostFunction returns the count of HostFunctionSection
 // * SectionIDExport returns the count of unique export names
 func (m *Module) SectionElementCount(sectionID SectionID) uint32 { // element as in vector elements!
 	switch sectionID {
 	case SectionIDCustom:
+		count := uint32(len(m.CustomSections))
 		if m.NameSection != nil {
-			return 1
+			count++
 		}
-		return 0
+		return count
 	case SectionIDType:
 		return uint32(len(m.TypeSection))
 	case SectionIDImport:
@@ -60,10 +61,7 @@ func (m *Module) SectionElementCount(sectionID SectionID) uint32 { // element as
 	case SectionIDTable:
 		return uint32(len(m.TableSection))
 	case SectionIDMemory:
-		if m.MemorySection != nil {
-			return 1
-		}
-		return 0
+		return uint32(len(m.MemorySection))
 	case SectionIDGlobal:
 		return uint32(len(m.GlobalSection))
 	case SectionIDExport:
@@ -114,7 +114,7 @@ func TestModule_ImportMemoryCount(t *testing.T) {
 		},
 		{
 			name:  "none with memory section",
-			input: &Module{MemorySection: &Memory{Min: 1}},
+			input: &Module{MemorySection: []*Memory{{Min: 1}}},
 		},
 		{
 			name:     "one",
@@ -125,7 +125,7 @@ func TestModule_ImportMemoryCount(t *testing.T) {
 			name: "one with memory section",
 			input: &Module{
 				ImportSection: []*Import{{Type: ExternTypeMemory}},
-				MemorySection: &Memory{Min: 1},
+				MemorySection: []*Memory{{Min: 1}},
 			},
 			expected: 1,
 		},
@@ -219,6 +219,14 @@ func TestModule_SectionElementCount(t *testing.T) {
 			input:    &Module{NameSection: &NameSection{ModuleName: "simple"}},
 			expected: map[string]uint32{"custom": 1},
 		},
+		{
+			name: "NameSection and CustomSections",
+			input: &Module{
+				NameSection:    &NameSection{ModuleName: "simple"},
+				CustomSections: []*CustomSection{{Name: "producers"}, {Name: ".debug_info"}},
+			},
+			expected: map[string]uint32{"custom": 3},
+		},
 		{
 			name:     "HostFunctionSection",
 			input:    &Module{HostFunctionSection: []*reflect.Value{&fn}},
@@ -279,7 +287,7 @@ func TestModule_SectionElementCount(t *testing.T) {
 		{
 			name: "MemorySection and DataSection",
 			input: &Module{
-				MemorySection: &Memory{Min: 1},
+				MemorySection: []*Memory{{Min: 1}},
 				DataSection:   []*DataSegment{{OffsetExpression: empty}},
 			},
 			expected: map[string]uint32{"data": 1, "memory": 1},
@@ -36,6 +36,21 @@ type Engine interface {
 	DeleteCompiledModule(module *Module)
 }
 
+// CompiledCodeCodec is optionally implemented by an Engine whose compiled code can be persisted, such as in a
+// compilation cache shared across processes.
+type CompiledCodeCodec interface {
+	// EncodeCompiledCode returns the code compiled by Engine.CompileModule for the module.
+	EncodeCompiledCode(module *Module) ([]byte, error)
+
+	// DecodeCompiledCode restores the code returned by EncodeCompiledCode, which makes Engine.CompileModule for
+	// the module unnecessary. This errs if the content is invalid, for example if it was encoded by another Engine.
+	DecodeCompiledCode(module *Module, content []byte) error
+
+	// CompiledCodeVersion identifies the encoding used by EncodeCompiledCode. This is part of the compilation cache
+	// key, so that content encoded by an incompatible version of the engine is never looked up.
+	CompiledCodeVersion() []byte
+}
+
 // ModuleEngine implements function calls for a given module.
 type ModuleEngine interface {
 	// Name returns the name of the module this engine was compiled for.
@@ -44,6 +59,10 @@ type ModuleEngine interface {
 	// Call invokes a function instance f with given parameters.
 	Call(ctx context.Context, m *CallContext, f *FunctionInstance, params ...uint64) (results []uint64, err error)
 
+	// CallInto is like Call, except results are written into the given slice, which must be at least as long as
+	// FunctionType.ResultNumInUint64.
+	CallInto(ctx context.Context, m *CallContext, f *FunctionInstance, results []uint64, params ...uint64) error
+
 	// CreateFuncElementInstance creates an ElementInstance whose references are engine-specific function pointers
 	// corresponding to the given `indexes`.
 	CreateFuncElementInstance(indexes []*Index) *ElementInstance
@@ -43,6 +43,23 @@ const (
 	// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/appendix/changes.html#bulk-memory-and-table-instructions
 	FeatureBulkMemoryOperations Features = 1 << iota
 
+	// FeatureExtendedConst decides if constant expressions can have more than one instruction, combining i32.const,
+	// i64.const and global.get with the following:
+	//
+	// * OpcodeI32Add, OpcodeI32Sub and OpcodeI32Mul
+	// * OpcodeI64Add, OpcodeI64Sub and OpcodeI64Mul
+	//
+	// See https://github.com/WebAssembly/extended-const/blob/main/proposals/extended-const/Overview.md
+	FeatureExtendedConst
+
+	// FeatureMultiMemory decides if parsing should succeed on the following:
+	//
+	// * More than one memory, whether imported or defined in this module.
+	// * Load, store, `memory.size` and `memory.grow` instructions with a non-zero memory index.
+	//
+	// See https://github.com/WebAssembly/multi-memory/blob/main/proposals/multi-memory/Overview.md
+	FeatureMultiMemory
+
 	// FeatureMultiValue decides if parsing should succeed on the following:
 	//
 	// * FunctionType.Results length greater than one.
@@ -102,6 +119,20 @@ const (
 	//
 	// See https://github.com/WebAssembly/spec/blob/main/proposals/simd/SIMD.md
 	FeatureSIMD
+
+	// FeatureTailCall decides if parsing should succeed on the following instructions:
+	//
+	// * OpcodeReturnCall
+	// * OpcodeReturnCallIndirect
+	//
+	// See https://github.com/WebAssembly/tail-call/blob/main/proposals/tail-call/Overview.md
+	FeatureTailCall
+
+	// FeatureThreads decides if parsing should succeed on the atomic instructions prefixed by OpcodeAtomicPrefix,
+	// such as [ OpcodeAtomicPrefix, OpcodeAtomicI32RmwAdd].
+	//
+	// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+	FeatureThreads
 )
 
 // Set assigns the value for the given feature.
@@ -150,6 +181,12 @@ func featureName(f Features) string {
 	case FeatureSignExtensionOps:
 		// match https://github.com/WebAssembly/spec/blob/main/proposals/sign-extension-ops/Overview.md
 		return "sign-extension-ops"
+	case FeatureExtendedConst:
+		// match https://github.com/WebAssembly/extended-const/blob/main/proposals/extended-const/Overview.md
+		return "extended-const"
+	case FeatureMultiMemory:
+		// match https://github.com/WebAssembly/multi-memory/blob/main/proposals/multi-memory/Overview.md
+		return "multi-memory"
 	case FeatureMultiValue:
 		// match https://github.com/WebAssembly/spec/blob/main/proposals/multi-value/Overview.md
 		return "multi-value"
@@ -165,6 +202,12 @@ func featureName(f Features) string {
 	case FeatureSIMD:
 		// match https://github.com/WebAssembly/spec/blob/main/proposals/simd/SIMD.md
 		return "simd"
+	case FeatureTailCall:
+		// match https://github.com/WebAssembly/tail-call/blob/main/proposals/tail-call/Overview.md
+		return "tail-call"
+	case FeatureThreads:
+		// match https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+		return "threads"
 	}
 	return ""
 }
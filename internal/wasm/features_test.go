@@ -59,8 +59,12 @@ func TestFeatures_String(t *testing.T) {
 		{name: "none", feature: 0, expected: ""},
 		{name: "mutable-global", feature: FeatureMutableGlobal, expected: "mutable-global"},
 		{name: "sign-extension-ops", feature: FeatureSignExtensionOps, expected: "sign-extension-ops"},
+		{name: "extended-const", feature: FeatureExtendedConst, expected: "extended-const"},
+		{name: "multi-memory", feature: FeatureMultiMemory, expected: "multi-memory"},
 		{name: "multi-value", feature: FeatureMultiValue, expected: "multi-value"},
 		{name: "simd", feature: FeatureSIMD, expected: "simd"},
+		{name: "tail-call", feature: FeatureTailCall, expected: "tail-call"},
+		{name: "threads", feature: FeatureThreads, expected: "threads"},
 		{name: "features", feature: FeatureMutableGlobal | FeatureMultiValue, expected: "multi-value|mutable-global"},
 		{name: "undefined", feature: 1 << 63, expected: ""},
 		{name: "2.0", feature: Features20220419,
@@ -14,21 +14,27 @@ import (
 // The wazero specific limitation described at RATIONALE.md.
 const maximumValuesOnStack = 1 << 27
 
+// MemoryArgMemoryIndexFlag is set in the alignment of a "memarg" when a memory index follows it. This is only valid
+// when FeatureMultiMemory is enabled.
+//
+// See https://github.com/WebAssembly/multi-memory/blob/main/proposals/multi-memory/Overview.md#binary-format
+const MemoryArgMemoryIndexFlag = 0x40
+
 // validateFunction validates the instruction sequence of a function.
 // following the specification https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#instructions%E2%91%A2.
 //
 // * idx is the index in the FunctionSection
 // * functions are the function index namespace, which is prefixed by imports. The value is the TypeSection index.
 // * globals are the global index namespace, which is prefixed by imports.
-// * memory is the potentially imported memory and can be nil.
+// * memories are the memory index namespace, which is prefixed by imports.
 // * table is the potentially imported table and can be nil.
-// * declaredFunctionIndexes is the set of function indexes declared by declarative element segments which can be acceed by OpcodeRefFunc instruction.
+// * declaredFunctionIndexes is the set of function indexes OpcodeRefFunc can access: those referenced outside functions, by element segments, globals or exports. See declaredFunctionIndexes.
 //
 // Returns an error if the instruction sequence is not valid,
 // or potentially it can exceed the maximum number of values on the stack.
 func (m *Module) validateFunction(enabledFeatures Features, idx Index, functions []Index,
-	globals []*GlobalType, memory *Memory, tables []*Table, declaredFunctionIndexes map[Index]struct{}) error {
-	return m.validateFunctionWithMaxStackValues(enabledFeatures, idx, functions, globals, memory, tables, maximumValuesOnStack, declaredFunctionIndexes)
+	globals []*GlobalType, memories []*Memory, tables []*Table, declaredFunctionIndexes map[Index]struct{}) error {
+	return m.validateFunctionWithMaxStackValues(enabledFeatures, idx, functions, globals, memories, tables, maximumValuesOnStack, declaredFunctionIndexes)
 }
 
 // validateFunctionWithMaxStackValues is like validateFunction, but allows overriding maxStackValues for testing.
@@ -39,7 +45,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 	idx Index,
 	functions []Index,
 	globals []*GlobalType,
-	memory *Memory,
+	memories []*Memory,
 	tables []*Table,
 	maxStackValues int,
 	declaredFunctionIndexes map[Index]struct{},
@@ -59,7 +65,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 	for pc := uint64(0); pc < uint64(len(body)); pc++ {
 		op := body[pc]
 		if OpcodeI32Load <= op && op <= OpcodeI64Store32 {
-			if memory == nil {
+			if len(memories) == 0 {
 				return fmt.Errorf("unknown memory access")
 			}
 			pc++
@@ -67,6 +73,16 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			if err != nil {
 				return fmt.Errorf("read memory align: %v", err)
 			}
+			if align&MemoryArgMemoryIndexFlag != 0 && enabledFeatures.Get(FeatureMultiMemory) {
+				align &^= MemoryArgMemoryIndexFlag
+				pc += num
+				var memoryIndex uint32
+				if memoryIndex, num, err = leb128.DecodeUint32(bytes.NewReader(body[pc:])); err != nil {
+					return fmt.Errorf("read memory index: %v", err)
+				} else if memoryIndex >= uint32(len(memories)) {
+					return fmt.Errorf("unknown memory %d", memoryIndex)
+				}
+			}
 			switch op {
 			case OpcodeI32Load:
 				if 1<<align > 32/8 {
@@ -247,7 +263,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			}
 			pc += num - 1
 		} else if OpcodeMemorySize <= op && op <= OpcodeMemoryGrow {
-			if memory == nil {
+			if len(memories) == 0 {
 				return fmt.Errorf("unknown memory access")
 			}
 			pc++
@@ -255,7 +271,11 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			if err != nil {
 				return fmt.Errorf("read immediate: %v", err)
 			}
-			if val != 0 || num != 1 {
+			if enabledFeatures.Get(FeatureMultiMemory) { // the reserved byte is a memory index.
+				if val >= uint32(len(memories)) {
+					return fmt.Errorf("unknown memory %d", val)
+				}
+			} else if val != 0 || num != 1 {
 				return fmt.Errorf("memory instruction reserved bytes not zero with 1 byte")
 			}
 			switch Opcode(op) {
@@ -493,7 +513,12 @@ func (m *Module) validateFunctionWithMaxStackValues(
 
 			// br_table instruction is stack-polymorphic.
 			valueTypeStack.unreachable()
-		} else if op == OpcodeCall {
+		} else if op == OpcodeCall || op == OpcodeReturnCall {
+			if op == OpcodeReturnCall {
+				if err := enabledFeatures.Require(FeatureTailCall); err != nil {
+					return fmt.Errorf("%s invalid as %v", OpcodeReturnCallName, err)
+				}
+			}
 			pc++
 			index, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 			if err != nil {
@@ -506,13 +531,26 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			funcType := types[functions[index]]
 			for i := 0; i < len(funcType.Params); i++ {
 				if err := valueTypeStack.popAndVerifyType(funcType.Params[len(funcType.Params)-1-i]); err != nil {
-					return fmt.Errorf("type mismatch on %s operation param type: %v", OpcodeCallName, err)
+					return fmt.Errorf("type mismatch on %s operation param type: %v", instructionNames[op], err)
 				}
 			}
-			for _, exp := range funcType.Results {
-				valueTypeStack.push(exp)
+			if op == OpcodeReturnCall {
+				if err := validateTailCallResults(functionType, funcType); err != nil {
+					return err
+				}
+				// return_call is stack-polymorphic like return.
+				valueTypeStack.unreachable()
+			} else {
+				for _, exp := range funcType.Results {
+					valueTypeStack.push(exp)
+				}
+			}
+		} else if op == OpcodeCallIndirect || op == OpcodeReturnCallIndirect {
+			if op == OpcodeReturnCallIndirect {
+				if err := enabledFeatures.Require(FeatureTailCall); err != nil {
+					return fmt.Errorf("%s invalid as %v", OpcodeReturnCallIndirectName, err)
+				}
 			}
-		} else if op == OpcodeCallIndirect {
 			pc++
 			typeIndex, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 			if err != nil {
@@ -521,7 +559,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			pc += num
 
 			if int(typeIndex) >= len(types) {
-				return fmt.Errorf("invalid type index at %s: %d", OpcodeCallIndirectName, typeIndex)
+				return fmt.Errorf("invalid type index at %s: %d", instructionNames[op], typeIndex)
 			}
 
 			tableIndex, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
@@ -541,22 +579,30 @@ func (m *Module) validateFunctionWithMaxStackValues(
 
 			table := tables[tableIndex]
 			if table == nil {
-				return fmt.Errorf("table not given while having %s", OpcodeCallIndirectName)
+				return fmt.Errorf("table not given while having %s", instructionNames[op])
 			} else if table.Type != RefTypeFuncref {
-				return fmt.Errorf("table is not funcref type but was %s for %s", RefTypeName(table.Type), OpcodeCallIndirectName)
+				return fmt.Errorf("table is not funcref type but was %s for %s", RefTypeName(table.Type), instructionNames[op])
 			}
 
 			if err = valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-				return fmt.Errorf("cannot pop the offset in table for %s", OpcodeCallIndirectName)
+				return fmt.Errorf("cannot pop the offset in table for %s", instructionNames[op])
 			}
 			funcType := types[typeIndex]
 			for i := 0; i < len(funcType.Params); i++ {
 				if err = valueTypeStack.popAndVerifyType(funcType.Params[len(funcType.Params)-1-i]); err != nil {
-					return fmt.Errorf("type mismatch on %s operation input type", OpcodeCallIndirectName)
+					return fmt.Errorf("type mismatch on %s operation input type", instructionNames[op])
 				}
 			}
-			for _, exp := range funcType.Results {
-				valueTypeStack.push(exp)
+			if op == OpcodeReturnCallIndirect {
+				if err := validateTailCallResults(functionType, funcType); err != nil {
+					return err
+				}
+				// return_call_indirect is stack-polymorphic like return.
+				valueTypeStack.unreachable()
+			} else {
+				for _, exp := range funcType.Results {
+					valueTypeStack.push(exp)
+				}
 			}
 		} else if OpcodeI32Eqz <= op && op <= OpcodeI64Extend32S {
 			switch op {
@@ -881,7 +927,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					}
 					pc += num - 1
 				case OpcodeMiscMemoryInit, OpcodeMiscMemoryCopy, OpcodeMiscMemoryFill:
-					if memory == nil {
+					if len(memories) == 0 {
 						return fmt.Errorf("memory must exist for %s", MiscInstructionName(miscOpcode))
 					}
 					params = []ValueType{ValueTypeI32, ValueTypeI32, ValueTypeI32}
@@ -1042,6 +1088,73 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					valueTypeStack.push(r)
 				}
 			}
+		} else if op == OpcodeAtomicPrefix {
+			pc++
+			// Atomic instructions come with two bytes where the first byte is always OpcodeAtomicPrefix,
+			// and the second byte determines the actual instruction.
+			atomicOpcode := body[pc]
+			if err := enabledFeatures.Require(FeatureThreads); err != nil {
+				return fmt.Errorf("%s invalid as %v", atomicInstructionNames[atomicOpcode], err)
+			}
+			pc++
+
+			if atomicOpcode == OpcodeAtomicFence {
+				if body[pc] != 0 {
+					return fmt.Errorf("%s reserved byte must be zero", OpcodeAtomicFenceName)
+				}
+				continue
+			}
+
+			access, ok := AtomicAccessOf(atomicOpcode)
+			if !ok {
+				return fmt.Errorf("invalid atomic opcode: %#x", atomicOpcode)
+			}
+			valType, width := access.ValType, access.Width
+			instName := atomicInstructionNames[atomicOpcode]
+
+			if len(memories) == 0 {
+				return fmt.Errorf("unknown memory access")
+			}
+			align, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
+			if err != nil {
+				return fmt.Errorf("read memory align: %v", err)
+			}
+			if 1<<align != width {
+				return fmt.Errorf("invalid memory alignment")
+			}
+			pc += num
+			// offset
+			_, num, err = leb128.DecodeUint32(bytes.NewReader(body[pc:]))
+			if err != nil {
+				return fmt.Errorf("read memory offset: %v", err)
+			}
+			pc += num - 1
+
+			// Operands are popped in reverse order, from the last operand to the address.
+			var operands []ValueType
+			results := []ValueType{valType}
+			switch {
+			case atomicOpcode == OpcodeAtomicMemoryNotify:
+				operands = []ValueType{ValueTypeI32} // count
+			case atomicOpcode == OpcodeAtomicMemoryWait32, atomicOpcode == OpcodeAtomicMemoryWait64:
+				operands = []ValueType{ValueTypeI64, valType} // timeout, expected
+				results = []ValueType{ValueTypeI32}
+			case atomicOpcode <= OpcodeAtomicI64Load32U: // load
+			case atomicOpcode <= OpcodeAtomicI64Store32: // store
+				operands, results = []ValueType{valType}, nil
+			case atomicOpcode < OpcodeAtomicI32RmwCmpxchg:
+				operands = []ValueType{valType}
+			default: // cmpxchg
+				operands = []ValueType{valType, valType} // replacement, expected
+			}
+			for _, operand := range append(operands, ValueTypeI32) {
+				if err := valueTypeStack.popAndVerifyType(operand); err != nil {
+					return fmt.Errorf("cannot pop the operand for %s: %v", instName, err)
+				}
+			}
+			for _, r := range results {
+				valueTypeStack.push(r)
+			}
 		} else if op == OpcodeVecPrefix {
 			pc++
 			// Vector instructions come with two bytes where the first byte is always OpcodeVecPrefix,
@@ -1226,7 +1339,11 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				}
 				pc++
 				tp := body[pc]
-				if tp != ValueTypeI32 && tp != ValueTypeI64 && tp != ValueTypeF32 && tp != ValueTypeF64 &&
+				if tp == ValueTypeV128 {
+					if err := enabledFeatures.Require(FeatureSIMD); err != nil {
+						return fmt.Errorf("invalid type %s for %s as %w", ValueTypeName(tp), OpcodeTypedSelectName, err)
+					}
+				} else if tp != ValueTypeI32 && tp != ValueTypeI64 && tp != ValueTypeF32 && tp != ValueTypeF64 &&
 					tp != api.ValueTypeExternref && tp != ValueTypeFuncref {
 					return fmt.Errorf("invalid type %s for %s", ValueTypeName(tp), OpcodeTypedSelectName)
 				}
@@ -1445,6 +1562,21 @@ func typeCountError(isParam bool, context string, have []ValueType, want []Value
 	return errors.New(ret.String())
 }
 
+// validateTailCallResults ensures a tail call's callee returns exactly what the calling function does, as its results
+// become those of the caller.
+func validateTailCallResults(caller, callee *FunctionType) error {
+	if bytes.Equal(caller.Results, callee.Results) {
+		return nil
+	}
+	var ret strings.Builder
+	ret.WriteString("type mismatch on tail call results\n\thave (")
+	writeValueTypes(callee.Results, &ret)
+	ret.WriteString(")\n\twant (")
+	writeValueTypes(caller.Results, &ret)
+	ret.WriteByte(')')
+	return errors.New(ret.String())
+}
+
 func writeValueTypes(vts []ValueType, ret *strings.Builder) {
 	switch len(vts) {
 	case 0:
@@ -287,7 +287,7 @@ func TestModule_ValidateFunction_BulkMemoryOperations(t *testing.T) {
 					ElementSection:   []*ElementSegment{{}},
 					DataCountSection: &c,
 				}
-				err := m.validateFunction(FeatureBulkMemoryOperations, 0, []Index{0}, nil, &Memory{}, []*Table{{}, {}}, nil)
+				err := m.validateFunction(FeatureBulkMemoryOperations, 0, []Index{0}, nil, []*Memory{{}}, []*Table{{}, {}}, nil)
 				require.NoError(t, err)
 			})
 		}
@@ -648,7 +648,11 @@ func TestModule_ValidateFunction_BulkMemoryOperations(t *testing.T) {
 					c := uint32(0)
 					m.DataCountSection = &c
 				}
-				err := m.validateFunction(tc.flag, 0, []Index{0}, nil, tc.memory, tc.tables, nil)
+				var memories []*Memory
+				if tc.memory != nil {
+					memories = []*Memory{tc.memory}
+				}
+				err := m.validateFunction(tc.flag, 0, []Index{0}, nil, memories, tc.tables, nil)
 				require.EqualError(t, err, tc.expectedErr)
 			})
 		}
@@ -676,6 +680,64 @@ var (
 // TestModule_ValidateFunction_TypeMismatchSpecTests are "type mismatch" tests when "multi-value" was merged.
 //
 // See https://github.com/WebAssembly/spec/commit/484180ba3d9d7638ba1cb400b699ffede796927c
+func TestModule_ValidateFunction_MultiMemory(t *testing.T) {
+	memArg := byte(MemoryArgMemoryIndexFlag | 2) // i32 alignment, followed by a memory index.
+	tests := []struct {
+		name                string
+		body                []byte
+		expectedErr         string
+		expectedErrDisabled string
+	}{
+		{
+			name: "i32.load memory[1]",
+			body: []byte{OpcodeI32Const, 0, OpcodeI32Load, memArg, 1, 0, OpcodeDrop, OpcodeEnd},
+		},
+		{
+			name:        "i32.store unknown memory",
+			body:        []byte{OpcodeI32Const, 0, OpcodeI32Const, 0, OpcodeI32Store, memArg, 2, 0, OpcodeEnd},
+			expectedErr: "unknown memory 2",
+		},
+		{
+			name:                "memory.size memory[1]",
+			body:                []byte{OpcodeMemorySize, 1, OpcodeDrop, OpcodeEnd},
+			expectedErrDisabled: "memory instruction reserved bytes not zero with 1 byte",
+		},
+		{
+			name:                "memory.grow unknown memory",
+			body:                []byte{OpcodeI32Const, 1, OpcodeMemoryGrow, 2, OpcodeDrop, OpcodeEnd},
+			expectedErr:         "unknown memory 2",
+			expectedErrDisabled: "memory instruction reserved bytes not zero with 1 byte",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: tc.body}},
+			}
+			memories := []*Memory{{Min: 1}, {Min: 1}}
+			t.Run("enabled", func(t *testing.T) {
+				err := m.validateFunction(FeatureMultiMemory, 0, []Index{0}, nil, memories, nil, nil)
+				if tc.expectedErr != "" {
+					require.EqualError(t, err, tc.expectedErr)
+				} else {
+					require.NoError(t, err)
+				}
+			})
+			if tc.expectedErrDisabled == "" {
+				return
+			}
+			t.Run("disabled", func(t *testing.T) {
+				err := m.validateFunction(Features20191205, 0, []Index{0}, nil, memories, nil, nil)
+				require.EqualError(t, err, tc.expectedErrDisabled)
+			})
+		})
+	}
+}
+
 func TestModule_ValidateFunction_MultiValue_TypeMismatch(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -2182,6 +2244,74 @@ func TestModule_ValidateFunction_MultiValue_TypeMismatch(t *testing.T) {
 	}
 }
 
+// TestModule_funcValidation_MemoryAlignment ensures each load and store accepts any alignment up to its natural
+// alignment, and rejects the next.
+func TestModule_funcValidation_MemoryAlignment(t *testing.T) {
+	i32Const := []byte{OpcodeI32Const, 0}
+	i64Const := []byte{OpcodeI64Const, 0}
+	f32Const := []byte{OpcodeF32Const, 0, 0, 0, 0}
+	f64Const := []byte{OpcodeF64Const, 0, 0, 0, 0, 0, 0, 0, 0}
+
+	tests := []struct {
+		name             string
+		opcode           Opcode
+		naturalAlignment uint32
+		// storeValue is the instruction pushing the value to store, or nil for loads.
+		storeValue []byte
+	}{
+		{name: OpcodeI32LoadName, opcode: OpcodeI32Load, naturalAlignment: 2},
+		{name: OpcodeI64LoadName, opcode: OpcodeI64Load, naturalAlignment: 3},
+		{name: OpcodeF32LoadName, opcode: OpcodeF32Load, naturalAlignment: 2},
+		{name: OpcodeF64LoadName, opcode: OpcodeF64Load, naturalAlignment: 3},
+		{name: OpcodeI32Load8SName, opcode: OpcodeI32Load8S, naturalAlignment: 0},
+		{name: OpcodeI32Load8UName, opcode: OpcodeI32Load8U, naturalAlignment: 0},
+		{name: OpcodeI32Load16SName, opcode: OpcodeI32Load16S, naturalAlignment: 1},
+		{name: OpcodeI32Load16UName, opcode: OpcodeI32Load16U, naturalAlignment: 1},
+		{name: OpcodeI64Load8SName, opcode: OpcodeI64Load8S, naturalAlignment: 0},
+		{name: OpcodeI64Load8UName, opcode: OpcodeI64Load8U, naturalAlignment: 0},
+		{name: OpcodeI64Load16SName, opcode: OpcodeI64Load16S, naturalAlignment: 1},
+		{name: OpcodeI64Load16UName, opcode: OpcodeI64Load16U, naturalAlignment: 1},
+		{name: OpcodeI64Load32SName, opcode: OpcodeI64Load32S, naturalAlignment: 2},
+		{name: OpcodeI64Load32UName, opcode: OpcodeI64Load32U, naturalAlignment: 2},
+		{name: OpcodeI32StoreName, opcode: OpcodeI32Store, naturalAlignment: 2, storeValue: i32Const},
+		{name: OpcodeI64StoreName, opcode: OpcodeI64Store, naturalAlignment: 3, storeValue: i64Const},
+		{name: OpcodeF32StoreName, opcode: OpcodeF32Store, naturalAlignment: 2, storeValue: f32Const},
+		{name: OpcodeF64StoreName, opcode: OpcodeF64Store, naturalAlignment: 3, storeValue: f64Const},
+		{name: OpcodeI32Store8Name, opcode: OpcodeI32Store8, naturalAlignment: 0, storeValue: i32Const},
+		{name: OpcodeI32Store16Name, opcode: OpcodeI32Store16, naturalAlignment: 1, storeValue: i32Const},
+		{name: OpcodeI64Store8Name, opcode: OpcodeI64Store8, naturalAlignment: 0, storeValue: i64Const},
+		{name: OpcodeI64Store16Name, opcode: OpcodeI64Store16, naturalAlignment: 1, storeValue: i64Const},
+		{name: OpcodeI64Store32Name, opcode: OpcodeI64Store32, naturalAlignment: 2, storeValue: i64Const},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		validate := func(alignment uint32) error {
+			body := append([]byte{}, i32Const...) // offset
+			body = append(body, tc.storeValue...)
+			body = append(body, tc.opcode, byte(alignment), 0)
+			if tc.storeValue == nil {
+				body = append(body, OpcodeDrop)
+			}
+			body = append(body, OpcodeEnd)
+			m := &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: body}},
+			}
+			return m.validateFunction(Features20220419, 0, []Index{0}, nil, []*Memory{{}}, nil, nil)
+		}
+
+		t.Run(tc.name, func(t *testing.T) {
+			for alignment := uint32(0); alignment <= tc.naturalAlignment; alignment++ {
+				require.NoError(t, validate(alignment))
+			}
+			require.EqualError(t, validate(tc.naturalAlignment+1), "invalid memory alignment")
+		})
+	}
+}
+
 func TestModule_funcValidation_CallIndirect(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		m := &Module{
@@ -2193,7 +2323,7 @@ func TestModule_funcValidation_CallIndirect(t *testing.T) {
 				OpcodeEnd,
 			}}},
 		}
-		err := m.validateFunction(FeatureReferenceTypes, 0, []Index{0}, nil, &Memory{}, []*Table{{Type: RefTypeFuncref}}, nil)
+		err := m.validateFunction(FeatureReferenceTypes, 0, []Index{0}, nil, []*Memory{{}}, []*Table{{Type: RefTypeFuncref}}, nil)
 		require.NoError(t, err)
 	})
 	t.Run("non zero table index", func(t *testing.T) {
@@ -2207,11 +2337,11 @@ func TestModule_funcValidation_CallIndirect(t *testing.T) {
 			}}},
 		}
 		t.Run("disabled", func(t *testing.T) {
-			err := m.validateFunction(Features20191205, 0, []Index{0}, nil, &Memory{}, []*Table{{}, {}}, nil)
+			err := m.validateFunction(Features20191205, 0, []Index{0}, nil, []*Memory{{}}, []*Table{{}, {}}, nil)
 			require.EqualError(t, err, "table index must be zero but was 100: feature \"reference-types\" is disabled")
 		})
 		t.Run("enabled but out of range", func(t *testing.T) {
-			err := m.validateFunction(FeatureReferenceTypes, 0, []Index{0}, nil, &Memory{}, []*Table{{}, {}}, nil)
+			err := m.validateFunction(FeatureReferenceTypes, 0, []Index{0}, nil, []*Memory{{}}, []*Table{{}, {}}, nil)
 			require.EqualError(t, err, "unknown table index: 100")
 		})
 	})
@@ -2225,7 +2355,7 @@ func TestModule_funcValidation_CallIndirect(t *testing.T) {
 				OpcodeEnd,
 			}}},
 		}
-		err := m.validateFunction(FeatureReferenceTypes, 0, []Index{0}, nil, &Memory{}, []*Table{{Type: RefTypeExternref}}, nil)
+		err := m.validateFunction(FeatureReferenceTypes, 0, []Index{0}, nil, []*Memory{{}}, []*Table{{Type: RefTypeExternref}}, nil)
 		require.EqualError(t, err, "table is not funcref type but was externref for call_indirect")
 	})
 }
@@ -2641,6 +2771,16 @@ func TestModule_funcValidation_Select_error(t *testing.T) {
 			flag:        FeatureReferenceTypes,
 			expectedErr: `invalid type unknown for typed_select`,
 		},
+		{
+			name: "typed_select v128 (simd disabled)",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI32Const, 0, OpcodeI32Const, 0,
+				OpcodeTypedSelect, 1, ValueTypeV128,
+				OpcodeEnd,
+			},
+			flag:        FeatureReferenceTypes,
+			expectedErr: `invalid type v128 for typed_select as feature "simd" is disabled`,
+		},
 	} {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
@@ -2800,3 +2940,233 @@ func TestModule_funcValidation_SIMD_error(t *testing.T) {
 		})
 	}
 }
+
+func TestModule_funcValidation_Atomic(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body []byte
+	}{
+		{
+			name: "i32.atomic.load",
+			body: []byte{OpcodeI32Const, 0, OpcodeAtomicPrefix, OpcodeAtomicI32Load, 2, 0, OpcodeDrop, OpcodeEnd},
+		},
+		{
+			name: "i64.atomic.load32_u",
+			body: []byte{OpcodeI32Const, 0, OpcodeAtomicPrefix, OpcodeAtomicI64Load32U, 2, 0, OpcodeDrop, OpcodeEnd},
+		},
+		{
+			name: "i64.atomic.store8",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI64Const, 1,
+				OpcodeAtomicPrefix, OpcodeAtomicI64Store8, 0, 0,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "i32.atomic.rmw.add",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI32Const, 1,
+				OpcodeAtomicPrefix, OpcodeAtomicI32RmwAdd, 2, 0,
+				OpcodeDrop, OpcodeEnd,
+			},
+		},
+		{
+			name: "i64.atomic.rmw16.xchg_u",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI64Const, 1,
+				OpcodeAtomicPrefix, OpcodeAtomicI64Rmw16XchgU, 1, 0,
+				OpcodeDrop, OpcodeEnd,
+			},
+		},
+		{
+			name: "i64.atomic.rmw.cmpxchg",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI64Const, 1, OpcodeI64Const, 2,
+				OpcodeAtomicPrefix, OpcodeAtomicI64RmwCmpxchg, 3, 0,
+				OpcodeDrop, OpcodeEnd,
+			},
+		},
+		{
+			name: "memory.atomic.wait32",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI32Const, 1, OpcodeI64Const, 2,
+				OpcodeAtomicPrefix, OpcodeAtomicMemoryWait32, 2, 0,
+				OpcodeDrop, OpcodeEnd,
+			},
+		},
+		{
+			name: "atomic.fence",
+			body: []byte{OpcodeAtomicPrefix, OpcodeAtomicFence, 0, OpcodeEnd},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(FeatureThreads, 0, []Index{0}, nil, []*Memory{{}}, nil, nil)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestModule_funcValidation_Atomic_error(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		body        []byte
+		features    Features
+		memories    []*Memory
+		expectedErr string
+	}{
+		{
+			name:        "disabled",
+			body:        []byte{OpcodeI32Const, 0, OpcodeAtomicPrefix, OpcodeAtomicI32Load, 2, 0, OpcodeDrop, OpcodeEnd},
+			features:    Features20220419,
+			memories:    []*Memory{{}},
+			expectedErr: `i32.atomic.load invalid as feature "threads" is disabled`,
+		},
+		{
+			name:        "no memory",
+			body:        []byte{OpcodeI32Const, 0, OpcodeAtomicPrefix, OpcodeAtomicI32Load, 2, 0, OpcodeDrop, OpcodeEnd},
+			features:    FeatureThreads,
+			expectedErr: "unknown memory access",
+		},
+		{
+			name:        "less than natural alignment",
+			body:        []byte{OpcodeI32Const, 0, OpcodeAtomicPrefix, OpcodeAtomicI32Load, 1, 0, OpcodeDrop, OpcodeEnd},
+			features:    FeatureThreads,
+			memories:    []*Memory{{}},
+			expectedErr: "invalid memory alignment",
+		},
+		{
+			name:        "more than natural alignment",
+			body:        []byte{OpcodeI32Const, 0, OpcodeAtomicPrefix, OpcodeAtomicI32Load8U, 1, 0, OpcodeDrop, OpcodeEnd},
+			features:    FeatureThreads,
+			memories:    []*Memory{{}},
+			expectedErr: "invalid memory alignment",
+		},
+		{
+			name: "operand type",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI32Const, 1,
+				OpcodeAtomicPrefix, OpcodeAtomicI64RmwAdd, 3, 0,
+				OpcodeDrop, OpcodeEnd,
+			},
+			features:    FeatureThreads,
+			memories:    []*Memory{{}},
+			expectedErr: "cannot pop the operand for i64.atomic.rmw.add: type mismatch: expected i64, but was i32",
+		},
+		{
+			name:        "fence reserved byte",
+			body:        []byte{OpcodeAtomicPrefix, OpcodeAtomicFence, 1, OpcodeEnd},
+			features:    FeatureThreads,
+			expectedErr: "atomic.fence reserved byte must be zero",
+		},
+		{
+			name:        "invalid opcode",
+			body:        []byte{OpcodeAtomicPrefix, 0x04, 0, 0, OpcodeEnd},
+			features:    FeatureThreads,
+			memories:    []*Memory{{}},
+			expectedErr: "invalid atomic opcode: 0x4",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(tc.features, 0, []Index{0}, nil, tc.memories, nil, nil)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestModule_funcValidation_TailCall(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body []byte
+	}{
+		{
+			name: "return_call",
+			body: []byte{OpcodeLocalGet, 0, OpcodeReturnCall, 0, OpcodeEnd},
+		},
+		{
+			name: "return_call_indirect",
+			body: []byte{OpcodeLocalGet, 0, OpcodeI32Const, 0, OpcodeReturnCallIndirect, 0, 0, OpcodeEnd},
+		},
+		{
+			name: "stack-polymorphic after return_call",
+			body: []byte{OpcodeLocalGet, 0, OpcodeReturnCall, 0, OpcodeI32Add, OpcodeEnd},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{i32_i32},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(FeatureTailCall, 0, []Index{0}, nil, nil, []*Table{{Type: RefTypeFuncref}}, nil)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestModule_funcValidation_TailCall_error(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		body        []byte
+		features    Features
+		expectedErr string
+	}{
+		{
+			name:        "return_call disabled",
+			body:        []byte{OpcodeLocalGet, 0, OpcodeReturnCall, 0, OpcodeEnd},
+			features:    Features20220419,
+			expectedErr: `return_call invalid as feature "tail-call" is disabled`,
+		},
+		{
+			name:        "return_call_indirect disabled",
+			body:        []byte{OpcodeLocalGet, 0, OpcodeI32Const, 0, OpcodeReturnCallIndirect, 0, 0, OpcodeEnd},
+			features:    Features20220419,
+			expectedErr: `return_call_indirect invalid as feature "tail-call" is disabled`,
+		},
+		{
+			name:        "return_call param type",
+			body:        []byte{OpcodeI64Const, 0, OpcodeReturnCall, 0, OpcodeEnd},
+			features:    FeatureTailCall,
+			expectedErr: "type mismatch on return_call operation param type: type mismatch: expected i32, but was i64",
+		},
+		{
+			name:     "return_call result mismatch",
+			body:     []byte{OpcodeLocalGet, 0, OpcodeReturnCall, 1, OpcodeEnd},
+			features: FeatureTailCall,
+			expectedErr: `type mismatch on tail call results
+	have ()
+	want (i32)`,
+		},
+		{
+			name:     "return_call_indirect result mismatch",
+			body:     []byte{OpcodeLocalGet, 0, OpcodeI32Const, 0, OpcodeReturnCallIndirect, 1, 0, OpcodeEnd},
+			features: FeatureTailCall,
+			expectedErr: `type mismatch on tail call results
+	have ()
+	want (i32)`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{i32_i32, i32_v},
+				FunctionSection: []Index{0, 1},
+				CodeSection:     []*Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(tc.features, 0, []Index{0, 1}, nil, nil, []*Table{{Type: RefTypeFuncref}}, nil)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -7,130 +7,116 @@ import (
 	"github.com/tetratelabs/wazero/api"
 )
 
-type mutableGlobal struct {
+// constantGlobal implements api.Global for an immutable GlobalInstance.
+type constantGlobal struct {
 	g *GlobalInstance
 }
 
-// compile-time check to ensure mutableGlobal is a api.Global.
-var _ api.Global = &mutableGlobal{}
+// compile-time check to ensure constantGlobal is a api.Global.
+var _ api.Global = constantGlobal{}
 
 // Type implements the same method as documented on api.Global.
-func (g *mutableGlobal) Type() api.ValueType {
+func (g constantGlobal) Type() api.ValueType {
 	return g.g.Type.ValType
 }
 
 // Get implements the same method as documented on api.Global.
-func (g *mutableGlobal) Get(_ context.Context) uint64 {
+func (g constantGlobal) Get(_ context.Context) uint64 {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
 	return g.g.Val
 }
 
-// Set implements the same method as documented on api.MutableGlobal.
-func (g *mutableGlobal) Set(_ context.Context, v uint64) {
-	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
-
-	g.g.Val = v
+// GetI32 implements the same method as documented on api.Global.
+func (g constantGlobal) GetI32(ctx context.Context) int32 {
+	g.requireType(ValueTypeI32)
+	return int32(g.Get(ctx))
 }
 
-// String implements fmt.Stringer
-func (g *mutableGlobal) String() string {
-	switch g.Type() {
-	case ValueTypeI32, ValueTypeI64:
-		return fmt.Sprintf("global(%d)", g.Get(context.Background()))
-	case ValueTypeF32:
-		return fmt.Sprintf("global(%f)", api.DecodeF32(g.Get(context.Background())))
-	case ValueTypeF64:
-		return fmt.Sprintf("global(%f)", api.DecodeF64(g.Get(context.Background())))
-	default:
-		panic(fmt.Errorf("BUG: unknown value type %X", g.Type()))
-	}
+// GetI64 implements the same method as documented on api.Global.
+func (g constantGlobal) GetI64(ctx context.Context) int64 {
+	g.requireType(ValueTypeI64)
+	return int64(g.Get(ctx))
 }
 
-type globalI32 uint64
-
-// compile-time check to ensure globalI32 is a api.Global
-var _ api.Global = globalI32(0)
-
-// Type implements the same method as documented on api.Global.
-func (g globalI32) Type() api.ValueType {
-	return ValueTypeI32
+// GetF32 implements the same method as documented on api.Global.
+func (g constantGlobal) GetF32(ctx context.Context) float32 {
+	g.requireType(ValueTypeF32)
+	return api.DecodeF32(g.Get(ctx))
 }
 
-// Get implements the same method as documented on api.Global.
-func (g globalI32) Get(_ context.Context) uint64 {
-	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
-
-	return uint64(g)
+// GetF64 implements the same method as documented on api.Global.
+func (g constantGlobal) GetF64(ctx context.Context) float64 {
+	g.requireType(ValueTypeF64)
+	return api.DecodeF64(g.Get(ctx))
 }
 
-// String implements fmt.Stringer
-func (g globalI32) String() string {
-	return fmt.Sprintf("global(%d)", g)
-}
-
-type globalI64 uint64
-
-// compile-time check to ensure globalI64 is a api.Global
-var _ api.Global = globalI64(0)
+// GetV128 implements the same method as documented on api.Global.
+func (g constantGlobal) GetV128(_ context.Context) (lo, hi uint64) {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-// Type implements the same method as documented on api.Global.
-func (g globalI64) Type() api.ValueType {
-	return ValueTypeI64
+	return g.g.Val, g.g.ValHi
 }
 
-// Get implements the same method as documented on api.Global.
-func (g globalI64) Get(_ context.Context) uint64 {
-	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
-
-	return uint64(g)
+// requireType panics if the value type of this global isn't expected, as the typed accessors would otherwise
+// silently reinterpret its bits.
+func (g constantGlobal) requireType(expected ValueType) {
+	if actual := g.Type(); actual != expected {
+		panic(fmt.Errorf("global is %s, not %s", ValueTypeName(actual), ValueTypeName(expected)))
+	}
 }
 
 // String implements fmt.Stringer
-func (g globalI64) String() string {
-	return fmt.Sprintf("global(%d)", g)
+func (g constantGlobal) String() string {
+	switch g.Type() {
+	case ValueTypeI32, ValueTypeI64:
+		return fmt.Sprintf("global(%d)", g.g.Val)
+	case ValueTypeF32:
+		return fmt.Sprintf("global(%f)", api.DecodeF32(g.g.Val))
+	case ValueTypeF64:
+		return fmt.Sprintf("global(%f)", api.DecodeF64(g.g.Val))
+	case ValueTypeV128:
+		return fmt.Sprintf("global(%#x, %#x)", g.g.Val, g.g.ValHi)
+	default:
+		panic(fmt.Errorf("BUG: unknown value type %X", g.Type()))
+	}
 }
 
-type globalF32 uint64
-
-// compile-time check to ensure globalF32 is a api.Global
-var _ api.Global = globalF32(0)
-
-// Type implements the same method as documented on api.Global.
-func (g globalF32) Type() api.ValueType {
-	return ValueTypeF32
+// mutableGlobal implements api.MutableGlobal by adding setters to constantGlobal.
+type mutableGlobal struct {
+	constantGlobal
 }
 
-// Get implements the same method as documented on api.Global.
-func (g globalF32) Get(_ context.Context) uint64 {
+// compile-time check to ensure mutableGlobal is a api.MutableGlobal.
+var _ api.MutableGlobal = &mutableGlobal{}
+
+// Set implements the same method as documented on api.MutableGlobal.
+func (g *mutableGlobal) Set(_ context.Context, v uint64) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
-	return uint64(g)
+	g.g.Val = v
 }
 
-// String implements fmt.Stringer
-func (g globalF32) String() string {
-	return fmt.Sprintf("global(%f)", api.DecodeF32(g.Get(context.Background())))
+// SetI32 implements the same method as documented on api.MutableGlobal.
+func (g *mutableGlobal) SetI32(ctx context.Context, v int32) {
+	g.requireType(ValueTypeI32)
+	g.Set(ctx, api.EncodeI32(v))
 }
 
-type globalF64 uint64
-
-// compile-time check to ensure globalF64 is a api.Global
-var _ api.Global = globalF64(0)
-
-// Type implements the same method as documented on api.Global.
-func (g globalF64) Type() api.ValueType {
-	return ValueTypeF64
+// SetI64 implements the same method as documented on api.MutableGlobal.
+func (g *mutableGlobal) SetI64(ctx context.Context, v int64) {
+	g.requireType(ValueTypeI64)
+	g.Set(ctx, api.EncodeI64(v))
 }
 
-// Get implements the same method as documented on api.Global.
-func (g globalF64) Get(_ context.Context) uint64 {
-	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
-
-	return uint64(g)
+// SetF32 implements the same method as documented on api.MutableGlobal.
+func (g *mutableGlobal) SetF32(ctx context.Context, v float32) {
+	g.requireType(ValueTypeF32)
+	g.Set(ctx, api.EncodeF32(v))
 }
 
-// String implements fmt.Stringer
-func (g globalF64) String() string {
-	return fmt.Sprintf("global(%f)", api.DecodeF64(g.Get(context.Background())))
+// SetF64 implements the same method as documented on api.MutableGlobal.
+func (g *mutableGlobal) SetF64(ctx context.Context, v float64) {
+	g.requireType(ValueTypeF64)
+	g.Set(ctx, api.EncodeF64(v))
 }
@@ -11,6 +11,11 @@ import (
 	"github.com/tetratelabs/wazero/internal/u64"
 )
 
+// newConstantGlobal returns an immutable global of the given type and value.
+func newConstantGlobal(valType ValueType, val uint64) constantGlobal {
+	return constantGlobal{&GlobalInstance{Type: &GlobalType{ValType: valType}, Val: val}}
+}
+
 func TestGlobalTypes(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -22,66 +27,66 @@ func TestGlobalTypes(t *testing.T) {
 	}{
 		{
 			name:           "i32 - immutable",
-			global:         globalI32(1),
+			global:         newConstantGlobal(ValueTypeI32, 1),
 			expectedType:   ValueTypeI32,
 			expectedVal:    1,
 			expectedString: "global(1)",
 		},
 		{
 			name:           "i32 - immutable - max",
-			global:         globalI32(math.MaxInt32),
+			global:         newConstantGlobal(ValueTypeI32, math.MaxInt32),
 			expectedType:   ValueTypeI32,
 			expectedVal:    math.MaxInt32,
 			expectedString: "global(2147483647)",
 		},
 		{
 			name:           "i64 - immutable",
-			global:         globalI64(1),
+			global:         newConstantGlobal(ValueTypeI64, 1),
 			expectedType:   ValueTypeI64,
 			expectedVal:    1,
 			expectedString: "global(1)",
 		},
 		{
 			name:           "i64 - immutable - max",
-			global:         globalI64(math.MaxInt64),
+			global:         newConstantGlobal(ValueTypeI64, math.MaxInt64),
 			expectedType:   ValueTypeI64,
 			expectedVal:    math.MaxInt64,
 			expectedString: "global(9223372036854775807)",
 		},
 		{
 			name:           "f32 - immutable",
-			global:         globalF32(api.EncodeF32(1.0)),
+			global:         newConstantGlobal(ValueTypeF32, api.EncodeF32(1.0)),
 			expectedType:   ValueTypeF32,
 			expectedVal:    api.EncodeF32(1.0),
 			expectedString: "global(1.000000)",
 		},
 		{
 			name:           "f32 - immutable - max",
-			global:         globalF32(api.EncodeF32(math.MaxFloat32)),
+			global:         newConstantGlobal(ValueTypeF32, api.EncodeF32(math.MaxFloat32)),
 			expectedType:   ValueTypeF32,
 			expectedVal:    api.EncodeF32(math.MaxFloat32),
 			expectedString: "global(340282346638528859811704183484516925440.000000)",
 		},
 		{
 			name:           "f64 - immutable",
-			global:         globalF64(api.EncodeF64(1.0)),
+			global:         newConstantGlobal(ValueTypeF64, api.EncodeF64(1.0)),
 			expectedType:   ValueTypeF64,
 			expectedVal:    api.EncodeF64(1.0),
 			expectedString: "global(1.000000)",
 		},
 		{
 			name:           "f64 - immutable - max",
-			global:         globalF64(api.EncodeF64(math.MaxFloat64)),
+			global:         newConstantGlobal(ValueTypeF64, api.EncodeF64(math.MaxFloat64)),
 			expectedType:   ValueTypeF64,
 			expectedVal:    api.EncodeF64(math.MaxFloat64),
 			expectedString: "global(179769313486231570814527423731704356798070567525844996598917476803157260780028538760589558632766878171540458953514382464234321326889464182768467546703537516986049910576551282076245490090389328944075868508455133942304583236903222948165808559332123348274797826204144723168738177180919299881250404026184124858368.000000)",
 		},
 		{
 			name: "i32 - mutable",
-			global: &mutableGlobal{g: &GlobalInstance{
+			global: &mutableGlobal{constantGlobal{&GlobalInstance{
 				Type: &GlobalType{ValType: ValueTypeI32, Mutable: true},
 				Val:  1,
-			}},
+			}}},
 			expectedType:    ValueTypeI32,
 			expectedVal:     1,
 			expectedString:  "global(1)",
@@ -89,10 +94,10 @@ func TestGlobalTypes(t *testing.T) {
 		},
 		{
 			name: "i64 - mutable",
-			global: &mutableGlobal{g: &GlobalInstance{
+			global: &mutableGlobal{constantGlobal{&GlobalInstance{
 				Type: &GlobalType{ValType: ValueTypeI64, Mutable: true},
 				Val:  1,
-			}},
+			}}},
 			expectedType:    ValueTypeI64,
 			expectedVal:     1,
 			expectedString:  "global(1)",
@@ -100,10 +105,10 @@ func TestGlobalTypes(t *testing.T) {
 		},
 		{
 			name: "f32 - mutable",
-			global: &mutableGlobal{g: &GlobalInstance{
+			global: &mutableGlobal{constantGlobal{&GlobalInstance{
 				Type: &GlobalType{ValType: ValueTypeF32, Mutable: true},
 				Val:  api.EncodeF32(1.0),
-			}},
+			}}},
 			expectedType:    ValueTypeF32,
 			expectedVal:     api.EncodeF32(1.0),
 			expectedString:  "global(1.000000)",
@@ -111,10 +116,10 @@ func TestGlobalTypes(t *testing.T) {
 		},
 		{
 			name: "f64 - mutable",
-			global: &mutableGlobal{g: &GlobalInstance{
+			global: &mutableGlobal{constantGlobal{&GlobalInstance{
 				Type: &GlobalType{ValType: ValueTypeF64, Mutable: true},
 				Val:  api.EncodeF64(1.0),
-			}},
+			}}},
 			expectedType:    ValueTypeF64,
 			expectedVal:     api.EncodeF64(1.0),
 			expectedString:  "global(1.000000)",
@@ -145,6 +150,98 @@ func TestGlobalTypes(t *testing.T) {
 	}
 }
 
+func TestGlobalTypes_V128(t *testing.T) {
+	for _, g := range []api.Global{
+		constantGlobal{&GlobalInstance{Type: &GlobalType{ValType: ValueTypeV128}, Val: 1, ValHi: 2}},
+		&mutableGlobal{constantGlobal{&GlobalInstance{Type: &GlobalType{ValType: ValueTypeV128, Mutable: true}, Val: 1, ValHi: 2}}},
+	} {
+		require.Equal(t, ValueTypeV128, g.Type())
+		require.Equal(t, uint64(1), g.Get(testCtx)) // Get only returns the lower bits.
+		lo, hi := g.GetV128(testCtx)
+		require.Equal(t, uint64(1), lo)
+		require.Equal(t, uint64(2), hi)
+		require.Equal(t, "global(0x1, 0x2)", g.String())
+	}
+
+	// Other types have no higher bits.
+	lo, hi := newConstantGlobal(ValueTypeI64, api.EncodeI64(-1)).GetV128(testCtx)
+	require.Equal(t, uint64(math.MaxUint64), lo)
+	require.Zero(t, hi)
+}
+
+func TestGlobalTypes_Typed(t *testing.T) {
+	newMutable := func(valType ValueType, val uint64) *mutableGlobal {
+		return &mutableGlobal{constantGlobal{&GlobalInstance{Type: &GlobalType{ValType: valType, Mutable: true}, Val: val}}}
+	}
+
+	t.Run("i32", func(t *testing.T) {
+		for _, g := range []api.Global{newConstantGlobal(ValueTypeI32, api.EncodeI32(-1)), newMutable(ValueTypeI32, api.EncodeI32(-1))} {
+			require.Equal(t, int32(-1), g.GetI32(testCtx))
+			if m, ok := g.(api.MutableGlobal); ok {
+				m.SetI32(testCtx, math.MinInt32)
+				require.Equal(t, int32(math.MinInt32), g.GetI32(testCtx))
+				require.Equal(t, uint64(0x80000000), g.Get(testCtx)) // high bits aren't set
+			}
+		}
+	})
+
+	t.Run("i64", func(t *testing.T) {
+		for _, g := range []api.Global{newConstantGlobal(ValueTypeI64, api.EncodeI64(-1)), newMutable(ValueTypeI64, api.EncodeI64(-1))} {
+			require.Equal(t, int64(-1), g.GetI64(testCtx))
+			if m, ok := g.(api.MutableGlobal); ok {
+				m.SetI64(testCtx, math.MinInt64)
+				require.Equal(t, int64(math.MinInt64), g.GetI64(testCtx))
+			}
+		}
+	})
+
+	t.Run("f32", func(t *testing.T) {
+		for _, g := range []api.Global{newConstantGlobal(ValueTypeF32, api.EncodeF32(-1.5)), newMutable(ValueTypeF32, api.EncodeF32(-1.5))} {
+			require.Equal(t, float32(-1.5), g.GetF32(testCtx))
+			if m, ok := g.(api.MutableGlobal); ok {
+				m.SetF32(testCtx, math.MaxFloat32)
+				require.Equal(t, float32(math.MaxFloat32), g.GetF32(testCtx))
+				require.Equal(t, api.EncodeF32(math.MaxFloat32), g.Get(testCtx))
+			}
+		}
+	})
+
+	t.Run("f64", func(t *testing.T) {
+		for _, g := range []api.Global{newConstantGlobal(ValueTypeF64, api.EncodeF64(-1.5)), newMutable(ValueTypeF64, api.EncodeF64(-1.5))} {
+			require.Equal(t, -1.5, g.GetF64(testCtx))
+			if m, ok := g.(api.MutableGlobal); ok {
+				m.SetF64(testCtx, math.MaxFloat64)
+				require.Equal(t, math.MaxFloat64, g.GetF64(testCtx))
+				require.Equal(t, api.EncodeF64(math.MaxFloat64), g.Get(testCtx))
+			}
+		}
+	})
+}
+
+func TestGlobalTypes_TypedMismatch(t *testing.T) {
+	g := &mutableGlobal{constantGlobal{&GlobalInstance{Type: &GlobalType{ValType: ValueTypeI64, Mutable: true}}}}
+
+	for _, tc := range []struct {
+		name     string
+		access   func()
+		expected string
+	}{
+		{name: "GetI32", access: func() { g.GetI32(testCtx) }, expected: "global is i64, not i32"},
+		{name: "GetF32", access: func() { g.GetF32(testCtx) }, expected: "global is i64, not f32"},
+		{name: "GetF64", access: func() { g.GetF64(testCtx) }, expected: "global is i64, not f64"},
+		{name: "SetI32", access: func() { g.SetI32(testCtx, 1) }, expected: "global is i64, not i32"},
+		{name: "SetF32", access: func() { g.SetF32(testCtx, 1) }, expected: "global is i64, not f32"},
+		{name: "SetF64", access: func() { g.SetF64(testCtx, 1) }, expected: "global is i64, not f64"},
+	} {
+		err := require.CapturePanic(tc.access)
+		require.EqualError(t, err, tc.expected, tc.name)
+	}
+	require.Zero(t, g.Get(testCtx)) // mismatched setters don't write
+
+	err := require.CapturePanic(func() { newConstantGlobal(ValueTypeF32, 0).GetI64(testCtx) })
+	require.EqualError(t, err, "global is f32, not i64")
+}
+
 func TestPublicModule_Global(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -177,7 +274,7 @@ func TestPublicModule_Global(t *testing.T) {
 				},
 				ExportSection: []*Export{{Type: ExternTypeGlobal, Name: "global"}},
 			},
-			expected: globalI32(1),
+			expected: newConstantGlobal(ValueTypeI32, 1),
 		},
 		{
 			name: "global exported - immutable I64",
@@ -190,7 +287,7 @@ func TestPublicModule_Global(t *testing.T) {
 				},
 				ExportSection: []*Export{{Type: ExternTypeGlobal, Name: "global"}},
 			},
-			expected: globalI64(1),
+			expected: newConstantGlobal(ValueTypeI64, 1),
 		},
 		{
 			name: "global exported - immutable F32",
@@ -205,7 +302,7 @@ func TestPublicModule_Global(t *testing.T) {
 				},
 				ExportSection: []*Export{{Type: ExternTypeGlobal, Name: "global"}},
 			},
-			expected: globalF32(api.EncodeF32(1.0)),
+			expected: newConstantGlobal(ValueTypeF32, api.EncodeF32(1.0)),
 		},
 		{
 			name: "global exported - immutable F64",
@@ -220,7 +317,7 @@ func TestPublicModule_Global(t *testing.T) {
 				},
 				ExportSection: []*Export{{Type: ExternTypeGlobal, Name: "global"}},
 			},
-			expected: globalF64(api.EncodeF64(1.0)),
+			expected: newConstantGlobal(ValueTypeF64, api.EncodeF64(1.0)),
 		},
 		{
 			name: "global exported - mutable I32",
@@ -233,9 +330,7 @@ func TestPublicModule_Global(t *testing.T) {
 				},
 				ExportSection: []*Export{{Type: ExternTypeGlobal, Name: "global"}},
 			},
-			expected: &mutableGlobal{
-				g: &GlobalInstance{Type: &GlobalType{ValType: ValueTypeI32, Mutable: true}, Val: 1},
-			},
+			expected: &mutableGlobal{constantGlobal{&GlobalInstance{Type: &GlobalType{ValType: ValueTypeI32, Mutable: true}, Val: 1}}},
 		},
 		{
 			name: "global exported - mutable I64",
@@ -248,9 +343,7 @@ func TestPublicModule_Global(t *testing.T) {
 				},
 				ExportSection: []*Export{{Type: ExternTypeGlobal, Name: "global"}},
 			},
-			expected: &mutableGlobal{
-				g: &GlobalInstance{Type: &GlobalType{ValType: ValueTypeI64, Mutable: true}, Val: 1},
-			},
+			expected: &mutableGlobal{constantGlobal{&GlobalInstance{Type: &GlobalType{ValType: ValueTypeI64, Mutable: true}, Val: 1}}},
 		},
 		{
 			name: "global exported - mutable F32",
@@ -265,9 +358,7 @@ func TestPublicModule_Global(t *testing.T) {
 				},
 				ExportSection: []*Export{{Type: ExternTypeGlobal, Name: "global"}},
 			},
-			expected: &mutableGlobal{
-				g: &GlobalInstance{Type: &GlobalType{ValType: ValueTypeF32, Mutable: true}, Val: api.EncodeF32(1.0)},
-			},
+			expected: &mutableGlobal{constantGlobal{&GlobalInstance{Type: &GlobalType{ValType: ValueTypeF32, Mutable: true}, Val: api.EncodeF32(1.0)}}},
 		},
 		{
 			name: "global exported - mutable F64",
@@ -282,9 +373,40 @@ func TestPublicModule_Global(t *testing.T) {
 				},
 				ExportSection: []*Export{{Type: ExternTypeGlobal, Name: "global"}},
 			},
-			expected: &mutableGlobal{
-				g: &GlobalInstance{Type: &GlobalType{ValType: ValueTypeF64, Mutable: true}, Val: api.EncodeF64(1.0)},
+			expected: &mutableGlobal{constantGlobal{&GlobalInstance{Type: &GlobalType{ValType: ValueTypeF64, Mutable: true}, Val: api.EncodeF64(1.0)}}},
+		},
+		{
+			name: "global exported - immutable V128",
+			module: &Module{
+				GlobalSection: []*Global{
+					{
+						Type: &GlobalType{ValType: ValueTypeV128},
+						Init: &ConstantExpression{Opcode: OpcodeVecV128Const,
+							Data: append(u64.LeBytes(0x0706050403020100), u64.LeBytes(0x0f0e0d0c0b0a0908)...),
+						},
+					},
+				},
+				ExportSection: []*Export{{Type: ExternTypeGlobal, Name: "global"}},
 			},
+			expected: constantGlobal{&GlobalInstance{Type: &GlobalType{ValType: ValueTypeV128}, Val: 0x0706050403020100, ValHi: 0x0f0e0d0c0b0a0908}},
+		},
+		{
+			name: "global exported - mutable V128",
+			module: &Module{
+				GlobalSection: []*Global{
+					{
+						Type: &GlobalType{ValType: ValueTypeV128, Mutable: true},
+						Init: &ConstantExpression{Opcode: OpcodeVecV128Const,
+							Data: append(u64.LeBytes(0x0706050403020100), u64.LeBytes(0x0f0e0d0c0b0a0908)...),
+						},
+					},
+				},
+				ExportSection: []*Export{{Type: ExternTypeGlobal, Name: "global"}},
+			},
+			expected: &mutableGlobal{constantGlobal{&GlobalInstance{
+				Type: &GlobalType{ValType: ValueTypeV128, Mutable: true},
+				Val:  0x0706050403020100, ValHi: 0x0f0e0d0c0b0a0908,
+			}}},
 		},
 	}
 
@@ -299,6 +421,11 @@ func TestPublicModule_Global(t *testing.T) {
 
 			if global := module.ExportedGlobal("global"); tc.expected != nil {
 				require.Equal(t, tc.expected, global)
+				if global.Type() == ValueTypeV128 {
+					lo, hi := global.GetV128(testCtx)
+					require.Equal(t, uint64(0x0706050403020100), lo)
+					require.Equal(t, uint64(0x0f0e0d0c0b0a0908), hi)
+				}
 			} else {
 				require.Nil(t, global)
 			}
@@ -7,6 +7,7 @@ import (
 	"reflect"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 )
 
 // FunctionKind identifies the type of function that can be called.
@@ -26,11 +27,18 @@ const (
 	// FunctionKindGoContextModule is a function implemented in Go, with a signature matching FunctionType, except arg
 	// zero is a context.Context and arg one is an api.Module.
 	FunctionKindGoContextModule
+	// FunctionKindGoMemory is a function implemented in Go, with a signature matching FunctionType, except arg zero is
+	// an api.Memory.
+	FunctionKindGoMemory
+	// FunctionKindGoContextMemory is a function implemented in Go, with a signature matching FunctionType, except arg
+	// zero is a context.Context and arg one is an api.Memory.
+	FunctionKindGoContextMemory
 )
 
 // Below are reflection code to get the interface type used to parse functions and set values.
 
 var moduleType = reflect.TypeOf((*api.Module)(nil)).Elem()
+var memoryType = reflect.TypeOf((*api.Memory)(nil)).Elem()
 var goContextType = reflect.TypeOf((*context.Context)(nil)).Elem()
 var errorType = reflect.TypeOf((*error)(nil)).Elem()
 
@@ -44,7 +52,7 @@ func PopGoFuncParams(f *FunctionInstance, popParam func() uint64) []uint64 {
 	paramCount := f.GoFunc.Type().NumIn()
 	switch f.Kind {
 	case FunctionKindGoNoContext:
-	case FunctionKindGoContextModule:
+	case FunctionKindGoContextModule, FunctionKindGoContextMemory:
 		paramCount -= 2
 	default:
 		paramCount--
@@ -74,6 +82,10 @@ func PopValues(count int, popper func() uint64) []uint64 {
 //
 // Note: ctx must use the caller's memory, which might be different from the defining module on an imported function.
 func CallGoFunc(ctx context.Context, callCtx *CallContext, f *FunctionInstance, params []uint64) []uint64 {
+	if buildoptions.IsDebugMode {
+		defer trackHostViews(callCtx)()
+	}
+
 	tp := f.GoFunc.Type()
 
 	var in []reflect.Value
@@ -92,6 +104,13 @@ func CallGoFunc(ctx context.Context, callCtx *CallContext, f *FunctionInstance,
 			in[0] = newContextVal(ctx)
 			in[1] = newModuleVal(callCtx)
 			i = 2
+		case FunctionKindGoMemory:
+			in[0] = newMemoryVal(callCtx)
+			i = 1
+		case FunctionKindGoContextMemory:
+			in[0] = newContextVal(ctx)
+			in[1] = newMemoryVal(callCtx)
+			i = 2
 		}
 
 		for _, raw := range params {
@@ -142,12 +161,43 @@ func newContextVal(ctx context.Context) reflect.Value {
 	return val
 }
 
+// trackHostViews is used in debug mode to detect views of memory made stale during the host function call. The result
+// must be called after the call. See MemoryInstance.checkHostViews
+func trackHostViews(callCtx *CallContext) (exit func()) {
+	// The host function can access the memory via api.Memory, and via api.Module, which may differ on imports.
+	var mems []*MemoryInstance
+	if mem, ok := callCtx.memory.(*MemoryInstance); ok && mem != nil {
+		mems = append(mems, mem)
+	}
+	if m := callCtx.module; m != nil && m.Memory != nil && (len(mems) == 0 || mems[0] != m.Memory) {
+		mems = append(mems, m.Memory)
+	}
+	for _, mem := range mems {
+		mem.enterHostCall()
+	}
+	return func() {
+		for _, mem := range mems {
+			mem.exitHostCall()
+		}
+	}
+}
+
 func newModuleVal(m api.Module) reflect.Value {
 	val := reflect.New(moduleType).Elem()
 	val.Set(reflect.ValueOf(m))
 	return val
 }
 
+// newMemoryVal returns the memory of the calling module as an api.Memory, which is nil if it has no memory.
+func newMemoryVal(callCtx *CallContext) reflect.Value {
+	val := reflect.New(memoryType).Elem()
+	// Check the concrete type, as an api.Memory holding a nil *MemoryInstance isn't itself nil.
+	if mem, ok := callCtx.memory.(*MemoryInstance); ok && mem != nil {
+		val.Set(reflect.ValueOf(mem))
+	}
+	return val
+}
+
 // getFunctionType returns the function type corresponding to the function signature or errs if invalid.
 func getFunctionType(fn *reflect.Value, enabledFeatures Features) (fk FunctionKind, ft *FunctionType, err error) {
 	p := fn.Type()
@@ -161,7 +211,7 @@ func getFunctionType(fn *reflect.Value, enabledFeatures Features) (fk FunctionKi
 	pOffset := 0
 	switch fk {
 	case FunctionKindGoNoContext:
-	case FunctionKindGoContextModule:
+	case FunctionKindGoContextModule, FunctionKindGoContextMemory:
 		pOffset = 2
 	default:
 		pOffset = 1
@@ -191,6 +241,8 @@ func getFunctionType(fn *reflect.Value, enabledFeatures Features) (fk FunctionKi
 		var arg0Type reflect.Type
 		if hc := pI.Implements(moduleType); hc {
 			arg0Type = moduleType
+		} else if mc := pI.Implements(memoryType); mc {
+			arg0Type = memoryType
 		} else if gc := pI.Implements(goContextType); gc {
 			arg0Type = goContextType
 		}
@@ -227,9 +279,13 @@ func kind(p reflect.Type) FunctionKind {
 		p0 := p.In(0)
 		if p0.Implements(moduleType) {
 			return FunctionKindGoModule
+		} else if p0.Implements(memoryType) {
+			return FunctionKindGoMemory
 		} else if p0.Implements(goContextType) {
 			if pCount >= 2 && p.In(1).Implements(moduleType) {
 				return FunctionKindGoContextModule
+			} else if pCount >= 2 && p.In(1).Implements(memoryType) {
+				return FunctionKindGoContextMemory
 			}
 			return FunctionKindGoContext
 		}
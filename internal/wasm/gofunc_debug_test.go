@@ -0,0 +1,66 @@
+//go:build debug_mode
+
+package wasm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// TestCallGoFunc_staleView ensures debug mode panics when memory is re-allocated while a host function holds a view.
+//
+// Note: This only runs with `go test -tags debug_mode`.
+func TestCallGoFunc_staleView(t *testing.T) {
+	newCallCtx := func() *CallContext {
+		mem := &MemoryInstance{Buffer: make([]byte, 0), Max: 2}
+		mem.observeGrow("math", nil)
+		return &CallContext{memory: mem, module: &ModuleInstance{Memory: mem}}
+	}
+
+	// callGoFunc calls the host function the same way engines do.
+	callGoFunc := func(callCtx *CallContext, fn interface{}) {
+		goFunc := reflect.ValueOf(fn)
+		fk, _, err := getFunctionType(&goFunc, Features20220419)
+		require.NoError(t, err)
+		CallGoFunc(testCtx, callCtx, &FunctionInstance{Kind: fk, GoFunc: &goFunc}, nil)
+	}
+
+	// grow is a host function which stands in for Wasm calling "memory.grow".
+	grow := func(ctx context.Context, m api.Module) {
+		_, ok := m.Memory().Grow(ctx, 1)
+		require.True(t, ok)
+	}
+
+	t.Run("grown during a callback", func(t *testing.T) {
+		callCtx := newCallCtx()
+		err := require.CapturePanic(func() {
+			callGoFunc(callCtx, func(ctx context.Context, m api.Module) {
+				_, _ = m.Memory().Slice(ctx, 0, 0)
+				callGoFunc(callCtx, grow) // ex. a callback into Wasm, which grows the memory.
+			})
+		})
+		require.EqualError(t, err, "memory of module[math] grew while a host function held a view from Read or Slice, "+
+			"which is now stale: read it again after calling back into Wasm")
+	})
+
+	t.Run("no view", func(t *testing.T) {
+		callCtx := newCallCtx()
+		callGoFunc(callCtx, func(ctx context.Context, m api.Module) {
+			callGoFunc(callCtx, grow)
+			_, ok := m.Memory().Read(ctx, 0, 1) // read after growing is fine
+			require.True(t, ok)
+		})
+	})
+
+	t.Run("view released", func(t *testing.T) {
+		callCtx := newCallCtx()
+		callGoFunc(callCtx, func(ctx context.Context, m api.Module) {
+			_, _ = m.Memory().Read(ctx, 0, 0)
+		})
+		callGoFunc(callCtx, grow) // the previous host function returned, so it can't use its view anymore.
+	})
+}
@@ -45,6 +45,18 @@ func TestGetFunctionType(t *testing.T) {
 			expectedKind: FunctionKindGoContextModule,
 			expectedType: &FunctionType{Params: []ValueType{}, Results: []ValueType{}},
 		},
+		{
+			name:         "api.Memory void return",
+			inputFunc:    func(api.Memory) {},
+			expectedKind: FunctionKindGoMemory,
+			expectedType: &FunctionType{Params: []ValueType{}, Results: []ValueType{}},
+		},
+		{
+			name:         "context.Context and api.Memory void return",
+			inputFunc:    func(context.Context, api.Memory) {},
+			expectedKind: FunctionKindGoContextMemory,
+			expectedType: &FunctionType{Params: []ValueType{}, Results: []ValueType{}},
+		},
 		{
 			name:         "all supported params and i32 result",
 			inputFunc:    func(uint32, uint64, float32, float64, uintptr) uint32 { return 0 },
@@ -81,6 +93,18 @@ func TestGetFunctionType(t *testing.T) {
 			expectedKind: FunctionKindGoContextModule,
 			expectedType: &FunctionType{Params: []ValueType{i32, i64, f32, f64, externref}, Results: []ValueType{i32}, ParamNumInUint64: 5, ResultNumInUint64: 1},
 		},
+		{
+			name:         "all supported params and i32 result - api.Memory",
+			inputFunc:    func(api.Memory, uint32, uint64, float32, float64, uintptr) uint32 { return 0 },
+			expectedKind: FunctionKindGoMemory,
+			expectedType: &FunctionType{Params: []ValueType{i32, i64, f32, f64, externref}, Results: []ValueType{i32}, ParamNumInUint64: 5, ResultNumInUint64: 1},
+		},
+		{
+			name:         "all supported params and i32 result - context.Context and api.Memory",
+			inputFunc:    func(context.Context, api.Memory, uint32, uint64, float32, float64, uintptr) uint32 { return 0 },
+			expectedKind: FunctionKindGoContextMemory,
+			expectedType: &FunctionType{Params: []ValueType{i32, i64, f32, f64, externref}, Results: []ValueType{i32}, ParamNumInUint64: 5, ResultNumInUint64: 1},
+		},
 	}
 	for _, tt := range tests {
 		tc := tt
@@ -142,6 +166,16 @@ func TestGetFunctionTypeErrors(t *testing.T) {
 			input:       func(api.Module, uint64, api.Module) error { return nil },
 			expectedErr: "param[2] is a api.Module, which may be defined only once as param[0]",
 		},
+		{
+			name:        "multiple api.Memory",
+			input:       func(api.Memory, uint32, api.Memory) {},
+			expectedErr: "param[2] is a api.Memory, which may be defined only once as param[0]",
+		},
+		{
+			name:        "api.Memory after a wasm param",
+			input:       func(context.Context, uint32, api.Memory) {},
+			expectedErr: "param[2] is a api.Memory, which may be defined only once as param[0]",
+		},
 	}
 
 	for _, tt := range tests {
@@ -227,6 +261,14 @@ func TestPopGoFuncParams(t *testing.T) {
 			name:      "context.Context and api.Module",
 			inputFunc: func(context.Context, api.Module) {},
 		},
+		{
+			name:      "api.Memory",
+			inputFunc: func(api.Memory) {},
+		},
+		{
+			name:      "context.Context and api.Memory",
+			inputFunc: func(context.Context, api.Memory) {},
+		},
 		{
 			name:      "all supported params",
 			inputFunc: func(uint32, uint64, float32, float64, uintptr) {},
@@ -247,6 +289,11 @@ func TestPopGoFuncParams(t *testing.T) {
 			inputFunc: func(context.Context, api.Module, uint32, uint64, float32, float64, uintptr) {},
 			expected:  []uint64{3, 4, 5, 6, 7},
 		},
+		{
+			name:      "all supported params - context.Context and api.Memory",
+			inputFunc: func(context.Context, api.Memory, uint32, uint64, float32, float64, uintptr) {},
+			expected:  []uint64{3, 4, 5, 6, 7},
+		},
 	}
 
 	for _, tt := range tests {
@@ -265,7 +312,8 @@ func TestPopGoFuncParams(t *testing.T) {
 
 func TestCallGoFunc(t *testing.T) {
 	tPtr := uintptr(unsafe.Pointer(t))
-	callCtx := &CallContext{}
+	mem := &MemoryInstance{Buffer: make([]byte, 8)}
+	callCtx := &CallContext{memory: mem}
 	callCtxPtr := uintptr(unsafe.Pointer(callCtx))
 
 	var tests = []struct {
@@ -296,6 +344,22 @@ func TestCallGoFunc(t *testing.T) {
 				require.Equal(t, callCtx, m)
 			},
 		},
+		{
+			name: "api.Memory void return",
+			inputFunc: func(m api.Memory) {
+				require.Equal(t, mem, m)
+			},
+		},
+		{
+			name: "context.Context, api.Memory and i32 params",
+			inputFunc: func(ctx context.Context, m api.Memory, offset, byteCount uint32) {
+				require.Equal(t, testCtx, ctx)
+				require.Equal(t, mem, m)
+				require.Equal(t, uint32(1), offset)
+				require.Equal(t, uint32(2), byteCount)
+			},
+			inputParams: []uint64{1, 2},
+		},
 		{
 			name: "all supported params and i32 result",
 			inputFunc: func(v uintptr, w uint32, x uint64, y float32, z float64) uint32 {
@@ -1,6 +1,7 @@
 package wasm
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
@@ -16,6 +17,7 @@ func NewHostModule(
 	nameToGoFunc map[string]interface{},
 	nameToMemory map[string]*Memory,
 	nameToGlobal map[string]*Global,
+	nameToTable map[string]*Table,
 	enabledFeatures Features,
 ) (m *Module, err error) {
 	if moduleName != "" {
@@ -27,7 +29,8 @@ func NewHostModule(
 	funcCount := uint32(len(nameToGoFunc))
 	memoryCount := uint32(len(nameToMemory))
 	globalCount := uint32(len(nameToGlobal))
-	exportCount := funcCount + memoryCount + globalCount
+	tableCount := uint32(len(nameToTable))
+	exportCount := funcCount + memoryCount + globalCount + tableCount
 	if exportCount > 0 {
 		m.ExportSection = make([]*Export, 0, exportCount)
 	}
@@ -46,6 +49,17 @@ func NewHostModule(
 			return nil, fmt.Errorf("memory[%s] exports the same name as a global", name)
 		}
 	}
+	for name := range nameToTable {
+		if _, ok := nameToGoFunc[name]; ok {
+			return nil, fmt.Errorf("table[%s] exports the same name as a func", name)
+		}
+		if _, ok := nameToMemory[name]; ok {
+			return nil, fmt.Errorf("table[%s] exports the same name as a memory", name)
+		}
+		if _, ok := nameToGlobal[name]; ok {
+			return nil, fmt.Errorf("table[%s] exports the same name as a global", name)
+		}
+	}
 
 	if funcCount > 0 {
 		if err = addFuncs(m, nameToGoFunc, enabledFeatures); err != nil {
@@ -66,9 +80,51 @@ func NewHostModule(
 		}
 	}
 
+	if tableCount > 0 {
+		if err = addTables(m, nameToTable, enabledFeatures); err != nil {
+			return
+		}
+	}
+
 	// Assins the ModuleID by calculating sha256 on inputs as host modules do not have `source` to hash.
-	m.AssignModuleID([]byte(fmt.Sprintf("%s:%v:%v:%v:%v",
-		moduleName, nameToGoFunc, nameToMemory, nameToGlobal, enabledFeatures)))
+	m.AssignModuleID([]byte(fmt.Sprintf("%s:%v:%v:%v:%v:%v",
+		moduleName, nameToGoFunc, nameToMemory, nameToGlobal, nameToTable, enabledFeatures)))
+	return
+}
+
+// StructGoFuncs returns the exported methods of v that have a signature supported by NewHostModule, keyed on prefix
+// plus the method name.
+//
+// * v must be a struct or a pointer to one. Methods with a pointer receiver are only included when v is a pointer.
+//
+// Methods with unsupported signatures are skipped, and an error describing all of them is returned along with the
+// methods that were supported.
+func StructGoFuncs(prefix string, v interface{}, enabledFeatures Features) (nameToGoFunc map[string]interface{}, err error) {
+	rv := reflect.ValueOf(v)
+	if reflect.Indirect(rv).Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%T is not a struct or a non-nil pointer to one", v)
+	}
+
+	nameToGoFunc = map[string]interface{}{}
+	var skipped []string
+	for i := 0; i < rv.NumMethod(); i++ { // methods are sorted by name
+		name := rv.Type().Method(i).Name
+		fn := rv.Method(i)
+		if _, _, err = getFunctionType(&fn, enabledFeatures); err != nil {
+			skipped = append(skipped, fmt.Sprintf("func[%s] %v", name, err))
+			continue
+		}
+		nameToGoFunc[prefix+name] = fn.Interface()
+	}
+
+	switch len(skipped) {
+	case 0:
+		err = nil
+	case 1:
+		err = errors.New(skipped[0])
+	default:
+		err = fmt.Errorf("%d methods have unsupported signatures:\n\t%s", len(skipped), strings.Join(skipped, "\n\t"))
+	}
 	return
 }
 
@@ -128,7 +184,7 @@ func addMemory(m *Module, nameToMemory map[string]*Memory) error {
 		if v.Min > v.Max {
 			return fmt.Errorf("memory[%s] min %d pages (%s) > max %d pages (%s)", name, v.Min, PagesToUnitOfBytes(v.Min), v.Max, PagesToUnitOfBytes(v.Max))
 		}
-		m.MemorySection = v
+		m.MemorySection = []*Memory{v}
 	}
 
 	m.ExportSection = append(m.ExportSection, &Export{Type: ExternTypeMemory, Name: name, Index: 0})
@@ -152,6 +208,41 @@ func addGlobals(m *Module, globals map[string]*Global) error {
 	return nil
 }
 
+func addTables(m *Module, nameToTable map[string]*Table, enabledFeatures Features) error {
+	tableCount := len(nameToTable)
+	if tableCount > 1 {
+		if err := enabledFeatures.Require(FeatureReferenceTypes); err != nil {
+			return fmt.Errorf("multiple tables are invalid as %w", err)
+		}
+	}
+	m.TableSection = make([]*Table, 0, tableCount)
+
+	tableNames := make([]string, 0, tableCount)
+	for name := range nameToTable {
+		tableNames = append(tableNames, name)
+	}
+	sort.Strings(tableNames) // For consistent iteration order
+
+	for i, name := range tableNames {
+		table := nameToTable[name]
+		switch table.Type {
+		case RefTypeFuncref:
+		case RefTypeExternref:
+			if err := enabledFeatures.Require(FeatureReferenceTypes); err != nil {
+				return fmt.Errorf("table[%s] externref is invalid as %w", name, err)
+			}
+		default:
+			return fmt.Errorf("table[%s] invalid type: %s", name, ValueTypeName(table.Type))
+		}
+		if table.Max != nil && table.Min > *table.Max {
+			return fmt.Errorf("table[%s] min %d > max %d", name, table.Min, *table.Max)
+		}
+		m.TableSection = append(m.TableSection, table)
+		m.ExportSection = append(m.ExportSection, &Export{Type: ExternTypeTable, Name: name, Index: Index(i)})
+	}
+	return nil
+}
+
 func (m *Module) maybeAddType(ft *FunctionType) Index {
 	for i, t := range m.TypeSection {
 		if t.EqualsSignature(ft.Params, ft.Results) {
@@ -1,7 +1,9 @@
 package wasm
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
@@ -45,6 +47,7 @@ func TestNewHostModule(t *testing.T) {
 		nameToGoFunc     map[string]interface{}
 		nameToMemory     map[string]*Memory
 		nameToGlobal     map[string]*Global
+		nameToTable      map[string]*Table
 		expected         *Module
 	}{
 		{
@@ -101,7 +104,7 @@ func TestNewHostModule(t *testing.T) {
 			name:         "memory",
 			nameToMemory: map[string]*Memory{"memory": {Min: 1, Max: 2}},
 			expected: &Module{
-				MemorySection: &Memory{Min: 1, Max: 2},
+				MemorySection: []*Memory{{Min: 1, Max: 2}},
 				ExportSection: []*Export{{Name: "memory", Type: ExternTypeMemory, Index: 0}},
 			},
 		},
@@ -134,6 +137,14 @@ func TestNewHostModule(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "table",
+			nameToTable: map[string]*Table{"table": {Min: 1, Type: RefTypeFuncref}},
+			expected: &Module{
+				TableSection:  []*Table{{Min: 1, Type: RefTypeFuncref}},
+				ExportSection: []*Export{{Name: "table", Type: ExternTypeTable, Index: 0}},
+			},
+		},
 		{
 			name:       "one of each",
 			moduleName: "env",
@@ -161,7 +172,7 @@ func TestNewHostModule(t *testing.T) {
 						Init: &ConstantExpression{Opcode: OpcodeI32Const, Data: const1},
 					},
 				},
-				MemorySection: &Memory{Min: 1, Max: 1},
+				MemorySection: []*Memory{{Min: 1, Max: 1}},
 				ExportSection: []*Export{
 					{Name: "args_sizes_get", Type: ExternTypeFunc, Index: 0},
 					{Name: "memory", Type: ExternTypeMemory, Index: 0},
@@ -186,6 +197,7 @@ func TestNewHostModule(t *testing.T) {
 				tc.nameToGoFunc,
 				tc.nameToMemory,
 				tc.nameToGlobal,
+				tc.nameToTable,
 				Features20191205|FeatureMultiValue,
 			)
 			require.NoError(t, e)
@@ -222,6 +234,7 @@ func TestNewHostModule_Errors(t *testing.T) {
 		nameToGoFunc     map[string]interface{}
 		nameToMemory     map[string]*Memory
 		nameToGlobal     map[string]*Global
+		nameToTable      map[string]*Table
 		expectedErr      string
 	}{
 		{
@@ -251,6 +264,32 @@ func TestNewHostModule_Errors(t *testing.T) {
 			nameToMemory: map[string]*Memory{"memory": {Min: 1, Max: 0}},
 			expectedErr:  "memory[memory] min 1 pages (64 Ki) > max 0 pages (0 Ki)",
 		},
+		{
+			name:         "table collides on func name",
+			nameToGoFunc: map[string]interface{}{"fn": ArgsSizesGet},
+			nameToTable:  map[string]*Table{"fn": {Type: RefTypeFuncref}},
+			expectedErr:  "table[fn] exports the same name as a func",
+		},
+		{
+			name:        "multiple tables",
+			nameToTable: map[string]*Table{"t1": {Type: RefTypeFuncref}, "t2": {Type: RefTypeFuncref}},
+			expectedErr: `multiple tables are invalid as feature "reference-types" is disabled`,
+		},
+		{
+			name:        "externref table",
+			nameToTable: map[string]*Table{"table": {Type: RefTypeExternref}},
+			expectedErr: `table[table] externref is invalid as feature "reference-types" is disabled`,
+		},
+		{
+			name:        "table invalid type",
+			nameToTable: map[string]*Table{"table": {Type: ValueTypeI32}},
+			expectedErr: "table[table] invalid type: i32",
+		},
+		{
+			name:        "table max < min",
+			nameToTable: map[string]*Table{"table": {Min: 2, Max: uint32Ptr(1), Type: RefTypeFuncref}},
+			expectedErr: "table[table] min 2 > max 1",
+		},
 		{
 			name:         "func collides on global name",
 			nameToGoFunc: map[string]interface{}{"fn": ArgsSizesGet},
@@ -263,8 +302,78 @@ func TestNewHostModule_Errors(t *testing.T) {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			_, e := NewHostModule(tc.moduleName, tc.nameToGoFunc, tc.nameToMemory, tc.nameToGlobal, Features20191205)
+			_, e := NewHostModule(tc.moduleName, tc.nameToGoFunc, tc.nameToMemory, tc.nameToGlobal, tc.nameToTable, Features20191205)
 			require.EqualError(t, e, tc.expectedErr)
 		})
 	}
 }
+
+// mixedAPI has methods with both supported and unsupported signatures.
+type mixedAPI struct{}
+
+func (mixedAPI) Add(x, y uint32) uint32 { return x + y }
+
+func (mixedAPI) Name() string { return "mixed" }
+
+func (*mixedAPI) Swap(x, y uint32) (uint32, uint32) { return y, x }
+
+func TestStructGoFuncs(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         interface{}
+		expectedNames []string
+		expectedErr   string
+	}{
+		{
+			name:          "pointer receivers",
+			input:         &wasiAPI{},
+			expectedNames: []string{"wasi.ArgsSizesGet", "wasi.FdWrite"},
+		},
+		{
+			name:  "struct value excludes pointer receivers",
+			input: wasiAPI{},
+		},
+		{
+			name:          "unsupported signature",
+			input:         mixedAPI{},
+			expectedNames: []string{"wasi.Add"},
+			expectedErr:   "func[Name] result[0] is unsupported: string",
+		},
+		{
+			name:          "unsupported signatures",
+			input:         &mixedAPI{},
+			expectedNames: []string{"wasi.Add"},
+			expectedErr: `2 methods have unsupported signatures:
+	func[Name] result[0] is unsupported: string
+	func[Swap] multiple result types invalid as feature "multi-value" is disabled`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			nameToGoFunc, err := StructGoFuncs("wasi.", tc.input, Features20191205)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+
+			var names []string
+			for name, fn := range nameToGoFunc {
+				require.Equal(t, reflect.Func, reflect.TypeOf(fn).Kind())
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			require.Equal(t, tc.expectedNames, names)
+		})
+	}
+}
+
+func TestStructGoFuncs_Errors(t *testing.T) {
+	for _, input := range []interface{}{nil, uint32(1), (*wasiAPI)(nil), ArgsSizesGet} {
+		_, err := StructGoFuncs("", input, Features20191205)
+		require.EqualError(t, err, fmt.Sprintf("%T is not a struct or a non-nil pointer to one", input))
+	}
+}
@@ -8,9 +8,11 @@ import (
 	"math"
 	"reflect"
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 )
 
 const (
@@ -47,17 +49,55 @@ type MemoryInstance struct {
 	Min, Cap, Max uint32
 	// mux is used to prevent overlapping calls to Grow.
 	mux sync.RWMutex
+
+	// moduleName is the name of the module that defined this memory, passed to growObserver.
+	moduleName string
+	// growObserver is nil unless set via observeGrow.
+	growObserver MemoryGrowObserver
+
+	// AtomicMux is held by engines while they execute an atomic instruction on this memory, so that the instruction
+	// is indivisible with regard to others. See FeatureThreads.
+	AtomicMux sync.Mutex
+
+	// Shared is true when the memory was declared shared. The Buffer of a shared memory never grows past its Cap, so
+	// that growing it never moves the data accessed by other threads.
+	Shared bool
+
+	// waiters are the channels of the calls blocked in AtomicWait, keyed by address in the order they waited. This is
+	// guarded by AtomicMux.
+	waiters map[uint32][]chan struct{}
+
+	// hostViews is only used in debug mode. It has an entry per host function call in progress, which becomes true
+	// when that call takes a view of the Buffer via Read or Slice. See checkHostViews.
+	hostViews    []bool
+	hostViewsMux sync.Mutex
+}
+
+// MemoryGrowObserver is called after a memory grows from previousPages to newPages.
+//
+// * moduleName - the name of the module that defined the memory.
+type MemoryGrowObserver func(ctx context.Context, moduleName string, previousPages, newPages uint32)
+
+// observeGrow notifies the observer each time Grow changes the size of this memory.
+func (m *MemoryInstance) observeGrow(moduleName string, observer MemoryGrowObserver) {
+	m.moduleName = moduleName
+	m.growObserver = observer
 }
 
 // NewMemoryInstance creates a new instance based on the parameters in the SectionIDMemory.
 func NewMemoryInstance(memSec *Memory) *MemoryInstance {
+	capPages := memSec.Cap
+	if memSec.Shared && capPages > memSec.Max { // Grow fails past the capacity, so there's no use reserving more.
+		capPages = memSec.Max
+	}
 	min := MemoryPagesToBytesNum(memSec.Min)
-	capacity := MemoryPagesToBytesNum(memSec.Cap)
+	capacity := MemoryPagesToBytesNum(capPages)
 	return &MemoryInstance{
 		Buffer: make([]byte, min, capacity),
 		Min:    memSec.Min,
-		Cap:    memSec.Cap,
+		Cap:    capPages,
 		Max:    memSec.Max,
+		Shared: memSec.Shared,
 	}
 }
 
@@ -139,6 +179,26 @@ func (m *MemoryInstance) ReadFloat64Le(_ context.Context, offset uint32) (float6
 	return math.Float64frombits(v), true
 }
 
+// ReadUint32Be implements the same method as documented on api.Memory.
+func (m *MemoryInstance) ReadUint32Be(_ context.Context, offset uint32) (uint32, bool) {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
+	if !m.hasSize(offset, 4) {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(m.Buffer[offset : offset+4]), true
+}
+
+// ReadUint64Be implements the same method as documented on api.Memory.
+func (m *MemoryInstance) ReadUint64Be(_ context.Context, offset uint32) (uint64, bool) {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
+	if !m.hasSize(offset, 8) {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(m.Buffer[offset : offset+8]), true
+}
+
 // Read implements the same method as documented on api.Memory.
 func (m *MemoryInstance) Read(_ context.Context, offset, byteCount uint32) ([]byte, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
@@ -146,9 +206,17 @@ func (m *MemoryInstance) Read(_ context.Context, offset, byteCount uint32) ([]by
 	if !m.hasSize(offset, byteCount) {
 		return nil, false
 	}
+	if buildoptions.IsDebugMode {
+		m.recordHostView()
+	}
 	return m.Buffer[offset : offset+byteCount : offset+byteCount], true
 }
 
+// Slice implements the same method as documented on api.Memory.
+func (m *MemoryInstance) Slice(ctx context.Context, offset, byteCount uint32) ([]byte, bool) {
+	return m.Read(ctx, offset, byteCount)
+}
+
 // WriteByte implements the same method as documented on api.Memory.
 func (m *MemoryInstance) WriteByte(_ context.Context, offset uint32, v byte) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
@@ -198,6 +266,28 @@ func (m *MemoryInstance) WriteFloat64Le(_ context.Context, offset uint32, v floa
 	return m.writeUint64Le(offset, math.Float64bits(v))
 }
 
+// WriteUint32Be implements the same method as documented on api.Memory.
+func (m *MemoryInstance) WriteUint32Be(_ context.Context, offset, v uint32) bool {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
+	if !m.hasSize(offset, 4) {
+		return false
+	}
+	binary.BigEndian.PutUint32(m.Buffer[offset:], v)
+	return true
+}
+
+// WriteUint64Be implements the same method as documented on api.Memory.
+func (m *MemoryInstance) WriteUint64Be(_ context.Context, offset uint32, v uint64) bool {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
+	if !m.hasSize(offset, 8) {
+		return false
+	}
+	binary.BigEndian.PutUint64(m.Buffer[offset:], v)
+	return true
+}
+
 // Write implements the same method as documented on api.Memory.
 func (m *MemoryInstance) Write(_ context.Context, offset uint32, val []byte) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
@@ -215,9 +305,20 @@ func MemoryPagesToBytesNum(pages uint32) (bytesNum uint64) {
 }
 
 // Grow implements the same method as documented on api.Memory.
-func (m *MemoryInstance) Grow(_ context.Context, delta uint32) (result uint32, ok bool) {
-	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+func (m *MemoryInstance) Grow(ctx context.Context, delta uint32) (result uint32, ok bool) {
+	result, ok = m.grow(delta)
+	// The observer is called outside the lock, so that it can read the memory.
+	if ok && delta != 0 && m.growObserver != nil {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		m.growObserver(ctx, m.moduleName, result, result+delta)
+	}
+	return
+}
 
+// grow is the locked implementation of Grow, which returns the previous size in pages.
+func (m *MemoryInstance) grow(delta uint32) (result uint32, ok bool) {
 	// We take write-lock here as the following might result in a new slice
 	m.mux.Lock()
 	defer m.mux.Unlock()
@@ -232,6 +333,12 @@ func (m *MemoryInstance) Grow(_ context.Context, delta uint32) (result uint32, o
 	if newPages > m.Max {
 		return 0, false
 	} else if newPages > m.Cap { // grow the memory.
+		if m.Shared { // Other threads may be accessing the Buffer, so it can't move.
+			return 0, false
+		}
+		if buildoptions.IsDebugMode {
+			m.checkHostViews()
+		}
 		m.Buffer = append(m.Buffer, make([]byte, MemoryPagesToBytesNum(delta))...)
 		m.Cap = newPages
 		return currentPages, true
@@ -322,3 +429,111 @@ func (m *MemoryInstance) writeUint64Le(offset uint32, v uint64) bool {
 	binary.LittleEndian.PutUint64(m.Buffer[offset:], v)
 	return true
 }
+
+// enterHostCall is called in debug mode before a host function is called with this memory.
+func (m *MemoryInstance) enterHostCall() {
+	m.hostViewsMux.Lock()
+	defer m.hostViewsMux.Unlock()
+	m.hostViews = append(m.hostViews, false)
+}
+
+// exitHostCall is called in debug mode after a host function called with this memory returned or panicked.
+func (m *MemoryInstance) exitHostCall() {
+	m.hostViewsMux.Lock()
+	defer m.hostViewsMux.Unlock()
+	m.hostViews = m.hostViews[:len(m.hostViews)-1]
+}
+
+// recordHostView notes that the innermost host function call in progress, if any, took a view of the Buffer.
+func (m *MemoryInstance) recordHostView() {
+	m.hostViewsMux.Lock()
+	defer m.hostViewsMux.Unlock()
+	if n := len(m.hostViews); n > 0 {
+		m.hostViews[n-1] = true
+	}
+}
+
+// checkHostViews panics when growing is about to re-allocate the Buffer while a host function call in progress holds
+// a view of it. Such a host function called back into Wasm, which grew the memory, so its view is now stale: any
+// writes to it are lost and it doesn't see new writes. This is a subtle bug, so it is only checked in debug mode.
+func (m *MemoryInstance) checkHostViews() {
+	m.hostViewsMux.Lock()
+	defer m.hostViewsMux.Unlock()
+	for _, hasView := range m.hostViews {
+		if hasView {
+			panic(fmt.Errorf("memory of module[%s] grew while a host function held a view from Read or Slice, "+
+				"which is now stale: read it again after calling back into Wasm", m.moduleName))
+		}
+	}
+}
+
+// AtomicWait blocks the caller of "memory.atomic.wait32" or "memory.atomic.wait64" on the address offset until
+// AtomicNotify wakes it, returning 0 ("ok"). When timeout isn't negative, 2 ("timed-out") is returned after that many
+// nanoseconds instead. If ctx is done first, its error is returned.
+//
+// Note: The caller must hold AtomicMux, after reading the expected value at offset, so that no notification is missed
+// in between. This unlocks it.
+func (m *MemoryInstance) AtomicWait(ctx context.Context, offset uint32, timeout int64) (uint64, error) {
+	woken := make(chan struct{})
+	if m.waiters == nil {
+		m.waiters = map[uint32][]chan struct{}{}
+	}
+	m.waiters[offset] = append(m.waiters[offset], woken)
+	m.AtomicMux.Unlock()
+
+	var timedOut <-chan time.Time // blocks forever when nil
+	if timeout >= 0 {
+		timer := time.NewTimer(time.Duration(timeout))
+		defer timer.Stop()
+		timedOut = timer.C
+	}
+	select {
+	case <-woken:
+		return 0, nil
+	case <-timedOut:
+		if m.removeWaiter(offset, woken) {
+			return 2, nil
+		}
+	case <-ctx.Done():
+		if m.removeWaiter(offset, woken) {
+			return 0, ctx.Err()
+		}
+	}
+	return 0, nil // woken concurrently with the timeout or ctx being done
+}
+
+// removeWaiter removes the channel of a waiter at offset, or returns false if AtomicNotify already woke it.
+func (m *MemoryInstance) removeWaiter(offset uint32, woken chan struct{}) bool {
+	m.AtomicMux.Lock()
+	defer m.AtomicMux.Unlock()
+	waiters := m.waiters[offset]
+	for i, ch := range waiters {
+		if ch == woken {
+			if len(waiters) == 1 {
+				delete(m.waiters, offset)
+			} else {
+				m.waiters[offset] = append(waiters[:i:i], waiters[i+1:]...)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// AtomicNotify implements "memory.atomic.notify", waking up to count callers of AtomicWait on the address offset in
+// the order they waited. This returns the count of woken waiters, which is always zero for an unshared memory.
+func (m *MemoryInstance) AtomicNotify(offset uint32, count uint32) uint32 {
+	m.AtomicMux.Lock()
+	defer m.AtomicMux.Unlock()
+	waiters := m.waiters[offset]
+	if uint32(len(waiters)) <= count {
+		count = uint32(len(waiters))
+		delete(m.waiters, offset)
+	} else {
+		m.waiters[offset] = waiters[count:]
+	}
+	for _, woken := range waiters[:count] {
+		close(woken)
+	}
+	return count
+}
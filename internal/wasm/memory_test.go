@@ -41,6 +41,38 @@ func Test_MemoryBytesNumToPages(t *testing.T) {
 	}
 }
 
+func TestNewMemoryInstance_Shared(t *testing.T) {
+	m := NewMemoryInstance(&Memory{Min: 1, Cap: 3, Max: 4, IsMaxEncoded: true, Shared: true})
+	require.True(t, m.Shared)
+	require.Equal(t, uint32(3), m.Cap)
+	require.Equal(t, MemoryPagesToBytesNum(1), uint64(len(m.Buffer)))
+	require.Equal(t, MemoryPagesToBytesNum(3), uint64(cap(m.Buffer)))
+
+	// Growing doesn't move the buffer, which other threads may be accessing.
+	base := &m.Buffer[0]
+	previous, ok := m.Grow(testCtx, 2)
+	require.True(t, ok)
+	require.Equal(t, uint32(1), previous)
+	require.Equal(t, MemoryPagesToBytesNum(3), uint64(len(m.Buffer)))
+	require.Same(t, base, &m.Buffer[0])
+
+	// Growing past the capacity fails, even though it is within the max.
+	_, ok = m.Grow(testCtx, 1)
+	require.False(t, ok)
+	require.Same(t, base, &m.Buffer[0])
+
+	t.Run("max isn't reserved", func(t *testing.T) {
+		m := NewMemoryInstance(&Memory{Min: 1, Cap: 1, Max: MemoryLimitPages, IsMaxEncoded: true, Shared: true})
+		require.Equal(t, MemoryPagesToBytesNum(1), uint64(cap(m.Buffer)))
+	})
+
+	t.Run("capacity past max", func(t *testing.T) {
+		m := NewMemoryInstance(&Memory{Min: 1, Cap: 4, Max: 2, IsMaxEncoded: true, Shared: true})
+		require.Equal(t, uint32(2), m.Cap)
+		require.Equal(t, MemoryPagesToBytesNum(2), uint64(cap(m.Buffer)))
+	})
+}
+
 func TestMemoryInstance_Grow_Size(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -620,6 +652,73 @@ func TestMemoryInstance_WriteUint32Le(t *testing.T) {
 	}
 }
 
+func TestMemoryInstance_Slice(t *testing.T) {
+	for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
+		memory := &MemoryInstance{Buffer: make([]byte, 16)}
+
+		buf, ok := memory.Slice(ctx, 4, 8)
+		require.True(t, ok)
+		require.Equal(t, 8, cap(buf))
+		copy(buf, "wazero")
+
+		read, ok := memory.Read(ctx, 4, 6)
+		require.True(t, ok)
+		require.Equal(t, "wazero", string(read))
+
+		// Appending doesn't overwrite memory after the slice.
+		_ = append(buf, 'a')
+		require.Equal(t, byte(0), memory.Buffer[12])
+
+		_, ok = memory.Slice(ctx, 8, 9)
+		require.False(t, ok)
+		_, ok = memory.Slice(ctx, math.MaxUint32, 1)
+		require.False(t, ok)
+	}
+}
+
+func TestMemoryInstance_BigEndian(t *testing.T) {
+	for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
+		memory := &MemoryInstance{Buffer: make([]byte, 16)}
+
+		require.True(t, memory.WriteUint64Be(ctx, 0, 0x0102030405060708))
+		require.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, memory.Buffer[0:8])
+		v64, ok := memory.ReadUint64Be(ctx, 0)
+		require.True(t, ok)
+		require.Equal(t, uint64(0x0102030405060708), v64)
+
+		// Overlapping reads see the same bytes in each encoding.
+		v32, ok := memory.ReadUint32Be(ctx, 4)
+		require.True(t, ok)
+		require.Equal(t, uint32(0x05060708), v32)
+		v32, ok = memory.ReadUint32Le(ctx, 4)
+		require.True(t, ok)
+		require.Equal(t, uint32(0x08070605), v32)
+		v64, ok = memory.ReadUint64Le(ctx, 0)
+		require.True(t, ok)
+		require.Equal(t, uint64(0x0807060504030201), v64)
+
+		// Overlapping writes replace only their own bytes.
+		require.True(t, memory.WriteUint32Be(ctx, 2, 0x0a0b0c0d))
+		require.Equal(t, []byte{1, 2, 0xa, 0xb, 0xc, 0xd, 7, 8}, memory.Buffer[0:8])
+		require.True(t, memory.WriteUint32Le(ctx, 2, 0x0a0b0c0d))
+		v32, ok = memory.ReadUint32Be(ctx, 2)
+		require.True(t, ok)
+		require.Equal(t, uint32(0x0d0c0b0a), v32)
+
+		// Bounds are checked the same as the little-endian functions.
+		require.True(t, memory.WriteUint32Be(ctx, 12, 1))
+		require.False(t, memory.WriteUint32Be(ctx, 13, 1))
+		require.True(t, memory.WriteUint64Be(ctx, 8, 1))
+		require.False(t, memory.WriteUint64Be(ctx, 9, 1))
+		_, ok = memory.ReadUint32Be(ctx, 13)
+		require.False(t, ok)
+		_, ok = memory.ReadUint64Be(ctx, 9)
+		require.False(t, ok)
+		_, ok = memory.ReadUint64Be(ctx, math.MaxUint32)
+		require.False(t, ok)
+	}
+}
+
 func TestMemoryInstance_WriteUint64Le(t *testing.T) {
 	memory := &MemoryInstance{Buffer: make([]byte, 100)}
 	tests := []struct {
@@ -776,3 +875,58 @@ func TestMemoryInstance_WriteFloat64Le(t *testing.T) {
 		})
 	}
 }
+
+func TestMemoryInstance_AtomicWaitNotify(t *testing.T) {
+	// wait calls AtomicWait on offset in a goroutine and returns the channel of its result once it is registered.
+	wait := func(m *MemoryInstance, ctx context.Context, offset uint32, timeout int64) <-chan uint64 {
+		result := make(chan uint64, 1)
+		m.AtomicMux.Lock()
+		go func() {
+			v, err := m.AtomicWait(ctx, offset, timeout)
+			require.NoError(t, err)
+			result <- v
+		}()
+		m.AtomicMux.Lock() // AtomicWait unlocks after registering the waiter.
+		m.AtomicMux.Unlock()
+		return result
+	}
+
+	t.Run("notify without waiters", func(t *testing.T) {
+		m := &MemoryInstance{Shared: true}
+		require.Zero(t, m.AtomicNotify(0, 1))
+	})
+
+	t.Run("notify wakes up to count in order", func(t *testing.T) {
+		m := &MemoryInstance{Shared: true}
+		first := wait(m, testCtx, 4, -1)
+		second := wait(m, testCtx, 4, -1)
+		other := wait(m, testCtx, 8, -1)
+
+		require.Equal(t, uint32(1), m.AtomicNotify(4, 1))
+		require.Equal(t, uint64(0), <-first) // "ok"
+		require.Equal(t, uint32(1), m.AtomicNotify(4, math.MaxUint32))
+		require.Equal(t, uint64(0), <-second)
+		require.Zero(t, m.AtomicNotify(4, 1))
+
+		require.Equal(t, uint32(1), m.AtomicNotify(8, 1))
+		require.Equal(t, uint64(0), <-other)
+		require.Zero(t, len(m.waiters))
+	})
+
+	t.Run("timed-out", func(t *testing.T) {
+		m := &MemoryInstance{Shared: true}
+		require.Equal(t, uint64(2), <-wait(m, testCtx, 4, 0))
+		require.Zero(t, m.AtomicNotify(4, 1)) // the waiter was removed
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		m := &MemoryInstance{Shared: true}
+		ctx, cancel := context.WithCancel(testCtx)
+		cancel()
+
+		m.AtomicMux.Lock()
+		_, err := m.AtomicWait(ctx, 4, -1)
+		require.Equal(t, context.Canceled, err)
+		require.Zero(t, m.AtomicNotify(4, 1)) // the waiter was removed
+	})
+}
@@ -37,7 +37,7 @@ type EncodeModule func(m *Module) (bytes []byte)
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#modules%E2%91%A8
 //
 // Differences from the specification:
-// * NameSection is the only key ("name") decoded from the SectionIDCustom.
+// * NameSection is the only key ("name") decoded from the SectionIDCustom. Others are retained as CustomSections.
 // * ExportSection is represented as a map for lookup convenience.
 // * HostFunctionSection is a custom section that contains any go `func`s. It may be present when CodeSection is not.
 type Module struct {
@@ -92,16 +92,17 @@ type Module struct {
 	// MemorySection contains each memory defined in this module.
 	//
 	// Note: The memory Index namespace begins with imported memories and ends with those defined in this module.
-	// For example, if there are two imported memories and one defined in this module, the memory Index 3 is defined in
-	// this module at TableSection[0].
+	// For example, if there are two imported memories and one defined in this module, the memory Index 2 is defined in
+	// this module at MemorySection[0].
 	//
 	// Note: Version 1.0 (20191205) of the WebAssembly spec allows at most one memory definition per module, so the
-	// length of the MemorySection can be zero or one, and can only be one if there is no imported memory.
+	// length of the MemorySection can be zero or one, and can only be one if there is no imported memory. This is
+	// relaxed when FeatureMultiMemory is enabled.
 	//
 	// Note: In the Binary Format, this is SectionIDMemory.
 	//
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#memory-section%E2%91%A0
-	MemorySection *Memory
+	MemorySection []*Memory
 
 	// GlobalSection contains each global defined in this module.
 	//
@@ -148,12 +149,36 @@ type Module struct {
 	// NameSection is set when the SectionIDCustom "name" was successfully decoded from the binary format.
 	//
 	// Note: This is the only SectionIDCustom defined in the WebAssembly 1.0 (20191205) Binary Format.
-	// Others are skipped as they are not used in wazero.
+	// Others are retained as CustomSections.
 	//
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#name-section%E2%91%A0
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#custom-section%E2%91%A0
 	NameSection *NameSection
 
+	// CustomSections are any SectionIDCustom besides "name", in the order they were decoded.
+	//
+	// Note: These are not interpreted by wazero, rather retained for tools that read metadata such as ".debug_info"
+	// or "producers".
+	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#custom-section%E2%91%A0
+	CustomSections []*CustomSection
+
+	// SourceMap is index-correlated with CodeSection and contains the text format position of each instruction in
+	// the corresponding Code Body, in ascending order of offset.
+	//
+	// Note: This is only set when decoding the text format with a source map enabled, and is never encoded.
+	SourceMap [][]*SourcePosition
+
+	// DWARFLines resolves instructions in the CodeSection to the source lines they were compiled from.
+	//
+	// Note: This is only set when enabled in the RuntimeConfig and the module has DWARF custom sections.
+	DWARFLines *wasmdebug.DWARFLines
+
+	// DebugInfoEnabled is true when compiling this module should print verbose traces, such as each instruction
+	// lowered to wazeroir.
+	//
+	// Note: This is only set when enabled in the RuntimeConfig, and is never encoded.
+	DebugInfoEnabled bool
+
 	// HostFunctionSection is index-correlated with FunctionSection and contains a host function defined in Go.
 	// When present, the CodeSection must be nil.
 	//
@@ -231,11 +256,15 @@ func (m *Module) Validate(enabledFeatures Features) error {
 		return err
 	}
 
+	if err := m.validateValueTypes(enabledFeatures); err != nil {
+		return err
+	}
+
 	if m.SectionElementCount(SectionIDCode) > 0 && m.SectionElementCount(SectionIDHostFunction) > 0 {
 		return errors.New("cannot mix functions and host functions in the same module")
 	}
 
-	functions, globals, memory, tables, err := m.AllDeclarations()
+	functions, globals, memories, tables, err := m.AllDeclarations()
 	if err != nil {
 		return err
 	}
@@ -244,20 +273,20 @@ func (m *Module) Validate(enabledFeatures Features) error {
 		return err
 	}
 
-	if err = m.validateGlobals(globals, uint32(len(functions)), MaximumGlobals); err != nil {
+	if err = m.validateGlobals(enabledFeatures, globals, uint32(len(functions)), MaximumGlobals); err != nil {
 		return err
 	}
 
-	if err = m.validateMemory(memory, globals, enabledFeatures); err != nil {
+	if err = m.validateMemory(memories, globals, enabledFeatures); err != nil {
 		return err
 	}
 
-	if err = m.validateExports(enabledFeatures, functions, globals, memory, tables); err != nil {
+	if err = m.validateExports(enabledFeatures, functions, globals, memories, tables); err != nil {
 		return err
 	}
 
 	if m.CodeSection != nil {
-		if err = m.validateFunctions(enabledFeatures, functions, globals, memory, tables, MaximumFunctionIndex); err != nil {
+		if err = m.validateFunctions(enabledFeatures, functions, globals, memories, tables, MaximumFunctionIndex); err != nil {
 			return err
 		}
 	} // No need to validate host functions as NewHostModule validates
@@ -288,7 +317,50 @@ func (m *Module) validateStartSection() error {
 	return nil
 }
 
-func (m *Module) validateGlobals(globals []*GlobalType, numFuncts, maxGlobals uint32) error {
+// validateValueTypes ensures ValueTypeV128 isn't used in a signature, global or local unless FeatureSIMD is enabled.
+// This catches modules that declare vector types without using any vector instructions.
+func (m *Module) validateValueTypes(enabledFeatures Features) error {
+	err := enabledFeatures.Require(FeatureSIMD)
+	if err == nil {
+		return nil
+	}
+
+	for idx, t := range m.TypeSection {
+		if hasV128(t.Params) {
+			return fmt.Errorf("invalid type[%d] param: v128 requires WithFeatureSIMD: %w", idx, err)
+		}
+		if hasV128(t.Results) {
+			return fmt.Errorf("invalid type[%d] result: v128 requires WithFeatureSIMD: %w", idx, err)
+		}
+	}
+	for _, i := range m.ImportSection {
+		if i.Type == ExternTypeGlobal && i.DescGlobal.ValType == ValueTypeV128 {
+			return fmt.Errorf("invalid import[%q.%q] global: v128 requires WithFeatureSIMD: %w", i.Module, i.Name, err)
+		}
+	}
+	for idx, g := range m.GlobalSection {
+		if g.Type.ValType == ValueTypeV128 {
+			return fmt.Errorf("invalid global[%d]: v128 requires WithFeatureSIMD: %w", idx, err)
+		}
+	}
+	for idx, c := range m.CodeSection {
+		if hasV128(c.LocalTypes) {
+			return fmt.Errorf("invalid %s local: v128 requires WithFeatureSIMD: %w", m.funcDesc(SectionIDFunction, Index(idx)), err)
+		}
+	}
+	return nil
+}
+
+func hasV128(types []ValueType) bool {
+	for _, tp := range types {
+		if tp == ValueTypeV128 {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Module) validateGlobals(enabledFeatures Features, globals []*GlobalType, numFuncts, maxGlobals uint32) error {
 	if uint32(len(globals)) > maxGlobals {
 		return fmt.Errorf("too many globals in a module")
 	}
@@ -297,14 +369,14 @@ func (m *Module) validateGlobals(globals []*GlobalType, numFuncts, maxGlobals ui
 	// See the note on https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#constant-expressions%E2%91%A0
 	importedGlobals := globals[:m.ImportGlobalCount()]
 	for _, g := range m.GlobalSection {
-		if err := validateConstExpression(importedGlobals, numFuncts, g.Init, g.Type.ValType); err != nil {
+		if err := validateConstExpression(enabledFeatures, importedGlobals, numFuncts, g.Init, g.Type.ValType); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (m *Module) validateFunctions(enabledFeatures Features, functions []Index, globals []*GlobalType, memory *Memory, tables []*Table, maximumFunctionIndex uint32) error {
+func (m *Module) validateFunctions(enabledFeatures Features, functions []Index, globals []*GlobalType, memories []*Memory, tables []*Table, maximumFunctionIndex uint32) error {
 	if uint32(len(functions)) > maximumFunctionIndex {
 		return fmt.Errorf("too many functions in a store")
 	}
@@ -330,7 +402,7 @@ func (m *Module) validateFunctions(enabledFeatures Features, functions []Index,
 			return fmt.Errorf("invalid %s: type section index %d out of range", m.funcDesc(SectionIDFunction, Index(idx)), typeIndex)
 		}
 
-		if err := m.validateFunction(enabledFeatures, Index(idx), functions, globals, memory, tables, declaredFuncIndexes); err != nil {
+		if err := m.validateFunction(enabledFeatures, Index(idx), functions, globals, memories, tables, declaredFuncIndexes); err != nil {
 			return fmt.Errorf("invalid %s: %w", m.funcDesc(SectionIDFunction, Index(idx)), err)
 		}
 	}
@@ -404,20 +476,37 @@ func (m *Module) funcDesc(sectionID SectionID, sectionIndex Index) string {
 	return fmt.Sprintf("%s[%d] export[%s]", sectionIDName, sectionIndex, strings.Join(exportNames, ","))
 }
 
-func (m *Module) validateMemory(memory *Memory, globals []*GlobalType, enabledFeatures Features) error {
+func (m *Module) validateMemory(memories []*Memory, globals []*GlobalType, enabledFeatures Features) error {
+	if len(memories) > 1 {
+		if err := enabledFeatures.Require(FeatureMultiMemory); err != nil {
+			return fmt.Errorf("multiple memories invalid as %v", err)
+		}
+	}
+	for _, mem := range memories {
+		if !mem.Shared {
+			continue
+		}
+		if err := enabledFeatures.Require(FeatureThreads); err != nil {
+			return fmt.Errorf("shared memory invalid as %v", err)
+		}
+		if !mem.IsMaxEncoded {
+			return errors.New("shared memory must have a max")
+		}
+	}
+
 	var activeElementCount int
 	for _, sec := range m.DataSection {
 		if !sec.IsPassive() {
 			activeElementCount++
 		}
 	}
-	if activeElementCount > 0 && memory == nil {
+	if activeElementCount > 0 && len(memories) == 0 {
 		return fmt.Errorf("unknown memory")
 	}
 
 	for _, d := range m.DataSection {
 		if !d.IsPassive() {
-			if err := validateConstExpression(globals, 0, d.OffsetExpression, ValueTypeI32); err != nil {
+			if err := validateConstExpression(enabledFeatures, globals, 0, d.OffsetExpression, ValueTypeI32); err != nil {
 				return fmt.Errorf("calculate offset: %w", err)
 			}
 		}
@@ -440,8 +529,15 @@ func (m *Module) validateImports(enabledFeatures Features) error {
 	return nil
 }
 
-func (m *Module) validateExports(enabledFeatures Features, functions []Index, globals []*GlobalType, memory *Memory, tables []*Table) error {
+func (m *Module) validateExports(enabledFeatures Features, functions []Index, globals []*GlobalType, memories []*Memory, tables []*Table) error {
+	// Decoders already reject duplicate names, but check again as ModuleInstance.Exports would keep only the last.
+	names := make(map[string]struct{}, len(m.ExportSection))
 	for _, exp := range m.ExportSection {
+		if _, ok := names[exp.Name]; ok {
+			return fmt.Errorf("duplicate export %q", exp.Name)
+		}
+		names[exp.Name] = struct{}{}
+
 		index := exp.Index
 		switch exp.Type {
 		case ExternTypeFunc:
@@ -459,7 +555,7 @@ func (m *Module) validateExports(enabledFeatures Features, functions []Index, gl
 				return fmt.Errorf("invalid export[%q] global[%d]: %w", exp.Name, index, err)
 			}
 		case ExternTypeMemory:
-			if index > 0 || memory == nil {
+			if index >= uint32(len(memories)) {
 				return fmt.Errorf("memory for export[%q] out of range", exp.Name)
 			}
 		case ExternTypeTable:
@@ -471,7 +567,60 @@ func (m *Module) validateExports(enabledFeatures Features, functions []Index, gl
 	return nil
 }
 
-func validateConstExpression(globals []*GlobalType, numFuncs uint32, expr *ConstantExpression, expectedType ValueType) (err error) {
+// extendedConstInstructions returns the instructions of a constant expression allowed by FeatureExtendedConst. Data
+// holds those before the last one, the Opcode.
+func extendedConstInstructions(expr *ConstantExpression) []byte {
+	code := make([]byte, 0, len(expr.Data)+1)
+	return append(append(code, expr.Data...), expr.Opcode)
+}
+
+// validateExtendedConstExpression returns the type of a constant expression allowed by FeatureExtendedConst, after
+// checking the types of each instruction's operands.
+func validateExtendedConstExpression(globals []*GlobalType, expr *ConstantExpression) (ValueType, error) {
+	var stack []ValueType
+	r := bytes.NewReader(extendedConstInstructions(expr))
+	for r.Len() > 0 {
+		opcode, _ := r.ReadByte()
+		switch opcode {
+		case OpcodeI32Const:
+			if _, _, err := leb128.DecodeInt32(r); err != nil {
+				return 0, fmt.Errorf("read i32: %w", err)
+			}
+			stack = append(stack, ValueTypeI32)
+		case OpcodeI64Const:
+			if _, _, err := leb128.DecodeInt64(r); err != nil {
+				return 0, fmt.Errorf("read i64: %w", err)
+			}
+			stack = append(stack, ValueTypeI64)
+		case OpcodeGlobalGet:
+			id, _, err := leb128.DecodeUint32(r)
+			if err != nil {
+				return 0, fmt.Errorf("read index of global: %w", err)
+			}
+			if uint32(len(globals)) <= id {
+				return 0, fmt.Errorf("global index out of range")
+			}
+			stack = append(stack, globals[id].ValType)
+		case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+			vt := ValueTypeI32
+			if opcode >= OpcodeI64Add {
+				vt = ValueTypeI64
+			}
+			if len(stack) < 2 || stack[len(stack)-1] != vt || stack[len(stack)-2] != vt {
+				return 0, fmt.Errorf("%s requires two %s operands in const expression", InstructionName(opcode), ValueTypeName(vt))
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			return 0, fmt.Errorf("invalid opcode for const expression: 0x%x", opcode)
+		}
+	}
+	if len(stack) != 1 {
+		return 0, fmt.Errorf("const expression must produce one value, but produced %d", len(stack))
+	}
+	return stack[0], nil
+}
+
+func validateConstExpression(enabledFeatures Features, globals []*GlobalType, numFuncs uint32, expr *ConstantExpression, expectedType ValueType) (err error) {
 	var actualType ValueType
 	r := bytes.NewReader(expr.Data)
 	switch expr.Opcode {
@@ -531,6 +680,13 @@ func validateConstExpression(globals []*GlobalType, numFuncs uint32, expr *Const
 			return fmt.Errorf("%s needs 16 bytes but was %d bytes", OpcodeVecV128ConstName, len(expr.Data))
 		}
 		actualType = ValueTypeV128
+	case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+		if err = enabledFeatures.Require(FeatureExtendedConst); err != nil {
+			return fmt.Errorf("%s invalid in const expression as %v", InstructionName(expr.Opcode), err)
+		}
+		if actualType, err = validateExtendedConstExpression(globals, expr); err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("invalid opcode for const expression: 0x%x", expr.Opcode)
 	}
@@ -572,6 +728,21 @@ func (m *Module) buildGlobals(importedGlobals []*GlobalInstance) (globals []*Glo
 	return
 }
 
+// funcrefGlobalsToInitialize returns the globals built by buildGlobals which ModuleEngine.InitializeFuncrefGlobals must
+// lower: those initialized by "ref.null func" or "ref.func". A funcref global initialized by "global.get" copied the
+// value of an imported global, which was already lowered when its module was instantiated.
+func (m *Module) funcrefGlobalsToInitialize(globals []*GlobalInstance) (ret []*GlobalInstance) {
+	for i, gs := range m.GlobalSection {
+		if gs.Type.ValType != ValueTypeFuncref {
+			continue
+		}
+		if op := gs.Init.Opcode; op == OpcodeRefNull || op == OpcodeRefFunc {
+			ret = append(ret, globals[i])
+		}
+	}
+	return
+}
+
 func (m *Module) buildFunctions(moduleName string, fnlf experimental.FunctionListenerFactory) (functions []*FunctionInstance) {
 	var functionNames NameMap
 	var localNames IndirectNameMap
@@ -642,10 +813,9 @@ func paramNames(localNames IndirectNameMap, funcIdx uint32, paramLen int) []stri
 	return nil
 }
 
-func (m *Module) buildMemory() (mem *MemoryInstance) {
-	memSec := m.MemorySection
-	if memSec != nil {
-		mem = NewMemoryInstance(memSec)
+func (m *Module) buildMemories() (memories []*MemoryInstance) {
+	for _, mem := range m.MemorySection {
+		memories = append(memories, NewMemoryInstance(mem))
 	}
 	return
 }
@@ -754,6 +924,9 @@ type Memory struct {
 	Min, Cap, Max uint32
 	// IsMaxEncoded true if the Max is encoded in the original source (binary or text).
 	IsMaxEncoded bool
+	// Shared is true if the memory can be accessed by multiple threads, which requires FeatureThreads and an encoded
+	// Max. See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md#shared-linear-memory
+	Shared bool
 }
 
 // Validate ensures values assigned to Min, Cap and Max are within valid thresholds.
@@ -817,6 +990,12 @@ type Code struct {
 	// Body is a sequence of expressions ending in OpcodeEnd
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-expr
 	Body []byte
+
+	// BodyOffsetInCodeSection is the offset of the beginning of the Body, relative to the code section contents. This
+	// is used to translate a program counter in Body to an address in DWARF debug information.
+	//
+	// Note: This is only set when decoding the binary format with DWARF enabled, and is never encoded.
+	BodyOffsetInCodeSection uint64
 }
 
 type DataSegment struct {
@@ -833,6 +1012,79 @@ func (d *DataSegment) IsPassive() bool {
 	return d.OffsetExpression == nil
 }
 
+// CustomSection returns the data of the CustomSections with the given name, or nil if there is none.
+func (m *Module) CustomSection(name string) []byte {
+	for _, s := range m.CustomSections {
+		if s.Name == name {
+			return s.Data
+		}
+	}
+	return nil
+}
+
+// CustomSection is a SectionIDCustom other than "name", which is retained as raw data.
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#custom-section%E2%91%A0
+type CustomSection struct {
+	// Name is the name of the custom section. Ex. "producers"
+	Name string
+
+	// Data is the content of the custom section, following its name.
+	Data []byte
+}
+
+// SourcePosition is the text format line and column of the instruction that begins at Offset in a Code Body.
+type SourcePosition struct {
+	// Offset is the position of the instruction's opcode in Code.Body.
+	Offset uint64
+
+	// Line and Col are the one-based position of the instruction in the text format source.
+	Line, Col uint32
+}
+
+// SourcePosition returns the text format position of the instruction containing the offset pc in the body of funcIdx,
+// or false if the function has no SourceMap.
+//
+// * funcIdx is in the function namespace, where module defined functions are preceded by imported ones.
+// * pc is an offset in the Code.Body of that function.
+func (m *Module) SourcePosition(funcIdx Index, pc uint64) (*SourcePosition, bool) {
+	importCount := m.ImportFuncCount()
+	if funcIdx < importCount {
+		return nil, false
+	}
+	codeIdx := funcIdx - importCount
+	if int(codeIdx) >= len(m.SourceMap) {
+		return nil, false
+	}
+
+	positions := m.SourceMap[codeIdx]
+	// Find the last instruction which begins at or before pc.
+	i := sort.Search(len(positions), func(i int) bool { return positions[i].Offset > pc })
+	if i == 0 {
+		return nil, false
+	}
+	return positions[i-1], true
+}
+
+// DWARFLine returns the source file, line and column of the instruction containing the offset pc in the body of funcIdx,
+// or false if unknown per DWARFLines.
+//
+// * funcIdx is in the function namespace, where module defined functions are preceded by imported ones.
+// * pc is an offset in the Code.Body of that function.
+func (m *Module) DWARFLine(funcIdx Index, pc uint64) (file string, line, col uint32, ok bool) {
+	if m.DWARFLines == nil {
+		return
+	}
+	importCount := m.ImportFuncCount()
+	if funcIdx < importCount {
+		return
+	}
+	codeIdx := funcIdx - importCount
+	if int(codeIdx) >= len(m.CodeSection) {
+		return
+	}
+	return m.DWARFLines.Line(m.CodeSection[codeIdx].BodyOffsetInCodeSection + pc)
+}
+
 // NameSection represent the known custom name subsections defined in the WebAssembly Binary Format
 //
 // Note: This can be nil if no names were decoded for any reason including configuration.
@@ -867,6 +1119,25 @@ type NameSection struct {
 	LocalNames IndirectNameMap
 }
 
+// LocalName returns the symbolic identifier of a local (including parameters) in a function, or false if it has none.
+//
+// * funcIdx is in the function namespace, where module defined functions are preceded by imported ones.
+// * localIdx is in the local namespace of that function, where locals are preceded by parameters.
+func (n *NameSection) LocalName(funcIdx, localIdx Index) (string, bool) {
+	for _, nm := range n.LocalNames {
+		if nm.Index != funcIdx {
+			continue
+		}
+		for _, na := range nm.NameMap {
+			if na.Index == localIdx {
+				return na.Name, true
+			}
+		}
+		return "", false
+	}
+	return "", false
+}
+
 // NameMap associates an index with any associated names.
 //
 // Note: Often the index namespace bridges multiple sections. For example, the function index namespace starts with any
@@ -895,7 +1166,7 @@ type NameMapAssoc struct {
 }
 
 // AllDeclarations returns all declarations for functions, globals, memories and tables in a module including imported ones.
-func (m *Module) AllDeclarations() (functions []Index, globals []*GlobalType, memory *Memory, tables []*Table, err error) {
+func (m *Module) AllDeclarations() (functions []Index, globals []*GlobalType, memories []*Memory, tables []*Table, err error) {
 	for _, imp := range m.ImportSection {
 		switch imp.Type {
 		case ExternTypeFunc:
@@ -903,7 +1174,7 @@ func (m *Module) AllDeclarations() (functions []Index, globals []*GlobalType, me
 		case ExternTypeGlobal:
 			globals = append(globals, imp.DescGlobal)
 		case ExternTypeMemory:
-			memory = imp.DescMem
+			memories = append(memories, imp.DescMem)
 		case ExternTypeTable:
 			tables = append(tables, imp.DescTable)
 		}
@@ -913,13 +1184,7 @@ func (m *Module) AllDeclarations() (functions []Index, globals []*GlobalType, me
 	for _, g := range m.GlobalSection {
 		globals = append(globals, g.Type)
 	}
-	if m.MemorySection != nil {
-		if memory != nil { // shouldn't be possible due to Validate
-			err = errors.New("at most one table allowed in module")
-			return
-		}
-		memory = m.MemorySection
-	}
+	memories = append(memories, m.MemorySection...)
 	if m.TableSection != nil {
 		tables = append(tables, m.TableSection...)
 	}
@@ -1001,22 +1266,17 @@ func SectionIDName(sectionID SectionID) string {
 type ValueType = api.ValueType
 
 const (
-	ValueTypeI32  = api.ValueTypeI32
-	ValueTypeI64  = api.ValueTypeI64
-	ValueTypeF32  = api.ValueTypeF32
-	ValueTypeF64  = api.ValueTypeF64
-	ValueTypeV128 = api.ValueTypeV128
-	// TODO: ValueTypeFuncref is not exposed in the api pkg yet.
-	ValueTypeFuncref   ValueType = 0x70
-	ValueTypeExternref ValueType = api.ValueTypeExternref
+	ValueTypeI32       = api.ValueTypeI32
+	ValueTypeI64       = api.ValueTypeI64
+	ValueTypeF32       = api.ValueTypeF32
+	ValueTypeF64       = api.ValueTypeF64
+	ValueTypeV128      = api.ValueTypeV128
+	ValueTypeFuncref   = api.ValueTypeFuncref
+	ValueTypeExternref = api.ValueTypeExternref
 )
 
 // ValueTypeName is an alias of api.ValueTypeName defined to simplify imports.
 func ValueTypeName(t ValueType) string {
-	if t == ValueTypeFuncref {
-		// TODO: funcref is not exposed in the API pkg yet.
-		return "funcref"
-	}
 	return api.ValueTypeName(t)
 }
 
@@ -124,7 +124,7 @@ func TestModule_allDeclarations(t *testing.T) {
 		module            *Module
 		expectedFunctions []Index
 		expectedGlobals   []*GlobalType
-		expectedMemory    *Memory
+		expectedMemories  []*Memory
 		expectedTables    []*Table
 	}{
 		// Functions.
@@ -172,13 +172,20 @@ func TestModule_allDeclarations(t *testing.T) {
 			module: &Module{
 				ImportSection: []*Import{{Type: ExternTypeMemory, DescMem: &Memory{Min: 1, Max: 10}}},
 			},
-			expectedMemory: &Memory{Min: 1, Max: 10},
+			expectedMemories: []*Memory{{Min: 1, Max: 10}},
 		},
 		{
 			module: &Module{
-				MemorySection: &Memory{Min: 100},
+				MemorySection: []*Memory{{Min: 100}},
 			},
-			expectedMemory: &Memory{Min: 100},
+			expectedMemories: []*Memory{{Min: 100}},
+		},
+		{
+			module: &Module{
+				ImportSection: []*Import{{Type: ExternTypeMemory, DescMem: &Memory{Min: 1, Max: 10}}},
+				MemorySection: []*Memory{{Min: 100}},
+			},
+			expectedMemories: []*Memory{{Min: 1, Max: 10}, {Min: 100}},
 		},
 		// Tables.
 		{
@@ -196,12 +203,12 @@ func TestModule_allDeclarations(t *testing.T) {
 	} {
 		tc := tc
 		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
-			functions, globals, memory, tables, err := tc.module.AllDeclarations()
+			functions, globals, memories, tables, err := tc.module.AllDeclarations()
 			require.NoError(t, err)
 			require.Equal(t, tc.expectedFunctions, functions)
 			require.Equal(t, tc.expectedGlobals, globals)
 			require.Equal(t, tc.expectedTables, tables)
-			require.Equal(t, tc.expectedMemory, memory)
+			require.Equal(t, tc.expectedMemories, memories)
 		})
 	}
 }
@@ -209,7 +216,7 @@ func TestModule_allDeclarations(t *testing.T) {
 func TestValidateConstExpression(t *testing.T) {
 	t.Run("invalid opcode", func(t *testing.T) {
 		expr := &ConstantExpression{Opcode: OpcodeNop}
-		err := validateConstExpression(nil, 0, expr, valueTypeUnknown)
+		err := validateConstExpression(Features20191205, nil, 0, expr, valueTypeUnknown)
 		require.Error(t, err)
 	})
 	for _, vt := range []ValueType{ValueTypeI32, ValueTypeI64, ValueTypeF32, ValueTypeF64} {
@@ -231,7 +238,7 @@ func TestValidateConstExpression(t *testing.T) {
 					expr.Opcode = OpcodeF64Const
 				}
 
-				err := validateConstExpression(nil, 0, expr, vt)
+				err := validateConstExpression(Features20191205, nil, 0, expr, vt)
 				require.NoError(t, err)
 			})
 			t.Run("invalid", func(t *testing.T) {
@@ -247,7 +254,7 @@ func TestValidateConstExpression(t *testing.T) {
 				case ValueTypeF64:
 					expr.Opcode = OpcodeF64Const
 				}
-				err := validateConstExpression(nil, 0, expr, vt)
+				err := validateConstExpression(Features20191205, nil, 0, expr, vt)
 				require.Error(t, err)
 			})
 		})
@@ -255,38 +262,113 @@ func TestValidateConstExpression(t *testing.T) {
 	t.Run("ref types", func(t *testing.T) {
 		t.Run("ref.func", func(t *testing.T) {
 			expr := &ConstantExpression{Data: []byte{5}, Opcode: OpcodeRefFunc}
-			err := validateConstExpression(nil, 10, expr, ValueTypeFuncref)
+			err := validateConstExpression(Features20191205, nil, 10, expr, ValueTypeFuncref)
 			require.NoError(t, err)
-			err = validateConstExpression(nil, 2, expr, ValueTypeFuncref)
+			err = validateConstExpression(Features20191205, nil, 2, expr, ValueTypeFuncref)
 			require.EqualError(t, err, "ref.func index out of range [5] with length 1")
 		})
 		t.Run("ref.null", func(t *testing.T) {
-			err := validateConstExpression(nil, 0,
+			err := validateConstExpression(Features20191205, nil, 0,
 				&ConstantExpression{Data: []byte{ValueTypeFuncref}, Opcode: OpcodeRefNull},
 				ValueTypeFuncref)
 			require.NoError(t, err)
-			err = validateConstExpression(nil, 0,
+			err = validateConstExpression(Features20191205, nil, 0,
 				&ConstantExpression{Data: []byte{ValueTypeExternref}, Opcode: OpcodeRefNull},
 				ValueTypeExternref)
 			require.NoError(t, err)
-			err = validateConstExpression(nil, 0,
+			err = validateConstExpression(Features20191205, nil, 0,
 				&ConstantExpression{Data: []byte{0xff}, Opcode: OpcodeRefNull},
 				ValueTypeExternref)
 			require.EqualError(t, err, "invalid type for ref.null: 0xff")
 		})
 	})
+	t.Run("extended const", func(t *testing.T) {
+		globals := []*GlobalType{{ValType: ValueTypeI32}, {ValType: ValueTypeI64}}
+		for _, tc := range []struct {
+			name        string
+			expr        *ConstantExpression
+			expected    ValueType
+			expectedErr string
+		}{
+			{
+				name:     "i32.add",
+				expr:     &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeI32Const, 1, OpcodeI32Const, 2}},
+				expected: ValueTypeI32,
+			},
+			{
+				name:     "i64.mul of global",
+				expr:     &ConstantExpression{Opcode: OpcodeI64Mul, Data: []byte{OpcodeGlobalGet, 1, OpcodeI64Const, 2}},
+				expected: ValueTypeI64,
+			},
+			{
+				name:        "result type mismatch",
+				expr:        &ConstantExpression{Opcode: OpcodeI32Sub, Data: []byte{OpcodeI32Const, 1, OpcodeI32Const, 2}},
+				expected:    ValueTypeI64,
+				expectedErr: "const expression type mismatch expected i64 but got i32",
+			},
+			{
+				name:        "operand type mismatch",
+				expr:        &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeGlobalGet, 1, OpcodeI32Const, 2}},
+				expected:    ValueTypeI32,
+				expectedErr: "i32.add requires two i32 operands in const expression",
+			},
+			{
+				name:        "missing operand",
+				expr:        &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeI32Const, 2}},
+				expected:    ValueTypeI32,
+				expectedErr: "i32.add requires two i32 operands in const expression",
+			},
+			{
+				name: "too many values",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI32Add,
+					Data:   []byte{OpcodeI32Const, 1, OpcodeI32Const, 2, OpcodeI32Const, 3},
+				},
+				expected:    ValueTypeI32,
+				expectedErr: "const expression must produce one value, but produced 2",
+			},
+			{
+				name:        "global index out of range",
+				expr:        &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeGlobalGet, 2, OpcodeI32Const, 2}},
+				expected:    ValueTypeI32,
+				expectedErr: "global index out of range",
+			},
+			{
+				name:        "invalid opcode",
+				expr:        &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeRefNull, RefTypeFuncref}},
+				expected:    ValueTypeI32,
+				expectedErr: "invalid opcode for const expression: 0xd0",
+			},
+		} {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				err := validateConstExpression(FeatureExtendedConst, globals, 0, tc.expr, tc.expected)
+				if tc.expectedErr == "" {
+					require.NoError(t, err)
+				} else {
+					require.EqualError(t, err, tc.expectedErr)
+				}
+			})
+		}
+
+		t.Run("disabled", func(t *testing.T) {
+			expr := &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeI32Const, 1, OpcodeI32Const, 2}}
+			err := validateConstExpression(Features20191205, globals, 0, expr, ValueTypeI32)
+			require.EqualError(t, err, `i32.add invalid in const expression as feature "extended-const" is disabled`)
+		})
+	})
 	t.Run("global expr", func(t *testing.T) {
 		t.Run("failed to read global index", func(t *testing.T) {
 			// Empty data for global index is invalid.
 			expr := &ConstantExpression{Data: make([]byte, 0), Opcode: OpcodeGlobalGet}
-			err := validateConstExpression(nil, 0, expr, valueTypeUnknown)
+			err := validateConstExpression(Features20191205, nil, 0, expr, valueTypeUnknown)
 			require.Error(t, err)
 		})
 		t.Run("global index out of range", func(t *testing.T) {
 			// Data holds the index in leb128 and this time the value exceeds len(globals) (=0).
 			expr := &ConstantExpression{Data: []byte{1}, Opcode: OpcodeGlobalGet}
 			var globals []*GlobalType
-			err := validateConstExpression(globals, 0, expr, valueTypeUnknown)
+			err := validateConstExpression(Features20191205, globals, 0, expr, valueTypeUnknown)
 			require.Error(t, err)
 		})
 
@@ -299,7 +381,7 @@ func TestValidateConstExpression(t *testing.T) {
 					expr := &ConstantExpression{Data: []byte{0}, Opcode: OpcodeGlobalGet}
 					globals := []*GlobalType{{ValType: valueTypeUnknown}}
 
-					err := validateConstExpression(globals, 0, expr, vt)
+					err := validateConstExpression(Features20191205, globals, 0, expr, vt)
 					require.Error(t, err)
 				})
 			}
@@ -313,7 +395,7 @@ func TestValidateConstExpression(t *testing.T) {
 					expr := &ConstantExpression{Data: []byte{0}, Opcode: OpcodeGlobalGet}
 					globals := []*GlobalType{{ValType: vt}}
 
-					err := validateConstExpression(globals, 0, expr, vt)
+					err := validateConstExpression(Features20191205, globals, 0, expr, vt)
 					require.NoError(t, err)
 				})
 			}
@@ -403,7 +485,7 @@ func TestModule_validateStartSection(t *testing.T) {
 func TestModule_validateGlobals(t *testing.T) {
 	t.Run("too many globals", func(t *testing.T) {
 		m := Module{}
-		err := m.validateGlobals(make([]*GlobalType, 10), 0, 9)
+		err := m.validateGlobals(Features20191205, make([]*GlobalType, 10), 0, 9)
 		require.Error(t, err)
 		require.EqualError(t, err, "too many globals in a module")
 	})
@@ -415,7 +497,7 @@ func TestModule_validateGlobals(t *testing.T) {
 				Init: &ConstantExpression{Opcode: OpcodeGlobalGet, Data: []byte{1}},
 			},
 		}}
-		err := m.validateGlobals(nil, 0, 9)
+		err := m.validateGlobals(Features20191205, nil, 0, 9)
 		require.Error(t, err)
 		require.EqualError(t, err, "global index out of range")
 	})
@@ -426,7 +508,7 @@ func TestModule_validateGlobals(t *testing.T) {
 				Init: &ConstantExpression{Opcode: OpcodeUnreachable},
 			},
 		}}
-		err := m.validateGlobals(nil, 0, 9)
+		err := m.validateGlobals(Features20191205, nil, 0, 9)
 		require.Error(t, err)
 		require.EqualError(t, err, "invalid opcode for const expression: 0x0")
 	})
@@ -437,7 +519,7 @@ func TestModule_validateGlobals(t *testing.T) {
 				Init: &ConstantExpression{Opcode: OpcodeI32Const, Data: const0},
 			},
 		}}
-		err := m.validateGlobals(nil, 0, 9)
+		err := m.validateGlobals(Features20191205, nil, 0, 9)
 		require.NoError(t, err)
 	})
 	t.Run("ok with imported global", func(t *testing.T) {
@@ -455,7 +537,7 @@ func TestModule_validateGlobals(t *testing.T) {
 			{ValType: ValueTypeI32}, // Imported one.
 			nil,                     // the local one trying to validate.
 		}
-		err := m.validateGlobals(globalDeclarations, 0, 9)
+		err := m.validateGlobals(Features20191205, globalDeclarations, 0, 9)
 		require.NoError(t, err)
 	})
 }
@@ -506,6 +588,32 @@ func TestModule_validateFunctions(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "invalid function[0]: cannot pop the 1st f32 operand")
 	})
+	t.Run("ref.func undeclared", func(t *testing.T) {
+		m := Module{
+			TypeSection:     []*FunctionType{{}},
+			FunctionSection: []Index{0, 0},
+			CodeSection: []*Code{
+				{Body: []byte{OpcodeRefFunc, 1, OpcodeDrop, OpcodeEnd}},
+				{Body: []byte{OpcodeEnd}},
+			},
+		}
+		// Function 1 exists, but isn't declared by an element segment, global or export.
+		err := m.validateFunctions(FeatureReferenceTypes, []Index{0, 0}, nil, nil, nil, MaximumFunctionIndex)
+		require.EqualError(t, err, "invalid function[0]: undeclared function index 1 for ref.func")
+	})
+	t.Run("ref.func declared by a declarative element segment", func(t *testing.T) {
+		m := Module{
+			TypeSection:     []*FunctionType{{}},
+			FunctionSection: []Index{0, 0},
+			CodeSection: []*Code{
+				{Body: []byte{OpcodeRefFunc, 1, OpcodeDrop, OpcodeEnd}},
+				{Body: []byte{OpcodeEnd}},
+			},
+			ElementSection: []*ElementSegment{{Mode: ElementModeDeclarative, Init: []*Index{uint32Ptr(1)}}},
+		}
+		err := m.validateFunctions(FeatureReferenceTypes, []Index{0, 0}, nil, nil, nil, MaximumFunctionIndex)
+		require.NoError(t, err)
+	})
 	t.Run("in- exported", func(t *testing.T) {
 		m := Module{
 			TypeSection:     []*FunctionType{{}},
@@ -558,7 +666,7 @@ func TestModule_validateMemory(t *testing.T) {
 				Opcode: OpcodeUnreachable, // Invalid!
 			},
 		}}}
-		err := m.validateMemory(&Memory{}, nil, Features20191205)
+		err := m.validateMemory([]*Memory{{}}, nil, Features20191205)
 		require.EqualError(t, err, "calculate offset: invalid opcode for const expression: 0x0")
 	})
 	t.Run("ok", func(t *testing.T) {
@@ -569,9 +677,31 @@ func TestModule_validateMemory(t *testing.T) {
 				Data:   leb128.EncodeInt32(1),
 			},
 		}}}
-		err := m.validateMemory(&Memory{}, nil, Features20191205)
+		err := m.validateMemory([]*Memory{{}}, nil, Features20191205)
+		require.NoError(t, err)
+	})
+	t.Run("multiple memories", func(t *testing.T) {
+		m := Module{}
+		err := m.validateMemory([]*Memory{{}, {}}, nil, Features20220419)
+		require.EqualError(t, err, `multiple memories invalid as feature "multi-memory" is disabled`)
+
+		err = m.validateMemory([]*Memory{{}, {}}, nil, Features20220419|FeatureMultiMemory)
 		require.NoError(t, err)
 	})
+	t.Run("shared memory", func(t *testing.T) {
+		m := Module{}
+		shared := &Memory{Min: 1, Max: 2, IsMaxEncoded: true, Shared: true}
+		err := m.validateMemory([]*Memory{shared}, nil, Features20220419)
+		require.EqualError(t, err, `shared memory invalid as feature "threads" is disabled`)
+
+		err = m.validateMemory([]*Memory{shared}, nil, Features20220419|FeatureThreads)
+		require.NoError(t, err)
+	})
+	t.Run("shared memory without max", func(t *testing.T) {
+		m := Module{}
+		err := m.validateMemory([]*Memory{{Min: 1, Max: MemoryLimitPages, Shared: true}}, nil, FeatureThreads)
+		require.EqualError(t, err, "shared memory must have a max")
+	})
 }
 
 func TestModule_validateImports(t *testing.T) {
@@ -635,6 +765,57 @@ func TestModule_validateImports(t *testing.T) {
 	}
 }
 
+func TestModule_validateValueTypes(t *testing.T) {
+	v128 := []ValueType{ValueTypeV128}
+	for _, tc := range []struct {
+		name        string
+		m           *Module
+		expectedErr string
+	}{
+		{
+			name:        "param",
+			m:           &Module{TypeSection: []*FunctionType{{Params: v128}}},
+			expectedErr: `invalid type[0] param: v128 requires WithFeatureSIMD: feature "simd" is disabled`,
+		},
+		{
+			name:        "result",
+			m:           &Module{TypeSection: []*FunctionType{{}, {Results: v128}}},
+			expectedErr: `invalid type[1] result: v128 requires WithFeatureSIMD: feature "simd" is disabled`,
+		},
+		{
+			name: "imported global",
+			m: &Module{ImportSection: []*Import{
+				{Module: "m", Name: "n", Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: ValueTypeV128}},
+			}},
+			expectedErr: `invalid import["m"."n"] global: v128 requires WithFeatureSIMD: feature "simd" is disabled`,
+		},
+		{
+			name: "global",
+			m: &Module{GlobalSection: []*Global{
+				{Type: &GlobalType{ValType: ValueTypeV128}, Init: &ConstantExpression{Opcode: OpcodeVecV128Const, Data: make([]byte, 16)}},
+			}},
+			expectedErr: `invalid global[0]: v128 requires WithFeatureSIMD: feature "simd" is disabled`,
+		},
+		{
+			name: "local",
+			m: &Module{
+				TypeSection:     []*FunctionType{{}},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{LocalTypes: []ValueType{ValueTypeI32, ValueTypeV128}, Body: []byte{OpcodeEnd}}},
+			},
+			expectedErr: `invalid function[0] local: v128 requires WithFeatureSIMD: feature "simd" is disabled`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.m.validateValueTypes(Features20191205)
+			require.EqualError(t, err, tc.expectedErr)
+
+			require.NoError(t, tc.m.validateValueTypes(Features20191205.Set(FeatureSIMD, true)))
+		})
+	}
+}
+
 func TestModule_validateExports(t *testing.T) {
 	for _, tc := range []struct {
 		name            string
@@ -642,7 +823,7 @@ func TestModule_validateExports(t *testing.T) {
 		exportSection   []*Export
 		functions       []Index
 		globals         []*GlobalType
-		memory          *Memory
+		memories        []*Memory
 		tables          []*Table
 		expectedErr     string
 	}{
@@ -660,6 +841,17 @@ func TestModule_validateExports(t *testing.T) {
 			functions:       []Index{100 /* arbitrary type id*/},
 			expectedErr:     `unknown function for export["e"]`,
 		},
+		{
+			name:            "duplicate name",
+			enabledFeatures: Features20191205,
+			exportSection: []*Export{
+				{Type: ExternTypeFunc, Index: 0, Name: "e"},
+				{Type: ExternTypeGlobal, Index: 0, Name: "e"},
+			},
+			functions:   []Index{100 /* arbitrary type id*/},
+			globals:     []*GlobalType{{ValType: ValueTypeI32}},
+			expectedErr: `duplicate export "e"`,
+		},
 		{
 			name:            "global const",
 			enabledFeatures: Features20191205,
@@ -695,7 +887,7 @@ func TestModule_validateExports(t *testing.T) {
 		{
 			name:            "multiple tables",
 			enabledFeatures: Features20191205,
-			exportSection:   []*Export{{Type: ExternTypeTable, Index: 0}, {Type: ExternTypeTable, Index: 1}, {Type: ExternTypeTable, Index: 2}},
+			exportSection:   []*Export{{Type: ExternTypeTable, Index: 0, Name: "t0"}, {Type: ExternTypeTable, Index: 1, Name: "t1"}, {Type: ExternTypeTable, Index: 2, Name: "t2"}},
 			tables:          []*Table{{}, {}, {}},
 		},
 		{
@@ -709,7 +901,13 @@ func TestModule_validateExports(t *testing.T) {
 			name:            "memory",
 			enabledFeatures: Features20191205,
 			exportSection:   []*Export{{Type: ExternTypeMemory, Index: 0}},
-			memory:          &Memory{},
+			memories:        []*Memory{{}},
+		},
+		{
+			name:            "multiple memories",
+			enabledFeatures: Features20191205 | FeatureMultiMemory,
+			exportSection:   []*Export{{Type: ExternTypeMemory, Index: 0, Name: "m0"}, {Type: ExternTypeMemory, Index: 1, Name: "m1"}},
+			memories:        []*Memory{{}, {}},
 		},
 		{
 			name:            "memory out of range",
@@ -722,7 +920,7 @@ func TestModule_validateExports(t *testing.T) {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			m := Module{ExportSection: tc.exportSection}
-			err := m.validateExports(tc.enabledFeatures, tc.functions, tc.globals, tc.memory, tc.tables)
+			err := m.validateExports(tc.enabledFeatures, tc.functions, tc.globals, tc.memories, tc.tables)
 			if tc.expectedErr != "" {
 				require.EqualError(t, err, tc.expectedErr)
 			} else {
@@ -764,8 +962,26 @@ func TestModule_buildGlobals(t *testing.T) {
 	require.Equal(t, expectedGlobals, globals)
 }
 
+func TestModule_funcrefGlobalsToInitialize(t *testing.T) {
+	funcref := &GlobalType{ValType: ValueTypeFuncref}
+	m := Module{GlobalSection: []*Global{
+		{Type: funcref, Init: &ConstantExpression{Opcode: OpcodeRefNull, Data: []byte{RefTypeFuncref}}},
+		{Type: funcref, Init: &ConstantExpression{Opcode: OpcodeRefFunc, Data: []byte{0}}},
+		{Type: funcref, Init: &ConstantExpression{Opcode: OpcodeGlobalGet, Data: []byte{0}}},
+		{Type: &GlobalType{ValType: ValueTypeI32}, Init: &ConstantExpression{Opcode: OpcodeI32Const, Data: const0}},
+	}}
+
+	// The imported funcref global was already lowered to the null reference, zero.
+	imported := []*GlobalInstance{{Type: funcref, Val: 0}}
+	globals := m.buildGlobals(imported)
+	require.Equal(t, uint64(0), globals[2].Val)
+
+	// Only the globals initialized by ref.null and ref.func need to be lowered by the engine.
+	require.Equal(t, []*GlobalInstance{globals[0], globals[1]}, m.funcrefGlobalsToInitialize(globals))
+}
+
 func TestModule_buildFunctions(t *testing.T) {
-	nopCode := &Code{nil, []byte{OpcodeEnd}}
+	nopCode := &Code{Body: []byte{OpcodeEnd}}
 	m := Module{
 		TypeSection:   []*FunctionType{{}},
 		ImportSection: []*Import{{Type: ExternTypeFunc}},
@@ -789,19 +1005,27 @@ func TestModule_buildFunctions(t *testing.T) {
 	}
 }
 
-func TestModule_buildMemoryInstance(t *testing.T) {
+func TestModule_buildMemories(t *testing.T) {
 	t.Run("nil", func(t *testing.T) {
 		m := Module{}
-		mem := m.buildMemory()
-		require.Nil(t, mem)
+		memories := m.buildMemories()
+		require.Nil(t, memories)
 	})
 	t.Run("non-nil", func(t *testing.T) {
 		min := uint32(1)
 		max := uint32(10)
-		m := Module{MemorySection: &Memory{Min: min, Cap: min, Max: max}}
-		mem := m.buildMemory()
-		require.Equal(t, min, mem.Min)
-		require.Equal(t, max, mem.Max)
+		m := Module{MemorySection: []*Memory{{Min: min, Cap: min, Max: max}}}
+		memories := m.buildMemories()
+		require.Equal(t, 1, len(memories))
+		require.Equal(t, min, memories[0].Min)
+		require.Equal(t, max, memories[0].Max)
+	})
+	t.Run("multiple", func(t *testing.T) {
+		m := Module{MemorySection: []*Memory{{Min: 1, Cap: 1, Max: 1}, {Min: 2, Cap: 2, Max: 2}}}
+		memories := m.buildMemories()
+		require.Equal(t, 2, len(memories))
+		require.Equal(t, uint32(1), memories[0].Min)
+		require.Equal(t, uint32(2), memories[1].Min)
 	})
 }
 
@@ -839,6 +1063,71 @@ func TestModule_validateDataCountSection(t *testing.T) {
 	})
 }
 
+func TestNameSection_LocalName(t *testing.T) {
+	n := &NameSection{LocalNames: IndirectNameMap{
+		{Index: 1, NameMap: NameMap{{Index: 0, Name: "x"}, {Index: 2, Name: "z"}}},
+		{Index: 3, NameMap: NameMap{{Index: 0, Name: "a"}}},
+	}}
+
+	tests := []struct {
+		name              string
+		funcIdx, localIdx Index
+		expected          string
+		expectedOk        bool
+	}{
+		{name: "first local", funcIdx: 1, localIdx: 0, expected: "x", expectedOk: true},
+		{name: "sparse local", funcIdx: 1, localIdx: 2, expected: "z", expectedOk: true},
+		{name: "unnamed local", funcIdx: 1, localIdx: 1},
+		{name: "later function", funcIdx: 3, localIdx: 0, expected: "a", expectedOk: true},
+		{name: "unnamed function", funcIdx: 2, localIdx: 0},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			name, ok := n.LocalName(tc.funcIdx, tc.localIdx)
+			require.Equal(t, tc.expectedOk, ok)
+			require.Equal(t, tc.expected, name)
+		})
+	}
+}
+
+func TestModule_SourcePosition(t *testing.T) {
+	m := &Module{
+		ImportSection: []*Import{{Type: ExternTypeFunc}},
+		SourceMap: [][]*SourcePosition{
+			nil,
+			{{Offset: 1, Line: 3, Col: 5}, {Offset: 3, Line: 3, Col: 17}, {Offset: 8, Line: 4, Col: 5}},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		funcIdx         Index
+		pc              uint64
+		expected        *SourcePosition
+		expectedMissing bool
+	}{
+		{name: "imported function", funcIdx: 0, pc: 0, expectedMissing: true},
+		{name: "no instructions", funcIdx: 1, pc: 0, expectedMissing: true},
+		{name: "before first instruction", funcIdx: 2, pc: 0, expectedMissing: true},
+		{name: "first instruction", funcIdx: 2, pc: 1, expected: &SourcePosition{Offset: 1, Line: 3, Col: 5}},
+		{name: "instruction immediate", funcIdx: 2, pc: 4, expected: &SourcePosition{Offset: 3, Line: 3, Col: 17}},
+		{name: "last instruction", funcIdx: 2, pc: 8, expected: &SourcePosition{Offset: 8, Line: 4, Col: 5}},
+		{name: "past last instruction", funcIdx: 2, pc: 9, expected: &SourcePosition{Offset: 8, Line: 4, Col: 5}},
+		{name: "no such function", funcIdx: 3, pc: 0, expectedMissing: true},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			pos, ok := m.SourcePosition(tc.funcIdx, tc.pc)
+			require.Equal(t, !tc.expectedMissing, ok)
+			require.Equal(t, tc.expected, pos)
+		})
+	}
+}
+
 func TestModule_declaredFunctionIndexes(t *testing.T) {
 	for _, tc := range []struct {
 		name   string
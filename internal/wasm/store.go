@@ -7,12 +7,14 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/tetratelabs/wazero/api"
 	experimentalapi "github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/ieee754"
 	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 type (
@@ -34,6 +36,10 @@ type (
 		// Engine is a global context for a Store which is in responsible for compilation and execution of Wasm modules.
 		Engine Engine
 
+		// MemoryGrowObserver is notified when the memory defined by any module in this store grows. This is nil
+		// unless configured before instantiating modules.
+		MemoryGrowObserver MemoryGrowObserver
+
 		// moduleNames ensures no race conditions instantiating two modules of the same name
 		moduleNames []string // guarded by mux
 
@@ -86,6 +92,12 @@ type (
 		// ElementInstances holds the element instance, and each holds the references to either functions
 		// or external objects (unimplemented).
 		ElementInstances []ElementInstance
+
+		// Memories are all memories in the memory index namespace, beginning with Memory. This only has more than one
+		// element when FeatureMultiMemory is enabled.
+		//
+		// Note: This is after other fields to avoid changing their offsets, which are used by the compiler engine.
+		Memories []*MemoryInstance
 	}
 
 	// DataInstance holds bytes corresponding to the data segment in a module.
@@ -207,7 +219,7 @@ const (
 
 // addSections adds section elements to the ModuleInstance
 func (m *ModuleInstance) addSections(module *Module, importedFunctions, functions []*FunctionInstance,
-	importedGlobals, globals []*GlobalInstance, tables []*TableInstance, memory, importedMemory *MemoryInstance,
+	importedGlobals, globals []*GlobalInstance, tables []*TableInstance, importedMemories, memories []*MemoryInstance,
 	types []*FunctionType, typeIDs []FunctionTypeID) {
 
 	m.Types = types
@@ -226,10 +238,10 @@ func (m *ModuleInstance) addSections(module *Module, importedFunctions, function
 
 	m.Tables = tables
 
-	if importedMemory != nil {
-		m.Memory = importedMemory
-	} else {
-		m.Memory = memory
+	m.Memories = append(m.Memories, importedMemories...)
+	m.Memories = append(m.Memories, memories...)
+	if len(m.Memories) > 0 {
+		m.Memory = m.Memories[0]
 	}
 
 	m.buildExports(module.ExportSection)
@@ -245,10 +257,17 @@ func (m *ModuleInstance) buildDataInstances(segments []*DataSegment) {
 func (m *ModuleInstance) buildElementInstances(elements []*ElementSegment) {
 	m.ElementInstances = make([]ElementInstance, len(elements))
 	for i, elm := range elements {
-		if elm.Type == RefTypeFuncref && elm.Mode == ElementModePassive {
-			// Only passive elements can be access as element instances.
-			// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/syntax/modules.html#element-segments
+		// Only passive elements can be access as element instances.
+		// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/syntax/modules.html#element-segments
+		if elm.Mode != ElementModePassive {
+			continue
+		}
+		switch elm.Type {
+		case RefTypeFuncref:
 			m.ElementInstances[i] = *m.Engine.CreateFuncElementInstance(elm.Init)
+		case RefTypeExternref:
+			// The only constant expression of externref is "ref.null extern", so all references are null.
+			m.ElementInstances[i] = ElementInstance{References: make([]Reference, len(elm.Init)), Type: RefTypeExternref}
 		}
 	}
 }
@@ -264,7 +283,7 @@ func (m *ModuleInstance) buildExports(exports []*Export) {
 		case ExternTypeGlobal:
 			ei = &ExportInstance{Type: exp.Type, Global: m.Globals[index]}
 		case ExternTypeMemory:
-			ei = &ExportInstance{Type: exp.Type, Memory: m.Memory}
+			ei = &ExportInstance{Type: exp.Type, Memory: m.Memories[index]}
 		case ExternTypeTable:
 			ei = &ExportInstance{Type: exp.Type, Table: m.Tables[index]}
 		}
@@ -337,6 +356,28 @@ func (s *Store) Instantiate(
 	name string,
 	sys *SysContext,
 	functionListenerFactory experimentalapi.FunctionListenerFactory,
+) (*CallContext, error) {
+	return s.instantiate(ctx, module, name, sys, functionListenerFactory, false)
+}
+
+// InstantiateWithoutStart is like Instantiate, except it doesn't call the function in Module.StartSection, if any.
+func (s *Store) InstantiateWithoutStart(
+	ctx context.Context,
+	module *Module,
+	name string,
+	sys *SysContext,
+	functionListenerFactory experimentalapi.FunctionListenerFactory,
+) (*CallContext, error) {
+	return s.instantiate(ctx, module, name, sys, functionListenerFactory, true)
+}
+
+func (s *Store) instantiate(
+	ctx context.Context,
+	module *Module,
+	name string,
+	sys *SysContext,
+	functionListenerFactory experimentalapi.FunctionListenerFactory,
+	skipStart bool,
 ) (*CallContext, error) {
 	if ctx == nil {
 		ctx = context.Background()
@@ -352,7 +393,7 @@ func (s *Store) Instantiate(
 		return nil, err
 	}
 
-	importedFunctions, importedGlobals, importedTables, importedMemory, err := s.resolveImports(module)
+	importedFunctions, importedGlobals, importedTables, importedMemories, err := s.resolveImports(module)
 	if err != nil {
 		s.deleteModule(name)
 		return nil, err
@@ -365,7 +406,13 @@ func (s *Store) Instantiate(
 		s.deleteModule(name)
 		return nil, err
 	}
-	globals, memory := module.buildGlobals(importedGlobals), module.buildMemory()
+	for _, table := range tables[len(importedTables):] {
+		table.store = s // imported tables were defined in this store, too
+	}
+	globals, memories := module.buildGlobals(importedGlobals), module.buildMemories()
+	for _, memory := range memories {
+		memory.observeGrow(name, s.MemoryGrowObserver) // the observer may be nil, but the name is still used in errors
+	}
 
 	// If there are no module-defined functions, assume this is a host module.
 	var functions []*FunctionInstance
@@ -380,7 +427,7 @@ func (s *Store) Instantiate(
 
 	// Now we have all instances from imports and local ones, so ready to create a new ModuleInstance.
 	m := &ModuleInstance{Name: name}
-	m.addSections(module, importedFunctions, functions, importedGlobals, globals, tables, importedMemory, memory, module.TypeSection, typeIDs)
+	m.addSections(module, importedFunctions, functions, importedGlobals, globals, tables, importedMemories, memories, module.TypeSection, typeIDs)
 
 	// As of reference types proposal, data segment validation must happen after instantiation,
 	// and the side effect must persist even if there's out of bounds error after instantiation.
@@ -403,7 +450,7 @@ func (s *Store) Instantiate(
 
 	// After engine creation, we can create the funcref element instances and initialize funcref type globals.
 	m.buildElementInstances(module.ElementSection)
-	m.Engine.InitializeFuncrefGlobals(globals)
+	m.Engine.InitializeFuncrefGlobals(module.funcrefGlobalsToInitialize(globals))
 
 	// Now all the validation passes, we are safe to mutate memory instances (possibly imported ones).
 	if err := m.applyData(module.DataSection); err != nil {
@@ -414,12 +461,16 @@ func (s *Store) Instantiate(
 	m.CallCtx = NewCallContext(s, m, sys)
 
 	// Execute the start function.
-	if module.StartSection != nil {
+	if module.StartSection != nil && !skipStart {
 		funcIdx := *module.StartSection
 		f := m.Functions[funcIdx]
 		if _, err = f.Module.Engine.Call(ctx, m.CallCtx, f); err != nil {
 			s.deleteModule(name)
-			return nil, fmt.Errorf("start %s failed: %w", module.funcDesc(funcSection, funcIdx), err)
+			desc := module.funcDesc(funcSection, funcIdx)
+			if f.name != "" { // Include the name from the name section, as the start function is rarely exported.
+				desc = fmt.Sprintf("%s name[%q]", desc, f.name)
+			}
+			return nil, fmt.Errorf("start %s failed: %w", desc, err)
 		}
 	}
 
@@ -428,6 +479,25 @@ func (s *Store) Instantiate(
 	return m.CallCtx, nil
 }
 
+// Stats returns the count of instantiated modules, and the total count of pages in their memories. A memory imported
+// by other modules is only counted once.
+func (s *Store) Stats() (modules uint32, memoryPages uint64) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	seen := map[*MemoryInstance]struct{}{}
+	for _, m := range s.modules {
+		for _, mem := range m.Memories {
+			if _, ok := seen[mem]; ok {
+				continue
+			}
+			seen[mem] = struct{}{}
+			memoryPages += uint64(mem.PageSize(context.Background()))
+		}
+	}
+	return uint32(len(s.modules)), memoryPages
+}
+
 // deleteModule makes the moduleName available for instantiation again.
 func (s *Store) deleteModule(moduleName string) {
 	s.mux.Lock()
@@ -480,23 +550,37 @@ func (s *Store) module(moduleName string) *ModuleInstance {
 
 func (s *Store) resolveImports(module *Module) (
 	importedFunctions []*FunctionInstance, importedGlobals []*GlobalInstance,
-	importedTables []*TableInstance, importedMemory *MemoryInstance,
+	importedTables []*TableInstance, importedMemories []*MemoryInstance,
 	err error,
 ) {
 	s.mux.RLock()
 	defer s.mux.RUnlock()
 
+	// unresolved collects all imports without a matching export, or whose type doesn't match it, so that they can be
+	// reported at once.
+	var unresolved []sys.UnresolvedImport
+	// mismatch is the error of the first import whose type doesn't match its export. This is returned as-is when it is
+	// the only unresolved import.
+	var mismatch error
+	addMismatch := func(i *Import, idx int, cause error) {
+		if mismatch == nil {
+			mismatch = errorInvalidImport(i, idx, cause)
+		}
+		unresolved = append(unresolved, sys.UnresolvedImport{Module: i.Module, Name: i.Name, Type: i.Type, Reason: cause.Error()})
+	}
+
 	for idx, i := range module.ImportSection {
 		m, ok := s.modules[i.Module]
 		if !ok {
-			err = fmt.Errorf("module[%s] not instantiated", i.Module)
-			return
+			reason := fmt.Sprintf("module[%s] not instantiated", i.Module)
+			unresolved = append(unresolved, sys.UnresolvedImport{Module: i.Module, Name: i.Name, Type: i.Type, Reason: reason})
+			continue
 		}
 
-		var imported *ExportInstance
-		imported, err = m.getExport(i.Name, i.Type)
-		if err != nil {
-			return
+		imported, exportErr := m.getExport(i.Name, i.Type)
+		if exportErr != nil {
+			unresolved = append(unresolved, sys.UnresolvedImport{Module: i.Module, Name: i.Name, Type: i.Type, Reason: exportErr.Error()})
+			continue
 		}
 
 		switch i.Type {
@@ -512,8 +596,8 @@ func (s *Store) resolveImports(module *Module) (
 
 			actualType := importedFunction.Type
 			if !expectedType.EqualsSignature(actualType.Params, actualType.Results) {
-				err = errorInvalidImport(i, idx, fmt.Errorf("signature mismatch: %s != %s", expectedType, actualType))
-				return
+				addMismatch(i, idx, errorSignatureMismatch(expectedType, actualType))
+				continue
 			}
 
 			importedFunctions = append(importedFunctions, importedFunction)
@@ -521,76 +605,106 @@ func (s *Store) resolveImports(module *Module) (
 			expected := i.DescTable
 			importedTable := imported.Table
 			if expected.Type != importedTable.Type {
-				err = errorInvalidImport(i, idx, fmt.Errorf("table type mismatch: %s != %s",
+				addMismatch(i, idx, fmt.Errorf("table type mismatch: %s != %s",
 					RefTypeName(expected.Type), RefTypeName(importedTable.Type)))
+				continue
 			}
 
 			if expected.Min > importedTable.Min {
-				err = errorMinSizeMismatch(i, idx, expected.Min, importedTable.Min)
-				return
+				addMismatch(i, idx, errorMinSizeMismatch(expected.Min, importedTable.Min))
+				continue
 			}
 
 			if expected.Max != nil {
 				expectedMax := *expected.Max
 				if importedTable.Max == nil {
-					err = errorNoMax(i, idx, expectedMax)
-					return
+					addMismatch(i, idx, errorNoMax(expectedMax))
+					continue
 				} else if expectedMax < *importedTable.Max {
-					err = errorMaxSizeMismatch(i, idx, expectedMax, *importedTable.Max)
-					return
+					addMismatch(i, idx, errorMaxSizeMismatch(expectedMax, *importedTable.Max))
+					continue
 				}
 			}
 			importedTables = append(importedTables, importedTable)
 		case ExternTypeMemory:
 			expected := i.DescMem
-			importedMemory = imported.Memory
+			importedMemory := imported.Memory
 
 			if expected.Min > memoryBytesNumToPages(uint64(len(importedMemory.Buffer))) {
-				err = errorMinSizeMismatch(i, idx, expected.Min, importedMemory.Min)
-				return
+				addMismatch(i, idx, errorMinSizeMismatch(expected.Min, importedMemory.Min))
+				continue
 			}
 
 			if expected.Max < importedMemory.Max {
-				err = errorMaxSizeMismatch(i, idx, expected.Max, importedMemory.Max)
-				return
+				addMismatch(i, idx, errorMaxSizeMismatch(expected.Max, importedMemory.Max))
+				continue
 			}
+
+			if expected.Shared != importedMemory.Shared {
+				addMismatch(i, idx, fmt.Errorf("shared mismatch: %t != %t",
+					expected.Shared, importedMemory.Shared))
+				continue
+			}
+			importedMemories = append(importedMemories, importedMemory)
 		case ExternTypeGlobal:
 			expected := i.DescGlobal
 			importedGlobal := imported.Global
 
 			if expected.Mutable != importedGlobal.Type.Mutable {
-				err = errorInvalidImport(i, idx, fmt.Errorf("mutability mismatch: %t != %t",
+				addMismatch(i, idx, fmt.Errorf("mutability mismatch: %t != %t",
 					expected.Mutable, importedGlobal.Type.Mutable))
-				return
+				continue
 			}
 
 			if expected.ValType != importedGlobal.Type.ValType {
-				err = errorInvalidImport(i, idx, fmt.Errorf("value type mismatch: %s != %s",
+				addMismatch(i, idx, fmt.Errorf("value type mismatch: %s != %s",
 					ValueTypeName(expected.ValType), ValueTypeName(importedGlobal.Type.ValType)))
-				return
+				continue
 			}
 			importedGlobals = append(importedGlobals, importedGlobal)
 		}
 	}
+
+	if len(unresolved) == 1 && mismatch != nil {
+		err = mismatch
+	} else if unresolved != nil {
+		err = &sys.UnresolvedImportsError{Imports: unresolved}
+	}
 	return
 }
 
-func errorMinSizeMismatch(i *Import, idx int, expected, actual uint32) error {
-	return errorInvalidImport(i, idx, fmt.Errorf("minimum size mismatch: %d > %d", expected, actual))
+func errorMinSizeMismatch(expected, actual uint32) error {
+	return fmt.Errorf("minimum size mismatch: %d > %d", expected, actual)
 }
 
-func errorNoMax(i *Import, idx int, expected uint32) error {
-	return errorInvalidImport(i, idx, fmt.Errorf("maximum size mismatch: %d, but actual has no max", expected))
+func errorNoMax(expected uint32) error {
+	return fmt.Errorf("maximum size mismatch: %d, but actual has no max", expected)
 }
 
-func errorMaxSizeMismatch(i *Import, idx int, expected, actual uint32) error {
-	return errorInvalidImport(i, idx, fmt.Errorf("maximum size mismatch: %d < %d", expected, actual))
+func errorMaxSizeMismatch(expected, actual uint32) error {
+	return fmt.Errorf("maximum size mismatch: %d < %d", expected, actual)
 }
 
 func errorInvalidImport(i *Import, idx int, err error) error {
 	return fmt.Errorf("import[%d] %s[%s.%s]: %w", idx, ExternTypeName(i.Type), i.Module, i.Name, err)
 }
 
+// errorSignatureMismatch describes why the imported function type doesn't match the expected one, including which
+// arity differs, as the string forms are hard to compare when there are multiple results.
+func errorSignatureMismatch(expected, actual *FunctionType) error {
+	var arity []string
+	if want, got := len(expected.Params), len(actual.Params); want != got {
+		arity = append(arity, fmt.Sprintf("want %d params, got %d", want, got))
+	}
+	if want, got := len(expected.Results), len(actual.Results); want != got {
+		arity = append(arity, fmt.Sprintf("want %d results, got %d", want, got))
+	}
+	if len(arity) == 0 {
+		return fmt.Errorf("signature mismatch: %s != %s", expected, actual)
+	}
+	return fmt.Errorf("signature mismatch: %s != %s (%s)", expected, actual, strings.Join(arity, ", "))
+}
+
 // Global initialization constant expression can only reference the imported globals.
 // See the note on https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#constant-expressions%E2%91%A0
 func executeConstExpression(importedGlobals []*GlobalInstance, expr *ConstantExpression) (v interface{}) {
@@ -620,6 +734,10 @@ func executeConstExpression(importedGlobals []*GlobalInstance, expr *ConstantExp
 			v = api.DecodeF64(g.Val)
 		case ValueTypeV128:
 			v = [2]uint64{g.Val, g.ValHi}
+		case ValueTypeExternref, ValueTypeFuncref:
+			// The imported global is already instantiated, so a funcref is the engine's representation, where the
+			// null reference is zero. See funcrefGlobalsToInitialize.
+			v = int64(g.Val)
 		}
 	case OpcodeRefNull:
 		switch expr.Data[0] {
@@ -637,10 +755,55 @@ func executeConstExpression(importedGlobals []*GlobalInstance, expr *ConstantExp
 		v, _, _ = leb128.DecodeInt32(r)
 	case OpcodeVecV128Const:
 		v = [2]uint64{binary.LittleEndian.Uint64(expr.Data[0:8]), binary.LittleEndian.Uint64(expr.Data[8:16])}
+	case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul:
+		v = int32(executeExtendedConstExpression(importedGlobals, expr))
+	case OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+		v = int64(executeExtendedConstExpression(importedGlobals, expr))
 	}
 	return
 }
 
+// executeExtendedConstExpression runs a constant expression allowed by FeatureExtendedConst on a stack of raw values,
+// returning the result. This assumes the expression was validated by validateExtendedConstExpression.
+func executeExtendedConstExpression(importedGlobals []*GlobalInstance, expr *ConstantExpression) uint64 {
+	var stack []uint64
+	r := bytes.NewReader(extendedConstInstructions(expr))
+	for r.Len() > 0 {
+		opcode, _ := r.ReadByte()
+		switch opcode {
+		case OpcodeI32Const:
+			v, _, _ := leb128.DecodeInt32(r)
+			stack = append(stack, uint64(uint32(v)))
+		case OpcodeI64Const:
+			v, _, _ := leb128.DecodeInt64(r)
+			stack = append(stack, uint64(v))
+		case OpcodeGlobalGet:
+			id, _, _ := leb128.DecodeUint32(r)
+			stack = append(stack, importedGlobals[id].Val)
+		default:
+			x1, x2 := stack[len(stack)-2], stack[len(stack)-1]
+			stack = stack[:len(stack)-2]
+			var v uint64
+			switch opcode {
+			case OpcodeI32Add:
+				v = uint64(uint32(x1) + uint32(x2))
+			case OpcodeI32Sub:
+				v = uint64(uint32(x1) - uint32(x2))
+			case OpcodeI32Mul:
+				v = uint64(uint32(x1) * uint32(x2))
+			case OpcodeI64Add:
+				v = x1 + x2
+			case OpcodeI64Sub:
+				v = x1 - x2
+			case OpcodeI64Mul:
+				v = x1 * x2
+			}
+			stack = append(stack, v)
+		}
+	}
+	return stack[0]
+}
+
 // GlobalInstanceNullFuncRefValue is the temporarly value for ValueTypeFuncref globals which are initialized via ref.null.
 const GlobalInstanceNullFuncRefValue int64 = -1
 
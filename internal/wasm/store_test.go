@@ -1,6 +1,7 @@
 package wasm
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	"github.com/tetratelabs/wazero/internal/testing/hammer"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/u64"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 func TestModuleInstance_Memory(t *testing.T) {
@@ -28,23 +30,23 @@ func TestModuleInstance_Memory(t *testing.T) {
 		},
 		{
 			name:  "memory not exported",
-			input: &Module{MemorySection: &Memory{Min: 1, Cap: 1}},
+			input: &Module{MemorySection: []*Memory{{Min: 1, Cap: 1}}},
 		},
 		{
 			name:  "memory not exported, one page",
-			input: &Module{MemorySection: &Memory{Min: 1, Cap: 1}},
+			input: &Module{MemorySection: []*Memory{{Min: 1, Cap: 1}}},
 		},
 		{
 			name: "memory exported, different name",
 			input: &Module{
-				MemorySection: &Memory{Min: 1, Cap: 1},
+				MemorySection: []*Memory{{Min: 1, Cap: 1}},
 				ExportSection: []*Export{{Type: ExternTypeMemory, Name: "momory", Index: 0}},
 			},
 		},
 		{
 			name: "memory exported, but zero length",
 			input: &Module{
-				MemorySection: &Memory{},
+				MemorySection: []*Memory{{}},
 				ExportSection: []*Export{{Type: ExternTypeMemory, Name: "memory", Index: 0}},
 			},
 			expected: true,
@@ -52,7 +54,7 @@ func TestModuleInstance_Memory(t *testing.T) {
 		{
 			name: "memory exported, one page",
 			input: &Module{
-				MemorySection: &Memory{Min: 1, Cap: 1},
+				MemorySection: []*Memory{{Min: 1, Cap: 1}},
 				ExportSection: []*Export{{Type: ExternTypeMemory, Name: "memory", Index: 0}},
 			},
 			expected:    true,
@@ -61,7 +63,7 @@ func TestModuleInstance_Memory(t *testing.T) {
 		{
 			name: "memory exported, two pages",
 			input: &Module{
-				MemorySection: &Memory{Min: 2, Cap: 2},
+				MemorySection: []*Memory{{Min: 2, Cap: 2}},
 				ExportSection: []*Export{{Type: ExternTypeMemory, Name: "memory", Index: 0}},
 			},
 			expected:    true,
@@ -95,6 +97,7 @@ func TestStore_Instantiate(t *testing.T) {
 		map[string]interface{}{"fn": func(api.Module) {}},
 		map[string]*Memory{},
 		map[string]*Global{},
+		map[string]*Table{},
 		Features20191205,
 	)
 	require.NoError(t, err)
@@ -128,6 +131,7 @@ func TestStore_CloseModule(t *testing.T) {
 					map[string]interface{}{"fn": func(api.Module) {}},
 					map[string]*Memory{},
 					map[string]*Global{},
+					map[string]*Table{},
 					Features20191205,
 				)
 				require.NoError(t, err)
@@ -156,7 +160,7 @@ func TestStore_CloseModule(t *testing.T) {
 			_, err := s.Instantiate(testCtx, &Module{
 				TypeSection:   []*FunctionType{{}},
 				ImportSection: []*Import{{Type: ExternTypeFunc, Module: importedModuleName, Name: "fn", DescFunc: 0}},
-				MemorySection: &Memory{Min: 1, Cap: 1},
+				MemorySection: []*Memory{{Min: 1, Cap: 1}},
 				GlobalSection: []*Global{{Type: &GlobalType{}, Init: &ConstantExpression{Opcode: OpcodeI32Const, Data: const1}}},
 				TableSection:  []*Table{{Min: 10}},
 			}, importingModuleName, nil, nil)
@@ -214,7 +218,7 @@ func TestStore_CloseStore(t *testing.T) {
 			m2, err := s.Instantiate(testCtx, &Module{
 				TypeSection:   []*FunctionType{{}},
 				ImportSection: []*Import{{Type: ExternTypeFunc, Module: importedModuleName, Name: "fn", DescFunc: 0}},
-				MemorySection: &Memory{Min: 1, Cap: 1},
+				MemorySection: []*Memory{{Min: 1, Cap: 1}},
 				GlobalSection: []*Global{{Type: &GlobalType{}, Init: &ConstantExpression{Opcode: OpcodeI32Const, Data: const1}}},
 				TableSection:  []*Table{{Min: 10}},
 			}, importingModuleName, nil, nil)
@@ -244,6 +248,7 @@ func TestStore_hammer(t *testing.T) {
 		map[string]interface{}{"fn": func(api.Module) {}},
 		map[string]*Memory{},
 		map[string]*Global{},
+		map[string]*Table{},
 		Features20191205,
 	)
 	require.NoError(t, err)
@@ -259,7 +264,7 @@ func TestStore_hammer(t *testing.T) {
 		TypeSection:     []*FunctionType{{}},
 		FunctionSection: []uint32{0},
 		CodeSection:     []*Code{{Body: []byte{OpcodeEnd}}},
-		MemorySection:   &Memory{Min: 1, Cap: 1},
+		MemorySection:   []*Memory{{Min: 1, Cap: 1}},
 		GlobalSection:   []*Global{{Type: &GlobalType{}, Init: &ConstantExpression{Opcode: OpcodeI32Const, Data: const1}}},
 		TableSection:    []*Table{{Min: 10}},
 		ImportSection: []*Import{
@@ -291,6 +296,53 @@ func TestStore_hammer(t *testing.T) {
 	require.Zero(t, len(s.modules))
 }
 
+// TestStore_hammer_close ensures closing the same module concurrently finalizes it exactly once, and removes it from
+// the store so that its name can be reused.
+func TestStore_hammer_close(t *testing.T) {
+	const moduleName = "test"
+
+	m := &Module{
+		TypeSection:     []*FunctionType{{}},
+		FunctionSection: []uint32{0},
+		CodeSection:     []*Code{{Body: []byte{OpcodeEnd}}},
+		MemorySection:   []*Memory{{Min: 1, Cap: 1}},
+	}
+
+	P := 8               // max count of goroutines
+	rounds := 100        // count of modules to close concurrently
+	if testing.Short() { // Adjust down if `-test.short`
+		P = 4
+		rounds = 10
+	}
+
+	s := newStore()
+	for i := 0; i < rounds; i++ {
+		var out bytes.Buffer
+		stdout, _ := NewOutputBuffers(&out, nil, 16)
+		_, err := stdout.Write([]byte("buffered"))
+		require.NoError(t, err)
+		sysCtx, err := NewSysContext(0, nil, nil, nil, stdout, nil, nil, nil)
+		require.NoError(t, err)
+
+		// Reusing the name fails unless the last round removed the module from the store.
+		mod, err := s.Instantiate(testCtx, m, moduleName, sysCtx, nil)
+		require.NoError(t, err)
+
+		hammer.NewHammer(t, P, 1).Run(func(name string) {
+			require.NoError(t, mod.CloseWithExitCode(testCtx, 2))
+		}, nil)
+		if t.Failed() {
+			return // At least one test failed, so return now.
+		}
+
+		require.Equal(t, "buffered", out.String()) // flushed on close
+		exitCode, closed := mod.ExitCode()
+		require.True(t, closed)
+		require.Equal(t, uint32(2), exitCode)
+		require.Nil(t, s.Module(moduleName))
+	}
+}
+
 func TestStore_Instantiate_Errors(t *testing.T) {
 	const importedModuleName = "imported"
 	const importingModuleName = "test"
@@ -300,6 +352,7 @@ func TestStore_Instantiate_Errors(t *testing.T) {
 		map[string]interface{}{"fn": func(api.Module) {}},
 		map[string]*Memory{},
 		map[string]*Global{},
+		map[string]*Table{},
 		Features20191205,
 	)
 	require.NoError(t, err)
@@ -383,14 +436,31 @@ func TestStore_Instantiate_Errors(t *testing.T) {
 		}, importingModuleName, nil, nil)
 		require.EqualError(t, err, "start function[1] failed: call failed")
 	})
+
+	t.Run("named start func failed", func(t *testing.T) {
+		s := newStore()
+		engine := s.Engine.(*mockEngine)
+		engine.callFailIndex = 0
+
+		startFuncIndex := uint32(0)
+		_, err = s.Instantiate(testCtx, &Module{
+			TypeSection:     []*FunctionType{{}},
+			FunctionSection: []uint32{0},
+			CodeSection:     []*Code{{Body: []byte{OpcodeEnd}}},
+			StartSection:    &startFuncIndex,
+			NameSection:     &NameSection{FunctionNames: NameMap{{Index: 0, Name: "init"}}},
+		}, importingModuleName, nil, nil)
+		require.EqualError(t, err, `start function[0] name["init"] failed: call failed`)
+	})
 }
 
 func TestCallContext_ExportedFunction(t *testing.T) {
 	host, err := NewHostModule(
 		"host",
-		map[string]interface{}{"host_fn": func(api.Module) {}},
+		map[string]interface{}{"host_fn": func(api.Module) {}, "host_fn_i32i32": func(api.Module, uint32, uint32) {}},
 		map[string]*Memory{},
 		map[string]*Global{},
+		map[string]*Table{},
 		Features20191205,
 	)
 	require.NoError(t, err)
@@ -406,7 +476,7 @@ func TestCallContext_ExportedFunction(t *testing.T) {
 		importing, err := s.Instantiate(testCtx, &Module{
 			TypeSection:   []*FunctionType{{}},
 			ImportSection: []*Import{{Type: ExternTypeFunc, Module: "host", Name: "host_fn", DescFunc: 0}},
-			MemorySection: &Memory{Min: 1, Cap: 1},
+			MemorySection: []*Memory{{Min: 1, Cap: 1}},
 			ExportSection: []*Export{{Type: ExternTypeFunc, Name: "host.fn", Index: 0}},
 		}, "test", nil, nil)
 		require.NoError(t, err)
@@ -418,6 +488,34 @@ func TestCallContext_ExportedFunction(t *testing.T) {
 		require.Equal(t, fn.(*importedFn).importedFn, imported.ExportedFunction("host_fn"))
 		require.Equal(t, fn.(*importedFn).importingModule, importing)
 	})
+
+	t.Run("imported function param count mismatch", func(t *testing.T) {
+		importing, err := s.Instantiate(testCtx, &Module{
+			TypeSection:   []*FunctionType{{Params: []ValueType{ValueTypeI32, ValueTypeI32}, ParamNumInUint64: 2}},
+			ImportSection: []*Import{{Type: ExternTypeFunc, Module: "host", Name: "host_fn_i32i32", DescFunc: 0}},
+			ExportSection: []*Export{{Type: ExternTypeFunc, Name: "host.fn", Index: 0}},
+		}, "test_params", nil, nil)
+		require.NoError(t, err)
+		defer importing.Close(testCtx)
+
+		fn := importing.ExportedFunction("host.fn")
+		require.NotNil(t, fn)
+
+		_, err = fn.Call(testCtx, 1)
+		require.EqualError(t, err, "expected 2 params, but passed 1")
+		_, err = fn.Call(testCtx, 1, 2, 3)
+		require.EqualError(t, err, "expected 2 params, but passed 3")
+		_, err = fn.Call(testCtx, 1, 2)
+		require.NoError(t, err)
+	})
+
+	t.Run("function param count mismatch", func(t *testing.T) {
+		fn := imported.ExportedFunction("host_fn_i32i32")
+		require.NotNil(t, fn)
+
+		_, err = fn.Call(testCtx)
+		require.EqualError(t, err, "expected 2 params, but passed 0")
+	})
 }
 
 type mockEngine struct {
@@ -449,8 +547,15 @@ func (e *mockEngine) DeleteCompiledModule(*Module) {}
 func (e *mockEngine) CompileModule(_ context.Context, _ *Module) error { return nil }
 
 // CreateFuncElementInstance implements the same method as documented on wasm.ModuleEngine.
-func (me *mockModuleEngine) CreateFuncElementInstance([]*Index) *ElementInstance {
-	return nil
+func (me *mockModuleEngine) CreateFuncElementInstance(indexes []*Index) *ElementInstance {
+	// Encode each function index plus one, so that zero remains the null reference.
+	refs := make([]Reference, len(indexes))
+	for i, index := range indexes {
+		if index != nil {
+			refs[i] = Reference(*index + 1)
+		}
+	}
+	return &ElementInstance{References: refs, Type: RefTypeFuncref}
 }
 
 // InitializeFuncrefGlobals implements the same method as documented on wasm.ModuleEngine.
@@ -470,6 +575,12 @@ func (e *mockModuleEngine) Call(ctx context.Context, callCtx *CallContext, f *Fu
 	return
 }
 
+// CallInto implements the same method as documented on wasm.ModuleEngine.
+func (e *mockModuleEngine) CallInto(ctx context.Context, callCtx *CallContext, f *FunctionInstance, _ []uint64, params ...uint64) error {
+	_, err := e.Call(ctx, callCtx, f, params...)
+	return err
+}
+
 // Close implements the same method as documented on wasm.ModuleEngine.
 func (e *mockModuleEngine) Close(_ context.Context) {
 }
@@ -635,6 +746,51 @@ func TestExecuteConstExpression(t *testing.T) {
 		}
 	})
 
+	t.Run("extended const", func(t *testing.T) {
+		globals := []*GlobalInstance{
+			{Val: 10, Type: &GlobalType{ValType: ValueTypeI32}},
+			{Val: 20, Type: &GlobalType{ValType: ValueTypeI64}},
+		}
+		for _, tc := range []struct {
+			name string
+			expr *ConstantExpression
+			exp  interface{}
+		}{
+			{
+				name: "(i32.add (i32.const 1) (i32.const 2))",
+				expr: &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeI32Const, 1, OpcodeI32Const, 2}},
+				exp:  int32(3),
+			},
+			{
+				name: "(i32.sub (i32.const 1) (global.get 0))",
+				expr: &ConstantExpression{Opcode: OpcodeI32Sub, Data: []byte{OpcodeI32Const, 1, OpcodeGlobalGet, 0}},
+				exp:  int32(-9),
+			},
+			{
+				name: "(i64.mul (i64.sub (global.get 1) (i64.const 2)) (i64.const 3))",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI64Mul,
+					Data:   []byte{OpcodeGlobalGet, 1, OpcodeI64Const, 2, OpcodeI64Sub, OpcodeI64Const, 3},
+				},
+				exp: int64(54),
+			},
+			{
+				name: "i32 overflow wraps",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI32Mul,
+					Data:   append(append([]byte{OpcodeI32Const}, leb128.EncodeInt32(math.MaxInt32)...), OpcodeI32Const, 2),
+				},
+				exp: int32(-2),
+			},
+		} {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				val := executeConstExpression(globals, tc.expr)
+				require.Equal(t, tc.exp, val)
+			})
+		}
+	})
+
 	t.Run("vector", func(t *testing.T) {
 		expr := &ConstantExpression{Data: []byte{
 			1, 0, 0, 0, 0, 0, 0, 0,
@@ -658,6 +814,46 @@ func TestStore_resolveImports(t *testing.T) {
 		_, _, _, _, err := s.resolveImports(&Module{ImportSection: []*Import{{Module: "unknown", Name: "unknown"}}})
 		require.EqualError(t, err, "module[unknown] not instantiated")
 	})
+	t.Run("all unresolved imports", func(t *testing.T) {
+		s := newStore()
+		s.modules[moduleName] = &ModuleInstance{Exports: map[string]*ExportInstance{}, Name: moduleName}
+		_, _, _, _, err := s.resolveImports(&Module{ImportSection: []*Import{
+			{Module: "unknown", Name: "fn", Type: ExternTypeFunc},
+			{Module: moduleName, Name: "memory", Type: ExternTypeMemory},
+			{Module: moduleName, Name: "global", Type: ExternTypeGlobal},
+		}})
+		require.EqualError(t, err, `3 imports are unresolved:
+	func[unknown.fn]: module[unknown] not instantiated
+	memory[test.memory]: "memory" is not exported in module "test"
+	global[test.global]: "global" is not exported in module "test"`)
+
+		var unresolved *sys.UnresolvedImportsError
+		require.True(t, errors.As(err, &unresolved))
+		require.Equal(t, []sys.UnresolvedImport{
+			{Module: "unknown", Name: "fn", Type: ExternTypeFunc, Reason: "module[unknown] not instantiated"},
+			{Module: moduleName, Name: "memory", Type: ExternTypeMemory, Reason: `"memory" is not exported in module "test"`},
+			{Module: moduleName, Name: "global", Type: ExternTypeGlobal, Reason: `"global" is not exported in module "test"`},
+		}, unresolved.Imports)
+	})
+	t.Run("mismatches continue", func(t *testing.T) {
+		s := newStore()
+		s.modules[moduleName] = &ModuleInstance{Exports: map[string]*ExportInstance{
+			"fn":     {Type: ExternTypeFunc, Function: &FunctionInstance{Type: &FunctionType{}}},
+			"global": {Type: ExternTypeGlobal, Global: &GlobalInstance{Type: &GlobalType{ValType: ValueTypeI32}}},
+		}, Name: moduleName}
+		_, _, _, _, err := s.resolveImports(&Module{
+			TypeSection: []*FunctionType{{Results: []ValueType{ValueTypeF32}}},
+			ImportSection: []*Import{
+				{Module: moduleName, Name: "fn", Type: ExternTypeFunc, DescFunc: 0},
+				{Module: "unknown", Name: "fn", Type: ExternTypeFunc},
+				{Module: moduleName, Name: "global", Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: ValueTypeF64}},
+			},
+		})
+		require.EqualError(t, err, `3 imports are unresolved:
+	func[test.fn]: signature mismatch: v_f32 != v_v (want 1 results, got 0)
+	func[unknown.fn]: module[unknown] not instantiated
+	global[test.global]: value type mismatch: f64 != i32`)
+	})
 	t.Run("export instance not found", func(t *testing.T) {
 		s := newStore()
 		s.modules[moduleName] = &ModuleInstance{Exports: map[string]*ExportInstance{}, Name: moduleName}
@@ -704,7 +900,31 @@ func TestStore_resolveImports(t *testing.T) {
 				ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeFunc, DescFunc: 0}},
 			}
 			_, _, _, _, err := s.resolveImports(m)
-			require.EqualError(t, err, "import[0] func[test.target]: signature mismatch: v_f32 != v_v")
+			require.EqualError(t, err, "import[0] func[test.target]: signature mismatch: v_f32 != v_v (want 1 results, got 0)")
+		})
+		t.Run("signature mismatch same arity", func(t *testing.T) {
+			s := newStore()
+			s.modules[moduleName] = &ModuleInstance{Exports: map[string]*ExportInstance{name: {
+				Function: &FunctionInstance{Type: &FunctionType{Params: []ValueType{ValueTypeI64}}},
+			}}, Name: moduleName}
+			m := &Module{
+				TypeSection:   []*FunctionType{{Params: []ValueType{ValueTypeI32}}},
+				ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeFunc, DescFunc: 0}},
+			}
+			_, _, _, _, err := s.resolveImports(m)
+			require.EqualError(t, err, "import[0] func[test.target]: signature mismatch: i32_v != i64_v")
+		})
+		t.Run("signature mismatch multi-value results", func(t *testing.T) {
+			s := newStore()
+			s.modules[moduleName] = &ModuleInstance{Exports: map[string]*ExportInstance{name: {
+				Function: &FunctionInstance{Type: &FunctionType{Results: []ValueType{ValueTypeI32}}},
+			}}, Name: moduleName}
+			m := &Module{
+				TypeSection:   []*FunctionType{{Results: []ValueType{ValueTypeI32, ValueTypeI32}}},
+				ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeFunc, DescFunc: 0}},
+			}
+			_, _, _, _, err := s.resolveImports(m)
+			require.EqualError(t, err, "import[0] func[test.target]: signature mismatch: v_i32i32 != v_i32 (want 2 results, got 1)")
 		})
 	})
 	t.Run("global", func(t *testing.T) {
@@ -744,9 +964,9 @@ func TestStore_resolveImports(t *testing.T) {
 				Type:   ExternTypeMemory,
 				Memory: memoryInst,
 			}}, Name: moduleName}
-			_, _, _, memory, err := s.resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: &Memory{Max: max}}}})
+			_, _, _, memories, err := s.resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: &Memory{Max: max}}}})
 			require.NoError(t, err)
-			require.Equal(t, memory, memoryInst)
+			require.Equal(t, []*MemoryInstance{memoryInst}, memories)
 		})
 		t.Run("minimum size mismatch", func(t *testing.T) {
 			s := newStore()
@@ -769,6 +989,17 @@ func TestStore_resolveImports(t *testing.T) {
 			_, _, _, _, err := s.resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: importMemoryType}}})
 			require.EqualError(t, err, "import[0] memory[test.target]: maximum size mismatch: 10 < 65536")
 		})
+		t.Run("shared mismatch", func(t *testing.T) {
+			s := newStore()
+			max := uint32(10)
+			importMemoryType := &Memory{Max: max, IsMaxEncoded: true, Shared: true}
+			s.modules[moduleName] = &ModuleInstance{Exports: map[string]*ExportInstance{name: {
+				Type:   ExternTypeMemory,
+				Memory: &MemoryInstance{Max: max},
+			}}, Name: moduleName}
+			_, _, _, _, err := s.resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: importMemoryType}}})
+			require.EqualError(t, err, "import[0] memory[test.target]: shared mismatch: true != false")
+		})
 	})
 }
 
@@ -818,9 +1049,29 @@ func TestModuleInstance_applyData(t *testing.T) {
 	err := m.applyData([]*DataSegment{
 		{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: const0}, Init: []byte{0xa, 0xf}},
 		{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: leb128.EncodeUint32(8)}, Init: []byte{0x1, 0x5}},
+		// (i32.add (i32.const 2) (i32.const 3)) per FeatureExtendedConst
+		{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeI32Const, 2, OpcodeI32Const, 3}}, Init: []byte{0x7}},
 	})
 	require.NoError(t, err)
-	require.Equal(t, []byte{0xa, 0xf, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1, 0x5}, m.Memory.Buffer)
+	require.Equal(t, []byte{0xa, 0xf, 0x0, 0x0, 0x0, 0x7, 0x0, 0x0, 0x1, 0x5}, m.Memory.Buffer)
+}
+
+func TestModuleInstance_buildElementInstances(t *testing.T) {
+	one := Index(1)
+	m := &ModuleInstance{Engine: &mockModuleEngine{}}
+	m.buildElementInstances([]*ElementSegment{
+		{Mode: ElementModePassive, Type: RefTypeFuncref, Init: []*Index{nil, &one}},
+		{Mode: ElementModePassive, Type: RefTypeExternref, Init: []*Index{nil, nil}},
+		{Mode: ElementModeActive, Type: RefTypeFuncref, Init: []*Index{&one},
+			OffsetExpr: &ConstantExpression{Opcode: OpcodeI32Const, Data: const0}},
+		{Mode: ElementModeDeclarative, Type: RefTypeFuncref, Init: []*Index{&one}},
+	})
+	require.Equal(t, []ElementInstance{
+		{References: []Reference{0, 2}, Type: RefTypeFuncref},
+		{References: []Reference{0, 0}, Type: RefTypeExternref},
+		{}, // Active elements aren't accessible as element instances.
+		{}, // Neither are declarative ones.
+	}, m.ElementInstances)
 }
 
 func globalsContain(globals []*GlobalInstance, want *GlobalInstance) bool {
@@ -1,12 +1,14 @@
 package wasm
 
 import (
+	"bufio"
+	crand "crypto/rand"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
-	"math"
-	"sync/atomic"
+	"reflect"
+	"sync"
 )
 
 // FileEntry maps a path to an open file in a file system.
@@ -25,23 +27,29 @@ type SysContext struct {
 	argsSize, environSize uint32
 	stdin                 io.Reader
 	stdout, stderr        io.Writer
+	randSource            io.Reader
 
 	// openedFiles is a map of file descriptor numbers (>=3) to open files (or directories) and defaults to empty.
 	// TODO: This is unguarded, so not goroutine-safe!
 	openedFiles map[uint32]*FileEntry
 
-	// lastFD is not meant to be read directly. Rather by nextFD.
-	lastFD uint32
+	// closed is lazily initialized by Stdin and closed by Close to unblock any in-flight Stdin reads.
+	closed chan struct{}
+	// stdinReader is lazily initialized by Stdin, so that there is at most one goroutine reading stdin.
+	stdinReader *closeableReader
+	// closedMux guards closed and stdinReader.
+	closedMux sync.Mutex
 }
 
-// nextFD gets the next file descriptor number in a goroutine safe way (monotonically) or zero if we ran out.
-// TODO: opendFiles is still not goroutine safe!
-// TODO: This can return zero if we ran out of file descriptors. A future change can optimize by re-using an FD pool.
-func (c *SysContext) nextFD() uint32 {
-	if c.lastFD == math.MaxUint32 {
-		return 0
+// allocateFD returns the lowest file descriptor number not in use, as in POSIX, or zero if we ran out.
+// TODO: openedFiles is still not goroutine safe!
+func (c *SysContext) allocateFD() uint32 {
+	for fd := uint32(3); fd != 0; fd++ { // after stdin/stdout/stderr, until fd overflows to zero.
+		if _, ok := c.openedFiles[fd]; !ok {
+			return fd
+		}
 	}
-	return atomic.AddUint32(&c.lastFD, 1)
+	return 0
 }
 
 // Args is like os.Args and defaults to nil.
@@ -79,9 +87,24 @@ func (c *SysContext) EnvironSize() uint32 {
 }
 
 // Stdin is like exec.Cmd Stdin and defaults to a reader of os.DevNull.
+//
+// Note: A Read blocked on the configured reader returns fs.ErrClosed when Close is called, even if that reader never
+// returns. This allows a module to close while a host function is waiting for input.
 // See wazero.SysConfig WithStdin
 func (c *SysContext) Stdin() io.Reader {
-	return c.stdin
+	if _, ok := c.stdin.(eofReader); ok { // never blocks
+		return c.stdin
+	}
+
+	c.closedMux.Lock()
+	defer c.closedMux.Unlock()
+	if c.stdinReader == nil {
+		if c.closed == nil {
+			c.closed = make(chan struct{})
+		}
+		c.stdinReader = &closeableReader{r: c.stdin, closed: c.closed}
+	}
+	return c.stdinReader
 }
 
 // Stdout is like exec.Cmd Stdout and defaults to io.Discard.
@@ -96,6 +119,57 @@ func (c *SysContext) Stderr() io.Writer {
 	return c.stderr
 }
 
+// RandSource is the source of "random_get" and defaults to crypto/rand.Reader.
+// See wazero.ModuleConfig WithRandSource
+func (c *SysContext) RandSource() io.Reader {
+	return c.randSource
+}
+
+// OutputBuffer buffers writes to Stdout or Stderr, as configured by wazero.ModuleConfig WithOutputBufferSize.
+//
+// Note: This is a distinct type, so that Flush only flushes buffers wazero created, not any io.Writer the user
+// supplied, such as their own *bufio.Writer.
+type OutputBuffer struct {
+	*bufio.Writer
+}
+
+// NewOutputBuffers wraps stdout and stderr in an OutputBuffer of the given size, unless nil. When stdout and stderr
+// are the same io.Writer, they share one buffer, so that output to both is written in the order it was buffered.
+func NewOutputBuffers(stdout, stderr io.Writer, size int) (io.Writer, io.Writer) {
+	var bufferedStdout, bufferedStderr io.Writer
+	if stdout != nil {
+		bufferedStdout = &OutputBuffer{bufio.NewWriterSize(stdout, size)}
+	}
+	if stderr != nil {
+		if isSameWriter(stdout, stderr) {
+			bufferedStderr = bufferedStdout
+		} else {
+			bufferedStderr = &OutputBuffer{bufio.NewWriterSize(stderr, size)}
+		}
+	}
+	return bufferedStdout, bufferedStderr
+}
+
+// isSameWriter returns true if x and y are the same io.Writer, without panicking when they aren't comparable.
+func isSameWriter(x, y io.Writer) bool {
+	if x == nil || y == nil {
+		return false
+	}
+	if t := reflect.TypeOf(x); t != reflect.TypeOf(y) || !t.Comparable() {
+		return false
+	}
+	return x == y
+}
+
+// Flush writes any data buffered in Stdout or Stderr, via wazero.ModuleConfig WithOutputBufferSize, to the
+// underlying io.Writer. This is a no-op for other writers, even if they buffer, as wazero doesn't own them.
+func Flush(w io.Writer) error {
+	if b, ok := w.(*OutputBuffer); ok {
+		return b.Flush()
+	}
+	return nil
+}
+
 // eofReader is safer than reading from os.DevNull as it can never overrun operating system file descriptors.
 type eofReader struct{}
 
@@ -105,12 +179,82 @@ func (eofReader) Read([]byte) (int, error) {
 	return 0, io.EOF
 }
 
+// closeableReader is an io.Reader whose Read returns fs.ErrClosed once closed is closed, even if r is blocked.
+type closeableReader struct {
+	r      io.Reader
+	closed <-chan struct{}
+
+	// mux serializes Read, so that there is at most one Read of r in progress.
+	mux sync.Mutex
+	// requests is lazily initialized on the first Read, which starts the goroutine reading r until closed.
+	requests chan int
+	results  chan readResult
+	// buf is read into by the goroutine, as the caller of Read no longer owns p after close.
+	buf []byte
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+// Read implements io.Reader by reading r in a separate goroutine, so that the caller isn't blocked after close.
+//
+// Note: Once closed, r isn't read again. However, data from a Read of r still in progress is discarded.
+func (r *closeableReader) Read(p []byte) (int, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	select {
+	case <-r.closed:
+		return 0, fs.ErrClosed
+	default:
+	}
+
+	if r.requests == nil {
+		r.requests = make(chan int)
+		r.results = make(chan readResult, 1) // buffered to not block the goroutine when the read completes after close.
+		go r.readLoop()
+	}
+
+	// The goroutine is idle, as the previous Read either received its result or returned after close.
+	if cap(r.buf) < len(p) {
+		r.buf = make([]byte, len(p))
+	}
+	select {
+	case r.requests <- len(p):
+	case <-r.closed:
+		return 0, fs.ErrClosed
+	}
+
+	select {
+	case res := <-r.results:
+		copy(p, r.buf[:res.n])
+		return res.n, res.err
+	case <-r.closed:
+		return 0, fs.ErrClosed
+	}
+}
+
+// readLoop reads r into buf for each request until closed.
+func (r *closeableReader) readLoop() {
+	for {
+		select {
+		case size := <-r.requests:
+			n, err := r.r.Read(r.buf[:size])
+			r.results <- readResult{n, err}
+		case <-r.closed:
+			return
+		}
+	}
+}
+
 // DefaultSysContext returns SysContext with no values set.
 //
 // Note: This isn't a constant because SysContext.openedFiles is currently mutable even when empty.
 // TODO: Make it an error to open or close files when no FS was assigned.
 func DefaultSysContext() *SysContext {
-	if sys, err := NewSysContext(0, nil, nil, nil, nil, nil, nil); err != nil {
+	if sys, err := NewSysContext(0, nil, nil, nil, nil, nil, nil, nil); err != nil {
 		panic(fmt.Errorf("BUG: DefaultSysContext should never error: %w", err))
 	} else {
 		return sys
@@ -121,7 +265,7 @@ var _ = DefaultSysContext() // Force panic on bug.
 
 // NewSysContext is a factory function which helps avoid needing to know defaults or exporting all fields.
 // Note: max is exposed for testing. max is only used for env/args validation.
-func NewSysContext(max uint32, args, environ []string, stdin io.Reader, stdout, stderr io.Writer, openedFiles map[uint32]*FileEntry) (sys *SysContext, err error) {
+func NewSysContext(max uint32, args, environ []string, stdin io.Reader, stdout, stderr io.Writer, randSource io.Reader, openedFiles map[uint32]*FileEntry) (sys *SysContext, err error) {
 	sys = &SysContext{args: args, environ: environ}
 
 	if sys.argsSize, err = nullTerminatedByteCount(max, args); err != nil {
@@ -150,17 +294,16 @@ func NewSysContext(max uint32, args, environ []string, stdin io.Reader, stdout,
 		sys.stderr = stderr
 	}
 
+	if randSource == nil {
+		sys.randSource = crand.Reader
+	} else {
+		sys.randSource = randSource
+	}
+
 	if openedFiles == nil {
 		sys.openedFiles = map[uint32]*FileEntry{}
-		sys.lastFD = 2 // STDERR
 	} else {
 		sys.openedFiles = openedFiles
-		sys.lastFD = 2 // STDERR
-		for fd := range openedFiles {
-			if fd > sys.lastFD {
-				sys.lastFD = fd
-			}
-		}
 	}
 	return
 }
@@ -196,6 +339,25 @@ func nullTerminatedByteCount(max uint32, elements []string) (uint32, error) {
 
 // Close implements io.Closer
 func (c *SysContext) Close() (err error) {
+	// Unblock any in-flight reads of Stdin. This is guarded as Close can be called concurrently.
+	c.closedMux.Lock()
+	if c.closed == nil {
+		c.closed = make(chan struct{})
+	}
+	select {
+	case <-c.closed: // already closed
+	default:
+		close(c.closed)
+	}
+	c.closedMux.Unlock()
+
+	// Flush any buffered output, so that it isn't lost, for example on proc_exit.
+	for _, w := range []io.Writer{c.stdout, c.stderr} {
+		if e := Flush(w); e != nil {
+			err = e
+		}
+	}
+
 	// Close any files opened in this context
 	for fd, entry := range c.openedFiles {
 		delete(c.openedFiles, fd)
@@ -233,7 +395,7 @@ func (c *SysContext) OpenedFile(fd uint32) (*FileEntry, bool) {
 
 // OpenFile returns the file descriptor of the new file or false if we ran out of file descriptors
 func (c *SysContext) OpenFile(f *FileEntry) (uint32, bool) {
-	newFD := c.nextFD()
+	newFD := c.allocateFD()
 	if newFD == 0 {
 		return 0, false
 	}
@@ -1,11 +1,16 @@
 package wasm
 
 import (
+	"bufio"
 	"bytes"
+	crand "crypto/rand"
 	"io"
 	"io/fs"
 	"os"
 	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"testing/fstest"
 
@@ -20,6 +25,7 @@ func TestDefaultSysContext(t *testing.T) {
 		nil, // stdin
 		nil, // stdout
 		nil, // stderr
+		nil, // randSource
 		nil, // openedFiles
 	)
 	require.NoError(t, err)
@@ -31,6 +37,7 @@ func TestDefaultSysContext(t *testing.T) {
 	require.Equal(t, eofReader{}, sys.Stdin())
 	require.Equal(t, io.Discard, sys.Stdout())
 	require.Equal(t, io.Discard, sys.Stderr())
+	require.Equal(t, crand.Reader, sys.RandSource())
 	require.Equal(t, 0, len(sys.openedFiles), "expected no opened files")
 
 	require.Equal(t, sys, DefaultSysContext())
@@ -81,6 +88,7 @@ func TestNewSysContext_Args(t *testing.T) {
 				bytes.NewReader(make([]byte, 0)), // stdin
 				nil,                              // stdout
 				nil,                              // stderr
+				nil,                              // randSource
 				nil,                              // openedFiles
 			)
 			if tc.expectedErr == "" {
@@ -139,6 +147,7 @@ func TestNewSysContext_Environ(t *testing.T) {
 				bytes.NewReader(make([]byte, 0)), // stdin
 				nil,                              // stdout
 				nil,                              // stderr
+				nil,                              // randSource
 				nil,                              // openedFiles
 			)
 			if tc.expectedErr == "" {
@@ -152,6 +161,168 @@ func TestNewSysContext_Environ(t *testing.T) {
 	}
 }
 
+func TestSysContext_OpenFile(t *testing.T) {
+	testFS := fstest.MapFS{}
+	sys, err := NewSysContext(
+		0,   // max
+		nil, // args
+		nil, // environ
+		nil, // stdin
+		nil, // stdout
+		nil, // stderr
+		nil, // randSource
+		map[uint32]*FileEntry{ // openedFiles, with a gap at 4
+			3: {Path: "/", FS: testFS},
+			5: {Path: ".", FS: testFS},
+		},
+	)
+	require.NoError(t, err)
+
+	// The lowest file descriptor not in use is allocated, even if a higher one is.
+	fd, ok := sys.OpenFile(&FileEntry{Path: "a", FS: testFS})
+	require.True(t, ok)
+	require.Equal(t, uint32(4), fd)
+
+	fd, ok = sys.OpenFile(&FileEntry{Path: "b", FS: testFS})
+	require.True(t, ok)
+	require.Equal(t, uint32(6), fd)
+
+	// A closed file descriptor is reused.
+	closed, err := sys.CloseFile(4)
+	require.NoError(t, err)
+	require.True(t, closed)
+
+	fd, ok = sys.OpenFile(&FileEntry{Path: "c", FS: testFS})
+	require.True(t, ok)
+	require.Equal(t, uint32(4), fd)
+}
+
+func TestSysContext_Stdin(t *testing.T) {
+	t.Run("reads in order", func(t *testing.T) {
+		sys, err := NewSysContext(0, nil, nil, strings.NewReader("wazero"), nil, nil, nil, nil)
+		require.NoError(t, err)
+		defer sys.Close()
+
+		buf := make([]byte, 2)
+		n, err := sys.Stdin().Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, "wa", string(buf[:n]))
+
+		buf = make([]byte, 10)
+		n, err = sys.Stdin().Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, "zero", string(buf[:n]))
+	})
+
+	t.Run("not read after close", func(t *testing.T) {
+		stdin := &blockingReader{started: make(chan struct{}, 1), unblock: make(chan struct{})}
+		sys, err := NewSysContext(0, nil, nil, stdin, nil, nil, nil, nil)
+		require.NoError(t, err)
+
+		readErr := make(chan error)
+		go func() {
+			_, err := sys.Stdin().Read(make([]byte, 1))
+			readErr <- err
+		}()
+		<-stdin.started
+
+		// Closing unblocks the read in progress.
+		require.NoError(t, sys.Close())
+		require.ErrorIs(t, <-readErr, fs.ErrClosed)
+
+		// Reads after close fail without reading stdin again.
+		_, err = sys.Stdin().Read(make([]byte, 1))
+		require.ErrorIs(t, err, fs.ErrClosed)
+		close(stdin.unblock)
+		require.Equal(t, int32(1), atomic.LoadInt32(&stdin.reads))
+	})
+
+	t.Run("concurrent close", func(t *testing.T) {
+		sys, err := NewSysContext(0, nil, nil, strings.NewReader("wazero"), nil, nil, nil, nil)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = sys.Close() // must not panic closing the channel twice
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+// blockingReader counts calls to Read, which block until unblock is closed.
+type blockingReader struct {
+	reads   int32
+	started chan struct{}
+	unblock chan struct{}
+}
+
+// Read implements io.Reader
+func (r *blockingReader) Read(p []byte) (int, error) {
+	atomic.AddInt32(&r.reads, 1)
+	r.started <- struct{}{}
+	<-r.unblock
+	return copy(p, "x"), nil
+}
+
+func TestNewOutputBuffers(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		stdout, stderr := NewOutputBuffers(nil, nil, 16)
+		require.Nil(t, stdout)
+		require.Nil(t, stderr)
+	})
+
+	t.Run("different writers", func(t *testing.T) {
+		var out, err bytes.Buffer
+		stdout, stderr := NewOutputBuffers(&out, &err, 16)
+		require.NotSame(t, stdout, stderr)
+	})
+
+	t.Run("same writer shares a buffer", func(t *testing.T) {
+		var out bytes.Buffer
+		stdout, stderr := NewOutputBuffers(&out, &out, 16)
+		require.Same(t, stdout, stderr)
+
+		_, _ = stdout.Write([]byte("1"))
+		_, _ = stderr.Write([]byte("2"))
+		_, _ = stdout.Write([]byte("3"))
+		require.NoError(t, Flush(stderr))
+		require.Equal(t, "123", out.String())
+	})
+
+	t.Run("same writer not comparable", func(t *testing.T) {
+		out := uncomparableWriter{nil}
+		stdout, stderr := NewOutputBuffers(out, out, 16)
+		require.NotSame(t, stdout, stderr)
+	})
+}
+
+// uncomparableWriter panics on == as it includes a slice.
+type uncomparableWriter struct{ _ []byte }
+
+// Write implements io.Writer
+func (uncomparableWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func TestFlush(t *testing.T) {
+	var out bytes.Buffer
+	user := bufio.NewWriter(&out)
+	_, _ = user.WriteString("wazero")
+
+	// An io.Writer wazero didn't create isn't flushed, even if it can be.
+	require.NoError(t, Flush(user))
+	require.Zero(t, out.Len())
+
+	buffered, _ := NewOutputBuffers(&out, nil, 16)
+	_, _ = buffered.Write([]byte("wazero"))
+	require.NoError(t, Flush(buffered))
+	require.Equal(t, "wazero", out.String())
+}
+
 func TestSysContext_Close(t *testing.T) {
 	t.Run("no files", func(t *testing.T) {
 		sys := DefaultSysContext()
@@ -170,6 +341,7 @@ func TestSysContext_Close(t *testing.T) {
 			nil, // stdin
 			nil, // stdout
 			nil, // stderr
+			nil, // randSource
 			map[uint32]*FileEntry{ // openedFiles
 				3: {Path: "/", FS: testFS},
 				4: {Path: ".", FS: testFS},
@@ -199,6 +371,7 @@ func TestSysContext_Close(t *testing.T) {
 			nil, // stdin
 			nil, // stdout
 			nil, // stderr
+			nil, // randSource
 			map[uint32]*FileEntry{ // no openedFiles
 				3: {Path: "/", FS: testFS},
 				4: {Path: ".", FS: testFS},
@@ -223,6 +396,7 @@ func TestSysContext_Close(t *testing.T) {
 			nil, // stdin
 			nil, // stdout
 			nil, // stderr
+			nil, // randSource
 			map[uint32]*FileEntry{ // openedFiles
 				3: {Path: "/", FS: testFS},
 				4: {Path: ".", FS: testFS},
@@ -3,10 +3,12 @@ package wasm
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sync"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/leb128"
 )
 
@@ -104,6 +106,49 @@ type TableInstance struct {
 
 	// mux is used to prevent overlapping calls to Grow.
 	mux sync.RWMutex
+
+	// store is the Store of the module which defined this table, so that SetFunc can reject functions from another.
+	store *Store
+}
+
+// compile-time check to ensure TableInstance implements api.Table
+var _ api.Table = &TableInstance{}
+
+// Size implements the same method as documented on api.Table.
+func (t *TableInstance) Size(context.Context) uint32 {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	return uint32(len(t.References))
+}
+
+// SetFunc implements the same method as documented on api.Table.
+func (t *TableInstance) SetFunc(_ context.Context, index uint32, fn api.Function) error {
+	if t.Type != RefTypeFuncref {
+		return fmt.Errorf("cannot set a function in a %s table", RefTypeName(t.Type))
+	}
+
+	var f *FunctionInstance
+	switch fn := fn.(type) {
+	case *FunctionInstance:
+		f = fn
+	case *importedFn:
+		f = fn.importedFn
+	default:
+		return fmt.Errorf("unsupported api.Function implementation: %T", fn)
+	}
+	if f.Module.CallCtx == nil || f.Module.CallCtx.store != t.store {
+		return errors.New("function is not from the same runtime as the table")
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if size := uint32(len(t.References)); index >= size {
+		return fmt.Errorf("index %d out of range for table of size %d", index, size)
+	}
+	// The reference is specific to the engine of the module defining the function, which is the same for all modules
+	// in the store.
+	t.References[index] = f.Module.Engine.CreateFuncElementInstance([]*Index{&f.Idx}).References[0]
+	return nil
 }
 
 // ElementInstance represents an element instance in a module.
@@ -123,7 +168,7 @@ type Reference = uintptr
 //
 // Note: The global imported at globalIdx may have an offset value that is out-of-bounds for the corresponding table.
 type validatedActiveElementSegment struct {
-	// opcode is OpcodeGlobalGet or OpcodeI32Const
+	// opcode is OpcodeGlobalGet, OpcodeI32Const or, when FeatureExtendedConst is enabled, the last instruction of expr.
 	opcode Opcode
 
 	// arg is the only argument to opcode, which when applied results in the offset to add to init indices.
@@ -131,6 +176,9 @@ type validatedActiveElementSegment struct {
 	//  * OpcodeI32Const: a constant ValueTypeI32 offset.
 	arg uint32
 
+	// expr is the offset expression when it has more than one instruction, allowed by FeatureExtendedConst.
+	expr *ConstantExpression
+
 	// init are a range of table elements whose values are positions in the function index namespace. This range
 	// replaces any values in TableInstance.Table at an offset arg which is a constant if opcode == OpcodeI32Const or
 	// derived from a globalIdx if opcode == OpcodeGlobalGet
@@ -220,6 +268,19 @@ func (m *Module) validateTable(enabledFeatures Features, tables []*Table, maximu
 				}
 
 				ret = append(ret, &validatedActiveElementSegment{opcode: oc, arg: offset, init: elem.Init, tableIndex: elem.TableIndex})
+			} else if oc == OpcodeI32Add || oc == OpcodeI32Sub || oc == OpcodeI32Mul {
+				// Like global.get, the offset is only known once imported globals are, so bounds are checked then.
+				_, globals, _, _, _ := m.AllDeclarations()
+				importedGlobals := globals[:m.ImportGlobalCount()]
+				if err := validateConstExpression(enabledFeatures, importedGlobals, 0, elem.OffsetExpr, ValueTypeI32); err != nil {
+					return nil, fmt.Errorf("%s[%d] has an invalid const expression: %w", SectionIDName(SectionIDElement), idx, err)
+				}
+
+				if initCount == 0 {
+					continue // Per https://github.com/WebAssembly/spec/issues/1427 init can be no-op, but validate anyway!
+				}
+
+				ret = append(ret, &validatedActiveElementSegment{opcode: oc, expr: elem.OffsetExpr, init: elem.Init, tableIndex: elem.TableIndex})
 			} else {
 				return nil, fmt.Errorf("%s[%d] has an invalid const expression: %s", SectionIDName(SectionIDElement), idx, InstructionName(oc))
 			}
@@ -256,7 +317,9 @@ func (m *Module) buildTables(importedTables []*TableInstance, importedGlobals []
 	for elemI, elem := range elementSegments {
 		table := tables[elem.tableIndex]
 		var offset uint32
-		if elem.opcode == OpcodeGlobalGet {
+		if elem.expr != nil {
+			offset = uint32(executeExtendedConstExpression(importedGlobals, elem.expr))
+		} else if elem.opcode == OpcodeGlobalGet {
 			global := importedGlobals[elem.arg]
 			offset = uint32(global.Val)
 		} else {
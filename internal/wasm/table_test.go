@@ -598,6 +598,67 @@ func TestModule_validateTable_Errors(t *testing.T) {
 	}
 }
 
+// TestModule_validateTable_ExtendedConst ensures an active element segment offset can be an extended-const
+// expression, which is only evaluated once imported globals are known.
+func TestModule_validateTable_ExtendedConst(t *testing.T) {
+	newModule := func(importedType ValueType) *Module {
+		return &Module{
+			ImportSection: []*Import{
+				{Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: importedType}},
+			},
+			TypeSection:     []*FunctionType{{}},
+			TableSection:    []*Table{{Min: 4}},
+			FunctionSection: []Index{0},
+			CodeSection:     []*Code{codeEnd},
+			ElementSection: []*ElementSegment{
+				{
+					// (i32.add (global.get 0) (i32.const 1))
+					OffsetExpr: &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{OpcodeGlobalGet, 0, OpcodeI32Const, 1}},
+					Init:       []*Index{uint32Ptr(0)},
+					Type:       RefTypeFuncref,
+				},
+			},
+		}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		m := newModule(ValueTypeI32)
+		_, _, _, tables, err := m.AllDeclarations()
+		require.NoError(t, err)
+		vt, err := m.validateTable(FeatureExtendedConst, tables, 1)
+		require.NoError(t, err)
+		require.Equal(t, []*validatedActiveElementSegment{
+			{opcode: OpcodeI32Add, expr: m.ElementSection[0].OffsetExpr, init: []*Index{uint32Ptr(0)}},
+		}, vt)
+
+		importedGlobals := []*GlobalInstance{{Type: &GlobalType{ValType: ValueTypeI32}, Val: 2}}
+		_, init, err := m.buildTables(nil, importedGlobals, false)
+		require.NoError(t, err)
+		require.Equal(t, []TableInitEntry{{Offset: 3, FunctionIndexes: []*Index{uint32Ptr(0)}}}, init)
+
+		// The offset is bounds checked when the imported global is known.
+		importedGlobals[0].Val = 4
+		_, _, err = m.buildTables(nil, importedGlobals, false)
+		require.EqualError(t, err, "element[0].init exceeds min table size")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		m := newModule(ValueTypeI32)
+		_, _, _, tables, err := m.AllDeclarations()
+		require.NoError(t, err)
+		_, err = m.validateTable(Features20220419, tables, 1)
+		require.EqualError(t, err, `element[0] has an invalid const expression: i32.add invalid in const expression as feature "extended-const" is disabled`)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		m := newModule(ValueTypeI64)
+		_, _, _, tables, err := m.AllDeclarations()
+		require.NoError(t, err)
+		_, err = m.validateTable(FeatureExtendedConst, tables, 1)
+		require.EqualError(t, err, "element[0] has an invalid const expression: i32.add requires two i32 operands in const expression")
+	})
+}
+
 var const0 = leb128.EncodeInt32(0)
 var const1 = leb128.EncodeInt32(1)
 
@@ -1000,3 +1061,34 @@ func TestTableInstance_Grow(t *testing.T) {
 		})
 	}
 }
+
+func TestTableInstance_SetFunc_Errors(t *testing.T) {
+	s := &Store{}
+	fn := &FunctionInstance{Module: &ModuleInstance{Engine: &mockModuleEngine{}, CallCtx: &CallContext{store: s}}}
+	for _, tc := range []struct {
+		name        string
+		table       *TableInstance
+		expectedErr string
+	}{
+		{
+			name:        "externref",
+			table:       &TableInstance{References: make([]uintptr, 1), Type: RefTypeExternref, store: s},
+			expectedErr: "cannot set a function in a externref table",
+		},
+		{
+			name:        "out of range",
+			table:       &TableInstance{References: make([]uintptr, 1), Type: RefTypeFuncref, store: s},
+			expectedErr: "index 1 out of range for table of size 1",
+		},
+		{
+			name:        "another store",
+			table:       &TableInstance{References: make([]uintptr, 2), Type: RefTypeFuncref, store: &Store{}},
+			expectedErr: "function is not from the same runtime as the table",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.EqualError(t, tc.table.SetFunc(testCtx, 1, fn), tc.expectedErr)
+		})
+	}
+}
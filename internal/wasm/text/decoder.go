@@ -100,6 +100,9 @@ type moduleParser struct {
 	fieldCountFunc uint32
 
 	exportedName map[string]struct{}
+
+	// sourceMap is true when the text format position of each instruction should be recorded in wasm.Module SourceMap.
+	sourceMap bool
 }
 
 // DecodeModule implements wasm.DecodeModule for the WebAssembly 1.0 (20191205) Text Format
@@ -108,6 +111,25 @@ func DecodeModule(
 	source []byte,
 	enabledFeatures wasm.Features,
 	memorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32),
+) (module *wasm.Module, err error) {
+	return decodeModule(source, enabledFeatures, memorySizer, false)
+}
+
+// DecodeModuleWithSourceMap implements wasm.DecodeModule similar to DecodeModule, except it also populates
+// wasm.Module SourceMap with the line and column of each instruction.
+func DecodeModuleWithSourceMap(
+	source []byte,
+	enabledFeatures wasm.Features,
+	memorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32),
+) (module *wasm.Module, err error) {
+	return decodeModule(source, enabledFeatures, memorySizer, true)
+}
+
+func decodeModule(
+	source []byte,
+	enabledFeatures wasm.Features,
+	memorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32),
+	sourceMap bool,
 ) (module *wasm.Module, err error) {
 	// TODO: when globals are supported, err on global vars if disabled
 
@@ -120,6 +142,7 @@ func DecodeModule(
 	module = &wasm.Module{NameSection: names}
 	p := newModuleParser(module, enabledFeatures, memorySizer)
 	p.source = source
+	p.sourceMap = sourceMap
 
 	// A valid source must begin with the token '(', but it could be preceded by whitespace or comments. For this
 	// reason, we cannot enforce source[0] == '(', and instead need to start the lexer to check the first token.
@@ -451,6 +474,9 @@ func (p *moduleParser) endFunc(typeIdx wasm.Index, code *wasm.Code, name string,
 	p.addFunctionName(name)
 	p.module.FunctionSection = append(p.module.FunctionSection, typeIdx)
 	p.module.CodeSection = append(p.module.CodeSection, code)
+	if p.sourceMap {
+		p.module.SourceMap = append(p.module.SourceMap, p.funcParser.currentPositions)
+	}
 	p.addLocalNames(localNames)
 
 	// Multiple funcs are allowed, so advance in case there's a next.
@@ -463,7 +489,7 @@ func (p *moduleParser) endFunc(typeIdx wasm.Index, code *wasm.Code, name string,
 // endMemory adds the limits for the current memory, and increments memoryNamespace as it is shared across imported and
 // module-defined memories. Finally, this returns parseModule to prepare for the next field.
 func (p *moduleParser) endMemory(mem *wasm.Memory) tokenParser {
-	p.module.MemorySection = mem
+	p.module.MemorySection = []*wasm.Memory{mem}
 	p.pos = positionModule
 	return p.parseModule
 }
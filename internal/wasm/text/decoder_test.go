@@ -1226,14 +1226,14 @@ func TestDecodeModule(t *testing.T) {
 			name:  "memory",
 			input: "(module (memory 1))",
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages}},
 			},
 		},
 		{
 			name:  "memory ID",
 			input: "(module (memory $mem 1))",
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages}},
 			},
 		},
 		{
@@ -1420,7 +1420,7 @@ func TestDecodeModule(t *testing.T) {
 	(export "foo" (memory 0))
 )`,
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 0, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 0, Max: wasm.MemoryLimitPages}},
 				ExportSection: []*wasm.Export{
 					{Name: "foo", Type: wasm.ExternTypeMemory, Index: 0},
 				},
@@ -1433,7 +1433,7 @@ func TestDecodeModule(t *testing.T) {
 	(memory 0)
 )`,
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 0, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 0, Max: wasm.MemoryLimitPages}},
 				ExportSection: []*wasm.Export{
 					{Name: "foo", Type: wasm.ExternTypeMemory, Index: 0},
 				},
@@ -1465,7 +1465,7 @@ func TestDecodeModule(t *testing.T) {
     (export "memory" (memory $mem))
 )`,
 			expected: &wasm.Module{
-				MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages},
+				MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages}},
 				ExportSection: []*wasm.Export{
 					{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
 				},
@@ -1571,6 +1571,30 @@ func TestDecodeModule(t *testing.T) {
 	}
 }
 
+func TestDecodeModuleWithSourceMap(t *testing.T) {
+	m, err := DecodeModuleWithSourceMap([]byte(`(module
+	(func)
+	(func $div (param i32) (param i32) (result i32)
+		local.get 0
+		local.get 1 i32.div_s
+	)
+)`), wasm.Features20220419, wasm.MemorySizer)
+	require.NoError(t, err)
+	require.Equal(t, [][]*wasm.SourcePosition{
+		nil, // (func) has no instructions
+		{
+			{Offset: 0, Line: 4, Col: 3},  // local.get 0
+			{Offset: 2, Line: 5, Col: 3},  // local.get 1
+			{Offset: 4, Line: 5, Col: 15}, // i32.div_s
+		},
+	}, m.SourceMap)
+
+	// The source map isn't recorded by default.
+	m, err = DecodeModule([]byte(`(module (func i32.const 1 drop))`), wasm.Features20220419, wasm.MemorySizer)
+	require.NoError(t, err)
+	require.Nil(t, m.SourceMap)
+}
+
 func TestParseModule_Errors(t *testing.T) {
 	tests := []struct {
 		name, input string
@@ -42,6 +42,10 @@ type funcParser struct {
 
 	// currentBody is the current function body encoded in WebAssembly 1.0 (20191205) binary format
 	currentBody []byte
+
+	// currentPositions holds the source position of each instruction in currentBody. This is read by
+	// moduleParser.endFunc when building wasm.Module SourceMap.
+	currentPositions []*wasm.SourcePosition
 }
 
 // end indicates the end of instructions in this function body
@@ -103,6 +107,7 @@ func (p *funcParser) parseFunc(tok tokenType, tokenBytes []byte, line, col uint3
 //          afterTypeUse starts here --^  ^
 //                    calls onFunc here --+
 func (p *funcParser) afterTypeUse(typeIdx wasm.Index, paramNames wasm.NameMap, pos callbackPosition, tok tokenType, tokenBytes []byte, line, col uint32) (tokenParser, error) {
+	p.currentPositions = nil
 	switch pos {
 	case callbackPositionEndField:
 		return p.onFunc(typeIdx, codeEnd, p.currentName, paramNames)
@@ -129,13 +134,16 @@ func sExpressionsUnsupported(tok tokenType, tokenBytes []byte, _, _ uint32) (tok
 	return nil, fmt.Errorf("TODO: s-expressions are not yet supported: %s", tokenBytes)
 }
 
-func (p *funcParser) beginFieldOrInstruction(tok tokenType, tokenBytes []byte, _, _ uint32) (tokenParser, error) {
+func (p *funcParser) beginFieldOrInstruction(tok tokenType, tokenBytes []byte, line, col uint32) (tokenParser, error) {
 	switch tok {
 	case tokenLParen:
 		return sExpressionsUnsupported, nil
 	case tokenRParen:
 		return p.end()
 	case tokenKeyword:
+		p.currentPositions = append(p.currentPositions, &wasm.SourcePosition{
+			Offset: uint64(len(p.currentBody)), Line: line, Col: col,
+		})
 		return p.beginInstruction(tokenBytes)
 	}
 	return nil, unexpectedToken(tok, tokenBytes)
@@ -165,6 +173,12 @@ func (p *funcParser) beginInstruction(tokenBytes []byte) (next tokenParser, err
 	case wasm.OpcodeI32SubName: // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#syntax-instr-numeric
 		opCode = wasm.OpcodeI32Sub
 		next = p.beginFieldOrInstruction
+	case wasm.OpcodeI32DivSName: // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#syntax-instr-numeric
+		opCode = wasm.OpcodeI32DivS
+		next = p.beginFieldOrInstruction
+	case wasm.OpcodeI32DivUName: // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#syntax-instr-numeric
+		opCode = wasm.OpcodeI32DivU
+		next = p.beginFieldOrInstruction
 	case wasm.OpcodeI32ConstName: // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#syntax-instr-numeric
 		opCode = wasm.OpcodeI32Const
 		next = p.parseI32
@@ -72,6 +72,20 @@ func TestFuncParser(t *testing.T) {
 				wasm.OpcodeI32Const, 0x02, wasm.OpcodeI32Const, 0x01, wasm.OpcodeI32Sub, wasm.OpcodeEnd,
 			}},
 		},
+		{
+			name:   "i32.div_s",
+			source: "(func i32.const 2 i32.const 1 i32.div_s)",
+			expected: &wasm.Code{Body: []byte{
+				wasm.OpcodeI32Const, 0x02, wasm.OpcodeI32Const, 0x01, wasm.OpcodeI32DivS, wasm.OpcodeEnd,
+			}},
+		},
+		{
+			name:   "i32.div_u",
+			source: "(func i32.const 2 i32.const 1 i32.div_u)",
+			expected: &wasm.Code{Body: []byte{
+				wasm.OpcodeI32Const, 0x02, wasm.OpcodeI32Const, 0x01, wasm.OpcodeI32DivU, wasm.OpcodeEnd,
+			}},
+		},
 		{
 			name:   "i32.load",
 			source: "(func i32.const 8 i32.load)",
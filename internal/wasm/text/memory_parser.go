@@ -97,6 +97,28 @@ func (p *memoryParser) beginMax(tok tokenType, tokenBytes []byte, line, col uint
 				return nil, err
 			}
 		}
+		return p.beginShared, nil
+	case tokenKeyword:
+		return p.beginShared(tok, tokenBytes, line, col)
+	case tokenRParen:
+		return p.end(tok, tokenBytes, line, col)
+	default:
+		return nil, unexpectedToken(tok, tokenBytes)
+	}
+}
+
+// beginShared looks for the "shared" keyword and returns end. If this is an ')' end completes the memory. Otherwise,
+// this errs on any other token.
+//
+// Ex. `(memory 1 2 shared)`
+//      records shared --^
+func (p *memoryParser) beginShared(tok tokenType, tokenBytes []byte, line, col uint32) (tokenParser, error) {
+	switch tok {
+	case tokenKeyword:
+		if string(tokenBytes) != "shared" {
+			return nil, unexpectedToken(tok, tokenBytes)
+		}
+		p.currentMemory.Shared = true
 		return p.end, nil
 	case tokenRParen:
 		return p.end(tok, tokenBytes, line, col)
@@ -53,6 +53,16 @@ func TestMemoryParser(t *testing.T) {
 			expected:   &wasm.Memory{Min: max, Cap: max, Max: max, IsMaxEncoded: true},
 			expectedID: "mem",
 		},
+		{
+			name:     "min 1, max 2 shared",
+			input:    "(memory 1 2 shared)",
+			expected: &wasm.Memory{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true, Shared: true},
+		},
+		{
+			name:     "min 1 shared", // invalid, but parses as the module validates the max.
+			input:    "(memory 1 shared)",
+			expected: &wasm.Memory{Min: 1, Cap: 1, Max: max, Shared: true},
+		},
 	}
 
 	for _, tt := range tests {
@@ -114,6 +124,16 @@ func TestMemoryParser_Errors(t *testing.T) {
 			input:       "(memory 0 0 $0)",
 			expectedErr: "unexpected ID: $0",
 		},
+		{
+			name:        "invalid after max keyword",
+			input:       "(memory 0 0 unshared)",
+			expectedErr: "unexpected keyword: unshared",
+		},
+		{
+			name:        "invalid after shared",
+			input:       "(memory 0 0 shared 1)",
+			expectedErr: "unexpected uN: 1",
+		},
 		{
 			name:        "max < min",
 			input:       "(memory 1 0)",
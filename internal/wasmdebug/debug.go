@@ -1,6 +1,6 @@
 // Package wasmdebug contains utilities used to give consistent search keys between stack traces and error messages.
 // Note: This is named wasmdebug to avoid conflicts with the normal go module.
-// Note: This only imports "api" as importing "wasm" would create a cyclic dependency.
+// Note: This only imports "api" and "sys" as importing "wasm" would create a cyclic dependency.
 package wasmdebug
 
 import (
@@ -12,7 +12,7 @@ import (
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/buildoptions"
-	"github.com/tetratelabs/wazero/internal/wasmruntime"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 // FuncName returns the naming convention of "moduleName.funcName".
@@ -98,6 +98,13 @@ type ErrorBuilder interface {
 	// Note: paramTypes and resultTypes are present because signature misunderstanding, mismatch or overflow are common.
 	AddFrame(funcName string, paramTypes, resultTypes []api.ValueType)
 
+	// SetProgramCounter records the location of the instruction that trapped in the first frame.
+	//
+	// * moduleName is the name of the module instance defining the function.
+	// * funcIdx is the position in the function index namespace, prefixed with imported functions.
+	// * pc is the offset of the trapping instruction in the function's wasm.Code Body.
+	SetProgramCounter(moduleName string, funcIdx uint32, pc uint64)
+
 	// FromRecovered returns an error with the wasm stack trace appended to it.
 	FromRecovered(recovered interface{}) error
 }
@@ -107,7 +114,8 @@ func NewErrorBuilder() ErrorBuilder {
 }
 
 type stackTrace struct {
-	frames []string
+	frames   []string
+	location *sys.TrapLocation
 }
 
 func (s *stackTrace) FromRecovered(recovered interface{}) error {
@@ -118,8 +126,12 @@ func (s *stackTrace) FromRecovered(recovered interface{}) error {
 	stack := strings.Join(s.frames, "\n\t")
 
 	// If the error was internal, don't mention it was recovered.
-	if wasmErr, ok := recovered.(*wasmruntime.Error); ok {
-		return fmt.Errorf("wasm error: %w\nwasm stack trace:\n\t%s", wasmErr, stack)
+	// Note: This matches *wasmruntime.Error as well as errors with more detail, such as *wasmruntime.MemoryAccessError.
+	if wasmErr, ok := recovered.(interface {
+		error
+		Code() sys.TrapCode
+	}); ok {
+		return sys.NewTrapError(wasmErr.Code(), wasmErr, fmt.Sprintf("wasm error: %s\nwasm stack trace:\n\t%s", wasmErr, stack), s.location)
 	}
 
 	// If we have a runtime.Error, something severe happened which should include the stack trace. This could be
@@ -143,3 +155,8 @@ func (s *stackTrace) AddFrame(funcName string, paramTypes, resultTypes []api.Val
 	// TODO: include DWARF symbols. See #58
 	s.frames = append(s.frames, signature(funcName, paramTypes, resultTypes))
 }
+
+// SetProgramCounter implements ErrorBuilder.SetProgramCounter
+func (s *stackTrace) SetProgramCounter(moduleName string, funcIdx uint32, pc uint64) {
+	s.location = &sys.TrapLocation{ModuleName: moduleName, FunctionIndex: funcIdx, ProgramCounter: pc}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasmruntime"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 func TestFuncName(t *testing.T) {
@@ -63,6 +64,7 @@ func TestAddSignature(t *testing.T) {
 func TestErrorBuilder(t *testing.T) {
 	argErr := errors.New("invalid argument")
 	rteErr := testRuntimeErr("index out of bounds")
+	memErr := &wasmruntime.MemoryAccessError{Address: 65535, Width: 4, MemorySize: 65536}
 	i32 := api.ValueTypeI32
 	i32i32i32i32 := []api.ValueType{i32, i32, i32, i32}
 
@@ -122,6 +124,17 @@ wasm stack trace:
 	x.y()`,
 			expectUnwrap: wasmruntime.ErrRuntimeCallStackOverflow,
 		},
+		{
+			name: "wasmruntime.MemoryAccessError",
+			build: func(builder ErrorBuilder) error {
+				builder.AddFrame("x.y", nil, nil)
+				return builder.FromRecovered(memErr)
+			},
+			expectedErr: `wasm error: out of bounds memory access: 4 byte access at address 65535, but memory size is 65536
+wasm stack trace:
+	x.y()`,
+			expectUnwrap: memErr,
+		},
 	} {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
@@ -132,6 +145,22 @@ wasm stack trace:
 	}
 }
 
+func TestErrorBuilder_SetProgramCounter(t *testing.T) {
+	builder := NewErrorBuilder()
+	builder.SetProgramCounter("x", 1, 4)
+	builder.AddFrame("x.y", nil, nil)
+	err := builder.FromRecovered(wasmruntime.ErrRuntimeIntegerDivideByZero)
+
+	trapErr, ok := err.(*sys.TrapError)
+	require.True(t, ok)
+	require.Equal(t, sys.TrapDivByZero, trapErr.Code())
+	require.Equal(t, &sys.TrapLocation{ModuleName: "x", FunctionIndex: 1, ProgramCounter: 4}, trapErr.Location())
+
+	// The location is unknown unless set.
+	err = NewErrorBuilder().FromRecovered(wasmruntime.ErrRuntimeIntegerDivideByZero)
+	require.Nil(t, err.(*sys.TrapError).Location())
+}
+
 // compile-time check to ensure testRuntimeErr implements runtime.Error.
 var _ runtime.Error = testRuntimeErr("")
 
@@ -0,0 +1,108 @@
+package wasmdebug
+
+import (
+	"debug/dwarf"
+	"errors"
+	"io"
+	"sort"
+)
+
+// DWARFLines resolves offsets in the code section to source lines, using the DWARF debug information compilers such as
+// rustc or clang emit into custom sections named ".debug_info", ".debug_line", etc.
+//
+// Note: Per the WebAssembly DWARF convention, an address is the offset of an instruction from the beginning of the
+// code section contents, which follow the section ID and size.
+// See https://yurydelendik.github.io/webassembly-dwarf/#pc
+type DWARFLines struct {
+	// rows are sorted by address, where each applies until the next.
+	rows []dwarfLineRow
+}
+
+type dwarfLineRow struct {
+	address uint64
+	// endSequence is true when this row is the first address after a sequence of instructions.
+	endSequence bool
+	file        string
+	line, col   uint32
+}
+
+// NewDWARFLines returns the line table of the DWARF custom sections returned by section, or nil if there are none.
+//
+// * section returns the data of the custom section with the given name, or nil if it doesn't exist.
+func NewDWARFLines(section func(name string) []byte) (*DWARFLines, error) {
+	info, line := section(".debug_info"), section(".debug_line")
+	if info == nil || line == nil {
+		return nil, nil
+	}
+
+	d, err := dwarf.New(section(".debug_abbrev"), section(".debug_aranges"), section(".debug_frame"), info, line,
+		section(".debug_pubnames"), section(".debug_ranges"), section(".debug_str"))
+	if err != nil {
+		return nil, err
+	}
+	// DWARF 5 moved some data into new sections, which must be added separately.
+	for _, name := range []string{".debug_addr", ".debug_line_str", ".debug_rnglists", ".debug_str_offsets"} {
+		if data := section(name); data != nil {
+			if err = d.AddSection(name, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	ret := &DWARFLines{}
+	r := d.Reader()
+	for {
+		entry, err := r.Next()
+		if err != nil {
+			return nil, err
+		} else if entry == nil {
+			break
+		}
+		if entry.Tag != dwarf.TagCompileUnit {
+			r.SkipChildren()
+			continue
+		}
+		lr, err := d.LineReader(entry)
+		r.SkipChildren()
+		if err != nil {
+			return nil, err
+		} else if lr == nil { // no line table in this unit.
+			continue
+		}
+
+		var le dwarf.LineEntry
+		for {
+			if err = lr.Next(&le); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			row := dwarfLineRow{address: le.Address, endSequence: le.EndSequence, line: uint32(le.Line), col: uint32(le.Column)}
+			if le.File != nil {
+				row.file = le.File.Name
+			}
+			ret.rows = append(ret.rows, row)
+		}
+	}
+
+	// Sort by address, where the end of a sequence precedes any row beginning at the same address.
+	sort.SliceStable(ret.rows, func(i, j int) bool {
+		if ret.rows[i].address != ret.rows[j].address {
+			return ret.rows[i].address < ret.rows[j].address
+		}
+		return ret.rows[i].endSequence && !ret.rows[j].endSequence
+	})
+	return ret, nil
+}
+
+// Line returns the source file, line and column of the instruction at the given offset in the code section, or false
+// if it isn't covered by the line table.
+func (d *DWARFLines) Line(address uint64) (file string, line, col uint32, ok bool) {
+	rows := d.rows
+	i := sort.Search(len(rows), func(i int) bool { return rows[i].address > address })
+	if i == 0 || rows[i-1].endSequence {
+		return
+	}
+	row := rows[i-1]
+	return row.file, row.line, row.col, true
+}
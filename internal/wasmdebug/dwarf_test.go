@@ -0,0 +1,46 @@
+package wasmdebug
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestNewDWARFLines_NoSections(t *testing.T) {
+	lines, err := NewDWARFLines(func(string) []byte { return nil })
+	require.NoError(t, err)
+	require.Nil(t, lines)
+}
+
+func TestDWARFLines_Line(t *testing.T) {
+	lines := &DWARFLines{rows: []dwarfLineRow{
+		{address: 10, file: "a.rs", line: 1, col: 2},
+		{address: 14, file: "a.rs", line: 2, col: 5},
+		{address: 20, endSequence: true},
+		{address: 30, file: "b.rs", line: 7},
+		{address: 32, endSequence: true},
+	}}
+
+	for _, tc := range []struct {
+		address  uint64
+		file     string
+		line     uint32
+		col      uint32
+		expected bool
+	}{
+		{address: 9},
+		{address: 10, file: "a.rs", line: 1, col: 2, expected: true},
+		{address: 13, file: "a.rs", line: 1, col: 2, expected: true},
+		{address: 14, file: "a.rs", line: 2, col: 5, expected: true},
+		{address: 19, file: "a.rs", line: 2, col: 5, expected: true},
+		{address: 20}, // between sequences
+		{address: 31, file: "b.rs", line: 7, expected: true},
+		{address: 32},
+	} {
+		file, line, col, ok := lines.Line(tc.address)
+		require.Equal(t, tc.expected, ok, tc.address)
+		require.Equal(t, tc.file, file, tc.address)
+		require.Equal(t, tc.line, line, tc.address)
+		require.Equal(t, tc.col, col, tc.address)
+	}
+}
@@ -1,44 +1,115 @@
 // Package wasmruntime contains internal symbols shared between modules for error handling.
 // Note: This is named wasmruntime to avoid conflicts with the normal go module.
-// Note: This only imports "api" as importing "wasm" would create a cyclic dependency.
+// Note: This only imports "sys" as importing "wasm" would create a cyclic dependency.
 package wasmruntime
 
+import (
+	"fmt"
+
+	"github.com/tetratelabs/wazero/sys"
+)
+
 var (
 	// ErrRuntimeCallStackOverflow indicates that there are too many function calls,
 	// and the Engine terminated the execution.
-	ErrRuntimeCallStackOverflow = New("callstack overflow")
+	ErrRuntimeCallStackOverflow = New(sys.TrapCallStackOverflow, "callstack overflow")
 	// ErrRuntimeInvalidConversionToInteger indicates the Wasm function tries to
 	// convert NaN floating point value to integers during trunc variant instructions.
-	ErrRuntimeInvalidConversionToInteger = New("invalid conversion to integer")
+	ErrRuntimeInvalidConversionToInteger = New(sys.TrapInvalidConversion, "invalid conversion to integer")
 	// ErrRuntimeIntegerOverflow indicates that an integer arithmetic resulted in
 	// overflow value. For example, when the program tried to truncate a float value
 	// which doesn't fit in the range of target integer.
-	ErrRuntimeIntegerOverflow = New("integer overflow")
+	ErrRuntimeIntegerOverflow = New(sys.TrapIntegerOverflow, "integer overflow")
 	// ErrRuntimeIntegerDivideByZero indicates that an integer div or rem instructions
 	// was executed with 0 as the divisor.
-	ErrRuntimeIntegerDivideByZero = New("integer divide by zero")
+	ErrRuntimeIntegerDivideByZero = New(sys.TrapDivByZero, "integer divide by zero")
 	// ErrRuntimeUnreachable means "unreachable" instruction was executed by the program.
-	ErrRuntimeUnreachable = New("unreachable")
+	ErrRuntimeUnreachable = New(sys.TrapUnreachable, "unreachable")
 	// ErrRuntimeOutOfBoundsMemoryAccess indicates that the program tried to access the
 	// region beyond the linear memory.
-	ErrRuntimeOutOfBoundsMemoryAccess = New("out of bounds memory access")
+	ErrRuntimeOutOfBoundsMemoryAccess = New(sys.TrapMemoryOutOfBounds, "out of bounds memory access")
 	// ErrRuntimeInvalidTableAccess means either offset to the table was out of bounds of table, or
 	// the target element in the table was uninitialized during call_indirect instruction.
-	ErrRuntimeInvalidTableAccess = New("invalid table access")
+	ErrRuntimeInvalidTableAccess = New(sys.TrapInvalidTableAccess, "invalid table access")
 	// ErrRuntimeIndirectCallTypeMismatch indicates that the type check failed during call_indirect.
-	ErrRuntimeIndirectCallTypeMismatch = New("indirect call type mismatch")
+	ErrRuntimeIndirectCallTypeMismatch = New(sys.TrapIndirectCallTypeMismatch, "indirect call type mismatch")
+	// ErrRuntimeUnalignedAtomic indicates that an atomic instruction accessed an address which isn't naturally
+	// aligned, for example i32.atomic.load on an address that isn't a multiple of four.
+	ErrRuntimeUnalignedAtomic = New(sys.TrapUnalignedAtomic, "unaligned atomic")
+	// ErrRuntimeExpectedSharedMemory indicates that memory.atomic.wait32 or memory.atomic.wait64 was executed on a
+	// memory which isn't shared.
+	ErrRuntimeExpectedSharedMemory = New(sys.TrapExpectedSharedMemory, "expected shared memory")
 )
 
 // Error is returned by a wasm.Engine during the execution of Wasm functions, and they indicate that the Wasm runtime
 // state is unrecoverable.
 type Error struct {
-	s string
+	code sys.TrapCode
+	s    string
 }
 
-func New(text string) *Error {
-	return &Error{s: text}
+func New(code sys.TrapCode, text string) *Error {
+	return &Error{code: code, s: text}
+}
+
+// Code returns the classification of this error for sys.TrapError.
+func (e *Error) Code() sys.TrapCode {
+	return e.code
 }
 
 func (e *Error) Error() string {
 	return e.s
 }
+
+// ContextDoneError is returned when the context.Context of a call was done while a Wasm function ran, so that callers
+// can tell cancellation apart from a crash.
+//
+// Note: errors.Is reports this as Err, for example context.Canceled.
+type ContextDoneError struct {
+	// Err is the context error, for example context.Canceled or context.DeadlineExceeded.
+	Err error
+}
+
+// Code returns sys.TrapContextDone.
+func (e *ContextDoneError) Code() sys.TrapCode {
+	return sys.TrapContextDone
+}
+
+func (e *ContextDoneError) Error() string {
+	return "context done: " + e.Err.Error()
+}
+
+// Unwrap returns Err.
+func (e *ContextDoneError) Unwrap() error {
+	return e.Err
+}
+
+// MemoryAccessError is ErrRuntimeOutOfBoundsMemoryAccess with the details of the load or store which trapped.
+//
+// Note: This is only raised by the interpreter for scalar loads and stores, such as i32.load or f64.store. Other
+// out-of-bounds accesses, including all of those in the compiler, v128 loads and stores and atomic instructions, raise
+// ErrRuntimeOutOfBoundsMemoryAccess without details. errors.Is reports this as ErrRuntimeOutOfBoundsMemoryAccess, so
+// callers can match either without knowing which raised it.
+type MemoryAccessError struct {
+	// Address is the effective address of the access: the dynamic base plus the static offset of the instruction.
+	Address uint64
+	// Width is the count of bytes accessed, e.g. 4 for i32.store.
+	Width uint32
+	// MemorySize is the size of the memory in bytes at the time of the access.
+	MemorySize uint64
+}
+
+// Code returns sys.TrapMemoryOutOfBounds.
+func (e *MemoryAccessError) Code() sys.TrapCode {
+	return sys.TrapMemoryOutOfBounds
+}
+
+func (e *MemoryAccessError) Error() string {
+	return fmt.Sprintf("%s: %d byte access at address %d, but memory size is %d",
+		ErrRuntimeOutOfBoundsMemoryAccess, e.Width, e.Address, e.MemorySize)
+}
+
+// Is returns true when target is ErrRuntimeOutOfBoundsMemoryAccess.
+func (e *MemoryAccessError) Is(target error) bool {
+	return target == ErrRuntimeOutOfBoundsMemoryAccess
+}
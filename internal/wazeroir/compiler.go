@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
 	"os"
@@ -155,6 +156,13 @@ type compiler struct {
 	funcs []uint32
 	// globals holds the global types for all declard globas in the module where the targe function exists.
 	globals []*wasm.GlobalType
+
+	// recordSourceOffsets is true when CompilationResult.SourceOffsets should be populated.
+	recordSourceOffsets bool
+	// debugInfo is true when each instruction handled and operation emitted should be printed to os.Stdout.
+	debugInfo bool
+	// currentOffset is the offset in body of the instruction currently being handled.
+	currentOffset uint64
 }
 
 // For debugging only.
@@ -210,6 +218,23 @@ type CompilationResult struct {
 	NeedsAccessToDataInstances bool
 	// NeedsAccessToDataInstances is true if the function needs access to element instances via table.init or elem.drop instructions.
 	NeedsAccessToElementInstances bool
+
+	// SourceOffsets is index-correlated with Operations and holds the offset in the function body of the Wasm
+	// instruction each operation was lowered from. This is only set when the module has a wasm.Module SourceMap.
+	SourceOffsets []uint64
+}
+
+// DistinctLabels returns the count of labels with at least one caller in LabelCallers. Ex. a br_table whose targets
+// are all the same block adds only one label, regardless of the count of targets.
+//
+// Note: This is computed on each call, so it adds no overhead unless used, ex. by tests or tooling.
+func (r *CompilationResult) DistinctLabels() (count int) {
+	for _, callers := range r.LabelCallers {
+		if callers > 0 {
+			count++
+		}
+	}
+	return
 }
 
 func CompileFunctions(_ context.Context, enabledFeatures wasm.Features, module *wasm.Module) ([]*CompilationResult, error) {
@@ -220,7 +245,7 @@ func CompileFunctions(_ context.Context, enabledFeatures wasm.Features, module *
 		return nil, err
 	}
 
-	hasMemory, hasTable := mem != nil, len(tables) > 0
+	hasMemory, hasTable := len(mem) > 0, len(tables) > 0
 
 	tableTypes := make([]wasm.ValueType, len(tables))
 	for i := range tableTypes {
@@ -232,7 +257,8 @@ func CompileFunctions(_ context.Context, enabledFeatures wasm.Features, module *
 		typeID := module.FunctionSection[funcInxdex]
 		sig := module.TypeSection[typeID]
 		code := module.CodeSection[funcInxdex]
-		r, err := compile(enabledFeatures, sig, code.Body, code.LocalTypes, module.TypeSection, functions, globals)
+		r, err := compile(enabledFeatures, sig, code.Body, code.LocalTypes, module.TypeSection, functions, globals,
+			module.SourceMap != nil || module.DWARFLines != nil, module.DebugInfoEnabled)
 		if err != nil {
 			return nil, fmt.Errorf("failed to lower func[%d/%d] to wazeroir: %w", funcInxdex, len(functions)-1, err)
 		}
@@ -257,17 +283,21 @@ func compile(enabledFeatures wasm.Features,
 	localTypes []wasm.ValueType,
 	types []*wasm.FunctionType,
 	functions []uint32, globals []*wasm.GlobalType,
+	recordSourceOffsets bool,
+	debugInfo bool,
 ) (*CompilationResult, error) {
 	c := compiler{
-		enabledFeatures: enabledFeatures,
-		controlFrames:   &controlFrames{},
-		result:          CompilationResult{LabelCallers: map[string]uint32{}},
-		body:            body,
-		localTypes:      localTypes,
-		sig:             sig,
-		globals:         globals,
-		funcs:           functions,
-		types:           types,
+		enabledFeatures:     enabledFeatures,
+		controlFrames:       &controlFrames{},
+		result:              CompilationResult{LabelCallers: map[string]uint32{}},
+		body:                body,
+		localTypes:          localTypes,
+		sig:                 sig,
+		globals:             globals,
+		funcs:               functions,
+		types:               types,
+		recordSourceOffsets: recordSourceOffsets,
+		debugInfo:           debugInfo || buildoptions.IsDebugMode,
 	}
 
 	c.calcLocalIndexToStackHeight()
@@ -300,17 +330,48 @@ func compile(enabledFeatures wasm.Features,
 	return &c.result, nil
 }
 
+// errUnexpectedEnd is returned when a function body ends in the middle of the immediates of an instruction.
+var errUnexpectedEnd = errors.New("unexpected end of function body")
+
+// ensureImmediates returns errUnexpectedEnd unless there are at least n bytes in the body after c.pc.
+//
+// Note: LEB128 immediates don't need this as they are read with a bytes.Reader, which returns io.EOF instead of
+// panicking. This is for immediates read by index, so that malformed bodies can't panic the compiler.
+func (c *compiler) ensureImmediates(n uint64) error {
+	if uint64(len(c.body))-c.pc-1 < n {
+		return errUnexpectedEnd
+	}
+	return nil
+}
+
 // Translate the current Wasm instruction to wazeroir's operations,
 // and emit the results into c.results.
 func (c *compiler) handleInstruction() error {
 	op := c.body[c.pc]
-	if buildoptions.IsDebugMode {
+	c.currentOffset = c.pc
+	if c.debugInfo {
 		fmt.Printf("handling %s, unreachable_state(on=%v,depth=%d)\n",
 			wasm.InstructionName(op),
 			c.unreachableState.on, c.unreachableState.depth,
 		)
 	}
 
+	// Ensure fixed-width immediates and sub-opcodes exist before any of them are read, including in applyToStack.
+	var immediates uint64
+	switch op {
+	case wasm.OpcodeMiscPrefix, wasm.OpcodeVecPrefix, wasm.OpcodeAtomicPrefix:
+		immediates = 1
+	case wasm.OpcodeTypedSelect:
+		immediates = 2
+	case wasm.OpcodeF32Const:
+		immediates = 4
+	case wasm.OpcodeF64Const:
+		immediates = 8
+	}
+	if err := c.ensureImmediates(immediates); err != nil {
+		return err
+	}
+
 	// Modify the stack according the current instruction.
 	// Note that some instructions will read "index" in
 	// applyToStack and advance c.pc inside the function.
@@ -706,6 +767,25 @@ operatorSwitch:
 		c.emit(
 			&OperationCallIndirect{TypeIndex: *index, TableIndex: tableIndex},
 		)
+	case wasm.OpcodeReturnCall:
+		if index == nil {
+			return fmt.Errorf("index does not exist for tail call")
+		}
+		if err := c.emitTailCall(op, *index, &OperationReturnCall{FunctionIndex: *index}); err != nil {
+			return err
+		}
+	case wasm.OpcodeReturnCallIndirect:
+		if index == nil {
+			return fmt.Errorf("index does not exist for indirect tail call")
+		}
+		tableIndex, n, err := leb128.DecodeUint32(bytes.NewReader(c.body[c.pc+1:]))
+		if err != nil {
+			return fmt.Errorf("read table index for return_call_indirect: %w", err)
+		}
+		c.pc += n
+		if err := c.emitTailCall(op, *index, &OperationReturnCallIndirect{TypeIndex: *index, TableIndex: tableIndex}); err != nil {
+			return err
+		}
 	case wasm.OpcodeDrop:
 		c.emit(
 			&OperationDrop{Depth: &InclusiveRange{Start: 0, End: 0}},
@@ -717,9 +797,10 @@ operatorSwitch:
 	case wasm.OpcodeTypedSelect:
 		// Skips two bytes: vector size fixed to 1, and the value type for select.
 		c.pc += 2
-		// Typed select is semantically equivalent to select at runtime.
+		// Typed select is semantically equivalent to select at runtime, except that
+		// v128 operands need twice the stack slots.
 		c.emit(
-			&OperationSelect{},
+			&OperationSelect{IsTargetVector: c.body[c.pc] == wasm.ValueTypeV128},
 		)
 	case wasm.OpcodeLocalGet:
 		if index == nil {
@@ -985,14 +1066,20 @@ operatorSwitch:
 			&OperationStore32{Arg: imm},
 		)
 	case wasm.OpcodeMemorySize:
-		c.pc++ // Skip the reserved one byte.
+		memoryIndex, err := c.readMemoryIndex(wasm.OpcodeMemorySizeName)
+		if err != nil {
+			return err
+		}
 		c.emit(
-			&OperationMemorySize{},
+			&OperationMemorySize{MemoryIndex: memoryIndex},
 		)
 	case wasm.OpcodeMemoryGrow:
-		c.pc++ // Skip the reserved one byte.
+		memoryIndex, err := c.readMemoryIndex(wasm.OpcodeMemoryGrowName)
+		if err != nil {
+			return err
+		}
 		c.emit(
-			&OperationMemoryGrow{},
+			&OperationMemoryGrow{MemoryIndex: memoryIndex},
 		)
 	case wasm.OpcodeI32Const:
 		val, num, err := leb128.DecodeInt32(bytes.NewReader(c.body[c.pc+1:]))
@@ -1552,7 +1639,8 @@ operatorSwitch:
 			&OperationConstI64{Value: 0},
 		)
 	case wasm.OpcodeRefIsNull:
-		// Simply compare the opaque pointer (i64) with zero.
+		// Simply compare the opaque pointer (i64) with zero. This is correct for funcref, too, as engines replace
+		// wasm.GlobalInstanceNullFuncRefValue with zero when initializing globals.
 		c.emit(
 			&OperationEqz{Type: UnsignedInt64},
 		)
@@ -1722,9 +1810,17 @@ operatorSwitch:
 			return fmt.Errorf("unsupported misc instruction in wazeroir: 0x%x", op)
 		}
 	case wasm.OpcodeVecPrefix:
+		// Validation already rejects vector instructions when SIMD is disabled, but check again instead of lowering
+		// them, in case this is called on a module that wasn't validated with the same features.
+		if !c.enabledFeatures.Get(wasm.FeatureSIMD) {
+			return errors.New("SIMD instructions require WithFeatureSIMD")
+		}
 		c.pc++
 		switch miscOp := c.body[c.pc]; miscOp {
 		case wasm.OpcodeVecV128Const:
+			if err := c.ensureImmediates(16); err != nil {
+				return err
+			}
 			c.pc++
 			lo := binary.LittleEndian.Uint64(c.body[c.pc : c.pc+8])
 			c.pc += 8
@@ -1744,6 +1840,56 @@ operatorSwitch:
 		default:
 			return fmt.Errorf("unsupported vector instruction in wazeroir: 0x%x", op)
 		}
+	case wasm.OpcodeAtomicPrefix:
+		c.pc++
+		atomicOp := c.body[c.pc]
+		if atomicOp == wasm.OpcodeAtomicFence {
+			c.pc++ // skip the reserved byte.
+			c.emit(
+				&OperationAtomicFence{},
+			)
+			break
+		}
+		imm, err := c.readMemoryImmediate(wasm.AtomicInstructionName(atomicOp))
+		if err != nil {
+			return err
+		}
+		switch {
+		case atomicOp == wasm.OpcodeAtomicMemoryNotify:
+			c.emit(
+				&OperationAtomicMemoryNotify{Arg: imm},
+			)
+		case atomicOp == wasm.OpcodeAtomicMemoryWait32:
+			c.emit(
+				&OperationAtomicMemoryWait{Type: UnsignedTypeI32, Arg: imm},
+			)
+		case atomicOp == wasm.OpcodeAtomicMemoryWait64:
+			c.emit(
+				&OperationAtomicMemoryWait{Type: UnsignedTypeI64, Arg: imm},
+			)
+		case wasm.OpcodeAtomicI32Load <= atomicOp && atomicOp <= wasm.OpcodeAtomicI64Load32U:
+			t, width := atomicAccess(atomicOp)
+			c.emit(
+				&OperationAtomicLoad{Type: t, Width: width, Arg: imm},
+			)
+		case wasm.OpcodeAtomicI32Store <= atomicOp && atomicOp <= wasm.OpcodeAtomicI64Store32:
+			t, width := atomicAccess(atomicOp)
+			c.emit(
+				&OperationAtomicStore{Type: t, Width: width, Arg: imm},
+			)
+		case wasm.OpcodeAtomicI32RmwAdd <= atomicOp && atomicOp < wasm.OpcodeAtomicI32RmwCmpxchg:
+			t, width := atomicAccess(atomicOp)
+			c.emit(
+				&OperationAtomicRMW{Type: t, Width: width, Op: AtomicRMWOp((atomicOp - wasm.OpcodeAtomicI32RmwAdd) / 7), Arg: imm},
+			)
+		case wasm.OpcodeAtomicI32RmwCmpxchg <= atomicOp && atomicOp <= wasm.OpcodeAtomicI64Rmw32CmpxchgU:
+			t, width := atomicAccess(atomicOp)
+			c.emit(
+				&OperationAtomicRMWCmpxchg{Type: t, Width: width, Arg: imm},
+			)
+		default:
+			return fmt.Errorf("unsupported atomic instruction in wazeroir: %s", wasm.AtomicInstructionName(atomicOp))
+		}
 	default:
 		return fmt.Errorf("unsupported instruction in wazeroir: 0x%x", op)
 	}
@@ -1760,6 +1906,8 @@ func (c *compiler) nextID() (id uint32) {
 }
 
 func (c *compiler) applyToStack(opcode wasm.Opcode) (*uint32, error) {
+	// Keep the position of the opcode for error messages as reading immediates advances c.pc.
+	pc := c.pc
 	var index uint32
 	var ptr *uint32
 	switch opcode {
@@ -1768,6 +1916,8 @@ func (c *compiler) applyToStack(opcode wasm.Opcode) (*uint32, error) {
 		// and it DOES affect the signature of opcode.
 		wasm.OpcodeCall,
 		wasm.OpcodeCallIndirect,
+		wasm.OpcodeReturnCall,
+		wasm.OpcodeReturnCallIndirect,
 		wasm.OpcodeLocalGet,
 		wasm.OpcodeLocalSet,
 		wasm.OpcodeLocalTee,
@@ -1814,7 +1964,9 @@ func (c *compiler) applyToStack(opcode wasm.Opcode) (*uint32, error) {
 			typeParam = &actual
 		}
 		if want != actual {
-			return nil, fmt.Errorf("input signature mismatch: want %s but have %s", want, actual)
+			// This indicates either a gap in the validation or a bug in the signature table of this opcode.
+			return nil, fmt.Errorf("%s at pc %d, operand %d: input signature mismatch: want %s but have %s",
+				wasm.InstructionName(opcode), pc, len(s.in)-1-i, want, actual)
 		}
 	}
 
@@ -1860,7 +2012,10 @@ func (c *compiler) emit(ops ...Operation) {
 				}
 			}
 			c.result.Operations = append(c.result.Operations, op)
-			if buildoptions.IsDebugMode {
+			if c.recordSourceOffsets {
+				c.result.SourceOffsets = append(c.result.SourceOffsets, c.currentOffset)
+			}
+			if c.debugInfo {
 				fmt.Printf("emitting ")
 				formatOperation(os.Stdout, op)
 			}
@@ -1939,6 +2094,52 @@ func (c *compiler) getFrameDropRange(frame *controlFrame, isEnd bool) *Inclusive
 	return nil
 }
 
+// emitTailCall emits the given tail call operation after dropping everything in the current function frame except
+// the callee's inputs, and then marks the rest of the block unreachable like wasm.OpcodeReturn.
+//
+// Note: applyToStack already replaced the inputs with the results, which are the same as the current function's.
+func (c *compiler) emitTailCall(op wasm.Opcode, index uint32, callOp Operation) error {
+	if c.unreachableState.on {
+		return nil
+	}
+	s, err := c.wasmOpcodeSignature(op, index)
+	if err != nil {
+		return err
+	}
+	inputNum, belowNum := len(s.in), len(c.stack)-len(s.out)
+	if belowNum > 0 {
+		c.emit(&OperationDrop{Depth: &InclusiveRange{Start: inputNum, End: inputNum + belowNum - 1}})
+	}
+	c.emit(callOp)
+	c.markUnreachable()
+	return nil
+}
+
+// readMemoryIndex reads the memory index of memory.size or memory.grow, which is a reserved zero byte unless
+// wasm.FeatureMultiMemory is enabled.
+func (c *compiler) readMemoryIndex(tag string) (uint32, error) {
+	if !c.enabledFeatures.Get(wasm.FeatureMultiMemory) {
+		c.pc++ // Skip the reserved one byte.
+		return 0, nil
+	}
+	memoryIndex, num, err := leb128.DecodeUint32(bytes.NewReader(c.body[c.pc+1:]))
+	if err != nil {
+		return 0, fmt.Errorf("reading memory index for %s: %w", tag, err)
+	}
+	c.pc += num
+	return memoryIndex, nil
+}
+
+// atomicAccess returns the type and width in bytes of the value the atomic instruction accesses per
+// wasm.AtomicAccessOf.
+func atomicAccess(atomicOp wasm.OpcodeAtomic) (UnsignedType, uint32) {
+	access, _ := wasm.AtomicAccessOf(atomicOp)
+	if access.ValType == wasm.ValueTypeI32 {
+		return UnsignedTypeI32, access.Width
+	}
+	return UnsignedTypeI64, access.Width
+}
+
 func (c *compiler) readMemoryImmediate(tag string) (*MemoryImmediate, error) {
 	r := bytes.NewReader(c.body[c.pc+1:])
 	alignment, num, err := leb128.DecodeUint32(r)
@@ -1946,10 +2147,18 @@ func (c *compiler) readMemoryImmediate(tag string) (*MemoryImmediate, error) {
 		return nil, fmt.Errorf("reading alignment for %s: %w", tag, err)
 	}
 	c.pc += num
+	var memoryIndex uint32
+	if alignment&wasm.MemoryArgMemoryIndexFlag != 0 && c.enabledFeatures.Get(wasm.FeatureMultiMemory) {
+		alignment &^= wasm.MemoryArgMemoryIndexFlag
+		if memoryIndex, num, err = leb128.DecodeUint32(r); err != nil {
+			return nil, fmt.Errorf("reading memory index for %s: %w", tag, err)
+		}
+		c.pc += num
+	}
 	offset, num, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("reading offset for %s: %w", tag, err)
 	}
 	c.pc += num
-	return &MemoryImmediate{Offset: offset, Alignment: alignment}, nil
+	return &MemoryImmediate{Offset: offset, Alignment: alignment, MemoryIndex: memoryIndex}, nil
 }
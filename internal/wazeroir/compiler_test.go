@@ -3,9 +3,13 @@ package wazeroir
 import (
 	"context"
 	"fmt"
+	"io"
+	"math/bits"
+	"os"
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	"github.com/tetratelabs/wazero/internal/wasm/text"
@@ -124,6 +128,57 @@ func TestCompile(t *testing.T) {
 	}
 }
 
+// TestCompileFunctions_DebugInfoEnabled ensures lowering traces are only printed when wasm.Module DebugInfoEnabled.
+func TestCompileFunctions_DebugInfoEnabled(t *testing.T) {
+	if buildoptions.IsDebugMode {
+		t.Skip("traces are always printed in debug mode")
+	}
+
+	for _, enabled := range []bool{false, true} {
+		enabled := enabled
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			module := &wasm.Module{
+				TypeSection:      []*wasm.FunctionType{v_v},
+				FunctionSection:  []wasm.Index{0},
+				CodeSection:      []*wasm.Code{{Body: []byte{wasm.OpcodeNop, wasm.OpcodeEnd}}},
+				DebugInfoEnabled: enabled,
+			}
+
+			out := captureStdout(t, func() {
+				_, err := CompileFunctions(ctx, wasm.Features20191205, module)
+				require.NoError(t, err)
+			})
+
+			if enabled {
+				require.Equal(t, `handling nop, unreachable_state(on=false,depth=0)
+handling end, unreachable_state(on=false,depth=0)
+emitting 	br .return
+`, out)
+			} else {
+				require.Equal(t, "", out)
+			}
+		})
+	}
+}
+
+// captureStdout returns what was written to os.Stdout while calling f.
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	f()
+	require.NoError(t, w.Close())
+
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
 func TestCompile_Block(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -205,7 +260,7 @@ func TestCompile_BulkMemoryOperations(t *testing.T) {
 	module := &wasm.Module{
 		TypeSection:     []*wasm.FunctionType{v_v},
 		FunctionSection: []wasm.Index{0},
-		MemorySection:   &wasm.Memory{Min: 1},
+		MemorySection:   []*wasm.Memory{{Min: 1}},
 		DataSection: []*wasm.DataSegment{
 			{
 				OffsetExpression: &wasm.ConstantExpression{
@@ -593,6 +648,186 @@ func TestCompile_SignExtensionOps(t *testing.T) {
 	require.Equal(t, expected, res[0])
 }
 
+func TestCompile_Atomic(t *testing.T) {
+	module := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{i32i32_i32},
+		FunctionSection: []wasm.Index{0},
+		MemorySection:   []*wasm.Memory{{Min: 1}},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1,
+			wasm.OpcodeAtomicPrefix, wasm.OpcodeAtomicI32Rmw16SubU, 1, 8, // alignment=1, offset=8
+			wasm.OpcodeEnd,
+		}}},
+	}
+
+	expected := &CompilationResult{
+		Operations: []Operation{ // begin with params: [$0, $1]
+			&OperationPick{Depth: 1}, // [$0, $1, $0]
+			&OperationPick{Depth: 1}, // [$0, $1, $0, $1]
+			&OperationAtomicRMW{ // [$0, $1, i32.atomic.rmw16.sub_u($0, $1)]
+				Type: UnsignedTypeI32, Width: 2, Op: AtomicRMWOpSub, Arg: &MemoryImmediate{Alignment: 1, Offset: 8},
+			},
+			&OperationDrop{Depth: &InclusiveRange{Start: 1, End: 2}}, // [i32.atomic.rmw16.sub_u($0, $1)]
+			&OperationBr{Target: &BranchTarget{}},                    // return!
+		},
+		HasMemory:    true,
+		LabelCallers: map[string]uint32{},
+		Signature:    i32i32_i32,
+		Functions:    []wasm.Index{0},
+		Types:        []*wasm.FunctionType{i32i32_i32},
+		TableTypes:   []wasm.RefType{},
+	}
+
+	res, err := CompileFunctions(ctx, wasm.FeatureThreads, module)
+	require.NoError(t, err)
+	require.Equal(t, expected, res[0])
+}
+
+func TestCompile_AtomicNotifyWait(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     []byte
+		expected []Operation
+	}{
+		{
+			name: "memory.atomic.notify",
+			body: []byte{
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeAtomicPrefix, wasm.OpcodeAtomicMemoryNotify, 2, 8, // alignment=2, offset=8
+				wasm.OpcodeEnd,
+			},
+			expected: []Operation{ // begin with params: [$0, $1]
+				&OperationPick{Depth: 1}, // [$0, $1, $0]
+				&OperationPick{Depth: 1}, // [$0, $1, $0, $1]
+				&OperationAtomicMemoryNotify{ // [$0, $1, memory.atomic.notify($0, $1)]
+					Arg: &MemoryImmediate{Alignment: 2, Offset: 8},
+				},
+				&OperationDrop{Depth: &InclusiveRange{Start: 1, End: 2}}, // [memory.atomic.notify($0, $1)]
+				&OperationBr{Target: &BranchTarget{}},                    // return!
+			},
+		},
+		{
+			name: "memory.atomic.wait32",
+			body: []byte{
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI64Const, 0,
+				wasm.OpcodeAtomicPrefix, wasm.OpcodeAtomicMemoryWait32, 2, 8, // alignment=2, offset=8
+				wasm.OpcodeEnd,
+			},
+			expected: []Operation{ // begin with params: [$0, $1]
+				&OperationPick{Depth: 1},     // [$0, $1, $0]
+				&OperationPick{Depth: 1},     // [$0, $1, $0, $1]
+				&OperationConstI64{Value: 0}, // [$0, $1, $0, $1, 0]
+				&OperationAtomicMemoryWait{ // [$0, $1, memory.atomic.wait32($0, $1, 0)]
+					Type: UnsignedTypeI32, Arg: &MemoryImmediate{Alignment: 2, Offset: 8},
+				},
+				&OperationDrop{Depth: &InclusiveRange{Start: 1, End: 2}}, // [memory.atomic.wait32($0, $1, 0)]
+				&OperationBr{Target: &BranchTarget{}},                    // return!
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			module := &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{i32i32_i32},
+				FunctionSection: []wasm.Index{0},
+				MemorySection:   []*wasm.Memory{{Min: 1}},
+				CodeSection:     []*wasm.Code{{Body: tc.body}},
+			}
+			res, err := CompileFunctions(ctx, wasm.FeatureThreads, module)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, res[0].Operations)
+		})
+	}
+}
+
+// TestCompile_AtomicValidated ensures every atomic instruction that passes validation is lowered.
+func TestCompile_AtomicValidated(t *testing.T) {
+	constOf := func(vt wasm.ValueType) []byte {
+		if vt == wasm.ValueTypeI32 {
+			return []byte{wasm.OpcodeI32Const, 0}
+		}
+		return []byte{wasm.OpcodeI64Const, 0}
+	}
+
+	for op := 0; op <= 0xff; op++ {
+		atomicOp := wasm.OpcodeAtomic(op)
+		access, ok := wasm.AtomicAccessOf(atomicOp)
+		if !ok {
+			continue
+		}
+		t.Run(wasm.AtomicInstructionName(atomicOp), func(t *testing.T) {
+			body := constOf(wasm.ValueTypeI32) // address
+			hasResult := true
+			switch {
+			case atomicOp == wasm.OpcodeAtomicMemoryNotify:
+				body = append(body, constOf(wasm.ValueTypeI32)...) // count
+			case atomicOp == wasm.OpcodeAtomicMemoryWait32, atomicOp == wasm.OpcodeAtomicMemoryWait64:
+				body = append(body, constOf(access.ValType)...)    // expected
+				body = append(body, constOf(wasm.ValueTypeI64)...) // timeout
+			case atomicOp <= wasm.OpcodeAtomicI64Load32U:
+			case atomicOp <= wasm.OpcodeAtomicI64Store32:
+				body = append(body, constOf(access.ValType)...)
+				hasResult = false
+			case atomicOp < wasm.OpcodeAtomicI32RmwCmpxchg:
+				body = append(body, constOf(access.ValType)...)
+			default:
+				body = append(body, constOf(access.ValType)...) // expected
+				body = append(body, constOf(access.ValType)...) // replacement
+			}
+			alignment := byte(bits.TrailingZeros32(access.Width))
+			body = append(body, wasm.OpcodeAtomicPrefix, atomicOp, alignment, 0)
+			if hasResult {
+				body = append(body, wasm.OpcodeDrop)
+			}
+			body = append(body, wasm.OpcodeEnd)
+
+			module := &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{v_v},
+				FunctionSection: []wasm.Index{0},
+				MemorySection:   []*wasm.Memory{{Min: 1, Max: 1, IsMaxEncoded: true, Shared: true}},
+				CodeSection:     []*wasm.Code{{Body: body}},
+			}
+			require.NoError(t, module.Validate(wasm.FeatureThreads))
+			_, err := CompileFunctions(ctx, wasm.FeatureThreads, module)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCompile_TailCall(t *testing.T) {
+	module := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{i32i32_i32, i32_i32},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{
+				wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeReturnCall, 1,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeEnd}},
+		},
+	}
+
+	expected := &CompilationResult{
+		Operations: []Operation{ // begin with params: [$0, $1]
+			&OperationPick{Depth: 0},                                 // [$0, $1, $1]
+			&OperationDrop{Depth: &InclusiveRange{Start: 1, End: 2}}, // [$1]
+			&OperationReturnCall{FunctionIndex: 1},                   // the callee's results are returned.
+		},
+		LabelCallers: map[string]uint32{},
+		Signature:    i32i32_i32,
+		Functions:    []wasm.Index{0, 1},
+		Types:        []*wasm.FunctionType{i32i32_i32, i32_i32},
+		TableTypes:   []wasm.RefType{},
+	}
+
+	res, err := CompileFunctions(ctx, wasm.FeatureTailCall, module)
+	require.NoError(t, err)
+	require.Equal(t, expected, res[0])
+}
+
 func requireCompilationResult(t *testing.T, enabledFeatures wasm.Features, expected *CompilationResult, module *wasm.Module) {
 	if enabledFeatures == 0 {
 		enabledFeatures = wasm.Features20220419
@@ -1047,6 +1282,126 @@ func TestCompile_Locals_vector(t *testing.T) {
 				&OperationBr{Target: &BranchTarget{}}, // return!
 			},
 		},
+		{
+			name: "interleaved with i32",
+			mod: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32, wasm.ValueTypeV128}, ParamNumInUint64: 3}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection: []*wasm.Code{{
+					Body: []byte{
+						wasm.OpcodeLocalGet, 3, // v128
+						wasm.OpcodeLocalSet, 1, // v128
+						wasm.OpcodeLocalGet, 4, // i32
+						wasm.OpcodeLocalTee, 2, // i32
+						wasm.OpcodeDrop,
+						wasm.OpcodeEnd,
+					},
+					LocalTypes: []wasm.ValueType{i32, wasm.ValueTypeV128, i32},
+				}},
+			},
+			expected: []Operation{
+				// [p0, p1.lo, p1.hi] -> [p0, p1.lo, p1.hi, l2, l3.lo, l3.hi, l4]
+				&OperationConstI32{Value: 0},
+				&OperationConstI64{Value: 0},
+				&OperationConstI64{Value: 0},
+				&OperationConstI32{Value: 0},
+				// local.get 3: [..., l3.lo, l3.hi, l4] -> [..., l3.lo, l3.hi, l4, l3.lo, l3.hi]
+				&OperationPick{Depth: 2},
+				&OperationPick{Depth: 2},
+				// local.set 1: [p0, p1.lo, p1.hi, l2, l3.lo, l3.hi, l4, l3.lo, l3.hi] -> [p0, l3.lo, l3.hi, l2, l3.lo, l3.hi, l4]
+				&OperationSwap{Depth: 6},
+				&OperationDrop{Depth: &InclusiveRange{Start: 0, End: 0}},
+				&OperationSwap{Depth: 6},
+				&OperationDrop{Depth: &InclusiveRange{Start: 0, End: 0}},
+				// local.get 4: [..., l4] -> [..., l4, l4]
+				&OperationPick{Depth: 0},
+				// local.tee 2: [p0, l3.lo, l3.hi, l2, l3.lo, l3.hi, l4, l4] -> [p0, l3.lo, l3.hi, l4, l3.lo, l3.hi, l4, l4]
+				&OperationPick{Depth: 0},
+				&OperationSwap{Depth: 5},
+				&OperationDrop{Depth: &InclusiveRange{Start: 0, End: 0}},
+				// drop: [..., l4, l4] -> [..., l4]
+				&OperationDrop{Depth: &InclusiveRange{Start: 0, End: 0}},
+				&OperationDrop{Depth: &InclusiveRange{Start: 0, End: 6}},
+				&OperationBr{Target: &BranchTarget{}}, // return!
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := CompileFunctions(ctx, wasm.Features20220419, tc.mod)
+			require.NoError(t, err)
+			msg := fmt.Sprintf("\nhave:\n\t%s\nwant:\n\t%s", Format(res[0].Operations), Format(tc.expected))
+			require.Equal(t, tc.expected, res[0].Operations, msg)
+		})
+	}
+}
+
+func TestCompiler_calcLocalIndexToStackHeight(t *testing.T) {
+	c := &compiler{
+		sig:        &wasm.FunctionType{Params: []wasm.ValueType{i32, wasm.ValueTypeV128}},
+		localTypes: []wasm.ValueType{i32, wasm.ValueTypeV128, i32},
+	}
+	c.calcLocalIndexToStackHeight()
+
+	// Each v128 takes two stack slots, so the heights of locals following one are offset by an extra slot.
+	require.Equal(t, map[wasm.Index]int{0: 0, 1: 1, 2: 3, 3: 4, 4: 6}, c.localIndexToStackHeight)
+}
+
+func TestCompile_TypedSelect(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		mod      *wasm.Module
+		expected []Operation
+	}{
+		{
+			name: "i64",
+			mod: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection: []*wasm.Code{{Body: []byte{
+					wasm.OpcodeI64Const, 1,
+					wasm.OpcodeI64Const, 2,
+					wasm.OpcodeI32Const, 0,
+					wasm.OpcodeTypedSelect, 1, wasm.ValueTypeI64,
+					wasm.OpcodeDrop,
+					wasm.OpcodeEnd,
+				}}},
+			},
+			expected: []Operation{
+				&OperationConstI64{Value: 1},
+				&OperationConstI64{Value: 2},
+				&OperationConstI32{Value: 0},
+				&OperationSelect{},
+				&OperationDrop{Depth: &InclusiveRange{Start: 0, End: 0}},
+				&OperationBr{Target: &BranchTarget{}}, // return!
+			},
+		},
+		{
+			name: "v128",
+			mod: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection: []*wasm.Code{{Body: []byte{
+					wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Const, // [] -> [0x01, 0x02]
+					1, 0, 0, 0, 0, 0, 0, 0,
+					2, 0, 0, 0, 0, 0, 0, 0,
+					wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Const, // [0x01, 0x02] -> [0x01, 0x02, 0x03, 0x04]
+					3, 0, 0, 0, 0, 0, 0, 0,
+					4, 0, 0, 0, 0, 0, 0, 0,
+					wasm.OpcodeI32Const, 0,
+					wasm.OpcodeTypedSelect, 1, wasm.ValueTypeV128, // [0x01, 0x02, 0x03, 0x04, 0] -> [0x03, 0x04]
+					wasm.OpcodeEnd,
+				}}},
+			},
+			expected: []Operation{
+				&OperationConstV128{Lo: 0x01, Hi: 0x02},
+				&OperationConstV128{Lo: 0x03, Hi: 0x04},
+				&OperationConstI32{Value: 0},
+				&OperationSelect{IsTargetVector: true},
+				&OperationDrop{Depth: &InclusiveRange{Start: 0, End: 1}},
+				&OperationBr{Target: &BranchTarget{}}, // return!
+			},
+		},
 	} {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
@@ -1057,3 +1412,203 @@ func TestCompile_Locals_vector(t *testing.T) {
 		})
 	}
 }
+
+func TestCompile_InputSignatureMismatch(t *testing.T) {
+	// CompileFunctions assumes the module is already validated, so this body is deliberately invalid.
+	mod := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeI32Const, 0,
+			wasm.OpcodeI64Const, 0,
+			wasm.OpcodeI32Add, // i32.add on [i32, i64]
+			wasm.OpcodeDrop,
+			wasm.OpcodeEnd,
+		}}},
+	}
+	_, err := CompileFunctions(ctx, wasm.Features20220419, mod)
+	require.EqualError(t, err, "failed to lower func[0/0] to wazeroir: handling instruction: apply stack failed for i32.add: "+
+		"i32.add at pc 4, operand 1: input signature mismatch: want i32 but have i64")
+}
+
+func TestCompilationResult_DistinctLabels(t *testing.T) {
+	// brTableBody returns a function body which branches from three nested blocks with the br_table targets.
+	brTableBody := func(targets ...byte) []byte {
+		body := []byte{
+			wasm.OpcodeBlock, 0x40, wasm.OpcodeBlock, 0x40, wasm.OpcodeBlock, 0x40,
+			wasm.OpcodeLocalGet, 0,
+			wasm.OpcodeBrTable, byte(len(targets)),
+		}
+		body = append(body, targets...)
+		return append(body, 2, // default target
+			wasm.OpcodeEnd, wasm.OpcodeEnd, wasm.OpcodeEnd, wasm.OpcodeEnd)
+	}
+
+	tests := []struct {
+		name     string
+		body     []byte
+		expected int
+	}{
+		{
+			name:     "dense table",
+			body:     brTableBody(0, 0, 0, 0, 0, 0, 0, 0),
+			expected: 2,
+		},
+		{
+			name:     "sparse table",
+			body:     brTableBody(0, 1, 2, 0, 1, 2, 0, 1),
+			expected: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := CompileFunctions(ctx, wasm.Features20220419, &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, ParamNumInUint64: 1}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{Body: tc.body}},
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, res[0].DistinctLabels())
+		})
+	}
+}
+
+func TestCompile_VectorRequiresSIMD(t *testing.T) {
+	mod := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{v_v},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Const,
+			1, 0, 0, 0, 0, 0, 0, 0,
+			2, 0, 0, 0, 0, 0, 0, 0,
+			wasm.OpcodeDrop,
+			wasm.OpcodeEnd,
+		}}},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		_, err := CompileFunctions(ctx, wasm.Features20220419.Set(wasm.FeatureSIMD, false), mod)
+		require.EqualError(t, err, "failed to lower func[0/0] to wazeroir: handling instruction: "+
+			"SIMD instructions require WithFeatureSIMD")
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		_, err := CompileFunctions(ctx, wasm.Features20220419.Set(wasm.FeatureSIMD, true), mod)
+		require.NoError(t, err)
+	})
+}
+
+func TestCompile_OverlongLEB128(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		expectedErr string
+	}{
+		{
+			name:        "i32.const with a 6th byte",
+			body:        []byte{wasm.OpcodeI32Const, 0x81, 0x80, 0x80, 0x80, 0x80, 0x00, wasm.OpcodeDrop, wasm.OpcodeEnd},
+			expectedErr: "reading i32.const value: overflows a 32-bit integer",
+		},
+		{
+			name:        "i32.const out of range",
+			body:        []byte{wasm.OpcodeI32Const, 0xff, 0xff, 0xff, 0xff, 0x0f, wasm.OpcodeDrop, wasm.OpcodeEnd},
+			expectedErr: "reading i32.const value: overflows a 32-bit integer",
+		},
+		{
+			name: "memory offset with a 6th byte",
+			body: []byte{
+				wasm.OpcodeI32Const, 0,
+				wasm.OpcodeI32Load, 0x2, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00,
+				wasm.OpcodeDrop, wasm.OpcodeEnd,
+			},
+			expectedErr: "reading offset for i32.load: overflows a 32-bit integer",
+		},
+		{
+			name: "memory offset out of range",
+			body: []byte{
+				wasm.OpcodeI32Const, 0,
+				wasm.OpcodeI32Load, 0x2, 0xff, 0xff, 0xff, 0xff, 0x1f,
+				wasm.OpcodeDrop, wasm.OpcodeEnd,
+			},
+			expectedErr: "reading offset for i32.load: overflows a 32-bit integer",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := CompileFunctions(ctx, wasm.Features20220419, &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{v_v},
+				FunctionSection: []wasm.Index{0},
+				MemorySection:   []*wasm.Memory{{}},
+				CodeSection:     []*wasm.Code{{Body: tc.body}},
+			})
+			require.EqualError(t, err, "failed to lower func[0/0] to wazeroir: handling instruction: "+tc.expectedErr)
+		})
+	}
+}
+
+func TestCompile_UnexpectedEnd(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		expectedErr string
+	}{
+		{
+			name:        wasm.OpcodeF32ConstName,
+			body:        []byte{wasm.OpcodeF32Const, 0, 0},
+			expectedErr: "unexpected end of function body",
+		},
+		{
+			name:        wasm.OpcodeF64ConstName,
+			body:        []byte{wasm.OpcodeF64Const, 0, 0, 0, 0},
+			expectedErr: "unexpected end of function body",
+		},
+		{
+			name:        wasm.OpcodeVecV128ConstName,
+			body:        []byte{wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Const, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			expectedErr: "unexpected end of function body",
+		},
+		{
+			name:        wasm.OpcodeTypedSelectName,
+			body:        []byte{wasm.OpcodeI32Const, 0, wasm.OpcodeI32Const, 0, wasm.OpcodeI32Const, 0, wasm.OpcodeTypedSelect, 1},
+			expectedErr: "unexpected end of function body",
+		},
+		{
+			name:        "misc prefix",
+			body:        []byte{wasm.OpcodeMiscPrefix},
+			expectedErr: "unexpected end of function body",
+		},
+		{
+			name:        "atomic prefix",
+			body:        []byte{wasm.OpcodeAtomicPrefix},
+			expectedErr: "unexpected end of function body",
+		},
+		{
+			name:        wasm.OpcodeI32ConstName,
+			body:        []byte{wasm.OpcodeI32Const},
+			expectedErr: "reading i32.const value: readByte failed: EOF",
+		},
+		{
+			name:        wasm.OpcodeI32LoadName,
+			body:        []byte{wasm.OpcodeI32Const, 0, wasm.OpcodeI32Load, 0x2},
+			expectedErr: "reading offset for i32.load: EOF",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := CompileFunctions(ctx, wasm.Features20220419|wasm.FeatureSIMD|wasm.FeatureThreads, &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{v_v},
+				FunctionSection: []wasm.Index{0},
+				MemorySection:   []*wasm.Memory{{}},
+				CodeSection:     []*wasm.Code{{Body: tc.body}},
+			})
+			require.EqualError(t, err, "failed to lower func[0/0] to wazeroir: handling instruction: "+tc.expectedErr)
+		})
+	}
+}
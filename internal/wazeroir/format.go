@@ -46,7 +46,11 @@ func formatOperation(w io.StringWriter, b Operation) {
 	case *OperationDrop:
 		str = fmt.Sprintf("drop %d..%d", o.Depth.Start, o.Depth.End)
 	case *OperationSelect:
-		str = "select"
+		if o.IsTargetVector {
+			str = "select v128"
+		} else {
+			str = "select"
+		}
 	case *OperationPick:
 		str = fmt.Sprintf("pick %d", o.Depth)
 	case *OperationSwap:
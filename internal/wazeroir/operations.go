@@ -289,6 +289,24 @@ func (o OperationKind) String() (ret string) {
 		ret = "TableFill"
 	case OperationKindConstV128:
 		ret = "ConstV128"
+	case OperationKindAtomicLoad:
+		ret = "AtomicLoad"
+	case OperationKindAtomicStore:
+		ret = "AtomicStore"
+	case OperationKindAtomicRMW:
+		ret = "AtomicRMW"
+	case OperationKindAtomicRMWCmpxchg:
+		ret = "AtomicRMWCmpxchg"
+	case OperationKindAtomicFence:
+		ret = "AtomicFence"
+	case OperationKindReturnCall:
+		ret = "ReturnCall"
+	case OperationKindReturnCallIndirect:
+		ret = "ReturnCallIndirect"
+	case OperationKindAtomicMemoryNotify:
+		ret = "AtomicMemoryNotify"
+	case OperationKindAtomicMemoryWait:
+		ret = "AtomicMemoryWait"
 	default:
 		panic("BUG")
 	}
@@ -386,6 +404,15 @@ const (
 	OperationKindConstV128
 	OperationKindI32x4Add
 	OperationKindI64x2Add
+	OperationKindAtomicLoad
+	OperationKindAtomicStore
+	OperationKindAtomicRMW
+	OperationKindAtomicRMWCmpxchg
+	OperationKindAtomicFence
+	OperationKindReturnCall
+	OperationKindReturnCallIndirect
+	OperationKindAtomicMemoryNotify
+	OperationKindAtomicMemoryWait
 )
 
 type Label struct {
@@ -522,7 +549,12 @@ func (o *OperationDrop) Kind() OperationKind {
 	return OperationKindDrop
 }
 
-type OperationSelect struct{}
+// OperationSelect implements the `select` instruction, which pops the condition and two operands and pushes back the
+// first operand if the condition is non-zero, or the second otherwise.
+type OperationSelect struct {
+	// IsTargetVector is true if the operands are v128 values, which occupy two stack slots each.
+	IsTargetVector bool
+}
 
 func (o *OperationSelect) Kind() OperationKind {
 	return OperationKindSelect
@@ -565,6 +597,9 @@ type MemoryImmediate struct {
 	// Offset is the address offset added to the instruction's dynamic address operand, yielding a 33-bit effective
 	// address that is the zero-based index at which the memory is accessed. Default to zero.
 	Offset uint32
+
+	// MemoryIndex is the index of the memory to access, which is only non-zero when wasm.FeatureMultiMemory is enabled.
+	MemoryIndex uint32
 }
 
 type OperationLoad struct {
@@ -641,14 +676,21 @@ func (o *OperationStore32) Kind() OperationKind {
 	return OperationKindStore32
 }
 
-type OperationMemorySize struct{}
+type OperationMemorySize struct {
+	// MemoryIndex is the index of the memory, which is only non-zero when wasm.FeatureMultiMemory is enabled.
+	MemoryIndex uint32
+}
 
 // Kind implements Operation.Kind.
 func (o *OperationMemorySize) Kind() OperationKind {
 	return OperationKindMemorySize
 }
 
-type OperationMemoryGrow struct{ Alignment uint64 }
+type OperationMemoryGrow struct {
+	Alignment uint64
+	// MemoryIndex is the index of the memory, which is only non-zero when wasm.FeatureMultiMemory is enabled.
+	MemoryIndex uint32
+}
 
 // Kind implements Operation.Kind.
 func (o *OperationMemoryGrow) Kind() OperationKind {
@@ -669,6 +711,8 @@ func (o *OperationConstI64) Kind() OperationKind {
 	return OperationKindConstI64
 }
 
+// OperationConstF32 pushes Value, which may be a NaN with a payload. Lower it with math.Float32bits, as converting
+// through float64 would canonicalize the NaN.
 type OperationConstF32 struct{ Value float32 }
 
 // Kind implements Operation.Kind.
@@ -676,6 +720,7 @@ func (o *OperationConstF32) Kind() OperationKind {
 	return OperationKindConstF32
 }
 
+// OperationConstF64 pushes Value, which may be a NaN with a payload. Lower it with math.Float64bits.
 type OperationConstF64 struct{ Value float64 }
 
 // Kind implements Operation.Kind.
@@ -1175,3 +1220,155 @@ type OperationI64x2Add struct{}
 func (o *OperationI64x2Add) Kind() OperationKind {
 	return OperationKindI64x2Add
 }
+
+// AtomicRMWOp is the modification made by OperationAtomicRMW.
+type AtomicRMWOp byte
+
+const (
+	AtomicRMWOpAdd AtomicRMWOp = iota
+	AtomicRMWOpSub
+	AtomicRMWOpAnd
+	AtomicRMWOpOr
+	AtomicRMWOpXor
+	// AtomicRMWOpXchg replaces the value with the operand.
+	AtomicRMWOpXchg
+)
+
+// OperationAtomicLoad implements Operation.
+//
+// This corresponds to the atomic loads, such as wasm.OpcodeAtomicI32Load8U, which zero-extend the Width bytes read to
+// the Type. The effective address must be a multiple of Width, or the execution traps with
+// wasmruntime.ErrRuntimeUnalignedAtomic.
+type OperationAtomicLoad struct {
+	// Type is either UnsignedTypeI32 or UnsignedTypeI64.
+	Type UnsignedType
+	// Width is the number of bytes accessed: 1, 2, 4 or 8.
+	Width uint32
+	Arg   *MemoryImmediate
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationAtomicLoad) Kind() OperationKind {
+	return OperationKindAtomicLoad
+}
+
+// OperationAtomicStore implements Operation.
+//
+// This corresponds to the atomic stores, such as wasm.OpcodeAtomicI64Store32, which write the low Width bytes of the
+// value. See OperationAtomicLoad for the alignment requirement.
+type OperationAtomicStore struct {
+	// Type is either UnsignedTypeI32 or UnsignedTypeI64.
+	Type UnsignedType
+	// Width is the number of bytes accessed: 1, 2, 4 or 8.
+	Width uint32
+	Arg   *MemoryImmediate
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationAtomicStore) Kind() OperationKind {
+	return OperationKindAtomicStore
+}
+
+// OperationAtomicRMW implements Operation.
+//
+// This corresponds to the atomic read-modify-write instructions except cmpxchg, such as wasm.OpcodeAtomicI32RmwAdd.
+// This pops the operand and the address, writes the result of Op on the value read and the operand, then pushes the
+// value read. See OperationAtomicLoad for the alignment requirement.
+type OperationAtomicRMW struct {
+	// Type is either UnsignedTypeI32 or UnsignedTypeI64.
+	Type UnsignedType
+	// Width is the number of bytes accessed: 1, 2, 4 or 8.
+	Width uint32
+	Op    AtomicRMWOp
+	Arg   *MemoryImmediate
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationAtomicRMW) Kind() OperationKind {
+	return OperationKindAtomicRMW
+}
+
+// OperationAtomicRMWCmpxchg implements Operation.
+//
+// This corresponds to the atomic compare-exchange instructions, such as wasm.OpcodeAtomicI32RmwCmpxchg. This pops the
+// replacement, the expected value and the address, writes the replacement only if the value read equals the expected
+// one, then pushes the value read. See OperationAtomicLoad for the alignment requirement.
+type OperationAtomicRMWCmpxchg struct {
+	// Type is either UnsignedTypeI32 or UnsignedTypeI64.
+	Type UnsignedType
+	// Width is the number of bytes accessed: 1, 2, 4 or 8.
+	Width uint32
+	Arg   *MemoryImmediate
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationAtomicRMWCmpxchg) Kind() OperationKind {
+	return OperationKindAtomicRMWCmpxchg
+}
+
+// OperationAtomicFence implements Operation.
+//
+// This corresponds to wasm.OpcodeAtomicFence, which orders memory accesses without reading or writing any.
+type OperationAtomicFence struct{}
+
+// Kind implements Operation.Kind.
+func (o *OperationAtomicFence) Kind() OperationKind {
+	return OperationKindAtomicFence
+}
+
+// OperationAtomicMemoryNotify implements Operation.
+//
+// This corresponds to wasm.OpcodeAtomicMemoryNotify, which pops the maximum count of waiters to wake and the address,
+// then pushes the number of waiters woken. The address must be a multiple of four, or the execution traps with
+// wasmruntime.ErrRuntimeUnalignedAtomic.
+type OperationAtomicMemoryNotify struct {
+	Arg *MemoryImmediate
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationAtomicMemoryNotify) Kind() OperationKind {
+	return OperationKindAtomicMemoryNotify
+}
+
+// OperationAtomicMemoryWait implements Operation.
+//
+// This corresponds to wasm.OpcodeAtomicMemoryWait32 and wasm.OpcodeAtomicMemoryWait64, which pop the timeout in
+// nanoseconds, the expected value and the address, then push 0 when woken, 1 when the value isn't the expected one, or
+// 2 on timeout. The execution traps with wasmruntime.ErrRuntimeExpectedSharedMemory unless the memory is shared. See
+// OperationAtomicLoad for the alignment requirement.
+type OperationAtomicMemoryWait struct {
+	// Type is either UnsignedTypeI32 or UnsignedTypeI64, the type of the expected value.
+	Type UnsignedType
+	Arg  *MemoryImmediate
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationAtomicMemoryWait) Kind() OperationKind {
+	return OperationKindAtomicMemoryWait
+}
+
+// OperationReturnCall implements Operation.
+//
+// This corresponds to wasm.OpcodeReturnCall, and is emitted after the values below the callee's parameters are
+// dropped. Engines can therefore reuse the frame of the current function for the callee instead of pushing a new one.
+type OperationReturnCall struct {
+	FunctionIndex uint32
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationReturnCall) Kind() OperationKind {
+	return OperationKindReturnCall
+}
+
+// OperationReturnCallIndirect implements Operation.
+//
+// This corresponds to wasm.OpcodeReturnCallIndirect, and is emitted after the values below the table offset and the
+// callee's parameters are dropped, similar to OperationReturnCall.
+type OperationReturnCallIndirect struct {
+	TypeIndex, TableIndex uint32
+}
+
+// Kind implements Operation.Kind.
+func (o *OperationReturnCallIndirect) Kind() OperationKind {
+	return OperationKindReturnCallIndirect
+}
@@ -154,10 +154,34 @@ var (
 		in:  []UnsignedType{UnsignedTypeUnknown, UnsignedTypeUnknown, UnsignedTypeI32},
 		out: []UnsignedType{UnsignedTypeUnknown},
 	}
+	signature_I32I64_I64 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI64},
+	}
+	signature_I32I32I32_I32 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI32, UnsignedTypeI32},
+		out: []UnsignedType{UnsignedTypeI32},
+	}
+	signature_I32I64I64_I64 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI64, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI64},
+	}
+	signature_I32I32I64_I32 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI32, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI32},
+	}
+	signature_I32I64I64_I32 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI64, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI32},
+	}
 	signature_I64I64I64I64_I64I64 = &signature{
 		in:  []UnsignedType{UnsignedTypeI64, UnsignedTypeI64, UnsignedTypeI64, UnsignedTypeI64},
 		out: []UnsignedType{UnsignedTypeI64, UnsignedTypeI64},
 	}
+	signature_I64I64I64I64I32_I64I64 = &signature{
+		in:  []UnsignedType{UnsignedTypeI64, UnsignedTypeI64, UnsignedTypeI64, UnsignedTypeI64, UnsignedTypeI32},
+		out: []UnsignedType{UnsignedTypeI64, UnsignedTypeI64},
+	}
 )
 
 // wasmOpcodeSignature returns the signature of given Wasm opcode.
@@ -177,15 +201,22 @@ func (c *compiler) wasmOpcodeSignature(op wasm.Opcode, index uint32) (*signature
 		return signature_I32_None, nil
 	case wasm.OpcodeReturn:
 		return signature_None_None, nil
-	case wasm.OpcodeCall:
+	case wasm.OpcodeCall, wasm.OpcodeReturnCall:
 		return funcTypeToSignature(c.types[c.funcs[index]]), nil
-	case wasm.OpcodeCallIndirect:
+	case wasm.OpcodeCallIndirect, wasm.OpcodeReturnCallIndirect:
 		ret := funcTypeToSignature(c.types[index])
 		ret.in = append(ret.in, UnsignedTypeI32)
 		return ret, nil
 	case wasm.OpcodeDrop:
 		return signature_Unknown_None, nil
-	case wasm.OpcodeSelect, wasm.OpcodeTypedSelect:
+	case wasm.OpcodeSelect:
+		return signature_UnknownUnknownI32_Unknown, nil
+	case wasm.OpcodeTypedSelect:
+		// The immediates are the vector size fixed to 1, followed by the value type.
+		if c.body[c.pc+2] == wasm.ValueTypeV128 {
+			// v128 values occupy two stack slots.
+			return signature_I64I64I64I64I32_I64I64, nil
+		}
 		return signature_UnknownUnknownI32_Unknown, nil
 	case wasm.OpcodeLocalGet:
 		inputLen := uint32(len(c.sig.Params))
@@ -416,6 +447,39 @@ func (c *compiler) wasmOpcodeSignature(op wasm.Opcode, index uint32) (*signature
 		default:
 			return nil, fmt.Errorf("unsupported vector instruction in wazeroir: 0x%x", op)
 		}
+	case wasm.OpcodeAtomicPrefix:
+		switch atomicOp := c.body[c.pc+1]; {
+		case atomicOp == wasm.OpcodeAtomicFence:
+			return signature_None_None, nil
+		case atomicOp == wasm.OpcodeAtomicMemoryNotify:
+			return signature_I32I32_I32, nil
+		case atomicOp == wasm.OpcodeAtomicMemoryWait32:
+			return signature_I32I32I64_I32, nil
+		case atomicOp == wasm.OpcodeAtomicMemoryWait64:
+			return signature_I32I64I64_I32, nil
+		case wasm.OpcodeAtomicI32Load <= atomicOp && atomicOp <= wasm.OpcodeAtomicI64Load32U:
+			if t, _ := atomicAccess(atomicOp); t == UnsignedTypeI32 {
+				return signature_I32_I32, nil
+			}
+			return signature_I32_I64, nil
+		case wasm.OpcodeAtomicI32Store <= atomicOp && atomicOp <= wasm.OpcodeAtomicI64Store32:
+			if t, _ := atomicAccess(atomicOp); t == UnsignedTypeI32 {
+				return signature_I32I32_None, nil
+			}
+			return signature_I32I64_None, nil
+		case wasm.OpcodeAtomicI32RmwAdd <= atomicOp && atomicOp < wasm.OpcodeAtomicI32RmwCmpxchg:
+			if t, _ := atomicAccess(atomicOp); t == UnsignedTypeI32 {
+				return signature_I32I32_I32, nil
+			}
+			return signature_I32I64_I64, nil
+		case wasm.OpcodeAtomicI32RmwCmpxchg <= atomicOp && atomicOp <= wasm.OpcodeAtomicI64Rmw32CmpxchgU:
+			if t, _ := atomicAccess(atomicOp); t == UnsignedTypeI32 {
+				return signature_I32I32I32_I32, nil
+			}
+			return signature_I32I64I64_I64, nil
+		default:
+			return nil, fmt.Errorf("unsupported atomic instruction in wazeroir: %s", wasm.AtomicInstructionName(atomicOp))
+		}
 	default:
 		return nil, fmt.Errorf("unsupported instruction in wazeroir: 0x%x", op)
 	}
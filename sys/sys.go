@@ -3,6 +3,9 @@ package sys
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/tetratelabs/wazero/api"
 )
 
 // ExitError is returned to a caller of api.Function still running when api.Module CloseWithExitCode was invoked.
@@ -50,3 +53,136 @@ func (e *ExitError) Is(err error) bool {
 	}
 	return false
 }
+
+// TrapError is returned to a caller of api.Function when the WebAssembly runtime trapped, for example on an integer
+// divide by zero. The cause is available via errors.Unwrap, and its classification via Code.
+//
+// Here's an example of treating out-of-bounds memory access differently than other traps:
+//	if trapErr, ok := err.(*sys.TrapError); ok && trapErr.Code() == sys.TrapMemoryOutOfBounds {
+//		// Log a security event
+//	}
+//
+// Here's an example of how to get the source position of a trap in a module compiled from the text format:
+//	if trapErr, ok := err.(*sys.TrapError); ok {
+//		if loc := trapErr.Location(); loc != nil {
+//			line, col, ok := compiled.SourcePosition(loc.FunctionIndex, loc.ProgramCounter)
+//		}
+//	--snip--
+type TrapError struct {
+	code     TrapCode
+	cause    error
+	message  string
+	location *TrapLocation
+}
+
+// TrapCode classifies the cause of a TrapError.
+type TrapCode uint32
+
+const (
+	// TrapUnknown is the zero value, returned when the cause isn't classified below.
+	TrapUnknown TrapCode = iota
+	// TrapUnreachable means the "unreachable" instruction was executed.
+	TrapUnreachable
+	// TrapMemoryOutOfBounds means an instruction accessed memory beyond its current size.
+	TrapMemoryOutOfBounds
+	// TrapDivByZero means an integer division or remainder instruction had a zero divisor.
+	TrapDivByZero
+	// TrapIntegerOverflow means an integer result isn't representable, such as i32.div_s of math.MinInt32 by -1.
+	TrapIntegerOverflow
+	// TrapInvalidConversion means a trapping float-to-int truncation had a NaN operand.
+	TrapInvalidConversion
+	// TrapIndirectCallTypeMismatch means the function called by "call_indirect" has a different type than expected.
+	TrapIndirectCallTypeMismatch
+	// TrapInvalidTableAccess means a table index was out of bounds or, for "call_indirect", the element was null.
+	TrapInvalidTableAccess
+	// TrapCallStackOverflow means there were too many nested function calls.
+	TrapCallStackOverflow
+	// TrapUnalignedAtomic means an atomic instruction accessed an address that isn't a multiple of its size.
+	TrapUnalignedAtomic
+	// TrapExpectedSharedMemory means an atomic wait instruction was executed on a memory that isn't shared.
+	TrapExpectedSharedMemory
+	// TrapContextDone means the context.Context of the call was done, for example canceled, while the function ran.
+	// errors.Is reports the context error, such as context.Canceled.
+	TrapContextDone
+)
+
+// TrapLocation is the position of the instruction that trapped.
+type TrapLocation struct {
+	// ModuleName is the name of the api.Module that defines the function.
+	ModuleName string
+
+	// FunctionIndex is the position of the function in the module's function index namespace, imports first.
+	FunctionIndex uint32
+
+	// ProgramCounter is the offset of the trapping instruction in the function body of the code section.
+	ProgramCounter uint64
+}
+
+// NewTrapError returns a TrapError with the given code, cause and message. location is nil when unknown.
+func NewTrapError(code TrapCode, cause error, message string, location *TrapLocation) *TrapError {
+	return &TrapError{code: code, cause: cause, message: message, location: location}
+}
+
+// Code returns the classification of the cause, or TrapUnknown.
+func (e *TrapError) Code() TrapCode {
+	return e.code
+}
+
+// Location returns the position of the instruction that trapped, or nil if unknown.
+//
+// Note: This is interpreter-only for now, and requires the RuntimeConfig to enable a source map.
+func (e *TrapError) Location() *TrapLocation {
+	return e.location
+}
+
+func (e *TrapError) Error() string {
+	return e.message
+}
+
+// Unwrap allows use via errors.Is and errors.Unwrap
+func (e *TrapError) Unwrap() error {
+	return e.cause
+}
+
+// UnresolvedImportsError is returned when instantiating a module with imports that don't match any export, such as
+// when the imported module wasn't instantiated or the export has a different type. Imports lists all of them, so that
+// they can be fixed at once.
+//
+// Note: When the only unresolved import is a type mismatch, the error describing it is returned instead.
+//
+// Here's an example of how to list the missing imports:
+//	var unresolved *sys.UnresolvedImportsError
+//	if errors.As(err, &unresolved) {
+//		for _, i := range unresolved.Imports {
+//			fmt.Println(i.Module, i.Name)
+//		}
+//	--snip--
+type UnresolvedImportsError struct {
+	// Imports are in the order they were declared in the module.
+	Imports []UnresolvedImport
+}
+
+// UnresolvedImport is an import that doesn't match any export, or whose type doesn't match it.
+type UnresolvedImport struct {
+	// Module and Name are the import's module and name. Ex. "wasi_snapshot_preview1" and "fd_write"
+	Module, Name string
+
+	// Type is the kind of the import. Ex. api.ExternTypeFunc
+	Type api.ExternType
+
+	// Reason describes why the import is unresolved. Ex. "module[env] not instantiated"
+	Reason string
+}
+
+// Error returns the reason when there is only one unresolved import, or otherwise lists all of them.
+func (e *UnresolvedImportsError) Error() string {
+	if len(e.Imports) == 1 {
+		return e.Imports[0].Reason
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d imports are unresolved:", len(e.Imports))
+	for _, i := range e.Imports {
+		fmt.Fprintf(&b, "\n\t%s[%s.%s]: %s", api.ExternTypeName(i.Type), i.Module, i.Name, i.Reason)
+	}
+	return b.String()
+}
@@ -20,6 +20,18 @@ func ErrnoName(errno Errno) string {
 	return fmt.Sprintf("errno(%d)", errno)
 }
 
+// ErrnoFromName returns the Errno of a name returned by ErrnoName, or false if it isn't one. Ex. "E2BIG" -> Errno2big
+//
+// Note: This is the inverse of ErrnoName, so it is case-sensitive and returns false for names like "errno(100)".
+func ErrnoFromName(name string) (Errno, bool) {
+	for errno, n := range errnoToString {
+		if n == name {
+			return Errno(errno), true
+		}
+	}
+	return 0, false
+}
+
 // Note: Below prefers POSIX symbol names over WASI ones, even if the docs are from WASI.
 // See https://linux.die.net/man/3/errno
 // See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#variants-1
@@ -0,0 +1,40 @@
+package wasi
+
+import (
+	"context"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// iovecSize is the size in bytes of an encoded IOVec: a little-endian uint32 offset followed by a uint32 length.
+const iovecSize = 8
+
+// IOVec is a buffer in memory, as used in the "iovs" parameter of "fd_read" and "fd_write".
+//
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-iovec-struct
+type IOVec struct {
+	// Offset is the offset in memory of the buffer.
+	Offset uint32
+	// Length is the length in bytes of the buffer.
+	Length uint32
+}
+
+// WriteIOVecs encodes iovs as an array at the given offset in memory, returning the sum of their lengths. This is
+// useful when the host calls "fd_read" or "fd_write" directly, passing the offset as "iovs" and len(iovs) as
+// "iovs_len".
+//
+// This returns false if the array doesn't fit in memory. The buffers themselves are not checked.
+//
+// Ex. To read up to 8 bytes into memory offset 16, given "fdRead" is an api.Function calling "fd_read":
+//	_, _ = WriteIOVecs(ctx, mod.Memory(), 0, []IOVec{{Offset: 16, Length: 8}})
+//	results, err := fdRead.Call(ctx, uint64(fd), 0, 1, uint64(resultSize))
+func WriteIOVecs(ctx context.Context, mem api.Memory, offset uint32, iovs []IOVec) (total uint32, ok bool) {
+	for i, iov := range iovs {
+		iovPtr := offset + uint32(i)*iovecSize
+		if !mem.WriteUint32Le(ctx, iovPtr, iov.Offset) || !mem.WriteUint32Le(ctx, iovPtr+4, iov.Length) {
+			return 0, false
+		}
+		total += iov.Length
+	}
+	return total, true
+}
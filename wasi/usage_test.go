@@ -3,10 +3,14 @@ package wasi
 import (
 	"bytes"
 	_ "embed"
+	"io"
+	"sync"
 	"testing"
 
 	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 // wasiArg was compiled from testdata/wasi_arg.wat
@@ -40,3 +44,415 @@ func TestInstantiateModule(t *testing.T) {
 		require.NoError(t, mod.Close(testCtx))
 	}
 }
+
+// TestInstantiateModule_Reactor ensures a WASI reactor is initialized, and its exports are usable afterwards.
+func TestInstantiateModule_Reactor(t *testing.T) {
+	r := wazero.NewRuntime()
+	defer r.Close(testCtx)
+
+	_, err := InstantiateSnapshotPreview1(testCtx, r)
+	require.NoError(t, err)
+
+	// The reactor doesn't export "_start", and "_initialize" stores the value read by "get".
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+  (import "wasi_snapshot_preview1" "fd_write" (func $fd_write (param i32 i32 i32 i32) (result i32)))
+  (memory 1)
+  (func $initialize i32.const 0 i32.const 42 i32.store)
+  (func $get (result i32) i32.const 0 i32.load)
+  (export "_initialize" (func $initialize))
+  (export "get" (func $get))
+)`), wazero.NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	mod, err := r.InstantiateModule(testCtx, compiled, ReactorModuleConfig(wazero.NewModuleConfig()))
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	results, err := mod.ExportedFunction("get").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+}
+
+// TestInstantiateModule_RenameWASIUnstable shows how to run a module importing the older "wasi_unstable".
+func TestInstantiateModule_RenameWASIUnstable(t *testing.T) {
+	r := wazero.NewRuntime()
+	defer r.Close(testCtx)
+
+	_, err := InstantiateSnapshotPreview1(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+  (import "wasi_unstable" "fd_write" (func $fd_write (param i32 i32 i32 i32) (result i32)))
+  (memory 1)
+  (export "memory" (memory 0))
+  (export "fd_write" (func $fd_write))
+)`), wazero.NewCompileConfig().WithImportRenamer(func(_ api.ExternType, oldModule, oldName string) (string, string) {
+		if oldModule == "wasi_unstable" {
+			return ModuleSnapshotPreview1, oldName
+		}
+		return oldModule, oldName
+	}))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	stdout := bytes.NewBuffer(nil)
+	mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithStdout(stdout))
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	iovs, resultSize := uint32(0), uint32(8)
+	require.True(t, mod.Memory().Write(testCtx, 16, []byte("wazero")))
+	_, ok := WriteIOVecs(testCtx, mod.Memory(), iovs, []IOVec{{Offset: 16, Length: 6}})
+	require.True(t, ok)
+	results, err := mod.ExportedFunction("fd_write").Call(testCtx, uint64(fdStdout), uint64(iovs), 1, uint64(resultSize))
+	require.NoError(t, err)
+	require.Equal(t, ErrnoSuccess, Errno(results[0]))
+	require.Equal(t, "wazero", stdout.String())
+}
+
+// TestInstantiateUnstable ensures a module importing the older "wasi_unstable" runs without renaming its imports.
+func TestInstantiateUnstable(t *testing.T) {
+	r := wazero.NewRuntime()
+	defer r.Close(testCtx)
+
+	_, err := InstantiateUnstable(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+  (import "wasi_unstable" "fd_write" (func $fd_write (param i32 i32 i32 i32) (result i32)))
+  (memory 1)
+  (export "memory" (memory 0))
+  (export "fd_write" (func $fd_write))
+)`), wazero.NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	stdout := bytes.NewBuffer(nil)
+	mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithStdout(stdout))
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	iovs, resultSize := uint32(0), uint32(8)
+	require.True(t, mod.Memory().Write(testCtx, 16, []byte("wazero")))
+	_, ok := WriteIOVecs(testCtx, mod.Memory(), iovs, []IOVec{{Offset: 16, Length: 6}})
+	require.True(t, ok)
+	results, err := mod.ExportedFunction("fd_write").Call(testCtx, uint64(fdStdout), uint64(iovs), 1, uint64(resultSize))
+	require.NoError(t, err)
+	require.Equal(t, ErrnoSuccess, Errno(results[0]))
+	require.Equal(t, "wazero", stdout.String())
+}
+
+// TestBuilder_WithoutFilesystem ensures excluded functions are stubbed while the others still work.
+func TestBuilder_WithoutFilesystem(t *testing.T) {
+	r := wazero.NewRuntime()
+	defer r.Close(testCtx)
+
+	_, err := NewBuilder(r).WithoutFilesystem().WithoutSockets().Instantiate(testCtx)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+  `+importPathOpen+`
+  `+importFdPrestatGet+`
+  `+importClockTimeGet+`
+  `+importSockRecv+`
+  (memory 1)
+  (export "memory" (memory 0))
+  (export "path_open" (func $wasi.path_open))
+  (export "fd_prestat_get" (func $wasi.fd_prestat_get))
+  (export "clock_time_get" (func $wasi.clock_time_get))
+  (export "sock_recv" (func $wasi.sock_recv))
+)`), wazero.NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig())
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	results, err := mod.ExportedFunction("path_open").Call(testCtx, 3, 0, 0, 1, 0, 0, 0, 0, 8)
+	require.NoError(t, err)
+	require.Equal(t, ErrnoNotcapable, Errno(results[0]))
+
+	// wasi-libc stops scanning for pre-opens on EBADF, so this must not be ENOTCAPABLE.
+	results, err = mod.ExportedFunction("fd_prestat_get").Call(testCtx, 3, 0)
+	require.NoError(t, err)
+	require.Equal(t, ErrnoBadf, Errno(results[0]))
+
+	results, err = mod.ExportedFunction("sock_recv").Call(testCtx, 3, 0, 0, 0, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, ErrnoNotcapable, Errno(results[0]))
+
+	results, err = mod.ExportedFunction("clock_time_get").Call(testCtx, 0, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, ErrnoSuccess, Errno(results[0]))
+}
+
+// TestInstantiateModule_environOrder ensures "environ_get" returns entries in the order keys were first set with
+// wazero.ModuleConfig WithEnv, even when a later call replaces a value, and that a derived config doesn't change the
+// one it was derived from.
+func TestInstantiateModule_environOrder(t *testing.T) {
+	r := wazero.NewRuntime()
+	defer r.Close(testCtx)
+
+	_, err := InstantiateSnapshotPreview1(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+  `+importEnvironGet+`
+  (memory 1)
+  (export "memory" (memory 0))
+  (export "environ_get" (func $wasi.environ_get))
+)`), wazero.NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	// environGet returns the null-terminated environment variables written to the buffer at offset 16.
+	environGet := func(config wazero.ModuleConfig) string {
+		mod, err := r.InstantiateModule(testCtx, compiled, config)
+		require.NoError(t, err)
+		defer mod.Close(testCtx)
+
+		results, err := mod.ExportedFunction("environ_get").Call(testCtx, 0, 16)
+		require.NoError(t, err)
+		require.Equal(t, ErrnoSuccess, Errno(results[0]))
+
+		buf, ok := mod.Memory().Read(testCtx, 16, 8)
+		require.True(t, ok)
+		return string(buf)
+	}
+
+	base := wazero.NewModuleConfig().WithEnv("A", "1").WithEnv("B", "2")
+	replaced := base.WithEnv("A", "3")
+
+	require.Equal(t, "A=3\x00B=2\x00", environGet(replaced))
+	require.Equal(t, "A=1\x00B=2\x00", environGet(base))
+}
+
+func TestBuilder_WithUnimplementedErrno(t *testing.T) {
+	tests := []struct {
+		name          string
+		builder       func(Builder) Builder
+		expectedErrno Errno
+		// expectedResultsErrno is the errno of a function with result parameters, which never succeeds when stubbed.
+		expectedResultsErrno Errno
+	}{
+		{
+			name:                 "default",
+			builder:              func(b Builder) Builder { return b },
+			expectedErrno:        ErrnoNosys,
+			expectedResultsErrno: ErrnoNosys,
+		},
+		{
+			name:                 "ErrnoNotsup",
+			builder:              func(b Builder) Builder { return b.WithUnimplementedErrno(ErrnoNotsup) },
+			expectedErrno:        ErrnoNotsup,
+			expectedResultsErrno: ErrnoNotsup,
+		},
+		{
+			name:                 "ErrnoSuccess",
+			builder:              func(b Builder) Builder { return b.WithUnimplementedErrno(ErrnoSuccess) },
+			expectedErrno:        ErrnoSuccess,
+			expectedResultsErrno: ErrnoNosys,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			r := wazero.NewRuntime()
+			defer r.Close(testCtx)
+
+			_, err := tc.builder(NewBuilder(r)).Instantiate(testCtx)
+			require.NoError(t, err)
+
+			compiled, err := r.CompileModule(testCtx, []byte(`(module
+  `+importFdAdvise+`
+  `+importFdAllocate+`
+  `+importFdFilestatGet+`
+  (memory 1)
+  (export "memory" (memory 0))
+  (export "fd_advise" (func $wasi.fd_advise))
+  (export "fd_allocate" (func $wasi.fd_allocate))
+  (export "fd_filestat_get" (func $wasi.fd_filestat_get))
+)`), wazero.NewCompileConfig())
+			require.NoError(t, err)
+			defer compiled.Close(testCtx)
+
+			mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig())
+			require.NoError(t, err)
+			defer mod.Close(testCtx)
+
+			results, err := mod.ExportedFunction("fd_advise").Call(testCtx, 3, 0, 0, 0)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedErrno, Errno(results[0]))
+
+			results, err = mod.ExportedFunction("fd_allocate").Call(testCtx, 3, 0, 0)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedErrno, Errno(results[0]))
+
+			results, err = mod.ExportedFunction("fd_filestat_get").Call(testCtx, 3, 0)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedResultsErrno, Errno(results[0]))
+		})
+	}
+}
+
+func TestInstantiateModule_ExitCode(t *testing.T) {
+	r := wazero.NewRuntime()
+	defer r.Close(testCtx)
+
+	_, err := InstantiateSnapshotPreview1(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+  `+importProcExit+`
+  (func $main
+     i32.const 42
+     call $wasi.proc_exit
+  )
+  (export "_start" (func $main))
+)`), wazero.NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig())
+	require.Equal(t, uint32(42), err.(*sys.ExitError).ExitCode())
+
+	exitCode, ok := mod.ExitCode()
+	require.True(t, ok)
+	require.Equal(t, uint32(42), exitCode)
+}
+
+// countingWriter counts the writes to the buffer it wraps.
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+// Write implements io.Writer
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestInstantiateModule_WithOutputBufferSize(t *testing.T) {
+	r := wazero.NewRuntime()
+	defer r.Close(testCtx)
+
+	_, err := InstantiateSnapshotPreview1(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+  (import "wasi_snapshot_preview1" "fd_write" (func $fd_write (param i32 i32 i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "fd_sync" (func $fd_sync (param i32) (result i32)))
+  (memory 1)
+  (export "memory" (memory 0))
+  (export "fd_write" (func $fd_write))
+  (export "fd_sync" (func $fd_sync))
+)`), wazero.NewCompileConfig())
+	require.NoError(t, err)
+
+	// write calls fd_write on stdout with "wazero" in memory, as a guest logging line by line would.
+	write := func(t *testing.T, mod api.Module) {
+		iovs, resultSize := uint32(0), uint32(8)
+		require.True(t, mod.Memory().Write(testCtx, 16, []byte("wazero")))
+		_, ok := WriteIOVecs(testCtx, mod.Memory(), iovs, []IOVec{{Offset: 16, Length: 6}})
+		require.True(t, ok)
+		results, err := mod.ExportedFunction("fd_write").Call(testCtx, uint64(fdStdout), uint64(iovs), 1, uint64(resultSize))
+		require.NoError(t, err)
+		require.Equal(t, ErrnoSuccess, Errno(results[0]))
+	}
+
+	t.Run("unbuffered", func(t *testing.T) {
+		stdout := &countingWriter{}
+		mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithName(t.Name()).WithStdout(stdout))
+		require.NoError(t, err)
+		defer mod.Close(testCtx)
+
+		for i := 0; i < 3; i++ {
+			write(t, mod)
+		}
+		require.Equal(t, 3, stdout.writes)
+		require.Equal(t, "wazerowazerowazero", stdout.String())
+	})
+
+	t.Run("buffered", func(t *testing.T) {
+		stdout := &countingWriter{}
+		config := wazero.NewModuleConfig().WithName(t.Name()).WithStdout(stdout).WithOutputBufferSize(1024)
+		mod, err := r.InstantiateModule(testCtx, compiled, config)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			write(t, mod)
+		}
+		require.Zero(t, stdout.writes)
+
+		// fd_sync writes the buffered output in one write.
+		results, err := mod.ExportedFunction("fd_sync").Call(testCtx, uint64(fdStdout))
+		require.NoError(t, err)
+		require.Equal(t, ErrnoSuccess, Errno(results[0]))
+		require.Equal(t, 1, stdout.writes)
+		require.Equal(t, "wazerowazerowazero", stdout.String())
+
+		// Closing the module writes any remaining output, so it isn't lost.
+		write(t, mod)
+		require.Equal(t, 1, stdout.writes)
+		require.NoError(t, mod.Close(testCtx))
+		require.Equal(t, 2, stdout.writes)
+		require.Equal(t, "wazerowazerowazerowazero", stdout.String())
+	})
+}
+
+// notifyingReader closes reading on the first Read, so that a test can tell when the guest is blocked on it.
+type notifyingReader struct {
+	io.Reader
+	reading chan struct{}
+	once    sync.Once
+}
+
+func (r *notifyingReader) Read(p []byte) (int, error) {
+	r.once.Do(func() { close(r.reading) })
+	return r.Reader.Read(p)
+}
+
+// TestInstantiateModule_StdinUnblockedByClose ensures closing a module doesn't wait for stdin, such as a request body
+// piped by a server, to return.
+func TestInstantiateModule_StdinUnblockedByClose(t *testing.T) {
+	r := wazero.NewRuntime()
+	defer r.Close(testCtx)
+
+	_, err := InstantiateSnapshotPreview1(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+  (import "wasi_snapshot_preview1" "fd_read" (func $fd_read (param i32 i32 i32 i32) (result i32)))
+  (memory 1)
+  (export "memory" (memory 0))
+  (export "fd_read" (func $fd_read))
+)`), wazero.NewCompileConfig())
+	require.NoError(t, err)
+
+	pr, pw := io.Pipe()
+	defer pw.Close() // release the goroutine reading stdin
+	stdin := &notifyingReader{Reader: pr, reading: make(chan struct{})}
+
+	mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithStdin(stdin))
+	require.NoError(t, err)
+
+	iovs, resultSize := uint32(0), uint32(8)
+	_, ok := WriteIOVecs(testCtx, mod.Memory(), iovs, []IOVec{{Offset: 16, Length: 6}})
+	require.True(t, ok)
+
+	callErr := make(chan error, 1)
+	go func() {
+		_, err := mod.ExportedFunction("fd_read").Call(testCtx, uint64(fdStdin), uint64(iovs), 1, uint64(resultSize))
+		callErr <- err
+	}()
+
+	<-stdin.reading
+	require.NoError(t, mod.Close(testCtx))
+
+	// The call should return even though nothing was written to the pipe.
+	require.Equal(t, sys.NewExitError(mod.Name(), 0), <-callErr)
+}
@@ -11,7 +11,11 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"os"
 	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/tetratelabs/wazero"
@@ -35,11 +39,173 @@ const ModuleSnapshotPreview1 = "wasi_snapshot_preview1"
 //	_, _ = wasi.InstantiateSnapshotPreview1(ctx, r)
 //	mod, _ := r.InstantiateModuleFromCode(ctx, source)
 //
+// Note: If your source imports the older "wasi_unstable", such as from older versions of TinyGo, call
+// InstantiateUnstable instead.
 // Note: All WASI functions return a single Errno result, ErrnoSuccess on success.
 // Note: Closing the wazero.Runtime closes this instance of WASI as well.
 func InstantiateSnapshotPreview1(ctx context.Context, r wazero.Runtime) (api.Closer, error) {
+	return NewBuilder(r).Instantiate(ctx)
+}
+
+// FunctionStart is the function a WASI command exports as its entry point, like "main" in other languages. By default,
+// wazero.Runtime InstantiateModule calls it, if exported.
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/design/application-abi.md#current-unstable-abi
+const FunctionStart = "_start"
+
+// FunctionInitialize is the function a WASI reactor exports to initialize itself, before the host calls its other
+// exports. Unlike a WASI command, a reactor stays usable after instantiation.
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/design/application-abi.md#current-unstable-abi
+const FunctionInitialize = "_initialize"
+
+// ReactorModuleConfig returns a copy of the config which calls FunctionInitialize, if exported, on instantiation
+// instead of FunctionStart. Use this to instantiate a WASI reactor, which doesn't export FunctionStart.
+//
+// Ex. To instantiate a reactor and call one of its exports:
+//	mod, _ := r.InstantiateModule(ctx, compiled, wasi.ReactorModuleConfig(wazero.NewModuleConfig()))
+//	results, _ := mod.ExportedFunction("add").Call(ctx, 1, 2)
+func ReactorModuleConfig(config wazero.ModuleConfig) wazero.ModuleConfig {
+	return config.WithStartFunctions(FunctionInitialize)
+}
+
+// ModuleUnstable is the legacy module name of WASI functions, imported by modules compiled before
+// ModuleSnapshotPreview1 was defined.
+// See https://github.com/WebAssembly/WASI/blob/main/legacy/preview0/docs.md
+const ModuleUnstable = "wasi_unstable"
+
+// InstantiateUnstable instantiates ModuleUnstable with the same functions as InstantiateSnapshotPreview1. This avoids
+// renaming the imports of older modules, as the functions used in practice have the same signatures.
+//
+// Ex. If your source (%.wasm binary) includes an import "wasi_unstable", call InstantiateUnstable prior to
+// instantiating it.
+//	_, _ = wasi.InstantiateUnstable(ctx, r)
+//	mod, _ := r.InstantiateModuleFromCode(ctx, source)
+//
+// Note: Both can be instantiated in the same wazero.Runtime, in which case each has its own functions.
+// Note: Closing the wazero.Runtime closes this instance of WASI as well.
+func InstantiateUnstable(ctx context.Context, r wazero.Runtime) (api.Closer, error) {
 	_, fns := snapshotPreview1Functions(ctx)
-	return r.NewModuleBuilder(ModuleSnapshotPreview1).ExportFunctions(fns).Instantiate(ctx)
+	return r.NewModuleBuilder(ModuleUnstable).ExportFunctions(fns).Instantiate(ctx)
+}
+
+// Builder configures the ModuleSnapshotPreview1 module for later use via Instantiate, in order to reduce the
+// capabilities WASI presents to guests.
+//
+// Ex. To deny a guest any filesystem or socket access, even if it imports those functions:
+//	_, _ = wasi.NewBuilder(r).WithoutFilesystem().WithoutSockets().Instantiate(ctx)
+//
+// Note: Builder is mutable. WithXXX functions return the same instance for chaining.
+// Note: Excluded functions are still exported, so that modules importing them can be instantiated. They return an
+// error instead of doing anything.
+type Builder interface {
+	// WithoutFilesystem replaces the functions only used to access files and directories, such as `path_open`, with
+	// ones that return ErrnoNotcapable. `fd_prestat_get` returns ErrnoBadf, so that guests see no pre-opened
+	// directories.
+	//
+	// Note: Functions also used on standard I/O, such as `fd_read` and `fd_write`, are not replaced.
+	WithoutFilesystem() Builder
+
+	// WithoutSockets replaces the socket functions, such as `sock_recv`, with ones that return ErrnoNotcapable.
+	WithoutSockets() Builder
+
+	// WithUnimplementedErrno sets the Errno returned by functions which are not yet implemented, such as `fd_advise`.
+	// Defaults to ErrnoNosys.
+	//
+	// Some language runtimes treat ErrnoNosys as fatal, even when the function is optional. For example, ErrnoNotsup
+	// may let them fall back, and ErrnoSuccess turns advisory calls into no-ops.
+	//
+	// Note: ErrnoSuccess only applies to the advisory functions `fd_advise` and `fd_allocate`, which have no results.
+	// Other functions still return ErrnoNosys, as succeeding without writing their results would mislead the guest.
+	WithUnimplementedErrno(Errno) Builder
+
+	// Instantiate instantiates ModuleSnapshotPreview1 with the configured functions, so that other modules can import
+	// them.
+	//
+	// Note: Closing the wazero.Runtime closes this instance of WASI as well.
+	Instantiate(ctx context.Context) (api.Closer, error)
+}
+
+// NewBuilder returns a Builder for ModuleSnapshotPreview1 in the given runtime, which by default includes all
+// functions, the same as InstantiateSnapshotPreview1.
+func NewBuilder(r wazero.Runtime) Builder {
+	return &builder{r: r, unimplementedErrno: ErrnoNosys}
+}
+
+type builder struct {
+	r                                 wazero.Runtime
+	withoutFilesystem, withoutSockets bool
+	unimplementedErrno                Errno
+}
+
+// WithoutFilesystem implements Builder.WithoutFilesystem
+func (b *builder) WithoutFilesystem() Builder {
+	b.withoutFilesystem = true
+	return b
+}
+
+// WithoutSockets implements Builder.WithoutSockets
+func (b *builder) WithoutSockets() Builder {
+	b.withoutSockets = true
+	return b
+}
+
+// WithUnimplementedErrno implements Builder.WithUnimplementedErrno
+func (b *builder) WithUnimplementedErrno(errno Errno) Builder {
+	b.unimplementedErrno = errno
+	return b
+}
+
+// Instantiate implements Builder.Instantiate
+func (b *builder) Instantiate(ctx context.Context) (api.Closer, error) {
+	a, fns := snapshotPreview1Functions(ctx)
+	a.advisoryErrno = b.unimplementedErrno
+	if b.unimplementedErrno != ErrnoSuccess { // success would leave result parameters unwritten
+		a.unimplementedErrno = b.unimplementedErrno
+	}
+	if b.withoutFilesystem {
+		for _, name := range filesystemFunctions {
+			fns[name] = stubFunction(fns[name], ErrnoNotcapable)
+		}
+		// wasi-libc stops looking for pre-opened directories on ErrnoBadf, but fails on any other error.
+		fns[functionFdPrestatGet] = stubFunction(fns[functionFdPrestatGet], ErrnoBadf)
+	}
+	if b.withoutSockets {
+		for _, name := range socketFunctions {
+			fns[name] = stubFunction(fns[name], ErrnoNotcapable)
+		}
+	}
+	return b.r.NewModuleBuilder(ModuleSnapshotPreview1).ExportFunctions(fns).Instantiate(ctx)
+}
+
+// filesystemFunctions are replaced by Builder.WithoutFilesystem, except functionFdPrestatGet.
+var filesystemFunctions = []string{
+	functionFdAdvise,
+	functionFdAllocate,
+	functionFdFilestatGet,
+	functionFdFilestatSetSize,
+	functionFdFilestatSetTimes,
+	functionFdPrestatDirName,
+	functionFdReaddir,
+	functionPathCreateDirectory,
+	functionPathFilestatGet,
+	functionPathFilestatSetTimes,
+	functionPathLink,
+	functionPathOpen,
+	functionPathReadlink,
+	functionPathRemoveDirectory,
+	functionPathRename,
+	functionPathSymlink,
+	functionPathUnlinkFile,
+}
+
+// socketFunctions are replaced by Builder.WithoutSockets.
+var socketFunctions = []string{functionSockRecv, functionSockSend, functionSockShutdown}
+
+// stubFunction returns a function with the same signature as goFunc, which returns errno without calling it.
+func stubFunction(goFunc interface{}, errno Errno) interface{} {
+	result := []reflect.Value{reflect.ValueOf(errno)}
+	return reflect.MakeFunc(reflect.TypeOf(goFunc), func([]reflect.Value) []reflect.Value {
+		return result
+	}).Interface()
 }
 
 const (
@@ -439,6 +605,70 @@ const (
 // See https://wwa.w3.org/TR/2019/REC-wasm-core-1-20191205/#memory-instances%E2%91%A0.
 type snapshotPreview1 struct {
 	sys experimental.Sys
+
+	// logger is nil unless experimental.WASILoggerKey was set when instantiating.
+	logger experimental.WASILogger
+
+	// unimplementedErrno is returned by functions not yet implemented. Defaults to ErrnoNosys, and is never
+	// ErrnoSuccess.
+	unimplementedErrno Errno
+
+	// advisoryErrno is returned by the advisory functions not yet implemented, which have no results. Unlike
+	// unimplementedErrno, this can be ErrnoSuccess.
+	advisoryErrno Errno
+}
+
+// fdParams are the functions that act on a file descriptor, mapped to the index of that parameter after the
+// context.Context and api.Module.
+var fdParams = map[string]int{
+	functionFdAdvise:             0,
+	functionFdAllocate:           0,
+	functionFdClose:              0,
+	functionFdDatasync:           0,
+	functionFdFdstatGet:          0,
+	functionFdFdstatSetFlags:     0,
+	functionFdFdstatSetRights:    0,
+	functionFdFilestatGet:        0,
+	functionFdFilestatSetSize:    0,
+	functionFdFilestatSetTimes:   0,
+	functionFdPread:              0,
+	functionFdPrestatGet:         0,
+	functionFdPrestatDirName:     0,
+	functionFdPwrite:             0,
+	functionFdRead:               0,
+	functionFdReaddir:            0,
+	functionFdRenumber:           0,
+	functionFdSeek:               0,
+	functionFdSync:               0,
+	functionFdTell:               0,
+	functionFdWrite:              0,
+	functionPathCreateDirectory:  0,
+	functionPathFilestatGet:      0,
+	functionPathFilestatSetTimes: 0,
+	functionPathLink:             0, // old_fd
+	functionPathOpen:             0,
+	functionPathReadlink:         0,
+	functionPathRemoveDirectory:  0,
+	functionPathRename:           0,
+	functionPathSymlink:          2, // fd follows old_path and old_path_len
+	functionPathUnlinkFile:       0,
+	functionSockRecv:             0,
+	functionSockSend:             0,
+	functionSockShutdown:         0,
+}
+
+// logFdFunctions wraps each function in fdParams, so that the logger is notified of the errno it returns.
+func (a *snapshotPreview1) logFdFunctions(nameToGoFunc map[string]interface{}) {
+	for name, fdIndex := range fdParams {
+		name, fdIndex := name, fdIndex+2 // skip the context.Context and api.Module
+		fn := reflect.ValueOf(nameToGoFunc[name])
+		nameToGoFunc[name] = reflect.MakeFunc(fn.Type(), func(params []reflect.Value) []reflect.Value {
+			results := fn.Call(params)
+			ctx := params[0].Interface().(context.Context)
+			a.logger.LogFd(ctx, name, uint32(params[fdIndex].Uint()), Errno(results[0].Uint()))
+			return results
+		}).Interface()
+	}
 }
 
 // snapshotPreview1Functions returns all go functions that implement snapshotPreview1.
@@ -495,6 +725,9 @@ func snapshotPreview1Functions(ctx context.Context) (a *snapshotPreview1, nameTo
 		functionSockSend:             a.SockSend,
 		functionSockShutdown:         a.SockShutdown,
 	}
+	if a.logger != nil {
+		a.logFdFunctions(nameToGoFunc)
+	}
 	return
 }
 
@@ -642,7 +875,7 @@ func (a *snapshotPreview1) EnvironSizesGet(ctx context.Context, m api.Module, re
 
 // ClockResGet is the WASI function named functionClockResGet and is stubbed for GrainLang per #271
 func (a *snapshotPreview1) ClockResGet(ctx context.Context, m api.Module, id uint32, resultResolution uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // ClockTimeGet is the WASI function named functionClockTimeGet that returns the time value of a clock (time.Now).
@@ -675,12 +908,12 @@ func (a *snapshotPreview1) ClockTimeGet(ctx context.Context, m api.Module, id ui
 
 // FdAdvise is the WASI function named functionFdAdvise and is stubbed for GrainLang per #271
 func (a *snapshotPreview1) FdAdvise(ctx context.Context, m api.Module, fd uint32, offset, len uint64, resultAdvice uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.advisoryErrno // stubbed for GrainLang per #271
 }
 
 // FdAllocate is the WASI function named functionFdAllocate and is stubbed for GrainLang per #271
 func (a *snapshotPreview1) FdAllocate(ctx context.Context, m api.Module, fd uint32, offset, len uint64) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.advisoryErrno // stubbed for GrainLang per #271
 }
 
 // FdClose is the WASI function to close a file descriptor. This returns ErrnoBadf if the fd is invalid.
@@ -703,9 +936,9 @@ func (a *snapshotPreview1) FdClose(ctx context.Context, m api.Module, fd uint32)
 	return ErrnoSuccess
 }
 
-// FdDatasync is the WASI function named functionFdDatasync and is stubbed for GrainLang per #271
+// FdDatasync is the WASI function named functionFdDatasync, which is implemented the same as FdSync.
 func (a *snapshotPreview1) FdDatasync(ctx context.Context, m api.Module, fd uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.fdSync(m, fd)
 }
 
 // FdFdstatGet is the WASI function to return the attributes of a file descriptor.
@@ -788,7 +1021,8 @@ func (a *snapshotPreview1) FdPrestatGet(ctx context.Context, m api.Module, fd ui
 	if !m.Memory().WriteUint32Le(ctx, resultPrestat, uint32(0)) {
 		return ErrnoFault
 	}
-	// Write the length of the directory name at offset 4.
+	// Write the length of the directory name at offset 4. This is in bytes, not runes, as it is the size of the buffer
+	// the guest passes to fd_prestat_dir_name.
 	if !m.Memory().WriteUint32Le(ctx, resultPrestat+4, uint32(len(entry.Path))) {
 		return ErrnoFault
 	}
@@ -798,33 +1032,33 @@ func (a *snapshotPreview1) FdPrestatGet(ctx context.Context, m api.Module, fd ui
 
 // FdFdstatSetFlags is the WASI function named functionFdFdstatSetFlags and is stubbed for GrainLang per #271
 func (a *snapshotPreview1) FdFdstatSetFlags(ctx context.Context, m api.Module, fd uint32, flags uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // FdFdstatSetRights implements snapshotPreview1.FdFdstatSetRights
 // Note: This will never be implemented per https://github.com/WebAssembly/WASI/issues/469#issuecomment-1045251844
 func (a *snapshotPreview1) FdFdstatSetRights(ctx context.Context, m api.Module, fd uint32, fsRightsBase, fsRightsInheriting uint64) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // FdFilestatGet is the WASI function named functionFdFilestatGet
 func (a *snapshotPreview1) FdFilestatGet(ctx context.Context, m api.Module, fd uint32, resultBuf uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // FdFilestatSetSize is the WASI function named functionFdFilestatSetSize
 func (a *snapshotPreview1) FdFilestatSetSize(ctx context.Context, m api.Module, fd uint32, size uint64) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // FdFilestatSetTimes is the WASI function named functionFdFilestatSetTimes
 func (a *snapshotPreview1) FdFilestatSetTimes(ctx context.Context, m api.Module, fd uint32, atim, mtim uint64, fstFlags uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // FdPread is the WASI function named functionFdPread
 func (a *snapshotPreview1) FdPread(ctx context.Context, m api.Module, fd, iovs, iovsCount uint32, offset uint64, resultNread uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // FdPrestatDirName is the WASI function to return the path of the pre-opened directory of a file descriptor.
@@ -873,7 +1107,7 @@ func (a *snapshotPreview1) FdPrestatDirName(ctx context.Context, m api.Module, f
 
 // FdPwrite is the WASI function named functionFdPwrite
 func (a *snapshotPreview1) FdPwrite(ctx context.Context, m api.Module, fd, iovs, iovsCount uint32, offset uint64, resultNwritten uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // FdRead is the WASI function to read from a file descriptor.
@@ -886,6 +1120,7 @@ func (a *snapshotPreview1) FdPwrite(ctx context.Context, m api.Module, fd, iovs,
 //
 // The wasi.Errno returned is wasi.ErrnoSuccess except the following error conditions:
 // * wasi.ErrnoBadf - if `fd` is invalid
+// * wasi.ErrnoIsdir - if `fd` is a directory, such as a pre-opened one
 // * wasi.ErrnoFault - if `iovs` or `resultSize` contain an invalid offset due to the memory constraint
 // * wasi.ErrnoIo - if an IO related error happens during the operation
 //
@@ -913,6 +1148,9 @@ func (a *snapshotPreview1) FdPwrite(ctx context.Context, m api.Module, fd, iovs,
 //                            iovs[1].offset --+           |
 //                                            resultSize --+
 //
+// Like `readv` in POSIX, the number of bytes read can be less than requested, for example when stdin has no more data
+// available. At EOF, zero bytes are read and wasi.ErrnoSuccess is returned.
+//
 // Note: importFdRead shows this signature in the WebAssembly 1.0 (20191205) Text Format.
 // Note: This is similar to `readv` in POSIX.
 // See FdWrite
@@ -926,8 +1164,10 @@ func (a *snapshotPreview1) FdRead(ctx context.Context, m api.Module, fd, iovs, i
 
 	if fd == fdStdin {
 		reader = sys.Stdin()
-	} else if f, ok := sys.OpenedFile(fd); !ok || f.File == nil {
+	} else if f, ok := sys.OpenedFile(fd); !ok {
 		return ErrnoBadf
+	} else if isDir(f) {
+		return ErrnoIsdir
 	} else {
 		reader = f.File
 	}
@@ -950,9 +1190,11 @@ func (a *snapshotPreview1) FdRead(ctx context.Context, m api.Module, fd, iovs, i
 		n, err := reader.Read(b)
 		nread += uint32(n)
 		if errors.Is(err, io.EOF) {
-			break
+			break // EOF isn't an error: zero bytes read signals it to the caller.
 		} else if err != nil {
 			return ErrnoIo
+		} else if n < len(b) {
+			break // short read, ex. stdin has no more data available now, so don't block reading the next iovec.
 		}
 	}
 	if !m.Memory().WriteUint32Le(ctx, resultSize, nread) {
@@ -963,12 +1205,12 @@ func (a *snapshotPreview1) FdRead(ctx context.Context, m api.Module, fd, iovs, i
 
 // FdReaddir is the WASI function named functionFdReaddir
 func (a *snapshotPreview1) FdReaddir(ctx context.Context, m api.Module, fd, buf, bufLen uint32, cookie uint64, resultBufused uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // FdRenumber is the WASI function named functionFdRenumber
 func (a *snapshotPreview1) FdRenumber(ctx context.Context, m api.Module, fd, to uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // FdSeek is the WASI function to move the offset of a file descriptor.
@@ -983,6 +1225,8 @@ func (a *snapshotPreview1) FdRenumber(ctx context.Context, m api.Module, fd, to
 //
 // The wasi.Errno returned is wasi.ErrnoSuccess except the following error conditions:
 // * wasi.ErrnoBadf - if `fd` is invalid
+// * wasi.ErrnoIsdir - if `fd` is a directory, such as a pre-opened one
+// * wasi.ErrnoSpipe - if `fd` is not seekable, such as stdout or a file that doesn't implement io.Seeker
 // * wasi.ErrnoFault - if `resultNewoffset` is an invalid offset in `m.Memory` due to the memory constraint
 // * wasi.ErrnoInval - if `whence` is an invalid value
 // * wasi.ErrnoIo - if other error happens during the operation of the underying file system
@@ -1003,15 +1247,9 @@ func (a *snapshotPreview1) FdRenumber(ctx context.Context, m api.Module, fd, to
 // See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#fd_seek
 // See https://linux.die.net/man/3/lseek
 func (a *snapshotPreview1) FdSeek(ctx context.Context, m api.Module, fd uint32, offset uint64, whence uint32, resultNewoffset uint32) Errno {
-	sys := sysCtx(m)
-
-	var seeker io.Seeker
-	// Check to see if the file descriptor is available
-	if f, ok := sys.OpenedFile(fd); !ok || f.File == nil {
-		return ErrnoBadf
-		// fs.FS doesn't declare io.Seeker, but implementations such as os.File implement it.
-	} else if seeker, ok = f.File.(io.Seeker); !ok {
-		return ErrnoBadf
+	seeker, errno := fdSeeker(sysCtx(m), fd)
+	if errno != ErrnoSuccess {
+		return errno
 	}
 
 	if whence > io.SeekEnd /* exceeds the largest valid whence */ {
@@ -1029,14 +1267,99 @@ func (a *snapshotPreview1) FdSeek(ctx context.Context, m api.Module, fd uint32,
 	return ErrnoSuccess
 }
 
-// FdSync is the WASI function named functionFdSync
+// FdSync is the WASI function named functionFdSync, which writes any output buffered for stdout or stderr, such as
+// via wazero.ModuleConfig WithOutputBufferSize.
+//
+// The wasi.Errno returned is wasi.ErrnoSuccess except the following error conditions:
+// * wasi.ErrnoNosys - if `fd` is not stdout or stderr, as syncing files is not yet supported. See
+//   Builder.WithUnimplementedErrno
+// * wasi.ErrnoIo - if the buffered output couldn't be written
+//
+// Note: importFdSync shows this signature in the WebAssembly 1.0 (20191205) Text Format.
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-fd_syncfd-fd---errno
 func (a *snapshotPreview1) FdSync(ctx context.Context, m api.Module, fd uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.fdSync(m, fd)
 }
 
-// FdTell is the WASI function named functionFdTell
+// fdSync implements FdSync and FdDatasync, which are the same as there is no metadata to sync.
+func (a *snapshotPreview1) fdSync(m api.Module, fd uint32) Errno {
+	sys := sysCtx(m)
+	var w io.Writer
+	switch fd {
+	case fdStdout:
+		w = sys.Stdout()
+	case fdStderr:
+		w = sys.Stderr()
+	default:
+		return a.unimplementedErrno // stubbed for GrainLang per #271
+	}
+	if err := wasm.Flush(w); err != nil {
+		return ErrnoIo
+	}
+	return ErrnoSuccess
+}
+
+// FdTell is the WASI function to return the current offset of a file descriptor.
+//
+// * fd: the file descriptor to get the offset of
+// * resultOffset: the offset in `m.Memory` to write the current offset to, relative to start of the file
+//
+// The wasi.Errno returned is wasi.ErrnoSuccess except the following error conditions:
+// * wasi.ErrnoBadf - if `fd` is invalid
+// * wasi.ErrnoIsdir - if `fd` is a directory, such as a pre-opened one
+// * wasi.ErrnoSpipe - if `fd` is not seekable, such as stdout or a file that doesn't implement io.Seeker
+// * wasi.ErrnoFault - if `resultOffset` is an invalid offset in `m.Memory` due to the memory constraint
+// * wasi.ErrnoIo - if other error happens during the operation of the underying file system
+//
+// Note: importFdTell shows this signature in the WebAssembly 1.0 (20191205) Text Format.
+// Note: This is similar to `lseek(fd, 0, SEEK_CUR)` in POSIX.
+// See FdSeek
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-fd_tellfd-fd---errno-filesize
 func (a *snapshotPreview1) FdTell(ctx context.Context, m api.Module, fd, resultOffset uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	seeker, errno := fdSeeker(sysCtx(m), fd)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return ErrnoIo
+	}
+
+	if !m.Memory().WriteUint64Le(ctx, resultOffset, uint64(offset)) {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
+
+// fdSeeker returns the io.Seeker of an opened file or wasi.ErrnoSpipe if it isn't seekable.
+func fdSeeker(sys *wasm.SysContext, fd uint32) (io.Seeker, Errno) {
+	switch fd {
+	case fdStdin, fdStdout, fdStderr: // streams such as pipes can't seek
+		return nil, ErrnoSpipe
+	}
+
+	// Check to see if the file descriptor is available
+	f, ok := sys.OpenedFile(fd)
+	if !ok {
+		return nil, ErrnoBadf
+	} else if isDir(f) {
+		return nil, ErrnoIsdir
+	}
+	// fs.FS doesn't declare io.Seeker, but implementations such as os.File implement it.
+	if seeker, ok := f.File.(io.Seeker); ok {
+		return seeker, ErrnoSuccess
+	}
+	return nil, ErrnoSpipe
+}
+
+// isDir returns true if the file entry is a directory, such as a pre-opened one which has no File.
+func isDir(f *wasm.FileEntry) bool {
+	if f.File == nil {
+		return true
+	}
+	stat, err := f.File.Stat()
+	return err == nil && stat.IsDir()
 }
 
 // FdWrite is the WASI function to write to a file descriptor.
@@ -1049,8 +1372,10 @@ func (a *snapshotPreview1) FdTell(ctx context.Context, m api.Module, fd, resultO
 //
 // The wasi.Errno returned is wasi.ErrnoSuccess except the following error conditions:
 // * wasi.ErrnoBadf - if `fd` is invalid
+// * wasi.ErrnoIsdir - if `fd` is a directory, such as a pre-opened one
 // * wasi.ErrnoFault - if `iovs` or `resultSize` contain an invalid offset due to the memory constraint
-// * wasi.ErrnoIo - if an IO related error happens during the operation
+// * wasi.ErrnoIo - if an IO related error happens during the operation, such as a short write. The count of bytes
+//   written before the error is still written to `resultSize`.
 //
 // For example, this function needs to first read `iovs` to determine what to write to `fd`. If
 //    parameters iovs=1 iovsCount=2, this function reads two offset/length pairs from `m.Memory`:
@@ -1100,8 +1425,10 @@ func (a *snapshotPreview1) FdWrite(ctx context.Context, m api.Module, fd, iovs,
 		writer = sys.Stderr()
 	default:
 		// Check to see if the file descriptor is available
-		if f, ok := sys.OpenedFile(fd); !ok || f.File == nil {
+		if f, ok := sys.OpenedFile(fd); !ok {
 			return ErrnoBadf
+		} else if isDir(f) {
+			return ErrnoIsdir
 			// fs.FS doesn't declare io.Writer, but implementations such as os.File implement it.
 		} else if writer, ok = f.File.(io.Writer); !ok {
 			return ErrnoBadf
@@ -1124,10 +1451,14 @@ func (a *snapshotPreview1) FdWrite(ctx context.Context, m api.Module, fd, iovs,
 			return ErrnoFault
 		}
 		n, err := writer.Write(b)
+		nwritten += uint32(n)
 		if err != nil {
+			// Stop at the first error, but still report what was written, so the caller can retry the remainder.
+			if !m.Memory().WriteUint32Le(ctx, resultSize, nwritten) {
+				return ErrnoFault
+			}
 			return ErrnoIo
 		}
-		nwritten += uint32(n)
 	}
 	if !m.Memory().WriteUint32Le(ctx, resultSize, nwritten) {
 		return ErrnoFault
@@ -1137,22 +1468,22 @@ func (a *snapshotPreview1) FdWrite(ctx context.Context, m api.Module, fd, iovs,
 
 // PathCreateDirectory is the WASI function named functionPathCreateDirectory
 func (a *snapshotPreview1) PathCreateDirectory(ctx context.Context, m api.Module, fd, path, pathLen uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // PathFilestatGet is the WASI function named functionPathFilestatGet
 func (a *snapshotPreview1) PathFilestatGet(ctx context.Context, m api.Module, fd, flags, path, pathLen, resultBuf uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // PathFilestatSetTimes is the WASI function named functionPathFilestatSetTimes
 func (a *snapshotPreview1) PathFilestatSetTimes(ctx context.Context, m api.Module, fd, flags, path, pathLen uint32, atim, mtime uint64, fstFlags uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // PathLink is the WASI function named functionPathLink
 func (a *snapshotPreview1) PathLink(ctx context.Context, m api.Module, oldFd, oldFlags, oldPath, oldPathLen, newFd, newPath, newPathLen uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // PathOpen is the WASI function to open a file or directory. This returns ErrnoBadf if the fd is invalid.
@@ -1174,6 +1505,9 @@ func (a *snapshotPreview1) PathLink(ctx context.Context, m api.Module, oldFd, ol
 // * wasi.ErrnoNoent - if `path` does not exist.
 // * wasi.ErrnoExist - if `path` exists, while `oFlags` requires that it must not.
 // * wasi.ErrnoNotdir - if `path` is not a directory, while `oFlags` requires that it must be.
+// * wasi.ErrnoNotcapable - if `path` is absolute or escapes the pre-opened directory, ex. "../../etc/passwd".
+// * wasi.ErrnoLoop - if `path` is a symbolic link, while `dirflags` doesn't include LOOKUPFLAGS_SYMLINK_FOLLOW.
+// * wasi.ErrnoRofs - if opening requires writing, but the file system isn't an experimental.WritableFS.
 // * wasi.ErrnoIo - if other error happens during the operation of the underying file system.
 //
 // For example, this function needs to first read `path` to determine the file to open.
@@ -1197,7 +1531,12 @@ func (a *snapshotPreview1) PathLink(ctx context.Context, m api.Module, oldFd, ol
 // Note: importPathOpen shows this signature in the WebAssembly 1.0 (20191205) Text Format.
 // Note: This is similar to `openat` in POSIX.
 // Note: The returned file descriptor is not guaranteed to be the lowest-numbered file
-// Note: Rights will never be implemented per https://github.com/WebAssembly/WASI/issues/469#issuecomment-1045251844
+// Note: RIGHTS_FD_READ and RIGHTS_FD_WRITE in `fsRightsBase` choose the access mode, where OFLAGS_TRUNC and
+// FDFLAGS_APPEND imply writing. Opening for writing or with OFLAGS_CREAT is only possible when the file system is an
+// experimental.WritableFS, such as experimental.DirFS.
+// Note: Rights are otherwise not enforced per https://github.com/WebAssembly/WASI/issues/469#issuecomment-1045251844
+// Note: Symbolic links are only checked, for `dirflags` and for escaping the directory, when the file system is an
+// experimental.DirFS. Others, such as os.DirFS, can't report symbolic links, so follow them even outside the directory.
 // See https://github.com/WebAssembly/WASI/blob/main/phases/snapshot/docs.md#path_open
 // See https://linux.die.net/man/3/openat
 func (a *snapshotPreview1) PathOpen(ctx context.Context, m api.Module, fd, dirflags, pathPtr, pathLen, oflags uint32, fsRightsBase,
@@ -1214,10 +1553,15 @@ func (a *snapshotPreview1) PathOpen(ctx context.Context, m api.Module, fd, dirfl
 		return ErrnoFault
 	}
 
-	// TODO: Consider dirflags and oflags. Also, allow non-read-only open based on config about the mount.
-	// Ex. allow os.O_RDONLY, os.O_WRONLY, or os.O_RDWR either by config flag or pattern on filename
-	// See #390
-	entry, errno := openFileEntry(dir.FS, path.Join(dir.Path, string(b)))
+	pathName, ok := resolvePath(dir.Path, string(b))
+	if !ok {
+		return ErrnoNotcapable
+	}
+	if errno = checkSymlinks(dir.FS, pathName, dirflags); errno != ErrnoSuccess {
+		return errno
+	}
+
+	entry, errno := openFileEntry(dir.FS, pathName, oflags, fsRightsBase, fdflags)
 	if errno != ErrnoSuccess {
 		return errno
 	}
@@ -1234,32 +1578,32 @@ func (a *snapshotPreview1) PathOpen(ctx context.Context, m api.Module, fd, dirfl
 
 // PathReadlink is the WASI function named functionPathReadlink
 func (a *snapshotPreview1) PathReadlink(ctx context.Context, m api.Module, fd, path, pathLen, buf, bufLen, resultBufused uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // PathRemoveDirectory is the WASI function named functionPathRemoveDirectory
 func (a *snapshotPreview1) PathRemoveDirectory(ctx context.Context, m api.Module, fd, path, pathLen uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // PathRename is the WASI function named functionPathRename
 func (a *snapshotPreview1) PathRename(ctx context.Context, m api.Module, fd, oldPath, oldPathLen, newFd, newPath, newPathLen uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // PathSymlink is the WASI function named functionPathSymlink
 func (a *snapshotPreview1) PathSymlink(ctx context.Context, m api.Module, oldPath, oldPathLen, fd, newPath, newPathLen uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // PathUnlinkFile is the WASI function named functionPathUnlinkFile
 func (a *snapshotPreview1) PathUnlinkFile(ctx context.Context, m api.Module, fd, path, pathLen uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // PollOneoff is the WASI function named functionPollOneoff
 func (a *snapshotPreview1) PollOneoff(ctx context.Context, m api.Module, in, out, nsubscriptions, resultNevents uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // ProcExit is the WASI function that terminates the execution of the module with an exit code.
@@ -1277,12 +1621,12 @@ func (a *snapshotPreview1) ProcExit(ctx context.Context, m api.Module, exitCode
 
 // ProcRaise is the WASI function named functionProcRaise
 func (a *snapshotPreview1) ProcRaise(ctx context.Context, m api.Module, sig uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // SchedYield is the WASI function named functionSchedYield
 func (a *snapshotPreview1) SchedYield(m api.Module) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // RandomGet is the WASI function named functionRandomGet that write random data in buffer (rand.Read(ctx, )).
@@ -1300,9 +1644,14 @@ func (a *snapshotPreview1) SchedYield(m api.Module) Errno {
 //
 // Note: importRandomGet shows this signature in the WebAssembly 1.0 (20191205) Text Format.
 // See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-random_getbuf-pointeru8-bufLen-size---errno
-func (a *snapshotPreview1) RandomGet(ctx context.Context, m api.Module, buf uint32, bufLen uint32) (errno Errno) {
+func (a *snapshotPreview1) RandomGet(ctx context.Context, m api.Module, buf uint32, bufLen uint32) Errno {
 	randomBytes := make([]byte, bufLen)
-	err := a.sys.RandSource(randomBytes)
+	var err error
+	if _, ok := a.sys.(*defaultSys); ok { // experimental.SysKey wasn't set, so use the source configured for the module.
+		_, err = io.ReadFull(sysCtx(m).RandSource(), randomBytes)
+	} else {
+		err = a.sys.RandSource(randomBytes)
+	}
 	if err != nil {
 		// TODO: handle different errors that syscal to entropy source can return
 		return ErrnoIo
@@ -1317,17 +1666,17 @@ func (a *snapshotPreview1) RandomGet(ctx context.Context, m api.Module, buf uint
 
 // SockRecv is the WASI function named functionSockRecv
 func (a *snapshotPreview1) SockRecv(ctx context.Context, m api.Module, fd, riData, riDataCount, riFlags, resultRoDataLen, resultRoFlags uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // SockSend is the WASI function named functionSockSend
 func (a *snapshotPreview1) SockSend(ctx context.Context, m api.Module, fd, siData, siDataCount, siFlags, resultSoDataLen uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 // SockShutdown is the WASI function named functionSockShutdown
 func (a *snapshotPreview1) SockShutdown(ctx context.Context, m api.Module, fd, how uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	return a.unimplementedErrno // stubbed for GrainLang per #271
 }
 
 const (
@@ -1336,6 +1685,30 @@ const (
 	fdStderr = 2
 )
 
+// lookupflagsSymlinkFollow is the dirflags of PathOpen to follow a symbolic link at the end of the path.
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-lookupflags-flagsu32
+const lookupflagsSymlinkFollow = 1
+
+// These are the oflags of PathOpen.
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-oflags-flagsu16
+const (
+	oflagsCreat = 1 << iota
+	oflagsDirectory
+	oflagsExcl
+	oflagsTrunc
+)
+
+// These are the rights of PathOpen which choose the access mode of the opened file.
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-rights-flagsu64
+const (
+	rightFdRead  = 1 << 1
+	rightFdWrite = 1 << 6
+)
+
+// fdflagsAppend is the fdflags of PathOpen to append data to the end of the file on each write.
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-fdflags-flagsu16
+const fdflagsAppend = 1
+
 // compile-time check to ensure defaultSys implements experimental.Sys.
 var _ experimental.Sys = &defaultSys{}
 
@@ -1351,12 +1724,16 @@ func (d *defaultSys) RandSource(bytes []byte) error {
 }
 
 func newSnapshotPreview1(ctx context.Context) *snapshotPreview1 {
+	a := &snapshotPreview1{sys: &defaultSys{}, unimplementedErrno: ErrnoNosys, advisoryErrno: ErrnoNosys}
 	if ctx != nil { // Test to see if internal code are using an experimental feature.
 		if sys := ctx.Value(experimental.SysKey{}); sys != nil {
-			return &snapshotPreview1{sys: sys.(experimental.Sys)}
+			a.sys = sys.(experimental.Sys)
+		}
+		if logger := ctx.Value(experimental.WASILoggerKey{}); logger != nil {
+			a.logger = logger.(experimental.WASILogger)
 		}
 	}
-	return &snapshotPreview1{sys: &defaultSys{}}
+	return a
 }
 
 func sysCtx(m api.Module) *wasm.SysContext {
@@ -1367,8 +1744,32 @@ func sysCtx(m api.Module) *wasm.SysContext {
 	}
 }
 
-func openFileEntry(rootFS fs.FS, pathName string) (*wasm.FileEntry, Errno) {
-	f, err := rootFS.Open(pathName)
+func openFileEntry(rootFS fs.FS, pathName string, oflags uint32, fsRightsBase uint64, fdflags uint32) (*wasm.FileEntry, Errno) {
+	flag := accessMode(oflags, fsRightsBase, fdflags)
+	if oflags&oflagsCreat != 0 {
+		flag |= os.O_CREATE
+	}
+	if oflags&oflagsExcl != 0 {
+		flag |= os.O_EXCL
+	}
+	if oflags&oflagsTrunc != 0 {
+		flag |= os.O_TRUNC
+	}
+	if fdflags&fdflagsAppend != 0 {
+		flag |= os.O_APPEND
+	}
+
+	var f fs.File
+	var err error
+	if flag == os.O_RDONLY {
+		f, err = rootFS.Open(pathName)
+	} else if writableFS, ok := rootFS.(experimental.WritableFS); !ok {
+		return nil, ErrnoRofs // fs.FS is read-only
+	} else if !fs.ValidPath(pathName) {
+		return nil, ErrnoInval // not a valid fs.FS path, such as one escaping the directory
+	} else {
+		f, err = writableFS.OpenFile(pathName, flag, 0o666)
+	}
 	if err != nil {
 		switch {
 		case errors.Is(err, fs.ErrNotExist):
@@ -1386,6 +1787,88 @@ func openFileEntry(rootFS fs.FS, pathName string) (*wasm.FileEntry, Errno) {
 	return &wasm.FileEntry{Path: pathName, FS: rootFS, File: f}, ErrnoSuccess
 }
 
+// accessMode returns os.O_RDONLY, os.O_WRONLY or os.O_RDWR per the RIGHTS_FD_READ and RIGHTS_FD_WRITE in fsRightsBase.
+// OFLAGS_TRUNC and FDFLAGS_APPEND imply writing, and no rights at all imply reading.
+func accessMode(oflags uint32, fsRightsBase uint64, fdflags uint32) int {
+	write := fsRightsBase&rightFdWrite != 0 || oflags&oflagsTrunc != 0 || fdflags&fdflagsAppend != 0
+	read := fsRightsBase&rightFdRead != 0 || !write
+	switch {
+	case read && write:
+		return os.O_RDWR
+	case write:
+		return os.O_WRONLY
+	default:
+		return os.O_RDONLY
+	}
+}
+
+// resolvePath returns the path in the fs.FS of the directory at dirPath, or false if guestPath is absolute or escapes
+// it. This is a sandbox boundary: the guest must not open anything above its pre-opened directories.
+func resolvePath(dirPath, guestPath string) (string, bool) {
+	if strings.HasPrefix(guestPath, "/") {
+		return "", false
+	}
+	if dirPath == "/" { // the pre-opened root is the same as "." in fs.FS
+		dirPath = "."
+	}
+	pathName := path.Join(dirPath, guestPath)
+	if pathName == ".." || strings.HasPrefix(pathName, "../") {
+		return "", false
+	}
+	return pathName, true
+}
+
+// checkSymlinks applies dirflags to experimental.DirFS, as it is the only fs.FS known to be a host directory until
+// #390. When LOOKUPFLAGS_SYMLINK_FOLLOW is unset, a symbolic link fails with ErrnoLoop, like O_NOFOLLOW in POSIX.
+// Otherwise, ErrnoNotcapable is returned if any symbolic link in the path resolves outside the directory.
+func checkSymlinks(rootFS fs.FS, pathName string, dirflags uint32) Errno {
+	dirFS, ok := rootFS.(experimental.DirFS)
+	if !ok {
+		return ErrnoSuccess
+	}
+	dir := string(dirFS)
+	hostPath := filepath.Join(dir, filepath.FromSlash(pathName))
+	stat, err := os.Lstat(hostPath)
+	isSymlink := err == nil && stat.Mode()&fs.ModeSymlink != 0
+	if isSymlink && dirflags&lookupflagsSymlinkFollow == 0 {
+		return ErrnoLoop
+	}
+
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return ErrnoIo
+	}
+	// Check the parent, as the file may not exist yet, ex. when creating it.
+	if parent, err := filepath.EvalSymlinks(filepath.Dir(hostPath)); err != nil {
+		return ErrnoSuccess // let open report the missing directory
+	} else if !isWithin(realDir, parent) {
+		return ErrnoNotcapable
+	}
+	if isSymlink {
+		// A dangling link is rejected, too, as creating it would otherwise write outside the directory.
+		if target, err := filepath.EvalSymlinks(hostPath); err != nil || !isWithin(realDir, target) {
+			return ErrnoNotcapable
+		}
+	}
+	return ErrnoSuccess
+}
+
+// isWithin returns true if the host path is the directory or inside it.
+func isWithin(dir, hostPath string) bool {
+	rel, err := filepath.Rel(dir, hostPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// writeOffsetsAndNullTerminatedValues encodes values as used by "args_get" and "environ_get": a table of uint32
+// little-endian pointers at offsets, each to the corresponding NUL-terminated value written consecutively at bytes.
+//
+// Ex. Given values "a" and "bc", offsets 0 and bytes 8, this writes:
+//	[]byte{
+//		8, 0, 0, 0, // offsets[0]
+//		10, 0, 0, 0, // offsets[1]
+//		'a', 0, // bytes[0:2]
+//		'b', 'c', 0, // bytes[2:5]
+//	}
 func writeOffsetsAndNullTerminatedValues(ctx context.Context, mem api.Memory, values []string, offsets, bytes uint32) Errno {
 	for _, value := range values {
 		// Write current offset and advance it.
@@ -12,6 +12,8 @@ import (
 	"math/rand"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
 	"testing"
 	"testing/fstest"
 
@@ -47,6 +49,24 @@ func (d fakeSys) RandSource(p []byte) error {
 // testCtx ensures fakeSys is used for WASI functions.
 var testCtx = context.WithValue(context.Background(), experimental.SysKey{}, fakeSys{})
 
+func TestErrnoName(t *testing.T) {
+	// Every errno from ErrnoSuccess to ErrnoNotcapable has a name that round-trips.
+	for errno := ErrnoSuccess; errno <= ErrnoNotcapable; errno++ {
+		name := ErrnoName(errno)
+		require.True(t, strings.HasPrefix(name, "E"), name)
+
+		actual, ok := ErrnoFromName(name)
+		require.True(t, ok, name)
+		require.Equal(t, errno, actual, name)
+	}
+
+	require.Equal(t, "errno(77)", ErrnoName(ErrnoNotcapable+1))
+	for _, name := range []string{"errno(77)", "e2big", ""} {
+		_, ok := ErrnoFromName(name)
+		require.False(t, ok, name)
+	}
+}
+
 func TestSnapshotPreview1_ArgsGet(t *testing.T) {
 	sysCtx, err := newSysContext([]string{"a", "bc"}, nil, nil)
 	require.NoError(t, err)
@@ -325,6 +345,51 @@ func TestSnapshotPreview1_EnvironGet_Errors(t *testing.T) {
 	}
 }
 
+func TestWriteOffsetsAndNullTerminatedValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		expected []byte
+	}{
+		{
+			name:     "zero values",
+			expected: []byte{'?', '?', '?', '?', '?', '?', '?', '?', '?', '?', '?', '?', '?', '?', '?', '?'},
+		},
+		{
+			name:   "one empty value",
+			values: []string{""},
+			expected: []byte{
+				4, 0, 0, 0, // offsets[0]
+				0, // values[0]
+				'?', '?', '?', '?', '?', '?', '?', '?', '?', '?', '?',
+			},
+		},
+		{
+			name:   "multi-byte values",
+			values: []string{"a", "ωz"},
+			expected: []byte{
+				8, 0, 0, 0, // offsets[0]
+				10, 0, 0, 0, // offsets[1]
+				'a', 0, // values[0]
+				0xcf, 0x89, 'z', 0, // values[1], where "ω" is two bytes in UTF-8
+				'?', '?',
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			mem := &wasm.MemoryInstance{Buffer: bytes.Repeat([]byte{'?'}, 16), Min: 1}
+			// The values are written after the offsets, like a guest would allocate them.
+			offsets, valueBytes := uint32(0), uint32(4*len(tc.values))
+			errno := writeOffsetsAndNullTerminatedValues(testCtx, mem, tc.values, offsets, valueBytes)
+			require.Equal(t, ErrnoSuccess, errno)
+			require.Equal(t, tc.expected, mem.Buffer)
+		})
+	}
+}
+
 func TestSnapshotPreview1_EnvironSizesGet(t *testing.T) {
 	sysCtx, err := newSysContext(nil, []string{"a=b", "b=cd"}, nil)
 	require.NoError(t, err)
@@ -547,9 +612,9 @@ func TestSnapshotPreview1_FdClose(t *testing.T) {
 		// fd_close needs to close an open file descriptor. Open two files so that we can tell which is closed.
 		path1, path2 := "a", "b"
 		testFs := fstest.MapFS{path1: {Data: make([]byte, 0)}, path2: {Data: make([]byte, 0)}}
-		entry1, errno := openFileEntry(testFs, path1)
+		entry1, errno := openFileEntry(testFs, path1, 0, 0, 0)
 		require.Zero(t, errno, ErrnoName(errno))
-		entry2, errno := openFileEntry(testFs, path2)
+		entry2, errno := openFileEntry(testFs, path2, 0, 0, 0)
 		require.Zero(t, errno, ErrnoName(errno))
 
 		sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
@@ -923,6 +988,50 @@ func TestSnapshotPreview1_FdPrestatDirName_Errors(t *testing.T) {
 	}
 }
 
+// TestSnapshotPreview1_FdPrestatDirName_byteLength ensures "fd_prestat_get" advertises the length of the directory
+// name in bytes, as opposed to runes, and "fd_prestat_dir_name" writes exactly that many bytes.
+func TestSnapshotPreview1_FdPrestatDirName_byteLength(t *testing.T) {
+	fd := uint32(3) // arbitrary fd after 0, 1, and 2, that are stdin/out/err
+
+	for _, tt := range []struct {
+		name, path string
+	}{
+		{name: "root", path: "/"},
+		{name: "multi-byte", path: "/ディレクトリ"}, // 6 runes of 3 bytes each
+	} {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{fd: {Path: tc.path}})
+			require.NoError(t, err)
+
+			a, mod, _ := instantiateModule(testCtx, t, functionFdPrestatDirName, importFdPrestatDirName, sysCtx)
+			defer mod.Close(testCtx)
+
+			resultPrestat := uint32(0) // arbitrary offset
+			errno := a.FdPrestatGet(testCtx, mod, fd, resultPrestat)
+			require.Zero(t, errno, ErrnoName(errno))
+			pathLen, ok := mod.Memory().ReadUint32Le(testCtx, resultPrestat+4)
+			require.True(t, ok)
+			require.Equal(t, uint32(len(tc.path)), pathLen)
+
+			path := uint32(1) // arbitrary offset
+			expectedMemory := append(append([]byte{'?'}, tc.path...), '?')
+			maskMemory(t, testCtx, mod, len(expectedMemory))
+
+			errno = a.FdPrestatDirName(testCtx, mod, fd, path, pathLen)
+			require.Zero(t, errno, ErrnoName(errno))
+
+			actual, ok := mod.Memory().Read(testCtx, 0, uint32(len(expectedMemory)))
+			require.True(t, ok)
+			require.Equal(t, expectedMemory, actual)
+
+			errno = a.FdPrestatDirName(testCtx, mod, fd, path, pathLen+1)
+			require.Equal(t, ErrnoNametoolong, errno, ErrnoName(errno))
+		})
+	}
+}
+
 // TestSnapshotPreview1_FdPwrite only tests it is stubbed for GrainLang per #271
 func TestSnapshotPreview1_FdPwrite(t *testing.T) {
 	a, mod, fn := instantiateModule(testCtx, t, functionFdPwrite, importFdPwrite, nil)
@@ -1010,12 +1119,213 @@ func TestSnapshotPreview1_FdRead(t *testing.T) {
 	}
 }
 
+func TestSnapshotPreview1_FdRead_Stdin(t *testing.T) {
+	sysCtx, err := wasm.NewSysContext(math.MaxUint32, nil, nil, strings.NewReader("wazero"), nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	a, mod, fn := instantiateModule(testCtx, t, functionFdRead, importFdRead, sysCtx)
+	defer mod.Close(testCtx)
+
+	iovs, iovsCount, resultSize := uint32(0), uint32(2), uint32(16)
+	require.True(t, mod.Memory().Write(testCtx, iovs, []byte{
+		24, 0, 0, 0, // = iovs[0].offset
+		4, 0, 0, 0, // = iovs[0].length
+		28, 0, 0, 0, // = iovs[1].offset
+		4, 0, 0, 0, // = iovs[1].length
+	}))
+
+	// The first read is short, as stdin has only 6 of the 8 bytes requested.
+	errno := a.FdRead(testCtx, mod, fdStdin, iovs, iovsCount, resultSize)
+	require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+	nread, ok := mod.Memory().ReadUint32Le(testCtx, resultSize)
+	require.True(t, ok)
+	require.Equal(t, uint32(6), nread)
+	actual, ok := mod.Memory().Read(testCtx, 24, nread)
+	require.True(t, ok)
+	require.Equal(t, []byte("wazero"), actual)
+
+	// The second read is at EOF, which is success with zero bytes read.
+	results, err := fn.Call(testCtx, uint64(fdStdin), uint64(iovs), uint64(iovsCount), uint64(resultSize))
+	require.NoError(t, err)
+	require.Equal(t, ErrnoSuccess, Errno(results[0]), ErrnoName(Errno(results[0])))
+	nread, ok = mod.Memory().ReadUint32Le(testCtx, resultSize)
+	require.True(t, ok)
+	require.Zero(t, nread)
+}
+
+// fdLog is a single entry recorded by recordingWASILogger.
+type fdLog struct {
+	functionName string
+	fd           uint32
+	errno        Errno
+}
+
+// recordingWASILogger implements experimental.WASILogger
+type recordingWASILogger struct {
+	logs []fdLog
+}
+
+// LogFd implements experimental.WASILogger.LogFd
+func (l *recordingWASILogger) LogFd(_ context.Context, functionName string, fd uint32, errno uint32) {
+	l.logs = append(l.logs, fdLog{functionName, fd, errno})
+}
+
+func TestSnapshotPreview1_WASILogger(t *testing.T) {
+	fd := uint32(3) // arbitrary fd after 0, 1, and 2, that are stdin/out/err
+	file, testFS := createFile(t, "test_path", []byte("wazero"))
+	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
+		fd: {Path: "test_path", FS: testFS, File: file},
+	})
+	require.NoError(t, err)
+
+	logger := &recordingWASILogger{}
+	ctx := context.WithValue(testCtx, experimental.WASILoggerKey{}, logger)
+	_, mod, fn := instantiateModule(ctx, t, functionFdRead, importFdRead, sysCtx)
+	defer mod.Close(testCtx)
+
+	iovs, iovsCount, resultSize := uint32(0), uint32(1), uint32(16)
+	require.True(t, mod.Memory().Write(testCtx, iovs, []byte{
+		24, 0, 0, 0, // = iovs[0].offset
+		6, 0, 0, 0, // = iovs[0].length
+	}))
+
+	results, err := fn.Call(testCtx, uint64(fd), uint64(iovs), uint64(iovsCount), uint64(resultSize))
+	require.NoError(t, err)
+	require.Equal(t, ErrnoSuccess, Errno(results[0]), ErrnoName(Errno(results[0])))
+
+	// An invalid fd is logged with its errno.
+	results, err = fn.Call(testCtx, uint64(42), uint64(iovs), uint64(iovsCount), uint64(resultSize))
+	require.NoError(t, err)
+	require.Equal(t, ErrnoBadf, Errno(results[0]), ErrnoName(Errno(results[0])))
+
+	require.Equal(t, []fdLog{
+		{functionName: functionFdRead, fd: fd, errno: ErrnoSuccess},
+		{functionName: functionFdRead, fd: 42, errno: ErrnoBadf},
+	}, logger.logs)
+}
+
+func TestSnapshotPreview1_WASILogger_PathLinkSymlink(t *testing.T) {
+	logger := &recordingWASILogger{}
+	ctx := context.WithValue(testCtx, experimental.WASILoggerKey{}, logger)
+
+	_, mod, fn := instantiateModule(ctx, t, functionPathLink, importPathLink, nil)
+	defer mod.Close(testCtx)
+	oldFd, newFd := uint32(3), uint32(4)
+	_, err := fn.Call(testCtx, uint64(oldFd), 0, 0, 0, uint64(newFd), 0, 0)
+	require.NoError(t, err)
+
+	_, mod, fn = instantiateModule(ctx, t, functionPathSymlink, importPathSymlink, nil)
+	defer mod.Close(testCtx)
+	fd := uint32(5)
+	_, err = fn.Call(testCtx, 0, 0, uint64(fd), 0, 0)
+	require.NoError(t, err)
+
+	require.Equal(t, []fdLog{
+		{functionName: functionPathLink, fd: oldFd, errno: ErrnoNosys},
+		{functionName: functionPathSymlink, fd: fd, errno: ErrnoNosys},
+	}, logger.logs)
+}
+
+func TestWriteIOVecs(t *testing.T) {
+	file, testFS := createFile(t, "test_path", []byte("wazero"))
+	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
+		3: {Path: "test_path", FS: testFS, File: file},
+	})
+	require.NoError(t, err)
+
+	a, mod, _ := instantiateModule(testCtx, t, functionFdRead, importFdRead, sysCtx)
+	defer mod.Close(testCtx)
+
+	iovs, resultSize := uint32(1), uint32(32)
+	total, ok := WriteIOVecs(testCtx, mod.Memory(), iovs, []IOVec{{Offset: 40, Length: 4}, {Offset: 48, Length: 2}})
+	require.True(t, ok)
+	require.Equal(t, uint32(6), total)
+
+	// The encoding is the same as what a compiler would emit.
+	encoded, ok := mod.Memory().Read(testCtx, iovs, 2*iovecSize)
+	require.True(t, ok)
+	require.Equal(t, []byte{
+		40, 0, 0, 0, // = iovs[0].offset
+		4, 0, 0, 0, // = iovs[0].length
+		48, 0, 0, 0, // = iovs[1].offset
+		2, 0, 0, 0, // = iovs[1].length
+	}, encoded)
+
+	errno := a.FdRead(testCtx, mod, 3, iovs, 2, resultSize)
+	require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+	nread, ok := mod.Memory().ReadUint32Le(testCtx, resultSize)
+	require.True(t, ok)
+	require.Equal(t, total, nread)
+
+	actual, ok := mod.Memory().Read(testCtx, 40, 4)
+	require.True(t, ok)
+	require.Equal(t, []byte("waze"), actual)
+	actual, ok = mod.Memory().Read(testCtx, 48, 2)
+	require.True(t, ok)
+	require.Equal(t, []byte("ro"), actual)
+}
+
+func TestWriteIOVecs_OutOfRange(t *testing.T) {
+	sysCtx, err := newSysContext(nil, nil, nil)
+	require.NoError(t, err)
+
+	_, mod, _ := instantiateModule(testCtx, t, functionFdRead, importFdRead, sysCtx)
+	defer mod.Close(testCtx)
+
+	_, ok := WriteIOVecs(testCtx, mod.Memory(), mod.Memory().Size(testCtx)-iovecSize+1, []IOVec{{Offset: 0, Length: 1}})
+	require.False(t, ok)
+}
+
+// blockingReader is an io.Reader that blocks until unblock is closed, signaling reading once a Read starts.
+type blockingReader struct {
+	reading, unblock chan struct{}
+}
+
+// Read implements io.Reader
+func (r *blockingReader) Read([]byte) (int, error) {
+	close(r.reading)
+	<-r.unblock
+	return 0, io.EOF
+}
+
+func TestSnapshotPreview1_FdRead_UnblockedByClose(t *testing.T) {
+	stdin := &blockingReader{reading: make(chan struct{}), unblock: make(chan struct{})}
+	defer close(stdin.unblock) // release the goroutine reading stdin
+
+	sysCtx, err := wasm.NewSysContext(math.MaxUint32, nil, nil, stdin, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	_, mod, fn := instantiateModule(testCtx, t, functionFdRead, importFdRead, sysCtx)
+	require.True(t, mod.Memory().Write(testCtx, 0, []byte{
+		8, 0, 0, 0, // = iovs[0].offset
+		4, 0, 0, 0, // = iovs[0].length
+	}))
+
+	callErr := make(chan error, 1)
+	go func() {
+		_, err := fn.Call(testCtx, uint64(fdStdin), 0, 1, 16)
+		callErr <- err
+	}()
+
+	<-stdin.reading
+	require.NoError(t, mod.CloseWithExitCode(testCtx, 2))
+
+	// The call should return even though stdin never did.
+	require.Equal(t, sys.NewExitError(mod.Name(), 2), <-callErr)
+}
+
 func TestSnapshotPreview1_FdRead_Errors(t *testing.T) {
 	validFD := uint32(3)                                 // arbitrary valid fd after 0, 1, and 2, that are stdin/out/err
 	file, testFS := createFile(t, "test_path", []byte{}) // file with empty contents
 
+	preopenFD, dirFD := uint32(4), uint32(5)
+	dir, err := testFS.Open(".")
+	require.NoError(t, err)
+
 	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
-		validFD: {Path: "test_path", FS: testFS, File: file},
+		validFD:   {Path: "test_path", FS: testFS, File: file},
+		preopenFD: {Path: "/", FS: testFS},
+		dirFD:     {Path: ".", FS: testFS, File: dir},
 	})
 	require.NoError(t, err)
 
@@ -1033,6 +1343,16 @@ func TestSnapshotPreview1_FdRead_Errors(t *testing.T) {
 			fd:            42, // arbitrary invalid fd
 			expectedErrno: ErrnoBadf,
 		},
+		{
+			name:          "pre-opened directory",
+			fd:            preopenFD,
+			expectedErrno: ErrnoIsdir,
+		},
+		{
+			name:          "opened directory",
+			fd:            dirFD,
+			expectedErrno: ErrnoIsdir,
+		},
 		{
 			name:          "out-of-memory reading iovs[0].offset",
 			fd:            validFD,
@@ -1249,8 +1569,11 @@ func TestSnapshotPreview1_FdSeek_Errors(t *testing.T) {
 	validFD := uint32(3)                                         // arbitrary valid fd after 0, 1, and 2, that are stdin/out/err
 	file, testFS := createFile(t, "test_path", []byte("wazero")) // arbitrary valid file with non-empty contents
 
-	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
-		validFD: {Path: "test_path", FS: testFS, File: file},
+	nonSeekableFD, preopenFD := uint32(4), uint32(5)
+	sysCtx, err := wasm.NewSysContext(math.MaxUint32, nil, nil, nil, new(bytes.Buffer), nil, nil, map[uint32]*wasm.FileEntry{
+		validFD:       {Path: "test_path", FS: testFS, File: file},
+		nonSeekableFD: {Path: "non_seekable", File: nonSeekableFile{}},
+		preopenFD:     {Path: "/", FS: testFS},
 	})
 	require.NoError(t, err)
 
@@ -1271,6 +1594,21 @@ func TestSnapshotPreview1_FdSeek_Errors(t *testing.T) {
 			fd:            42, // arbitrary invalid fd
 			expectedErrno: ErrnoBadf,
 		},
+		{
+			name:          "pre-opened directory",
+			fd:            preopenFD,
+			expectedErrno: ErrnoIsdir,
+		},
+		{
+			name:          "stdout",
+			fd:            fdStdout, // backed by a bytes.Buffer
+			expectedErrno: ErrnoSpipe,
+		},
+		{
+			name:          "file isn't an io.Seeker",
+			fd:            nonSeekableFD,
+			expectedErrno: ErrnoSpipe,
+		},
 		{
 			name:          "invalid whence",
 			fd:            validFD,
@@ -1295,6 +1633,30 @@ func TestSnapshotPreview1_FdSeek_Errors(t *testing.T) {
 
 }
 
+func TestSnapshotPreview1_FdSync_Stdout(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	buffered, _ := wasm.NewOutputBuffers(stdout, nil, 4096)
+	sysCtx, err := wasm.NewSysContext(math.MaxUint32, nil, nil, nil, buffered, nil, nil, nil)
+	require.NoError(t, err)
+
+	a, mod, fn := instantiateModule(testCtx, t, functionFdSync, importFdSync, sysCtx)
+	defer mod.Close(testCtx)
+
+	_, err = buffered.Write([]byte("wazero"))
+	require.NoError(t, err)
+	require.Zero(t, stdout.Len())
+
+	errno := a.FdSync(testCtx, mod, fdStdout)
+	require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+	require.Equal(t, "wazero", stdout.String())
+
+	// stderr defaults to io.Discard, which doesn't buffer.
+	results, err := fn.Call(testCtx, uint64(fdStderr))
+	require.NoError(t, err)
+	errno = Errno(results[0]) // results[0] is the errno
+	require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+}
+
 // TestSnapshotPreview1_FdSync only tests it is stubbed for GrainLang per #271
 func TestSnapshotPreview1_FdSync(t *testing.T) {
 	a, mod, fn := instantiateModule(testCtx, t, functionFdSync, importFdSync, nil)
@@ -1313,24 +1675,119 @@ func TestSnapshotPreview1_FdSync(t *testing.T) {
 	})
 }
 
-// TestSnapshotPreview1_FdTell only tests it is stubbed for GrainLang per #271
 func TestSnapshotPreview1_FdTell(t *testing.T) {
-	a, mod, fn := instantiateModule(testCtx, t, functionFdTell, importFdTell, nil)
+	fd := uint32(3)                                              // arbitrary fd after 0, 1, and 2, that are stdin/out/err
+	resultOffset := uint32(1)                                    // arbitrary offset in `ctx.Memory` for the offset value
+	file, testFS := createFile(t, "test_path", []byte("wazero")) // arbitrary non-empty contents
+
+	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
+		fd: {Path: "test_path", FS: testFS, File: file},
+	})
+	require.NoError(t, err)
+
+	a, mod, fn := instantiateModule(testCtx, t, functionFdTell, importFdTell, sysCtx)
 	defer mod.Close(testCtx)
 
+	// set the offset of the file to 4
+	_, err = file.(io.Seeker).Seek(4, io.SeekStart)
+	require.NoError(t, err)
+
+	expectedMemory := []byte{
+		'?',                    // resultOffset is after this
+		4, 0, 0, 0, 0, 0, 0, 0, // = current offset
+		'?',
+	}
+
 	t.Run("snapshotPreview1.FdTell", func(t *testing.T) {
-		errno := a.FdTell(testCtx, mod, 0, 0)
-		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
+		maskMemory(t, testCtx, mod, len(expectedMemory))
+
+		errno := a.FdTell(testCtx, mod, fd, resultOffset)
+		require.Zero(t, errno, ErrnoName(errno))
+
+		actual, ok := mod.Memory().Read(testCtx, 0, uint32(len(expectedMemory)))
+		require.True(t, ok)
+		require.Equal(t, expectedMemory, actual)
 	})
 
 	t.Run(functionFdTell, func(t *testing.T) {
-		results, err := fn.Call(testCtx, 0, 0)
+		maskMemory(t, testCtx, mod, len(expectedMemory))
+
+		results, err := fn.Call(testCtx, uint64(fd), uint64(resultOffset))
 		require.NoError(t, err)
 		errno := Errno(results[0]) // results[0] is the errno
-		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
+		require.Zero(t, errno, ErrnoName(errno))
+
+		actual, ok := mod.Memory().Read(testCtx, 0, uint32(len(expectedMemory)))
+		require.True(t, ok)
+		require.Equal(t, expectedMemory, actual)
+	})
+}
+
+func TestSnapshotPreview1_FdTell_Errors(t *testing.T) {
+	validFD := uint32(3)                                         // arbitrary valid fd after 0, 1, and 2, that are stdin/out/err
+	file, testFS := createFile(t, "test_path", []byte("wazero")) // arbitrary valid file with non-empty contents
+
+	nonSeekableFD := uint32(4)
+	sysCtx, err := wasm.NewSysContext(math.MaxUint32, nil, nil, nil, new(bytes.Buffer), nil, nil, map[uint32]*wasm.FileEntry{
+		validFD:       {Path: "test_path", FS: testFS, File: file},
+		nonSeekableFD: {Path: "non_seekable", File: nonSeekableFile{}},
 	})
+	require.NoError(t, err)
+
+	a, mod, _ := instantiateModule(testCtx, t, functionFdTell, importFdTell, sysCtx)
+	defer mod.Close(testCtx)
+
+	memorySize := mod.Memory().Size(testCtx)
+
+	tests := []struct {
+		name             string
+		fd, resultOffset uint32
+		expectedErrno    Errno
+	}{
+		{
+			name:          "invalid fd",
+			fd:            42, // arbitrary invalid fd
+			expectedErrno: ErrnoBadf,
+		},
+		{
+			name:          "stdout",
+			fd:            fdStdout, // backed by a bytes.Buffer
+			expectedErrno: ErrnoSpipe,
+		},
+		{
+			name:          "file isn't an io.Seeker",
+			fd:            nonSeekableFD,
+			expectedErrno: ErrnoSpipe,
+		},
+		{
+			name:          "out-of-memory writing resultOffset",
+			fd:            validFD,
+			resultOffset:  memorySize,
+			expectedErrno: ErrnoFault,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			errno := a.FdTell(testCtx, mod, tc.fd, tc.resultOffset)
+			require.Equal(t, tc.expectedErrno, errno, ErrnoName(errno))
+		})
+	}
 }
 
+// nonSeekableFile is a fs.File that doesn't implement io.Seeker.
+type nonSeekableFile struct{}
+
+// Stat implements fs.File
+func (nonSeekableFile) Stat() (fs.FileInfo, error) { return nil, errors.New("unsupported") }
+
+// Read implements fs.File
+func (nonSeekableFile) Read([]byte) (int, error) { return 0, io.EOF }
+
+// Close implements fs.File
+func (nonSeekableFile) Close() error { return nil }
+
 func TestSnapshotPreview1_FdWrite(t *testing.T) {
 	fd := uint32(3)   // arbitrary fd after 0, 1, and 2, that are stdin/out/err
 	iovs := uint32(1) // arbitrary offset
@@ -1415,8 +1872,10 @@ func TestSnapshotPreview1_FdWrite_Errors(t *testing.T) {
 	pathName := "test_path"
 	file, testFS := createWriteableFile(t, tmpDir, pathName, []byte{})
 
+	preopenFD := uint32(4)
 	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
-		validFD: {Path: pathName, FS: testFS, File: file},
+		validFD:   {Path: pathName, FS: testFS, File: file},
+		preopenFD: {Path: "/", FS: testFS},
 	})
 	require.NoError(t, err)
 
@@ -1442,6 +1901,12 @@ func TestSnapshotPreview1_FdWrite_Errors(t *testing.T) {
 			fd:            42, // arbitrary invalid fd
 			expectedErrno: ErrnoBadf,
 		},
+		{
+			name:          "pre-opened directory",
+			fd:            preopenFD,
+			memory:        memory,
+			expectedErrno: ErrnoIsdir,
+		},
 		{
 			name:          "out-of-memory reading iovs[0].offset",
 			fd:            validFD,
@@ -1486,6 +1951,51 @@ func TestSnapshotPreview1_FdWrite_Errors(t *testing.T) {
 	}
 }
 
+// limitedWriter is an io.Writer that accepts up to limit bytes, then errs.
+type limitedWriter struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+// Write implements io.Writer
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if remaining := w.limit - w.buf.Len(); len(p) > remaining {
+		w.buf.Write(p[:remaining])
+		return remaining, io.ErrShortWrite
+	}
+	return w.buf.Write(p)
+}
+
+func TestSnapshotPreview1_FdWrite_ShortWrite(t *testing.T) {
+	stdout := &limitedWriter{limit: 5}
+	sysCtx, err := wasm.NewSysContext(math.MaxUint32, nil, nil, nil, stdout, nil, nil, nil)
+	require.NoError(t, err)
+
+	a, mod, _ := instantiateModule(testCtx, t, functionFdWrite, importFdWrite, sysCtx)
+	defer mod.Close(testCtx)
+
+	iovs, iovsCount, resultSize := uint32(0), uint32(3), uint32(32)
+	require.True(t, mod.Memory().Write(testCtx, iovs, []byte{
+		24, 0, 0, 0, // = iovs[0].offset
+		4, 0, 0, 0, // = iovs[0].length
+		28, 0, 0, 0, // = iovs[1].offset
+		2, 0, 0, 0, // = iovs[1].length
+		24, 0, 0, 0, // = iovs[2].offset
+		4, 0, 0, 0, // = iovs[2].length
+		'w', 'a', 'z', 'e', // iovs[0].length bytes
+		'r', 'o', // iovs[1].length bytes
+	}))
+
+	errno := a.FdWrite(testCtx, mod, fdStdout, iovs, iovsCount, resultSize)
+	require.Equal(t, ErrnoIo, errno, ErrnoName(errno))
+
+	// Only the bytes accepted before the error are reported, and later iovs are not written.
+	nwritten, ok := mod.Memory().ReadUint32Le(testCtx, resultSize)
+	require.True(t, ok)
+	require.Equal(t, uint32(5), nwritten)
+	require.Equal(t, "wazer", stdout.buf.String())
+}
+
 // TestSnapshotPreview1_PathCreateDirectory only tests it is stubbed for GrainLang per #271
 func TestSnapshotPreview1_PathCreateDirectory(t *testing.T) {
 	a, mod, fn := instantiateModule(testCtx, t, functionPathCreateDirectory, importPathCreateDirectory, nil)
@@ -1671,6 +2181,14 @@ func TestSnapshotPreview1_PathOpen_Errors(t *testing.T) {
 			pathLen:       validPathLen - 1, // this make the path "wazer", which doesn't exit
 			expectedErrno: ErrnoNoent,
 		},
+		{
+			name:          "create on a read-only file system",
+			fd:            validFD,
+			path:          validPath,
+			pathLen:       validPathLen - 1,
+			oflags:        oflagsCreat, // fstest.MapFS can't create files
+			expectedErrno: ErrnoRofs,
+		},
 		{
 			name:           "out-of-memory writing resultOpenedFd",
 			fd:             validFD,
@@ -1690,6 +2208,300 @@ func TestSnapshotPreview1_PathOpen_Errors(t *testing.T) {
 	}
 }
 
+func TestSnapshotPreview1_PathOpen_Resolve(t *testing.T) {
+	rootFD, subdirFD := uint32(3), uint32(4) // arbitrary fds after 0, 1, and 2, that are stdin/out/err
+	testFS := fstest.MapFS{"a/b/wazero": &fstest.MapFile{Data: []byte("wazero")}}
+	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
+		rootFD:   {Path: "/", FS: testFS},
+		subdirFD: {Path: "a", FS: testFS}, // as if opened via path_open
+	})
+	require.NoError(t, err)
+
+	a, mod, _ := instantiateModule(testCtx, t, functionPathOpen, importPathOpen, sysCtx)
+	defer mod.Close(testCtx)
+
+	resultOpenedFd := uint32(0)
+	tests := []struct {
+		name          string
+		fd            uint32
+		path          string
+		expectedErrno Errno
+		expectedPath  string
+	}{
+		{name: "nested", fd: rootFD, path: "a/b/wazero", expectedPath: "a/b/wazero"},
+		{name: "nested dot-dot", fd: rootFD, path: "a/../a/b/./wazero", expectedPath: "a/b/wazero"},
+		{name: "relative to a subdirectory", fd: subdirFD, path: "b/wazero", expectedPath: "a/b/wazero"},
+		{name: "dot-dot to the root", fd: subdirFD, path: "../a/b/wazero", expectedPath: "a/b/wazero"},
+		{name: "escapes the root", fd: rootFD, path: "../../etc/passwd", expectedErrno: ErrnoNotcapable},
+		{name: "escapes from a subdirectory", fd: subdirFD, path: "../../etc/passwd", expectedErrno: ErrnoNotcapable},
+		{name: "dot-dot only", fd: rootFD, path: "..", expectedErrno: ErrnoNotcapable},
+		{name: "absolute", fd: rootFD, path: "/etc/passwd", expectedErrno: ErrnoNotcapable},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			pathPtr := uint32(8)
+			require.True(t, mod.Memory().Write(testCtx, pathPtr, []byte(tc.path)))
+
+			errno := a.PathOpen(testCtx, mod, tc.fd, lookupflagsSymlinkFollow, pathPtr, uint32(len(tc.path)), 0, 0, 0, 0, resultOpenedFd)
+			require.Equal(t, tc.expectedErrno, errno, ErrnoName(errno))
+			if tc.expectedErrno != ErrnoSuccess {
+				return
+			}
+
+			fd, ok := mod.Memory().ReadUint32Le(testCtx, resultOpenedFd)
+			require.True(t, ok)
+			f, ok := sysCtx.OpenedFile(fd)
+			require.True(t, ok)
+			require.Equal(t, tc.expectedPath, f.Path)
+		})
+	}
+}
+
+// TestSnapshotPreview1_PathOpen_lowestFD ensures path_open returns the lowest file descriptor not in use, as in POSIX,
+// so that one freed by fd_close is reused.
+func TestSnapshotPreview1_PathOpen_lowestFD(t *testing.T) {
+	rootFD := uint32(3) // arbitrary fd after 0, 1, and 2, that are stdin/out/err
+	testFS := fstest.MapFS{"a": &fstest.MapFile{}, "b": &fstest.MapFile{}, "c": &fstest.MapFile{}}
+	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{rootFD: {Path: "/", FS: testFS}})
+	require.NoError(t, err)
+
+	a, mod, _ := instantiateModule(testCtx, t, functionPathOpen, importPathOpen, sysCtx)
+	defer mod.Close(testCtx)
+
+	pathOpen := func(path string) uint32 {
+		pathPtr, resultOpenedFd := uint32(8), uint32(0)
+		require.True(t, mod.Memory().Write(testCtx, pathPtr, []byte(path)))
+
+		errno := a.PathOpen(testCtx, mod, rootFD, 0, pathPtr, uint32(len(path)), 0, 0, 0, 0, resultOpenedFd)
+		require.Zero(t, errno, ErrnoName(errno))
+
+		fd, ok := mod.Memory().ReadUint32Le(testCtx, resultOpenedFd)
+		require.True(t, ok)
+		return fd
+	}
+
+	require.Equal(t, uint32(4), pathOpen("a"))
+	require.Equal(t, uint32(5), pathOpen("b"))
+
+	errno := a.FdClose(testCtx, mod, 4)
+	require.Zero(t, errno, ErrnoName(errno))
+
+	require.Equal(t, uint32(4), pathOpen("c"))
+	f, ok := sysCtx.OpenedFile(4)
+	require.True(t, ok)
+	require.Equal(t, "c", f.Path)
+}
+
+func TestSnapshotPreview1_PathOpen_Symlinks(t *testing.T) {
+	workdirFD := uint32(3) // arbitrary fd after 0, 1, and 2, that are stdin/out/err
+
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0o600))
+
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "wazero"), []byte("wazero"), 0o600))
+	if err := os.Symlink("wazero", filepath.Join(tmpDir, "link")); err != nil {
+		t.Skip("symbolic links are not supported:", err)
+	}
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret"), filepath.Join(tmpDir, "escape")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(tmpDir, "escapedir")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "missing"), filepath.Join(tmpDir, "dangling")))
+
+	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
+		workdirFD: {Path: ".", FS: experimental.DirFS(tmpDir)},
+	})
+	require.NoError(t, err)
+
+	a, mod, _ := instantiateModule(testCtx, t, functionPathOpen, importPathOpen, sysCtx)
+	defer mod.Close(testCtx)
+
+	tests := []struct {
+		name             string
+		path             string
+		dirflags, oflags uint32
+		expectedErrno    Errno
+	}{
+		{name: "follow", path: "link", dirflags: lookupflagsSymlinkFollow},
+		{name: "no follow", path: "link", expectedErrno: ErrnoLoop},
+		{name: "follow outside", path: "escape", dirflags: lookupflagsSymlinkFollow, expectedErrno: ErrnoNotcapable},
+		{name: "directory outside", path: "escapedir/secret", dirflags: lookupflagsSymlinkFollow, expectedErrno: ErrnoNotcapable},
+		{name: "create through a directory outside", path: "escapedir/new", oflags: oflagsCreat, expectedErrno: ErrnoNotcapable},
+		{name: "create through a dangling link", path: "dangling", dirflags: lookupflagsSymlinkFollow, oflags: oflagsCreat, expectedErrno: ErrnoNotcapable},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			pathPtr := uint32(8)
+			require.True(t, mod.Memory().Write(testCtx, pathPtr, []byte(tc.path)))
+
+			errno := a.PathOpen(testCtx, mod, workdirFD, tc.dirflags, pathPtr, uint32(len(tc.path)), tc.oflags, 0, 0, 0, 0)
+			require.Equal(t, tc.expectedErrno, errno, ErrnoName(errno))
+		})
+	}
+
+	_, err = os.Stat(filepath.Join(outside, "missing"))
+	require.True(t, errors.Is(err, fs.ErrNotExist))
+}
+
+func TestSnapshotPreview1_PathOpen_Flags(t *testing.T) {
+	workdirFD := uint32(3) // arbitrary fd after 0, 1, and 2, that are stdin/out/err
+	pathName := "wazero"
+	path, pathLen, resultOpenedFd := uint32(0), uint32(len(pathName)), uint32(16)
+
+	// setup returns a module whose workdir is a temp directory, which optionally includes a file named pathName.
+	setup := func(t *testing.T, existing []byte) (*snapshotPreview1, api.Module, string) {
+		tmpDir := t.TempDir()
+		if existing != nil {
+			require.NoError(t, os.WriteFile(filepath.Join(tmpDir, pathName), existing, 0o600))
+		}
+		sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
+			workdirFD: {Path: ".", FS: experimental.DirFS(tmpDir)},
+		})
+		require.NoError(t, err)
+		a, mod, _ := instantiateModule(testCtx, t, functionPathOpen, importPathOpen, sysCtx)
+		require.True(t, mod.Memory().Write(testCtx, path, []byte(pathName)))
+		return a, mod, filepath.Join(tmpDir, pathName)
+	}
+
+	// write writes "hi" to the fd PathOpen wrote to resultOpenedFd.
+	write := func(t *testing.T, a *snapshotPreview1, mod api.Module) {
+		fd, ok := mod.Memory().ReadUint32Le(testCtx, resultOpenedFd)
+		require.True(t, ok)
+
+		iovs, resultSize := uint32(32), uint32(48)
+		require.True(t, mod.Memory().Write(testCtx, iovs, []byte{
+			40, 0, 0, 0, // = iovs[0].offset
+			2, 0, 0, 0, // = iovs[0].length
+		}))
+		require.True(t, mod.Memory().Write(testCtx, 40, []byte("hi")))
+		errno := a.FdWrite(testCtx, mod, fd, iovs, 1, resultSize)
+		require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+
+		ok, err := sysCtx(mod).CloseFile(fd) // flush before reading it from the host
+		require.True(t, ok)
+		require.NoError(t, err)
+	}
+
+	t.Run("creat", func(t *testing.T) {
+		a, mod, hostPath := setup(t, nil)
+		defer mod.Close(testCtx)
+
+		errno := a.PathOpen(testCtx, mod, workdirFD, 0, path, pathLen, oflagsCreat, rightFdWrite, 0, 0, resultOpenedFd)
+		require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+		write(t, a, mod)
+
+		actual, err := os.ReadFile(hostPath)
+		require.NoError(t, err)
+		require.Equal(t, "hi", string(actual))
+	})
+
+	t.Run("creat excl exists", func(t *testing.T) {
+		a, mod, _ := setup(t, []byte("wazero"))
+		defer mod.Close(testCtx)
+
+		errno := a.PathOpen(testCtx, mod, workdirFD, 0, path, pathLen, oflagsCreat|oflagsExcl, 0, 0, 0, resultOpenedFd)
+		require.Equal(t, ErrnoExist, errno, ErrnoName(errno))
+	})
+
+	t.Run("trunc", func(t *testing.T) {
+		a, mod, hostPath := setup(t, []byte("wazero"))
+		defer mod.Close(testCtx)
+
+		errno := a.PathOpen(testCtx, mod, workdirFD, 0, path, pathLen, oflagsTrunc, 0, 0, 0, resultOpenedFd)
+		require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+		write(t, a, mod)
+
+		actual, err := os.ReadFile(hostPath)
+		require.NoError(t, err)
+		require.Equal(t, "hi", string(actual))
+	})
+
+	t.Run("append", func(t *testing.T) {
+		a, mod, hostPath := setup(t, []byte("wazero"))
+		defer mod.Close(testCtx)
+
+		errno := a.PathOpen(testCtx, mod, workdirFD, 0, path, pathLen, 0, 0, 0, fdflagsAppend, resultOpenedFd)
+		require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+		write(t, a, mod)
+
+		actual, err := os.ReadFile(hostPath)
+		require.NoError(t, err)
+		require.Equal(t, "wazerohi", string(actual))
+	})
+}
+
+// writableMapFS records the flag of OpenFile, which opens the file read-only from fstest.MapFS.
+type writableMapFS struct {
+	fstest.MapFS
+	flag int
+}
+
+func (w *writableMapFS) OpenFile(name string, flag int, _ fs.FileMode) (fs.File, error) {
+	w.flag = flag
+	return w.Open(name)
+}
+
+func TestSnapshotPreview1_PathOpen_AccessMode(t *testing.T) {
+	workdirFD := uint32(3) // arbitrary fd after 0, 1, and 2, that are stdin/out/err
+	pathName := "wazero"
+	path, pathLen, resultOpenedFd := uint32(0), uint32(len(pathName)), uint32(16)
+
+	tests := []struct {
+		name           string
+		oflags, fdflag uint32
+		rights         uint64
+		expectedFlag   int
+		expectedOpen   bool // true when fs.FS Open is used instead of OpenFile
+	}{
+		{name: "no rights", expectedOpen: true},
+		{name: "read", rights: rightFdRead, expectedOpen: true},
+		{name: "write", rights: rightFdWrite, expectedFlag: os.O_WRONLY},
+		{name: "read write", rights: rightFdRead | rightFdWrite, expectedFlag: os.O_RDWR},
+		{name: "creat", oflags: oflagsCreat, expectedFlag: os.O_RDONLY | os.O_CREATE},
+		{name: "trunc", oflags: oflagsTrunc, expectedFlag: os.O_WRONLY | os.O_TRUNC},
+		{name: "append", fdflag: fdflagsAppend, expectedFlag: os.O_WRONLY | os.O_APPEND},
+		{name: "read append", rights: rightFdRead, fdflag: fdflagsAppend, expectedFlag: os.O_RDWR | os.O_APPEND},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			testFS := &writableMapFS{MapFS: fstest.MapFS{pathName: {Data: []byte("wazero")}}, flag: -1}
+			sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
+				workdirFD: {Path: ".", FS: testFS},
+			})
+			require.NoError(t, err)
+			a, mod, _ := instantiateModule(testCtx, t, functionPathOpen, importPathOpen, sysCtx)
+			defer mod.Close(testCtx)
+			require.True(t, mod.Memory().Write(testCtx, path, []byte(pathName)))
+
+			errno := a.PathOpen(testCtx, mod, workdirFD, 0, path, pathLen, tc.oflags, tc.rights, 0, tc.fdflag, resultOpenedFd)
+			require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+			if tc.expectedOpen {
+				require.Equal(t, -1, testFS.flag)
+			} else {
+				require.Equal(t, tc.expectedFlag, testFS.flag)
+			}
+		})
+	}
+
+	t.Run("writing a read-only file system", func(t *testing.T) {
+		sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
+			workdirFD: {Path: ".", FS: fstest.MapFS{pathName: {Data: []byte("wazero")}}},
+		})
+		require.NoError(t, err)
+		a, mod, _ := instantiateModule(testCtx, t, functionPathOpen, importPathOpen, sysCtx)
+		defer mod.Close(testCtx)
+		require.True(t, mod.Memory().Write(testCtx, path, []byte(pathName)))
+
+		errno := a.PathOpen(testCtx, mod, workdirFD, 0, path, pathLen, 0, rightFdWrite, 0, 0, resultOpenedFd)
+		require.Equal(t, ErrnoRofs, errno, ErrnoName(errno))
+	})
+}
+
 // TestSnapshotPreview1_PathReadlink only tests it is stubbed for GrainLang per #271
 func TestSnapshotPreview1_PathReadlink(t *testing.T) {
 	a, mod, fn := instantiateModule(testCtx, t, functionPathReadlink, importPathReadlink, nil)
@@ -1940,6 +2752,48 @@ func TestSnapshotPreview1_RandomGet_Errors(t *testing.T) {
 	}
 }
 
+// TestSnapshotPreview1_RandomGet_RandSource ensures "random_get" reads the source configured by
+// wazero.RuntimeConfig WithRandSource, unless overridden by wazero.ModuleConfig WithRandSource.
+func TestSnapshotPreview1_RandomGet_RandSource(t *testing.T) {
+	ctx := context.Background() // no experimental.SysKey
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithRandSource(func() io.Reader {
+		return rand.New(rand.NewSource(42))
+	}))
+	defer r.Close(ctx)
+
+	_, err := InstantiateSnapshotPreview1(ctx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(ctx, []byte(fmt.Sprintf(`(module
+  %[2]s
+  (memory 1 1)
+  (export "memory" (memory 0))
+  (export "%[1]s" (func $wasi.%[1]s))
+)`, functionRandomGet, importRandomGet)), wazero.NewCompileConfig())
+	require.NoError(t, err)
+
+	randomGet := func(config wazero.ModuleConfig) []byte {
+		mod, err := r.InstantiateModule(ctx, compiled, config)
+		require.NoError(t, err)
+
+		results, err := mod.ExportedFunction(functionRandomGet).Call(ctx, 0, 5)
+		require.NoError(t, err)
+		errno := Errno(results[0]) // results[0] is the errno
+		require.Zero(t, errno, ErrnoName(errno))
+
+		buf, ok := mod.Memory().Read(ctx, 0, 5)
+		require.True(t, ok)
+		return buf
+	}
+
+	seeded := []byte{0x53, 0x8c, 0x7f, 0x96, 0xb1} // random data from seed value of 42
+	require.Equal(t, seeded, randomGet(wazero.NewModuleConfig().WithName("a")))
+	require.Equal(t, seeded, randomGet(wazero.NewModuleConfig().WithName("b")))
+
+	override := []byte{1, 2, 3, 4, 5}
+	require.Equal(t, override, randomGet(wazero.NewModuleConfig().WithName("c").WithRandSource(bytes.NewReader(override))))
+}
+
 // compile-time check to ensure fakeSysErr implements experimental.Sys.
 var _ experimental.Sys = &fakeSysErr{}
 
@@ -2057,7 +2911,7 @@ func instantiateModule(ctx context.Context, t *testing.T, wasifunction, wasiimpo
 }
 
 func newSysContext(args, environ []string, openedFiles map[uint32]*wasm.FileEntry) (sysCtx *wasm.SysContext, err error) {
-	return wasm.NewSysContext(math.MaxUint32, args, environ, new(bytes.Buffer), nil, nil, openedFiles)
+	return wasm.NewSysContext(math.MaxUint32, args, environ, new(bytes.Buffer), nil, nil, nil, openedFiles)
 }
 
 func createFile(t *testing.T, pathName string, data []byte) (fs.File, fs.FS) {
@@ -2080,5 +2934,5 @@ func createWriteableFile(t *testing.T, tmpDir string, pathName string, data []by
 	// open the file for writing in a custom way until #390
 	f, err := os.OpenFile(absolutePath, os.O_RDWR, 0o600)
 	require.NoError(t, err)
-	return f, os.DirFS(tmpDir)
+	return f, experimental.DirFS(tmpDir)
 }
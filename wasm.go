@@ -5,9 +5,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"sync"
 
 	"github.com/tetratelabs/wazero/api"
 	experimentalapi "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/compilationcache"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	"github.com/tetratelabs/wazero/internal/wasm/binary"
 	"github.com/tetratelabs/wazero/internal/wasm/text"
@@ -37,6 +41,13 @@ type Runtime interface {
 	// Module returns exports from an instantiated module or nil if there aren't any.
 	Module(moduleName string) api.Module
 
+	// Stats returns a snapshot of the resources used by this Runtime, such as to monitor an embedding server.
+	//
+	// Ex. To report the memory in use by all modules:
+	//	stats := r.Stats()
+	//	log.Printf("%d modules use %d bytes of memory", stats.Modules, stats.MemoryPages*65536)
+	Stats() RuntimeStats
+
 	// CompileModule decodes the WebAssembly text or binary source or errs if invalid.
 	// Any pre-compilation done after decoding the source is dependent on RuntimeConfig or CompileConfig.
 	//
@@ -46,9 +57,21 @@ type Runtime interface {
 	//
 	// Note: When the context is nil, it defaults to context.Background.
 	// Note: The resulting module name defaults to what was binary from the custom name section.
+	// Note: Both engines compile every function here, so no compilation is deferred to the first api.Function Call.
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#name-section%E2%91%A0
 	CompileModule(ctx context.Context, source []byte, config CompileConfig) (CompiledModule, error)
 
+	// CompileModuleFromReader is like CompileModule, except it reads the source from the given reader until EOF.
+	//
+	// Ex. To compile a module directly from a file:
+	//	f, _ := os.Open("module.wasm")
+	//	defer f.Close()
+	//	compiled, _ := r.CompileModuleFromReader(ctx, f, wazero.NewCompileConfig())
+	//
+	// Note: The source is read into memory once, sized up-front when the reader exposes its length (Ex. bytes.Reader
+	// or os.File), so callers don't need to buffer it themselves. Decoding and errors are the same as CompileModule.
+	CompileModuleFromReader(ctx context.Context, source io.Reader, config CompileConfig) (CompiledModule, error)
+
 	// InstantiateModuleFromCode instantiates a module from the WebAssembly text or binary source or errs if invalid.
 	//
 	// Ex.
@@ -116,6 +139,18 @@ type Runtime interface {
 	api.Closer
 }
 
+// RuntimeStats is a snapshot of the resources used by a Runtime, returned by Runtime.Stats.
+type RuntimeStats struct {
+	// Modules is the count of instantiated modules, including those defined with ModuleBuilder.
+	Modules uint32
+	// MemoryPages is the total count of pages (65536 bytes) in the memories of Modules. A memory imported by other
+	// modules is only counted once.
+	MemoryPages uint64
+	// CompiledModules is the count of modules compiled by this Runtime, which are retained until they or the Runtime
+	// are closed.
+	CompiledModules uint32
+}
+
 func NewRuntime() Runtime {
 	return NewRuntimeWithConfig(NewRuntimeConfig())
 }
@@ -126,17 +161,33 @@ func NewRuntimeWithConfig(rConfig RuntimeConfig) Runtime {
 	if !ok {
 		panic(fmt.Errorf("unsupported wazero.RuntimeConfig implementation: %#v", rConfig))
 	}
+	store := wasm.NewStore(config.enabledFeatures, config.newEngine(config.enabledFeatures))
+	store.MemoryGrowObserver = wasm.MemoryGrowObserver(config.memoryGrowObserver)
 	return &runtime{
-		store:           wasm.NewStore(config.enabledFeatures, config.newEngine(config.enabledFeatures)),
-		enabledFeatures: config.enabledFeatures,
+		store:            store,
+		enabledFeatures:  config.enabledFeatures,
+		sourceMap:        config.sourceMap,
+		dwarfSymbols:     config.dwarfSymbols,
+		debugInfo:        config.debugInfo,
+		compilationCache: config.compilationCache,
+		newRandSource:    config.newRandSource,
 	}
 }
 
 // runtime allows decoupling of public interfaces from internal representation.
 type runtime struct {
-	store           *wasm.Store
-	enabledFeatures wasm.Features
-	compiledModules []*compiledCode
+	store            *wasm.Store
+	enabledFeatures  wasm.Features
+	sourceMap        bool
+	dwarfSymbols     bool
+	debugInfo        bool
+	compilationCache CompilationCache
+	newRandSource    func() io.Reader
+
+	// compiledModules are the modules compiled by CompileModule which are not yet closed.
+	compiledModules map[*compiledCode]struct{}
+	// compiledModulesMu guards compiledModules, as compiled modules can be closed concurrently.
+	compiledModulesMu sync.Mutex
 }
 
 // Module implements Runtime.Module
@@ -144,6 +195,15 @@ func (r *runtime) Module(moduleName string) api.Module {
 	return r.store.Module(moduleName)
 }
 
+// Stats implements Runtime.Stats
+func (r *runtime) Stats() RuntimeStats {
+	modules, memoryPages := r.store.Stats()
+	r.compiledModulesMu.Lock()
+	compiledModules := uint32(len(r.compiledModules))
+	r.compiledModulesMu.Unlock()
+	return RuntimeStats{Modules: modules, MemoryPages: memoryPages, CompiledModules: compiledModules}
+}
+
 // CompileModule implements Runtime.CompileModule
 func (r *runtime) CompileModule(ctx context.Context, source []byte, cConfig CompileConfig) (CompiledModule, error) {
 	if source == nil {
@@ -162,7 +222,13 @@ func (r *runtime) CompileModule(ctx context.Context, source []byte, cConfig Comp
 	// Peek to see if this is a binary or text format
 	var decoder wasm.DecodeModule
 	if bytes.Equal(source[0:4], binary.Magic) {
-		decoder = binary.DecodeModule
+		if r.dwarfSymbols {
+			decoder = binary.DecodeModuleWithDWARF
+		} else {
+			decoder = binary.DecodeModule
+		}
+	} else if r.sourceMap {
+		decoder = text.DecodeModuleWithSourceMap
 	} else {
 		decoder = text.DecodeModule
 	}
@@ -184,16 +250,60 @@ func (r *runtime) CompileModule(ctx context.Context, source []byte, cConfig Comp
 	}
 
 	internal.AssignModuleID(source)
+	internal.DebugInfoEnabled = r.debugInfo
 
-	if err = r.store.Engine.CompileModule(ctx, internal); err != nil {
+	if err = r.compileModule(ctx, internal); err != nil {
 		return nil, err
 	}
 
-	c := &compiledCode{module: internal, compiledEngine: r.store.Engine}
-	r.compiledModules = append(r.compiledModules, c)
+	c := &compiledCode{module: internal, compiledEngine: r.store.Engine, runtime: r}
+	r.compiledModulesMu.Lock()
+	if r.compiledModules == nil {
+		r.compiledModules = map[*compiledCode]struct{}{}
+	}
+	r.compiledModules[c] = struct{}{}
+	r.compiledModulesMu.Unlock()
 	return c, nil
 }
 
+// deleteCompiledModule stops tracking the given module, so that it is no longer counted or closed by this runtime.
+func (r *runtime) deleteCompiledModule(c *compiledCode) {
+	r.compiledModulesMu.Lock()
+	delete(r.compiledModules, c)
+	r.compiledModulesMu.Unlock()
+}
+
+// compileModule compiles the module with the engine, unless its code was found in the compilation cache.
+//
+// Note: Errors reading or writing the cache are ignored, as it is only an optimization.
+func (r *runtime) compileModule(ctx context.Context, module *wasm.Module) error {
+	codec, ok := r.store.Engine.(wasm.CompiledCodeCodec)
+	if r.compilationCache == nil || !ok {
+		return r.store.Engine.CompileModule(ctx, module)
+	}
+
+	// The version of the encoding is in the key, as the wazero version is "dev" when unknown, such as in tests.
+	extra := append([]byte{}, codec.CompiledCodeVersion()...)
+	if r.sourceMap || r.dwarfSymbols { // source offsets are only compiled when there is a source map or DWARF.
+		extra = append(extra, 1)
+	}
+	key := compilationcache.NewKey(module.ID, r.enabledFeatures, extra...)
+	if content, ok, err := r.compilationCache.Get(key); err == nil && ok && codec.DecodeCompiledCode(module, content) == nil {
+		return nil // cache hit!
+	}
+	// Otherwise, this is a miss, or the entry is unreadable or invalid and will be replaced.
+
+	if err := r.store.Engine.CompileModule(ctx, module); err != nil {
+		return err
+	}
+	content, err := codec.EncodeCompiledCode(module)
+	if err != nil {
+		return err
+	}
+	_ = r.compilationCache.Add(key, content) // the next compilation will miss the cache again.
+	return nil
+}
+
 //
 //func (c *compileConfig) replaceImports(compile *wasm.Compile) *wasm.Compile {
 //	if (c.replacedImportCompiles == nil && c.replacedImports == nil) || compile.ImportSection == nil {
@@ -245,6 +355,50 @@ func (r *runtime) CompileModule(ctx context.Context, source []byte, cConfig Comp
 //	return &ret
 //}
 
+// CompileModuleFromReader implements Runtime.CompileModuleFromReader
+func (r *runtime) CompileModuleFromReader(ctx context.Context, source io.Reader, cConfig CompileConfig) (CompiledModule, error) {
+	if source == nil {
+		return nil, errors.New("source == nil")
+	}
+
+	bin, err := readSource(source)
+	if err != nil {
+		return nil, fmt.Errorf("read source: %w", err)
+	}
+	return r.CompileModule(ctx, bin, cConfig)
+}
+
+// readSource reads all of the source, allocating once when its size is known.
+func readSource(source io.Reader) ([]byte, error) {
+	var size int64
+	switch s := source.(type) {
+	case interface{ Len() int }: // Ex. *bytes.Reader, *bytes.Buffer or *strings.Reader
+		size = int64(s.Len())
+	case interface{ Stat() (fs.FileInfo, error) }: // Ex. *os.File or fs.File
+		if info, err := s.Stat(); err == nil && info.Mode().IsRegular() {
+			size = info.Size()
+		}
+	}
+	if size == 0 {
+		return io.ReadAll(source)
+	}
+
+	buf := make([]byte, size)
+	n, err := io.ReadFull(source, buf)
+	if err == io.ErrUnexpectedEOF { // the source shrank since its size was read.
+		return buf[:n], nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Read anything past the size, in case the source grew since its size was read.
+	rest, err := io.ReadAll(source)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, rest...), nil
+}
+
 // InstantiateModuleFromCode implements Runtime.InstantiateModuleFromCode
 func (r *runtime) InstantiateModuleFromCode(ctx context.Context, source []byte) (api.Module, error) {
 	if compiled, err := r.CompileModule(ctx, source, NewCompileConfig()); err != nil {
@@ -268,6 +422,10 @@ func (r *runtime) InstantiateModule(ctx context.Context, compiled CompiledModule
 		panic(fmt.Errorf("unsupported wazero.ModuleConfig implementation: %#v", mConfig))
 	}
 
+	if config.randSource == nil && r.newRandSource != nil {
+		config = config.WithRandSource(r.newRandSource()).(*moduleConfig)
+	}
+
 	var sysCtx *wasm.SysContext
 	if sysCtx, err = config.toSysContext(); err != nil {
 		return
@@ -285,7 +443,11 @@ func (r *runtime) InstantiateModule(ctx context.Context, compiled CompiledModule
 		}
 	}
 
-	mod, err = r.store.Instantiate(ctx, code.module, name, sysCtx, functionListenerFactory)
+	if config.withoutStartSection {
+		mod, err = r.store.InstantiateWithoutStart(ctx, code.module, name, sysCtx, functionListenerFactory)
+	} else {
+		mod, err = r.store.Instantiate(ctx, code.module, name, sysCtx, functionListenerFactory)
+	}
 	if err != nil {
 		return
 	}
@@ -302,6 +464,9 @@ func (r *runtime) InstantiateModule(ctx context.Context, compiled CompiledModule
 			err = fmt.Errorf("module[%s] function[%s] failed: %w", name, fn, err)
 			return
 		}
+		if config.startFunctionObserver != nil {
+			config.startFunctionObserver(fn)
+		}
 	}
 	return
 }
@@ -314,7 +479,13 @@ func (r *runtime) Close(ctx context.Context) error {
 // CloseWithExitCode implements Runtime.CloseWithExitCode
 func (r *runtime) CloseWithExitCode(ctx context.Context, exitCode uint32) error {
 	err := r.store.CloseWithExitCode(ctx, exitCode)
-	for _, c := range r.compiledModules {
+
+	r.compiledModulesMu.Lock()
+	compiledModules := r.compiledModules
+	r.compiledModules = nil
+	r.compiledModulesMu.Unlock()
+
+	for c := range compiledModules {
 		if e := c.Close(ctx); e != nil && err == nil {
 			err = e
 		}
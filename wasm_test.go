@@ -1,13 +1,23 @@
 package wazero
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	_ "embed"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"os"
+	"path"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/engine/interpreter"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
@@ -90,11 +100,11 @@ func TestRuntime_CompileModule(t *testing.T) {
 		code := m.(*compiledCode)
 		defer code.Close(testCtx)
 
-		require.Equal(t, &wasm.Memory{
+		require.Equal(t, []*wasm.Memory{{
 			Min: 1,
 			Cap: 2,
 			Max: 3,
-		}, code.module.MemorySection)
+		}}, code.module.MemorySection)
 	})
 
 	t.Run("WithImportReplacements", func(t *testing.T) {
@@ -148,6 +158,23 @@ func TestRuntime_CompileModule(t *testing.T) {
 	})
 }
 
+// TestRuntime_CompileModule_DebugInfoEnabled ensures RuntimeConfig.WithDebugInfoEnabled reaches the module compiled.
+func TestRuntime_CompileModule_DebugInfoEnabled(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		enabled := enabled
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter().WithDebugInfoEnabled(enabled))
+			defer r.Close(testCtx)
+
+			compiled, err := r.CompileModule(testCtx, []byte(`(module)`), NewCompileConfig())
+			require.NoError(t, err)
+			defer compiled.Close(testCtx)
+
+			require.Equal(t, enabled, compiled.(*compiledCode).module.DebugInfoEnabled)
+		})
+	}
+}
+
 func TestRuntime_CompileModule_Errors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -192,9 +219,54 @@ func TestRuntime_CompileModule_Errors(t *testing.T) {
 		},
 		{
 			name:        "memory has too many pages binary",
-			source:      binary.EncodeModule(&wasm.Module{MemorySection: &wasm.Memory{Min: 2, Cap: 2, Max: 70000, IsMaxEncoded: true}}),
+			source:      binary.EncodeModule(&wasm.Module{MemorySection: []*wasm.Memory{{Min: 2, Cap: 2, Max: 70000, IsMaxEncoded: true}}}),
 			expectedErr: "section memory: max 70000 pages (4 Gi) over limit of 65536 pages (4 Gi)",
 		},
+		{
+			name:        "duplicate export name text",
+			source:      []byte(`(module (func $a) (export "x" (func $a)) (export "x" (func $a)))`),
+			expectedErr: "1:50: \"x\" already exported in module.export[1]",
+		},
+		{
+			name: "duplicate export name binary",
+			source: binary.EncodeModule(&wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeEnd}}},
+				ExportSection: []*wasm.Export{
+					{Name: "x", Type: wasm.ExternTypeFunc, Index: 0},
+					{Name: "x", Type: wasm.ExternTypeFunc, Index: 0},
+				},
+			}),
+			expectedErr: "section export: export[1] duplicates name \"x\"",
+		},
+		{
+			name: "v128 param without WithFeatureSIMD",
+			source: binary.EncodeModule(&wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeV128}}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeEnd}}},
+			}),
+			expectedErr: `invalid type[0] param: v128 requires WithFeatureSIMD: feature "simd" is disabled`,
+		},
+		{
+			name: "v128 local without WithFeatureSIMD",
+			source: binary.EncodeModule(&wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{LocalTypes: []wasm.ValueType{wasm.ValueTypeV128}, Body: []byte{wasm.OpcodeEnd}}},
+			}),
+			expectedErr: `invalid function[0] local: v128 requires WithFeatureSIMD: feature "simd" is disabled`,
+		},
+		{
+			name: "v128 global without WithFeatureSIMD",
+			source: binary.EncodeModule(&wasm.Module{
+				ImportSection: []*wasm.Import{
+					{Module: "m", Name: "g", Type: wasm.ExternTypeGlobal, DescGlobal: &wasm.GlobalType{ValType: wasm.ValueTypeV128}},
+				},
+			}),
+			expectedErr: `invalid import["m"."g"] global: v128 requires WithFeatureSIMD: feature "simd" is disabled`,
+		},
 	}
 
 	r := NewRuntime()
@@ -212,6 +284,69 @@ func TestRuntime_CompileModule_Errors(t *testing.T) {
 	}
 }
 
+func TestRuntime_CompileModuleFromReader(t *testing.T) {
+	sources := map[string][]byte{
+		"text":   []byte(`(module $test (memory 1) (export "memory" (memory 0)))`),
+		"binary": binary.EncodeModule(&wasm.Module{NameSection: &wasm.NameSection{ModuleName: "test"}}),
+	}
+	file := path.Join(t.TempDir(), "module.wasm")
+	require.NoError(t, os.WriteFile(file, sources["binary"], 0o600))
+
+	readers := map[string]func(source []byte) io.Reader{
+		"bytes.Reader": func(source []byte) io.Reader { return bytes.NewReader(source) },
+		"unknown size": func(source []byte) io.Reader { return iotest.OneByteReader(bytes.NewReader(source)) },
+	}
+
+	r := NewRuntime()
+	for sourceName, source := range sources {
+		expected, err := r.CompileModule(testCtx, source, NewCompileConfig())
+		require.NoError(t, err)
+
+		for readerName, reader := range readers {
+			t.Run(sourceName+" "+readerName, func(t *testing.T) {
+				m, err := r.CompileModuleFromReader(testCtx, reader(source), NewCompileConfig())
+				require.NoError(t, err)
+				defer m.Close(testCtx)
+
+				require.Equal(t, expected.(*compiledCode).module, m.(*compiledCode).module)
+			})
+		}
+	}
+
+	t.Run("os.File", func(t *testing.T) {
+		f, err := os.Open(file)
+		require.NoError(t, err)
+		defer f.Close()
+
+		m, err := r.CompileModuleFromReader(testCtx, f, NewCompileConfig())
+		require.NoError(t, err)
+		defer m.Close(testCtx)
+
+		require.Equal(t, "test", m.(*compiledCode).module.NameSection.ModuleName)
+	})
+}
+
+func TestRuntime_CompileModuleFromReader_Errors(t *testing.T) {
+	r := NewRuntime()
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := r.CompileModuleFromReader(testCtx, nil, NewCompileConfig())
+		require.EqualError(t, err, "source == nil")
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		_, err := r.CompileModuleFromReader(testCtx, iotest.ErrReader(errors.New("boom")), NewCompileConfig())
+		require.EqualError(t, err, "read source: boom")
+	})
+
+	t.Run("same as CompileModule", func(t *testing.T) {
+		source := append(binary.Magic, []byte("yolo")...)
+		_, expectedErr := r.CompileModule(testCtx, source, NewCompileConfig())
+		_, err := r.CompileModuleFromReader(testCtx, bytes.NewReader(source), NewCompileConfig())
+		require.Equal(t, expectedErr, err)
+	})
+}
+
 // TestModule_Memory only covers a couple cases to avoid duplication of internal/wasm/runtime_test.go
 func TestModule_Memory(t *testing.T) {
 	tests := []struct {
@@ -256,6 +391,39 @@ func TestModule_Memory(t *testing.T) {
 	}
 }
 
+func TestModule_ExportedMemoryDefinition(t *testing.T) {
+	r := NewRuntime()
+	defer r.Close(testCtx)
+
+	module, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module
+  (memory 2 4)
+  (export "memory" (memory 0))
+  (func $grow (param i32) (result i32) local.get 0 memory.grow)
+  (export "grow" (func $grow))
+)`))
+	require.NoError(t, err)
+
+	min, current, max, ok := module.ExportedMemoryDefinition("memory")
+	require.True(t, ok)
+	require.Equal(t, uint32(2), min)
+	require.Equal(t, uint32(2), current)
+	require.Equal(t, uint32(4), max)
+
+	// The current size changes when the memory grows, but not the limits.
+	_, err = module.ExportedFunction("grow").Call(testCtx, 1)
+	require.NoError(t, err)
+	min, current, max, ok = module.ExportedMemoryDefinition("memory")
+	require.True(t, ok)
+	require.Equal(t, uint32(2), min)
+	require.Equal(t, uint32(3), current)
+	require.Equal(t, uint32(4), max)
+
+	_, _, _, ok = module.ExportedMemoryDefinition("grow") // not a memory
+	require.False(t, ok)
+	_, _, _, ok = module.ExportedMemoryDefinition("nope")
+	require.False(t, ok)
+}
+
 // TestModule_Global only covers a couple cases to avoid duplication of internal/wasm/global_test.go
 func TestModule_Global(t *testing.T) {
 	globalVal := int64(100) // intentionally a value that differs in signed vs unsigned encoding
@@ -391,6 +559,58 @@ func TestFunction_Context(t *testing.T) {
 	}
 }
 
+// TestFunction_Call_ContextDone ensures the interpreter returns a sys.TrapError wrapping the context error when the
+// context is canceled during an infinite loop.
+func TestFunction_Call_ContextDone(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{
+			name: "loop",
+			body: []byte{
+				wasm.OpcodeLoop, 0x40, // 0x40 is the empty block type.
+				wasm.OpcodeBr, 0,
+				wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+			},
+		},
+		{
+			name: "return_call", // reuses the frame, so has no back-edge
+			body: []byte{wasm.OpcodeReturnCall, 0, wasm.OpcodeEnd},
+		},
+	}
+
+	r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter().WithFeatureTailCall(true))
+	defer r.Close(testCtx)
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			module, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{Body: tc.body}},
+				ExportSection:   []*wasm.Export{{Name: "spin", Type: wasm.ExternTypeFunc, Index: 0}},
+				NameSection:     &wasm.NameSection{ModuleName: tc.name},
+			}))
+			require.NoError(t, err)
+			defer module.Close(testCtx)
+
+			ctx, cancel := context.WithCancel(testCtx)
+			time.AfterFunc(10*time.Millisecond, cancel)
+
+			_, err = module.ExportedFunction("spin").Call(ctx)
+			require.ErrorIs(t, err, context.Canceled)
+			trapErr, ok := err.(*sys.TrapError)
+			require.True(t, ok)
+			require.Equal(t, sys.TrapContextDone, trapErr.Code())
+			require.True(t, strings.HasPrefix(err.Error(), "wasm error: context done: context canceled"))
+		})
+	}
+}
+
 func TestRuntime_InstantiateModule_UsesContext(t *testing.T) {
 	r := NewRuntime()
 
@@ -422,6 +642,80 @@ func TestRuntime_InstantiateModule_UsesContext(t *testing.T) {
 	require.True(t, calledStart)
 }
 
+// TestRuntime_InstantiateModule_StartError ensures the name of a trapping start function is in the error, as it is
+// usually not exported.
+func TestRuntime_InstantiateModule_StartError(t *testing.T) {
+	r := NewRuntime()
+
+	_, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $runtime_test.go
+	(memory 0)
+	(func $init i32.const 0 i32.load drop) ;; traps as the memory is empty
+	(start $init)
+)`))
+	require.EqualError(t, err, `start function[0] name["init"] failed: wasm error: out of bounds memory access
+wasm stack trace:
+	runtime_test.go.init()`)
+}
+
+// TestRuntime_InstantiateModule_WithoutStartSection ensures a trapping start function is skipped, so that the module
+// can still be inspected.
+func TestRuntime_InstantiateModule_WithoutStartSection(t *testing.T) {
+	r := NewRuntime()
+	defer r.Close(testCtx)
+
+	start := wasm.Index(0)
+	code, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeUnreachable, wasm.OpcodeEnd}}},
+		StartSection:    &start,
+		MemorySection:   []*wasm.Memory{{Min: 1, Cap: 1, Max: 1}},
+		DataSection: []*wasm.DataSegment{{
+			OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:             []byte("wazero"),
+		}},
+		ExportSection: []*wasm.Export{{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0}},
+	}), NewCompileConfig())
+	require.NoError(t, err)
+	defer code.Close(testCtx)
+
+	// The start function traps, so instantiation fails by default.
+	_, err = r.InstantiateModule(testCtx, code, NewModuleConfig())
+	require.Error(t, err)
+
+	m, err := r.InstantiateModule(testCtx, code, NewModuleConfig().WithoutStartSection())
+	require.NoError(t, err)
+	defer m.Close(testCtx)
+
+	// Data segments are still applied.
+	buf, ok := m.ExportedMemory("memory").Read(testCtx, 0, 6)
+	require.True(t, ok)
+	require.Equal(t, "wazero", string(buf))
+}
+
+// TestRuntime_InstantiateModule_StartFunctionObserver ensures only start functions that ran are observed, as missing
+// ones are skipped.
+func TestRuntime_InstantiateModule_StartFunctionObserver(t *testing.T) {
+	r := NewRuntime()
+	defer r.Close(testCtx)
+
+	code, err := r.CompileModule(testCtx, []byte(`(module $runtime_test.go
+	(func $initialize)
+	(export "_initialize" (func $initialize))
+)`), NewCompileConfig())
+	require.NoError(t, err)
+
+	var ran []string
+	config := NewModuleConfig().
+		WithStartFunctions("_initialize", "_start").
+		WithStartFunctionObserver(func(name string) { ran = append(ran, name) })
+	m, err := r.InstantiateModule(testCtx, code, config)
+	require.NoError(t, err)
+	defer m.Close(testCtx)
+
+	require.Equal(t, []string{"_initialize"}, ran)
+}
+
 // TestInstantiateModuleFromCode_DoesntEnforce_Start ensures wapc-go work when modules import WASI, but don't export "_start".
 func TestInstantiateModuleFromCode_DoesntEnforce_Start(t *testing.T) {
 	r := NewRuntime()
@@ -516,6 +810,56 @@ func TestInstantiateModule_WithName(t *testing.T) {
 	require.Equal(t, internal.Module("2"), m2)
 }
 
+// TestInstantiateModule_Isolated ensures instances of the same compiled module don't share state, as data segments and
+// globals are applied to new instances each time.
+func TestInstantiateModule_Isolated(t *testing.T) {
+	r := NewRuntime()
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		MemorySection: []*wasm.Memory{{Min: 1, Cap: 1, Max: 1}},
+		DataSection: []*wasm.DataSegment{{
+			OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{1}},
+			Init:             []byte("wazero"),
+		}},
+		GlobalSection: []*wasm.Global{{
+			Type: &wasm.GlobalType{ValType: wasm.ValueTypeI32, Mutable: true},
+			Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{42}},
+		}},
+		ExportSection: []*wasm.Export{
+			{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
+			{Name: "global", Type: wasm.ExternTypeGlobal, Index: 0},
+		},
+	}), NewCompileConfig())
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	m1, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName("1"))
+	require.NoError(t, err)
+	defer m1.Close(testCtx)
+
+	// Mutate the state of the first instance.
+	require.True(t, m1.ExportedMemory("memory").Write(testCtx, 1, []byte("WAZERO")))
+	m1.ExportedGlobal("global").(api.MutableGlobal).Set(testCtx, 1)
+
+	m2, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName("2"))
+	require.NoError(t, err)
+	defer m2.Close(testCtx)
+
+	// The second instance begins with the original data and global.
+	data, ok := m2.ExportedMemory("memory").Read(testCtx, 1, 6)
+	require.True(t, ok)
+	require.Equal(t, "wazero", string(data))
+	require.Equal(t, uint64(42), m2.ExportedGlobal("global").Get(testCtx))
+
+	// Mutating the second instance doesn't affect the first.
+	require.True(t, m2.ExportedMemory("memory").Write(testCtx, 1, []byte("gazebo")))
+	data, ok = m1.ExportedMemory("memory").Read(testCtx, 1, 6)
+	require.True(t, ok)
+	require.Equal(t, "WAZERO", string(data))
+	require.Equal(t, uint64(1), m1.ExportedGlobal("global").Get(testCtx))
+}
+
 func TestInstantiateModule_ExitError(t *testing.T) {
 	r := NewRuntime()
 
@@ -529,6 +873,45 @@ func TestInstantiateModule_ExitError(t *testing.T) {
 	require.Equal(t, err, sys.NewExitError("env", 2))
 }
 
+func TestRuntime_Stats(t *testing.T) {
+	r := NewRuntime()
+	defer r.Close(testCtx)
+
+	require.Equal(t, RuntimeStats{}, r.Stats())
+
+	_, err := r.NewModuleBuilder("env").ExportMemory("memory", 2).Instantiate(testCtx)
+	require.NoError(t, err)
+
+	// The imported memory isn't counted again.
+	importing, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		ImportSection: []*wasm.Import{
+			{Module: "env", Name: "memory", Type: wasm.ExternTypeMemory, DescMem: &wasm.Memory{Min: 2}},
+		},
+	}), NewCompileConfig())
+	require.NoError(t, err)
+	defining, err := r.CompileModule(testCtx, []byte(`(module (memory 3))`), NewCompileConfig())
+	require.NoError(t, err)
+
+	for _, name := range []string{"a", "b"} {
+		_, err = r.InstantiateModule(testCtx, importing, NewModuleConfig().WithName("importing-"+name))
+		require.NoError(t, err)
+		_, err = r.InstantiateModule(testCtx, defining, NewModuleConfig().WithName("defining-"+name))
+		require.NoError(t, err)
+	}
+	require.Equal(t, RuntimeStats{Modules: 5, MemoryPages: 2 + 3 + 3, CompiledModules: 2}, r.Stats())
+
+	// Growing and closing modules are reflected.
+	_, ok := r.Module("defining-a").Memory().Grow(testCtx, 1)
+	require.True(t, ok)
+	require.NoError(t, r.Module("defining-b").Close(testCtx))
+	require.Equal(t, RuntimeStats{Modules: 4, MemoryPages: 2 + 4, CompiledModules: 2}, r.Stats())
+
+	// Closing a compiled module stops counting it, even if closed again.
+	require.NoError(t, importing.Close(testCtx))
+	require.NoError(t, importing.Close(testCtx))
+	require.Equal(t, RuntimeStats{Modules: 4, MemoryPages: 2 + 4, CompiledModules: 1}, r.Stats())
+}
+
 func TestClose(t *testing.T) {
 	for _, tc := range []struct {
 		name     string
@@ -596,6 +979,178 @@ func TestClose_ClosesCompiledModules(t *testing.T) {
 	require.Equal(t, 0, len(engine.cachedModules))
 }
 
+func TestRuntime_CompileModule_CompilationCache(t *testing.T) {
+	source := []byte(`(module
+  (func $add (param i32 i32) (result i32) local.get 0 local.get 1 i32.add)
+  (export "add" (func $add))
+)`)
+	fileCache, err := NewCompilationCacheWithDir(t.TempDir())
+	require.NoError(t, err)
+	cache := &countingCompilationCache{CompilationCache: fileCache}
+
+	// newRuntimeWithVersion returns a runtime sharing the cache, and the count of modules its engine compiled.
+	newRuntimeWithVersion := func(rConfig RuntimeConfig, version []byte) (Runtime, *int) {
+		conf := *rConfig.WithCompilationCache(cache).(*runtimeConfig)
+		var compiles int
+		conf.newEngine = func(enabledFeatures wasm.Features) wasm.Engine {
+			e := interpreter.NewEngine(enabledFeatures)
+			return &countingEngine{Engine: e, CompiledCodeCodec: e.(wasm.CompiledCodeCodec), compiles: &compiles, version: version}
+		}
+		return NewRuntimeWithConfig(&conf), &compiles
+	}
+	newRuntime := func(rConfig RuntimeConfig) (Runtime, *int) {
+		return newRuntimeWithVersion(rConfig, nil)
+	}
+
+	// The first compilation misses the cache, so populates it.
+	r, compiles := newRuntime(NewRuntimeConfigInterpreter())
+	defer r.Close(testCtx)
+	_, err = r.CompileModule(testCtx, source, NewCompileConfig())
+	require.NoError(t, err)
+	require.Equal(t, 1, *compiles)
+	require.Equal(t, 0, cache.hits)
+	require.Equal(t, 1, cache.adds)
+
+	// A new runtime, as if in a different process, is served from the cache.
+	r, compiles = newRuntime(NewRuntimeConfigInterpreter())
+	defer r.Close(testCtx)
+	compiled, err := r.CompileModule(testCtx, source, NewCompileConfig())
+	require.NoError(t, err)
+	require.Equal(t, 0, *compiles)
+	require.Equal(t, 1, cache.hits)
+	require.Equal(t, 1, cache.adds)
+
+	// The cached code works.
+	mod, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig())
+	require.NoError(t, err)
+	results, err := mod.ExportedFunction("add").Call(testCtx, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), results[0])
+
+	// Changing features invalidates the cache.
+	r, compiles = newRuntime(NewRuntimeConfigInterpreter().WithWasmCore2())
+	defer r.Close(testCtx)
+	_, err = r.CompileModule(testCtx, source, NewCompileConfig())
+	require.NoError(t, err)
+	require.Equal(t, 1, *compiles)
+	require.Equal(t, 1, cache.hits)
+	require.Equal(t, 2, cache.adds)
+
+	// Changing the encoding of compiled code invalidates the cache, even if the wazero version is the same.
+	r, compiles = newRuntimeWithVersion(NewRuntimeConfigInterpreter(), []byte("incompatible"))
+	defer r.Close(testCtx)
+	_, err = r.CompileModule(testCtx, source, NewCompileConfig())
+	require.NoError(t, err)
+	require.Equal(t, 1, *compiles)
+	require.Equal(t, 1, cache.hits)
+	require.Equal(t, 3, cache.adds)
+}
+
+// TestRuntime_CompileModule_LowersEagerly ensures both engines lower every function when compiling, so that a
+// lowering error fails Runtime.CompileModule instead of the first api.Function Call.
+func TestRuntime_CompileModule_LowersEagerly(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config RuntimeConfig
+	}{
+		{name: "default", config: NewRuntimeConfig()},
+		{name: "interpreter", config: NewRuntimeConfigInterpreter()},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRuntimeWithConfig(tc.config).(*runtime)
+			defer r.Close(testCtx)
+
+			// Validation would reject this, so compile it directly to reach the lowering error. func[1] is never
+			// called, and its call is missing the function index.
+			errModule := &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{}},
+				FunctionSection: []wasm.Index{0, 0},
+				CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeEnd}}, {Body: []byte{wasm.OpcodeCall}}},
+			}
+			err := r.compileModule(testCtx, errModule)
+			require.EqualError(t, err, "failed to lower func[1/1] to wazeroir: handling instruction: apply stack failed for call: reading immediates: EOF")
+
+			// Nothing was compiled, so it can't be instantiated and fail later.
+			_, err = r.store.Engine.NewModuleEngine("err", errModule, nil, nil, nil, nil)
+			require.EqualError(t, err, "source module for err must be compiled before instantiation")
+		})
+	}
+}
+
+// TestRuntime_CompileModule_CompilationCacheErrors ensures errors from the cache don't fail compilation.
+func TestRuntime_CompileModule_CompilationCacheErrors(t *testing.T) {
+	r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter().WithCompilationCache(failingCompilationCache{}))
+	defer r.Close(testCtx)
+
+	mod, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module
+  (func $add (param i32 i32) (result i32) local.get 0 local.get 1 i32.add)
+  (export "add" (func $add))
+)`))
+	require.NoError(t, err)
+
+	results, err := mod.ExportedFunction("add").Call(testCtx, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), results[0])
+}
+
+// failingCompilationCache errs on every operation.
+type failingCompilationCache struct{}
+
+// Get implements CompilationCache.Get
+func (failingCompilationCache) Get([sha256.Size]byte) ([]byte, bool, error) {
+	return nil, false, errors.New("get failed")
+}
+
+// Add implements CompilationCache.Add
+func (failingCompilationCache) Add([sha256.Size]byte, []byte) error {
+	return errors.New("add failed")
+}
+
+// countingCompilationCache counts the hits and adds of the CompilationCache it wraps.
+type countingCompilationCache struct {
+	CompilationCache
+	hits, adds int
+}
+
+// Get implements CompilationCache.Get
+func (c *countingCompilationCache) Get(key [sha256.Size]byte) ([]byte, bool, error) {
+	content, ok, err := c.CompilationCache.Get(key)
+	if ok {
+		c.hits++
+	}
+	return content, ok, err
+}
+
+// Add implements CompilationCache.Add
+func (c *countingCompilationCache) Add(key [sha256.Size]byte, content []byte) error {
+	c.adds++
+	return c.CompilationCache.Add(key, content)
+}
+
+// countingEngine counts the modules compiled by the wasm.Engine it wraps.
+type countingEngine struct {
+	wasm.Engine
+	wasm.CompiledCodeCodec
+	compiles *int
+	// version overrides CompiledCodeVersion when non-nil.
+	version []byte
+}
+
+// CompiledCodeVersion implements wasm.CompiledCodeCodec.CompiledCodeVersion
+func (e *countingEngine) CompiledCodeVersion() []byte {
+	if e.version != nil {
+		return e.version
+	}
+	return e.CompiledCodeCodec.CompiledCodeVersion()
+}
+
+// CompileModule implements wasm.Engine.CompileModule
+func (e *countingEngine) CompileModule(ctx context.Context, module *wasm.Module) error {
+	*e.compiles++
+	return e.Engine.CompileModule(ctx, module)
+}
+
 // requireImportAndExportFunction re-exports a host function because only host functions can see the propagated context.
 func requireImportAndExportFunction(t *testing.T, r Runtime, hostFn func(ctx context.Context) uint64, functionName string) ([]byte, func(context.Context) error) {
 	mod, err := r.NewModuleBuilder("host").ExportFunction(functionName, hostFn).Instantiate(testCtx)
@@ -625,3 +1180,44 @@ func (e *mockEngine) CompileModule(_ context.Context, module *wasm.Module) error
 	e.cachedModules[module] = struct{}{}
 	return nil
 }
+
+func TestRuntime_MemoryGrowObserver(t *testing.T) {
+	type transition struct {
+		moduleName              string
+		previousPages, newPages uint32
+	}
+
+	for _, tc := range []struct {
+		name   string
+		config RuntimeConfig
+	}{
+		{name: "default", config: NewRuntimeConfig()},
+		{name: "interpreter", config: NewRuntimeConfigInterpreter()},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var transitions []transition
+			r := NewRuntimeWithConfig(tc.config.WithMemoryGrowObserver(
+				func(ctx context.Context, moduleName string, previousPages, newPages uint32) {
+					require.Equal(t, testCtx, ctx)
+					transitions = append(transitions, transition{moduleName, previousPages, newPages})
+				}))
+			defer r.Close(testCtx)
+
+			module, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $guest
+  (memory 1 3)
+  (func $grow (param i32) (result i32) local.get 0 memory.grow)
+  (export "grow" (func $grow))
+)`))
+			require.NoError(t, err)
+
+			grow := module.ExportedFunction("grow")
+			for _, delta := range []uint64{1, 0, 1, 1} { // the last exceeds the max of 3 pages
+				_, err = grow.Call(testCtx, delta)
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, []transition{{"guest", 1, 2}, {"guest", 2, 3}}, transitions)
+		})
+	}
+}